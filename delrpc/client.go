@@ -1,15 +1,21 @@
 package delrpc
 
 import (
+	"bufio"
 	"bytes"
 	"compress/zlib"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"io"
 	"net"
 	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
 )
 
 const (
@@ -18,6 +24,9 @@ const (
 
 	// DefaultUserAgent is the default client user agent.
 	DefaultUserAgent = "delrpc/0.1"
+
+	// ClientVersion is the deluge client version sent when logging in.
+	ClientVersion = "2.0.3"
 )
 
 // Client is a deluge rpc client.
@@ -34,9 +43,22 @@ type Client struct {
 	// url is the remote url host.
 	url string
 
+	// tlsConfig is the TLS config.
+	tlsConfig *tls.Config
+
+	// dialer is the dialer.
+	dialer *tctypes.Dialer
+
 	// conn is the net connection.
 	conn net.Conn
 
+	// r is the buffered reader for the connection, ensuring that reading a
+	// response does not consume any of the following response.
+	r *bufio.Reader
+
+	// retry is the retry policy used on network errors.
+	retry tctypes.RetryPolicy
+
 	// id is the request id.
 	id int64
 
@@ -49,6 +71,9 @@ type Client struct {
 	// resf is the logging function used to send responses.
 	resf func(string, ...interface{})
 
+	// har is the HAR writer for requests and responses.
+	har *tctypes.HARWriter
+
 	sync.Mutex
 }
 
@@ -57,6 +82,11 @@ func NewClient(opts ...ClientOption) *Client {
 	cl := &Client{
 		timeout:   DefaultTimeout,
 		userAgent: DefaultUserAgent,
+		// deluge daemons use a self-signed certificate by default, and as
+		// with deluge's own clients, it is not verified unless a TLS config
+		// is provided
+		tlsConfig: &tls.Config{InsecureSkipVerify: true},
+		retry:     tctypes.DefaultRetryPolicy,
 	}
 	for _, o := range opts {
 		o(cl)
@@ -73,11 +103,44 @@ func (cl *Client) open(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	d := net.Dialer{
+	d := tctypes.Dialer{
 		Timeout: cl.timeout,
 	}
-	cl.conn, err = d.DialContext(ctx, "tcp", u.Hostname()+":"+u.Port())
-	return err
+	if cl.dialer != nil {
+		d.Socket, d.Proxy = cl.dialer.Socket, cl.dialer.Proxy
+	}
+	conn, err := d.DialContext(ctx, "tcp", u.Hostname()+":"+u.Port())
+	if err != nil {
+		return err
+	}
+
+	// tls handshake
+	cfg := cl.tlsConfig.Clone()
+	if cfg == nil {
+		cfg = new(tls.Config)
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+	tlsConn := tls.Client(conn, cfg)
+	deadline := time.Now().Add(cl.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	if err := tlsConn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return err
+	}
+	cl.conn, cl.r = tlsConn, bufio.NewReader(tlsConn)
+	return nil
 }
 
 // Close closes the connection.
@@ -89,25 +152,39 @@ func (cl *Client) Close() error {
 		if err := cl.conn.Close(); err != nil {
 			return err
 		}
-		cl.conn = nil
+		cl.conn, cl.r, cl.authenticated = nil, nil, false
 	}
 
 	return nil
 }
 
 // do executes a request and reads the response.
-func (cl *Client) do(ctx context.Context, method string, req, res interface{}) error {
+func (cl *Client) do(ctx context.Context, method string, req, kwargs, res interface{}) error {
 	reqID := atomic.AddInt64(&cl.id, 1)
 
 	// encode
 	var err error
 	var reqBuf bytes.Buffer
-	if err = encode(&reqBuf, reqID, method, req); err != nil {
+	if err = encode(&reqBuf, reqID, method, req, kwargs); err != nil {
 		return err
 	}
+	var logBuf []byte
+	if cl.reqf != nil || cl.har != nil {
+		// log the (redacted) json of the request, as the encoded request is
+		// binary
+		var v interface{} = req
+		if kwargs != nil {
+			v = map[string]interface{}{"args": req, "kwargs": kwargs}
+		}
+		if logBuf, err = json.Marshal(v); err != nil {
+			return err
+		}
+		logBuf = tctypes.RedactBody("application/json", logBuf)
+	}
 	if cl.reqf != nil {
-		cl.reqf("%s", string(reqBuf.Bytes()))
+		cl.reqf("%s %s", method, string(logBuf))
 	}
+	start := time.Now()
 
 	// write
 	w := zlib.NewWriter(cl.conn)
@@ -122,7 +199,7 @@ func (cl *Client) do(ctx context.Context, method string, req, res interface{}) e
 	}
 
 	// read
-	r, err := zlib.NewReader(cl.conn)
+	r, err := zlib.NewReader(cl.r)
 	if err != nil {
 		return err
 	}
@@ -133,7 +210,12 @@ func (cl *Client) do(ctx context.Context, method string, req, res interface{}) e
 
 	// decode
 	if cl.resf != nil {
-		cl.resf("%s", string(resBuf.Bytes()))
+		cl.resf("%s", string(tctypes.RedactBody("", resBuf.Bytes())))
+	}
+	if cl.har != nil {
+		// a failure writing the trace does not fail the request, as the
+		// request has already been executed
+		_ = cl.har.Add(cl.harEntry(method, logBuf, resBuf.Bytes(), start))
 	}
 	resID, err := decode(resBuf.Bytes(), res)
 	if err != nil {
@@ -145,6 +227,54 @@ func (cl *Client) do(ctx context.Context, method string, req, res interface{}) e
 	return nil
 }
 
+// harEntry creates a HAR entry for the method, with the request's json and
+// the raw response.
+func (cl *Client) harEntry(method string, reqBuf, resBuf []byte, start time.Time) tctypes.HAREntry {
+	ms := float64(time.Since(start)) / float64(time.Millisecond)
+	urlstr := cl.url
+	if u, err := url.Parse(cl.url); err == nil {
+		u.User, u.Path = nil, "/"+method
+		urlstr = u.String()
+	}
+	if tctypes.MaxLogBody > 0 && len(resBuf) > tctypes.MaxLogBody {
+		resBuf = resBuf[:tctypes.MaxLogBody]
+	}
+	return tctypes.HAREntry{
+		StartedDateTime: start,
+		Time:            ms,
+		Request: tctypes.HARRequest{
+			Method:      "POST",
+			URL:         urlstr,
+			HTTPVersion: "deluge-rpc",
+			Cookies:     []tctypes.HARNameValue{},
+			Headers:     []tctypes.HARNameValue{},
+			QueryString: []tctypes.HARNameValue{},
+			PostData: &tctypes.HARPostData{
+				MimeType: "application/json",
+				Text:     string(reqBuf),
+			},
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBuf)),
+		},
+		Response: tctypes.HARResponse{
+			Status:      200,
+			StatusText:  "OK",
+			HTTPVersion: "deluge-rpc",
+			Cookies:     []tctypes.HARNameValue{},
+			Headers:     []tctypes.HARNameValue{},
+			Content: tctypes.HARContent{
+				Size:     int64(len(resBuf)),
+				MimeType: "application/x-rencode",
+				Text:     base64.StdEncoding.EncodeToString(resBuf),
+				Encoding: "base64",
+			},
+			HeadersSize: -1,
+			BodySize:    int64(len(resBuf)),
+		},
+		Timings: tctypes.HARTimings{Wait: ms},
+	}
+}
+
 // authenticate
 func (cl *Client) authenticate(ctx context.Context) error {
 	if cl.authenticated {
@@ -162,23 +292,23 @@ func (cl *Client) authenticate(ctx context.Context) error {
 		creds = []string{u.User.Username(), pass}
 	}
 
-	if creds[0] == "" {
+	if len(creds) == 0 || creds[0] == "" {
 		cl.authenticated = true
 		return nil
 	}
 
+	// deluge 2.x daemons reject logins without the client_version kwarg
 	req := struct {
-		Username string
-		Password string
-		Params   map[string]interface{}
+		Username string `json:"username"`
+		Password string `json:"password"`
 	}{
 		Username: creds[0],
 		Password: creds[1],
-		Params: map[string]interface{}{
-			"client_version": "2.0.3",
-		},
 	}
-	if err := cl.do(ctx, "daemon.login", req, nil); err != nil {
+	kwargs := map[string]interface{}{
+		"client_version": ClientVersion,
+	}
+	if err := cl.do(ctx, "daemon.login", req, kwargs, nil); err != nil {
 		return err
 	}
 
@@ -186,22 +316,76 @@ func (cl *Client) authenticate(ctx context.Context) error {
 	return nil
 }
 
-// Do executes the qbittorrent web method, json marshaling the passed
-// arguments and unmarshaling the response to v (if provided).
+// Do executes the deluge rpc method, encoding the passed arguments and decoding
+// the response to v (if provided).
+//
+// The connection to the remote host is reused across calls, and is
+// re-established (and re-authenticated) after a network error.
 func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{}) error {
+	return cl.DoKwargs(ctx, method, arguments, nil, v)
+}
+
+// DoKwargs executes the deluge rpc method, encoding the passed positional
+// arguments and keyword arguments (a struct or map), and decoding the
+// response to v (if provided).
+func (cl *Client) DoKwargs(ctx context.Context, method string, arguments, kwargs, v interface{}) error {
 	cl.Lock()
 	defer cl.Unlock()
+	return cl.retry.Do(ctx, func(int) (bool, error) {
+		err := cl.roundTrip(ctx, method, arguments, kwargs, v)
+		if tctypes.IsTransient(err) || err == ErrMismatchedRequestAndResponseIDs {
+			cl.reset()
+		}
+		return retryable(method, err), err
+	})
+}
 
-	var err error
-
-	// open and authenticate
-	if err = cl.open(ctx); err != nil {
-		return err
+// roundTrip opens and authenticates the connection (if not already open), and
+// executes the method.
+func (cl *Client) roundTrip(ctx context.Context, method string, arguments, kwargs, v interface{}) error {
+	if cl.conn == nil {
+		if err := cl.open(ctx); err != nil {
+			return err
+		}
 	}
-	if err = cl.authenticate(ctx); err != nil {
+	if err := cl.authenticate(ctx); err != nil {
 		return err
 	}
-	return nil
+	return cl.do(ctx, method, arguments, kwargs, v)
+}
+
+// reset closes the connection, ensuring the next call re-opens and
+// re-authenticates.
+func (cl *Client) reset() {
+	if cl.conn != nil {
+		cl.conn.Close()
+	}
+	cl.conn, cl.r, cl.authenticated = nil, nil, false
+}
+
+// retryable determines if the method can be retried after the error.
+// Non-idempotent methods are only retried when the request was never sent.
+func retryable(method string, err error) bool {
+	switch {
+	case tctypes.IsDialError(err):
+		return true
+	case nonIdempotent[method]:
+		return false
+	}
+	return tctypes.IsTransient(err)
+}
+
+// nonIdempotent are the rpc methods that are not safe to blindly retry.
+var nonIdempotent = map[string]bool{
+	"core.add_torrent_file":   true,
+	"core.add_torrent_files":  true,
+	"core.add_torrent_magnet": true,
+	"core.add_torrent_url":    true,
+	"core.queue_down":         true,
+	"core.queue_up":           true,
+	"core.rename_files":       true,
+	"core.rename_folder":      true,
+	"daemon.shutdown":         true,
 }
 
 // ClientOption is a deluge rpc client option.
@@ -244,6 +428,31 @@ func WithCredentialFallback(user, pass string) ClientOption {
 	}
 }
 
+// WithTLSConfig is a deluge rpc client option to set the TLS config used when
+// connecting to the rpc host.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(cl *Client) {
+		cl.tlsConfig = cfg
+	}
+}
+
+// WithDialer is a deluge rpc client option to set the dialer used when
+// connecting to the rpc host, such as for unix sockets and proxies.
+func WithDialer(d *tctypes.Dialer) ClientOption {
+	return func(cl *Client) {
+		cl.dialer = d
+	}
+}
+
+// WithRetryPolicy is a deluge rpc client option to set the retry policy used
+// on network errors. Non-idempotent methods, such as core.add_torrent_file,
+// are not retried unless the request was never sent.
+func WithRetryPolicy(retry tctypes.RetryPolicy) ClientOption {
+	return func(cl *Client) {
+		cl.retry = retry
+	}
+}
+
 // WithLogf is a deluge rpc client option to set logging handlers HTTP
 // request and response bodies.
 func WithLogf(reqf, resf func(string, ...interface{})) ClientOption {
@@ -251,3 +460,11 @@ func WithLogf(reqf, resf func(string, ...interface{})) ClientOption {
 		cl.reqf, cl.resf = reqf, resf
 	}
 }
+
+// WithHAR is a deluge rpc client option to write requests and responses to a
+// HAR file.
+func WithHAR(har *tctypes.HARWriter) ClientOption {
+	return func(cl *Client) {
+		cl.har = har
+	}
+}
@@ -0,0 +1,255 @@
+package delrpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
+)
+
+func TestFake(t *testing.T) {
+	s := transctltest.NewDeluge(transctltest.WithDelugeCredentials("user", "pass"))
+	defer s.Close()
+	ctx := context.Background()
+	policy := tctypes.RetryPolicy{Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+
+	// bad credentials
+	cl := NewClient(WithURL(s.URL), WithCredentialFallback("user", "bad"), WithRetryPolicy(policy))
+	var failed *ErrRequestFailed
+	if err := cl.Do(ctx, "core.get_session_state", nil, nil); !errors.As(err, &failed) || failed.Type != "BadLoginError" {
+		t.Fatalf("expected BadLoginError, got: %v", err)
+	}
+	cl.Close()
+
+	// logins without the client_version kwarg are rejected
+	cl = NewClient(WithURL(s.URL), WithRetryPolicy(policy))
+	if err := cl.Do(ctx, "daemon.login", []string{"user", "pass"}, nil); !errors.As(err, &failed) || failed.Type != "IncompatibleClient" {
+		t.Fatalf("expected IncompatibleClient, got: %v", err)
+	}
+	kwargs := map[string]interface{}{"client_version": ClientVersion}
+	if err := cl.DoKwargs(ctx, "daemon.login", []string{"user", "pass"}, kwargs, nil); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := cl.DoKwargs(ctx, "daemon.info", nil, "2.0.3", nil); !errors.Is(err, ErrInvalidKwargs) {
+		t.Errorf("expected ErrInvalidKwargs, got: %v", err)
+	}
+	cl.Close()
+
+	// add
+	cl = NewClient(WithURL(s.URL), WithCredentialFallback("user", "pass"), WithRetryPolicy(policy))
+	defer cl.Close()
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	var added string
+	magnet := "magnet:?xt=urn:btih:" + hash + "&dn=fake"
+	if err := cl.Do(ctx, "core.add_torrent_magnet", []interface{}{magnet, map[string]interface{}{}}, &added); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if added != hash {
+		t.Errorf("expected %s, got: %s", hash, added)
+	}
+	if err := cl.Do(ctx, "core.add_torrent_file", []interface{}{"a.torrent", []byte("torrent"), map[string]interface{}{"add_paused": true}}, &added); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// method errors
+	err := cl.Do(ctx, "core.add_torrent_magnet", []interface{}{magnet, map[string]interface{}{}}, nil)
+	if !errors.As(err, &failed) || failed.Type != "InvalidTorrentError" {
+		t.Errorf("expected InvalidTorrentError, got: %v", err)
+	}
+
+	// dropped connections are re-established and re-authenticated
+	logins := s.Logins()
+	s.Disconnect()
+	if err := cl.Do(ctx, "core.pause_torrent", []interface{}{[]string{hash}}, nil); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := s.Logins(); n != logins+1 {
+		t.Errorf("expected %d logins, got: %d", logins+1, n)
+	}
+
+	// status
+	var status map[string]struct {
+		Name  string `json:"name"`
+		State string `json:"state"`
+	}
+	if err := cl.Do(ctx, "core.get_torrents_status", []interface{}{map[string]interface{}{}, []string{"name", "state"}}, &status); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(status) != 2 {
+		t.Fatalf("expected 2 torrents, got: %d", len(status))
+	}
+	if status[hash].Name != "fake" || status[hash].State != "Paused" {
+		t.Errorf("expected fake to be paused, got: %+v", status[hash])
+	}
+	if status[added].Name != "a" || status[added].State != "Paused" {
+		t.Errorf("expected a to be paused, got: %+v", status[added])
+	}
+
+	// remove
+	var removed bool
+	if err := cl.Do(ctx, "core.remove_torrent", []interface{}{hash, false}, &removed); err != nil || !removed {
+		t.Fatalf("expected no error and removed, got: %v, %t", err, removed)
+	}
+	if torrents := s.Torrents(); len(torrents) != 1 {
+		t.Errorf("expected 1 torrent, got: %d", len(torrents))
+	}
+}
+
+func TestTLS(t *testing.T) {
+	s := transctltest.NewDeluge()
+	defer s.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	hash := sha256.Sum256(s.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+	badPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	pinConfig := func(pin string) *tls.Config {
+		cfg, err := tctypes.TLSOptions{Insecure: true, Pins: []string{pin}}.Config()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return cfg
+	}
+	tests := []struct {
+		name string
+		cfg  *tls.Config
+		err  bool
+	}{
+		// deluge daemons use a self-signed certificate, which by default
+		// is not verified
+		{"default", nil, false},
+		{"verify", &tls.Config{}, true},
+		{"ca", &tls.Config{RootCAs: pool}, false},
+		{"pin", pinConfig(pin), false},
+		{"bad pin", pinConfig(badPin), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := []ClientOption{
+				WithURL(s.URL),
+				WithRetryPolicy(tctypes.RetryPolicy{Attempts: 1}),
+			}
+			if test.cfg != nil {
+				opts = append(opts, WithTLSConfig(test.cfg))
+			}
+			cl := NewClient(opts...)
+			defer cl.Close()
+			err := cl.Do(context.Background(), "daemon.info", nil, nil)
+			switch {
+			case test.err && err == nil:
+				t.Errorf("expected error, got nil")
+			case !test.err && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDialer(t *testing.T) {
+	s := transctltest.NewDeluge()
+	defer s.Close()
+
+	// unix socket listener, relaying to the daemon
+	var relayed int64
+	dir, err := ioutil.TempDir("", "delrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "rpc.sock")
+	var l net.Listener
+	if runtime.GOOS != "windows" {
+		if l, err = net.Listen("unix", socket); err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go relay(l, func(net.Conn) string {
+			atomic.AddInt64(&relayed, 1)
+			return s.Addr
+		})
+	}
+
+	// http connect proxy listener
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	go relay(proxy, func(conn net.Conn) string {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != "CONNECT" {
+			return ""
+		}
+		if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			return ""
+		}
+		atomic.AddInt64(&relayed, 1)
+		return req.Host
+	})
+
+	tests := []struct {
+		name string
+		d    *tctypes.Dialer
+	}{
+		{"unix", &tctypes.Dialer{Socket: socket}},
+		{"http", &tctypes.Dialer{Proxy: &url.URL{Scheme: "http", Host: proxy.Addr().String()}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name == "unix" && l == nil {
+				t.Skip("unix sockets not supported")
+			}
+			n, logins := atomic.LoadInt64(&relayed), s.Logins()
+			cl := NewClient(WithURL(s.URL), WithCredentialFallback("localclient", "deluge"), WithDialer(test.d), WithRetryPolicy(tctypes.RetryPolicy{Attempts: 1}))
+			defer cl.Close()
+			if err := cl.Do(context.Background(), "core.get_session_state", nil, nil); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if atomic.LoadInt64(&relayed) != n+1 || s.Logins() != logins+1 {
+				t.Errorf("expected request to be relayed to the daemon")
+			}
+		})
+	}
+}
+
+// relay accepts connections on l, relaying each to the address returned by
+// addr, until l is closed.
+func relay(l net.Listener, addr func(net.Conn) string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			a := addr(conn)
+			if a == "" {
+				return
+			}
+			upstream, err := net.Dial("tcp", a)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			go func() { _, _ = io.Copy(upstream, conn) }()
+			_, _ = io.Copy(conn, upstream)
+		}()
+	}
+}
@@ -1,9 +1,12 @@
 package delrpc
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
 	"strings"
 
@@ -22,81 +25,278 @@ func (err Error) Error() string {
 const (
 	// ErrMismatchedRequestAndResponseIDs is the mismatched request and response ids error.
 	ErrMismatchedRequestAndResponseIDs Error = "mismatched request and response ids"
+
+	// ErrInvalidResponse is the invalid response error.
+	ErrInvalidResponse Error = "invalid response"
+
+	// ErrInvalidKwargs is the invalid kwargs error.
+	ErrInvalidKwargs Error = "kwargs must be a struct or map"
 )
 
-// appendParams
-func appendParams(z []interface{}, v reflect.Value, depth int) []interface{} {
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// ErrRequestFailed is the error returned by the deluge rpc host when a method
+// fails.
+type ErrRequestFailed struct {
+	Type    string
+	Message string
+}
+
+// Error satisfies the error interface.
+func (err *ErrRequestFailed) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("request failed: %s", err.Type)
 	}
+	return fmt.Sprintf("request failed: %s: %s", err.Type, err.Message)
+}
 
-	var ret []interface{}
-	switch v.Kind() {
-	case reflect.Struct:
-		d := new(rencode.Dictionary)
+// Deluge rpc message types.
+const (
+	rpcResponse = 1
+	rpcError    = 2
+)
+
+// appendParams appends the positional params for v to z. Struct fields (in
+// order) and slice elements are each a positional param, while any other
+// value (including maps) is a single param.
+func appendParams(z []interface{}, v reflect.Value, depth int) ([]interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return z, nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid():
+		return z, nil
+	case depth == 0 && v.Kind() == reflect.Struct:
 		for i := 0; i < v.NumField(); i++ {
-			tag := strings.SplitN(v.Type().Field(i).Tag.Get("json"), ",", 2)
-			if tag[0] == "" || tag[1] == "-" {
+			name, _, ok := fieldName(v.Type().Field(i))
+			if !ok || name == "" {
 				continue
 			}
-			if depth == 0 {
-				ret = appendParams(ret, v.Field(i), depth+1)
-			} else {
-				var omitempty bool
-				if len(tag) > 1 {
-					omitempty = contains(strings.Split(tag[1], ","), "omitempty")
-				}
-				f := v.Field(i)
-				if !omitempty || !f.IsZero() {
-					d.Add(tag[0], f.Interface())
-				}
+			var err error
+			if z, err = appendParams(z, v.Field(i), depth+1); err != nil {
+				return nil, err
 			}
 		}
-		if depth != 0 {
-			ret = append(ret, d)
+		return z, nil
+	case depth == 0 && v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8,
+		depth == 0 && v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if z, err = appendParams(z, v.Index(i), depth+1); err != nil {
+				return nil, err
+			}
 		}
+		return z, nil
+	}
+	x, err := rencodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(z, x), nil
+}
 
-	case reflect.Slice:
-		switch x := v.Interface().(type) {
-		case []interface{}:
-			ret = append(ret, x...)
-		case []string:
-			for i := 0; i < len(x); i++ {
-				ret = append(ret, x[i])
+// rencodeValue converts v to a value that can be rencoded, using the json
+// field tags for struct fields.
+func rencodeValue(v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Struct:
+		var d rencode.Dictionary
+		for i := 0; i < v.NumField(); i++ {
+			name, omitempty, ok := fieldName(v.Type().Field(i))
+			f := v.Field(i)
+			if !ok || (omitempty && f.IsZero()) {
+				continue
 			}
-		case []byte:
-			ret = append(ret, base64.RawStdEncoding.EncodeToString(x))
-		default:
-			panic(fmt.Sprintf("unsupported slice type %T", v.Interface()))
+			x, err := rencodeValue(f)
+			if err != nil {
+				return nil, err
+			}
+			d.Add(name, x)
 		}
-
+		return d, nil
 	case reflect.Map:
-		d := new(rencode.Dictionary)
+		var d rencode.Dictionary
 		iter := v.MapRange()
 		for iter.Next() {
-			d.Add(iter.Key().Interface(), iter.Value().Interface())
+			k, err := rencodeValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			x, err := rencodeValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			d.Add(k, x)
+		}
+		return d, nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return base64.StdEncoding.EncodeToString(buf), nil
+		}
+		var l rencode.List
+		for i := 0; i < v.Len(); i++ {
+			x, err := rencodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			l.Add(x)
 		}
-		ret = append(ret, d)
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// fieldName returns the json name of the struct field, and whether or not it
+// is omitempty.
+func fieldName(f reflect.StructField) (string, bool, bool) {
+	if f.PkgPath != "" {
+		return "", false, false
+	}
+	tag := strings.Split(f.Tag.Get("json"), ",")
+	if tag[0] == "-" {
+		return "", false, false
+	}
+	name := tag[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name, contains(tag[1:], "omitempty"), true
+}
 
-	default:
-		ret = append(ret, v.Interface())
+// plainValue converts a decoded rencode value to the equivalent plain value
+// (ie, map[string]interface{}, []interface{}, string, int64, float64, and
+// bool) that can be json encoded.
+func plainValue(x interface{}) interface{} {
+	switch v := x.(type) {
+	case []byte:
+		return string(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case big.Int:
+		return json.Number(v.String())
+	case rencode.List:
+		l := make([]interface{}, v.Length())
+		for i, y := range v.Values() {
+			l[i] = plainValue(y)
+		}
+		return l
+	case rencode.Dictionary:
+		m := make(map[string]interface{}, v.Length())
+		keys := v.Keys()
+		for i, y := range v.Values() {
+			m[fmt.Sprintf("%v", plainValue(keys[i]))] = plainValue(y)
+		}
+		return m
 	}
-	return append(z, ret...)
+	return x
 }
 
-// encode encodes a deluge rpc request to the writer.
-func encode(w io.Writer, id int64, method string, v interface{}) error {
-	params := rencode.NewList()
-	params.Add(appendParams([]interface{}{id, method}, reflect.ValueOf(v), 0)...)
-	l := rencode.NewList()
-	l.Add(params)
+// encode encodes a deluge rpc request to the writer. The request is encoded as
+// [[id, method, [args...], {kwargs}]], with v converted to the positional
+// args, and kwargs (a struct or map, when not nil) converted to the keyword
+// args.
+func encode(w io.Writer, id int64, method string, v, kwargs interface{}) error {
+	args, err := appendParams(nil, reflect.ValueOf(v), 0)
+	if err != nil {
+		return err
+	}
+	kw := rencode.Dictionary{}
+	if kwargs != nil {
+		x, err := rencodeValue(reflect.ValueOf(kwargs))
+		if err != nil {
+			return err
+		}
+		d, ok := x.(rencode.Dictionary)
+		if !ok {
+			return ErrInvalidKwargs
+		}
+		kw = d
+	}
 	enc := rencode.NewEncoder(w)
-	return enc.Encode(l)
+	return enc.Encode(rencode.NewList(rencode.NewList(
+		id, method, rencode.NewList(args...), kw,
+	)))
 }
 
-// decode decodes a deluge rpc response.
+// decode decodes a deluge rpc response, returning the response id. The
+// response's return value is decoded to v (if provided).
 func decode(buf []byte, v interface{}) (int64, error) {
-	return 0, nil
+	x, err := rencode.NewDecoder(bytes.NewReader(buf)).DecodeNext()
+	if err != nil {
+		return 0, err
+	}
+	l, ok := plainValue(x).([]interface{})
+	if !ok || len(l) < 2 {
+		return 0, ErrInvalidResponse
+	}
+	typ, ok := l[0].(int64)
+	if !ok {
+		return 0, ErrInvalidResponse
+	}
+	id, _ := l[1].(int64)
+	switch {
+	case typ == rpcResponse && len(l) == 3:
+		if v == nil {
+			return id, nil
+		}
+		buf, err := json.Marshal(l[2])
+		if err != nil {
+			return 0, err
+		}
+		return id, json.Unmarshal(buf, v)
+	case typ == rpcError && len(l) == 3:
+		// deluge 1.x: [2, id, [type, message, traceback]]
+		e, _ := l[2].([]interface{})
+		return id, newRequestFailed(e...)
+	case typ == rpcError && len(l) > 3:
+		// deluge 2.x: [2, id, type, [args], {kwargs}, traceback]
+		var args []interface{}
+		if a, ok := l[3].([]interface{}); ok && len(a) != 0 {
+			args = a[:1]
+		}
+		return id, newRequestFailed(append([]interface{}{l[2]}, args...)...)
+	}
+	return 0, ErrInvalidResponse
+}
+
+// newRequestFailed creates a request failed error from the error type and
+// message.
+func newRequestFailed(v ...interface{}) error {
+	err := &ErrRequestFailed{Type: "Error"}
+	if len(v) > 0 {
+		err.Type = fmt.Sprintf("%v", v[0])
+	}
+	if len(v) > 1 {
+		err.Message = fmt.Sprintf("%v", v[1])
+	}
+	return err
 }
 
 // contains determines if needle is contained in haystack.
@@ -15,7 +15,9 @@ require (
 	github.com/knq/snaker v0.0.0-20200906011523-e648e8220bf9
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/olekukonko/tablewriter v0.0.4
-	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
+	github.com/oschwald/maxminddb-golang v1.6.0
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2
 	gopkg.in/yaml.v2 v2.2.7 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
@@ -7,9 +7,9 @@ import (
 	"os"
 
 	"github.com/kenshaw/transctl/providers"
+	_ "github.com/kenshaw/transctl/providers/deluge"
 	_ "github.com/kenshaw/transctl/providers/qbittorrent"
 	_ "github.com/kenshaw/transctl/providers/transmission"
-	//	_ "github.com/kenshaw/transctl/providers/deluge"
 	//	_ "github.com/kenshaw/transctl/providers/rtorrent"
 	//	_ "github.com/kenshaw/transctl/providers/utorrent"
 )
@@ -34,38 +34,93 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), args.Timeout)
-	defer cancel()
-	f := map[string]func(*providers.Args) error{
-		"config":             providers.DoConfig,
-		"add":                providers.DoAdd,
-		"get":                providers.DoGet,
-		"set":                providers.DoSet,
-		"start":              providers.DoReq,
-		"stop":               providers.DoReq,
-		"move":               providers.DoMove,
-		"remove":             providers.DoRemove,
-		"verify":             providers.DoReq,
-		"reannounce":         providers.DoReq,
-		"queue top":          providers.DoReq,
-		"queue bottom":       providers.DoReq,
-		"queue up":           providers.DoReq,
-		"queue down":         providers.DoReq,
-		"peers get":          providers.DoPeersGet,
-		"files get":          providers.DoFilesGet,
-		"files set-priority": providers.DoFilesSet,
-		"files set-wanted":   providers.DoFilesSet,
-		"files set-unwanted": providers.DoFilesSet,
-		"files rename":       providers.DoFilesRename,
-		"trackers get":       providers.DoTrackersGet,
-		"trackers add":       providers.DoTrackersAdd,
-		"trackers replace":   providers.DoTrackersReplace,
-		"trackers remove":    providers.DoTrackersRemove,
-		"stats":              providers.DoStats,
-		"shutdown":           providers.DoShutdown,
-		"free-space":         providers.DoFreeSpace,
-		"blocklist-update":   providers.DoBlocklistUpdate,
-		"port-test":          providers.DoPortTest,
+	defer args.Close()
+	ctx := context.Background()
+	switch cmd {
+	case "daemon", "watch-dir", "exporter", "serve", "logs main", "logs peers", "search query":
+	default:
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Host.Timeout)
+		defer cancel()
+	}
+	f := map[string]func(context.Context, *providers.Args, string) error{
+		"config":                   providers.DoConfig,
+		"add":                      providers.DoAdd,
+		"get":                      providers.DoGet,
+		"set":                      providers.DoSet,
+		"start":                    providers.DoReq,
+		"stop":                     providers.DoReq,
+		"move":                     providers.DoMove,
+		"remove":                   providers.DoRemove,
+		"verify":                   providers.DoReq,
+		"reannounce":               providers.DoReq,
+		"queue top":                providers.DoReq,
+		"queue bottom":             providers.DoReq,
+		"queue up":                 providers.DoReq,
+		"queue down":               providers.DoReq,
+		"peers get":                providers.DoPeersGet,
+		"peers stats":              providers.DoPeersStats,
+		"peers add":                providers.DoPeersAdd,
+		"peers ban":                providers.DoPeersBan,
+		"files get":                providers.DoFilesGet,
+		"files set-priority":       providers.DoFilesSet,
+		"files set-wanted":         providers.DoFilesSet,
+		"files set-unwanted":       providers.DoFilesSet,
+		"files rename":             providers.DoFilesRename,
+		"trackers get":             providers.DoTrackersGet,
+		"trackers add":             providers.DoTrackersAdd,
+		"trackers replace":         providers.DoTrackersReplace,
+		"trackers remove":          providers.DoTrackersRemove,
+		"trackers report":          providers.DoTrackersReport,
+		"trackers dedupe":          providers.DoTrackersDedupe,
+		"labels list":              providers.DoLabelsList,
+		"labels add":               providers.DoLabels,
+		"labels remove":            providers.DoLabels,
+		"labels set":               providers.DoLabels,
+		"categories list":          providers.DoCategoriesList,
+		"categories create":        providers.DoCategories,
+		"categories edit":          providers.DoCategories,
+		"categories delete":        providers.DoCategories,
+		"rss feeds list":           providers.DoRSSFeedsList,
+		"rss feeds articles":       providers.DoRSSFeedsArticles,
+		"rss feeds add":            providers.DoRSSFeeds,
+		"rss feeds add-folder":     providers.DoRSSFeeds,
+		"rss feeds remove":         providers.DoRSSFeeds,
+		"rss feeds move":           providers.DoRSSFeeds,
+		"rss rules list":           providers.DoRSSRulesList,
+		"rss rules export":         providers.DoRSSRules,
+		"rss rules import":         providers.DoRSSRules,
+		"rss rules rename":         providers.DoRSSRules,
+		"rss rules remove":         providers.DoRSSRules,
+		"rss test-rule":            providers.DoRSSTestRule,
+		"limits get":               providers.DoLimitsGet,
+		"limits set":               providers.DoLimits,
+		"limits alt":               providers.DoLimits,
+		"limits schedule":          providers.DoLimits,
+		"limits torrent":           providers.DoLimitsTorrent,
+		"pieces":                   providers.DoPieces,
+		"mode sequential":          providers.DoMode,
+		"mode first-last":          providers.DoMode,
+		"mode force-start":         providers.DoMode,
+		"mode super-seed":          providers.DoMode,
+		"logs main":                providers.DoLogs,
+		"logs peers":               providers.DoLogs,
+		"search query":             providers.DoSearch,
+		"search plugins list":      providers.DoSearchPluginsList,
+		"search plugins install":   providers.DoSearchPlugins,
+		"search plugins uninstall": providers.DoSearchPlugins,
+		"search plugins enable":    providers.DoSearchPlugins,
+		"search plugins disable":   providers.DoSearchPlugins,
+		"search plugins update":    providers.DoSearchPlugins,
+		"stats":                    providers.DoStats,
+		"shutdown":                 providers.DoShutdown,
+		"free-space":               providers.DoFreeSpace,
+		"blocklist-update":         providers.DoBlocklistUpdate,
+		"port-test":                providers.DoPortTest,
+		"daemon":                   providers.DoDaemon,
+		"watch-dir":                providers.DoWatchDir,
+		"exporter":                 providers.DoExporter,
+		"serve":                    providers.DoServe,
 	}[cmd]
 	return f(ctx, args, cmd)
 }
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,7 +16,6 @@ import (
 	"github.com/alecthomas/kingpin"
 	"github.com/jdxcode/netrc"
 	"github.com/kenshaw/transctl/tctypes"
-	"github.com/kenshaw/transctl/transrpc"
 	"github.com/knq/ini"
 )
 
@@ -27,6 +27,12 @@ type Args struct {
 	// version is the version.
 	version string
 
+	// sessionDir is the session cache directory.
+	sessionDir string
+
+	// har is the HAR writer for the trace file, shared by all remotes.
+	har *tctypes.HARWriter
+
 	// ConfigFile is the global config file.
 	ConfigFile string
 
@@ -36,6 +42,10 @@ type Args struct {
 	// Verbose is the global verbose toggle.
 	Verbose bool
 
+	// TraceFile is the HAR file to write remote host requests and responses
+	// to.
+	TraceFile string
+
 	// Host contains the global host configuration.
 	Host struct {
 		// URL is the URL to work with.
@@ -50,6 +60,9 @@ type Args struct {
 		// RpcPath is the rpc path to use for building URLs.
 		RpcPath string
 
+		// RpcPathWasSet is the rpc path was set toggle.
+		RpcPathWasSet bool
+
 		// Credentials is the user:pass credentials to work with.
 		Credentials string
 
@@ -64,6 +77,36 @@ type Args struct {
 
 		// NetRCFile is the NetRCFile to use.
 		NetrcFile string
+
+		// Strict toggles failing on unknown fields in remote host responses.
+		Strict bool
+
+		// CACert is the CA bundle used to verify the remote host's
+		// certificate.
+		CACert string
+
+		// Cert is the client certificate.
+		Cert string
+
+		// Key is the client certificate key.
+		Key string
+
+		// Pins are the remote host's pinned public keys.
+		Pins []string
+
+		// Insecure toggles skipping verification of the remote host's
+		// certificate.
+		Insecure bool
+
+		// Proxy is the proxy url.
+		Proxy *url.URL
+
+		// SessionCache toggles caching remote host sessions on disk.
+		SessionCache bool
+
+		// SecretKeyFile is the key file used to encrypt and decrypt stored
+		// passwords.
+		SecretKeyFile string
 	}
 
 	// Filter contains the global filter configuration.
@@ -118,14 +161,24 @@ type Args struct {
 
 		// SortOrderWasSet is the sort order was set toggle
 		SortOrderWasSet bool
+
+		// Versioned is the toggle to wrap json and yaml output with the
+		// apiVersion and kind.
+		Versioned bool
+
+		// VersionedWasSet is the versioned was set toggle.
+		VersionedWasSet bool
 	}
 
 	// ConfigParams are the config params.
 	ConfigParams struct {
-		Remote bool
-		Name   string
-		Value  string
-		Unset  bool
+		Remote          bool
+		Name            string
+		Value           string
+		Unset           bool
+		Username        string
+		PasswordStdin   bool
+		PasswordCommand string
 	}
 
 	// AddParams are the add params.
@@ -135,6 +188,7 @@ type Args struct {
 		Paused            bool
 		PeerLimit         int64
 		BandwidthPriority int64
+		Labels            []string
 		Remove            bool
 		RemoveWasSet      bool
 	}
@@ -165,9 +219,125 @@ type Args struct {
 		NewPath string
 	}
 
+	// LabelsParams are the labels params.
+	LabelsParams struct {
+		Labels string
+	}
+
+	// CategoriesParams are the categories params.
+	CategoriesParams struct {
+		Name     string
+		Names    []string
+		SavePath string
+	}
+
+	// RSSParams are the rss params.
+	RSSParams struct {
+		URL     string
+		Path    string
+		Dest    string
+		Paths   []string
+		File    string
+		Name    string
+		NewName string
+		Names   []string
+		Titles  []string
+	}
+
+	// PeersParams are the peers params.
+	PeersParams struct {
+		Torrent string
+		Peers   []string
+		GeoIPDB string
+	}
+
+	// LimitsParams are the limits params.
+	LimitsParams struct {
+		Down        string
+		Up          string
+		AltDown     string
+		AltUp       string
+		Alt         string
+		Schedule    string
+		Days        string
+		Ratio       string
+		Idle        string
+		SeedingTime string
+	}
+
+	// LogsParams are the logs params.
+	LogsParams struct {
+		Level    string
+		Follow   bool
+		Since    time.Duration
+		Interval time.Duration
+	}
+
+	// ModeParams are the mode params.
+	ModeParams struct {
+		State string
+	}
+
+	// PiecesParams are the pieces params.
+	PiecesParams struct {
+		Width int
+		Grid  bool
+	}
+
+	// SearchParams are the search params.
+	SearchParams struct {
+		Pattern    string
+		Plugins    []string
+		Category   string
+		Wait       time.Duration
+		Add        string
+		AddContext string
+		Names      []string
+	}
+
+	// TrackersAddParams are the trackers add params.
+	TrackersAddParams struct {
+		Tier       int64
+		TierWasSet bool
+	}
+
 	// TrackersReplacePramas are the trackers replace params.
 	TrackersReplaceParams struct {
 		Replace string
+		Regex   bool
+	}
+
+	// DaemonParams are the daemon params.
+	DaemonParams struct {
+		LogFormat       string
+		Once            bool
+		PollInterval    time.Duration
+		ShutdownTimeout time.Duration
+	}
+
+	// WatchDirParams are the watch-dir params.
+	WatchDirParams struct {
+		Dir          string
+		Interval     time.Duration
+		Poll         bool
+		PollInterval time.Duration
+		LogFormat    string
+	}
+
+	// ExporterParams are the exporter params.
+	ExporterParams struct {
+		Listen         string
+		Contexts       []string
+		TorrentMetrics bool
+		LogFormat      string
+	}
+
+	// ServeParams are the serve params.
+	ServeParams struct {
+		Listen    string
+		Token     string
+		ReadOnly  bool
+		LogFormat string
 	}
 
 	// FileMask is the file mask for files operations.
@@ -183,6 +353,10 @@ type Args struct {
 	Config *ini.File
 }
 
+// defaultFilter is the default torrent filter, matching torrents by the
+// passed identifiers.
+const defaultFilter = `id == identifier || name %% identifier || (strlen(identifier) >= 5 && hashString %^ identifier)`
+
 var getColumnNames = []string{
 	"activityDate=activity",
 	"addedDate=added",
@@ -255,6 +429,11 @@ var peersGetColumnNames = []string{
 	"shortHash=hash",
 }
 
+var peersStatsColumnNames = []string{
+	"rateToClient=down",
+	"rateToPeer=up",
+}
+
 var filesGetColumnNames = []string{
 	"bytesCompleted=have",
 	"hashString=fullHash",
@@ -263,6 +442,18 @@ var filesGetColumnNames = []string{
 	"shortHash=hash",
 }
 
+var trackersReportColumnNames = []string{
+	"avgPeers=peers",
+	"successRate=success",
+	"torrentNames=names",
+}
+
+var piecesColumnNames = []string{
+	"pieceCount=pieces",
+	"pieceSize=size",
+	"shortHash=hash",
+}
+
 var trackersGetColumnNames = []string{
 	"announceState=state",
 	"downloadCount=downloads",
@@ -288,6 +479,35 @@ var trackersGetColumnNames = []string{
 	"shortHash=hash",
 }
 
+var labelsListColumnNames = []string{
+	"torrents=count",
+}
+
+var categoriesListColumnNames = []string{
+	"savePath=path",
+}
+
+var rssFeedsListColumnNames = []string{
+	"isLoading=loading",
+	"hasError=error",
+}
+
+var rssFeedsArticlesColumnNames = []string{
+	"isRead=read",
+	"torrentURL=url",
+}
+
+var rssRulesListColumnNames = []string{
+	"mustContain=contains",
+	"mustNotContain=excludes",
+	"useRegex=regex",
+	"episodeFilter=episodes",
+	"smartFilter=smart",
+	"affectedFeeds=feeds",
+	"assignedCategory=category",
+	"savePath=path",
+}
+
 // NewArgs creates the command args.
 func NewArgs(name, version string) (*Args, string, error) {
 	// determine netrc path
@@ -311,22 +531,32 @@ func NewArgs(name, version string) (*Args, string, error) {
 	kingpin.UsageTemplate(kingpin.CompactUsageTemplate)
 
 	// create args
-	args := &Args{name: name, version: version}
+	args := &Args{name: name, version: version, sessionDir: filepath.Join(configDir, name, "sessions")}
 	args.AddParams.Cookies = make(map[string]string)
 	args.Output.ColumnNames = make(map[string]string)
 
 	// global options
 	kingpin.Flag("verbose", "toggle verbose").Short('v').Default("false").BoolVar(&args.Verbose)
+	kingpin.Flag("trace-file", "write remote host requests and responses to a HAR file").PlaceHolder("<file>").StringVar(&args.TraceFile)
 	kingpin.Flag("config", "config file").Short('C').Default(configFile).Envar("TRANSCONFIG").PlaceHolder("<file>").StringVar(&args.ConfigFile)
 	kingpin.Flag("context", "config context").Short('c').Envar("TRANSCONTEXT").PlaceHolder("<context>").StringVar(&args.Context)
-	kingpin.Flag("url", "remote host url").Short('U').Envar("TRANSURL").PlaceHolder("<url>").URLVar(&args.Host.URL)
+	kingpin.Flag("url", "remote host url (unix:///path/to/sock or unix:///path/to/sock:/rpc/path for unix sockets)").Short('U').Envar("TRANSURL").PlaceHolder("<url>").URLVar(&args.Host.URL)
 	kingpin.Flag("proto", "protocol to use").Default("http").PlaceHolder("http").StringVar(&args.Host.Proto)
 	kingpin.Flag("host", "remote host").Short('h').PlaceHolder("localhost:9091").StringVar(&args.Host.Host)
-	kingpin.Flag("rpc-path", "rpc path").Default("/transmission/rpc/").PlaceHolder("<path>").StringVar(&args.Host.RpcPath)
+	kingpin.Flag("rpc-path", "rpc path").Default("/transmission/rpc/").PlaceHolder("<path>").IsSetByUser(&args.Host.RpcPathWasSet).StringVar(&args.Host.RpcPath)
 	kingpin.Flag("user", "remote host username and password").Short('u').PlaceHolder("<user:pass>").IsSetByUser(&args.Host.CredentialsWasSet).StringVar(&args.Host.Credentials)
 	kingpin.Flag("no-netrc", "disable netrc loading").BoolVar(&args.Host.NoNetrc)
 	kingpin.Flag("netrc-file", "netrc file path").Default(netrcFile).PlaceHolder("<file>").StringVar(&args.Host.NetrcFile)
 	kingpin.Flag("timeout", "rpc request timeout (default: 25s)").Default("25s").PlaceHolder("<dur>").DurationVar(&args.Host.Timeout)
+	kingpin.Flag("strict", "fail on unknown fields in rpc responses").BoolVar(&args.Host.Strict)
+	kingpin.Flag("cacert", "ca bundle to verify remote host certificate").PlaceHolder("<file>").StringVar(&args.Host.CACert)
+	kingpin.Flag("cert", "client certificate").PlaceHolder("<file>").StringVar(&args.Host.Cert)
+	kingpin.Flag("key", "client certificate key").PlaceHolder("<file>").StringVar(&args.Host.Key)
+	kingpin.Flag("pin", "remote host public key pin (sha256//<base64>)").PlaceHolder("<pin>").StringsVar(&args.Host.Pins)
+	kingpin.Flag("insecure", "skip remote host certificate verification").BoolVar(&args.Host.Insecure)
+	kingpin.Flag("proxy", "proxy url (socks5, socks5h, http, https)").PlaceHolder("<url>").URLVar(&args.Host.Proxy)
+	kingpin.Flag("session-cache", "cache remote host sessions between runs").BoolVar(&args.Host.SessionCache)
+	kingpin.Flag("secret-key-file", "key file for stored passwords").Envar("TRANSSECRETKEYFILE").PlaceHolder("<file>").StringVar(&args.Host.SecretKeyFile)
 
 	// config command
 	configCmd := kingpin.Command("config", "Get and set local and remote config")
@@ -334,6 +564,9 @@ func NewArgs(name, version string) (*Args, string, error) {
 	configCmd.Flag("list", "list all options").Short('l').BoolVar(&args.Filter.ListAll)
 	configCmd.Flag("all", "list all options").Hidden().BoolVar(&args.Filter.ListAll)
 	configCmd.Flag("unset", "unset option").BoolVar(&args.ConfigParams.Unset)
+	configCmd.Flag("username", "username (set-credentials)").PlaceHolder("<user>").StringVar(&args.ConfigParams.Username)
+	configCmd.Flag("password-stdin", "read password from stdin (set-credentials)").BoolVar(&args.ConfigParams.PasswordStdin)
+	configCmd.Flag("password-command", "password command, such as 'pass show x' (set-credentials)").PlaceHolder("<cmd>").StringVar(&args.ConfigParams.PasswordCommand)
 	configCmd.Arg("name", "option name").StringVar(&args.ConfigParams.Name)
 	configCmd.Arg("value", "option value").StringVar(&args.ConfigParams.Value)
 
@@ -343,6 +576,7 @@ func NewArgs(name, version string) (*Args, string, error) {
 	addCmd.Flag("bandwidth-priority", "bandwidth priority").Short('b').PlaceHolder("<bw>").Int64Var(&args.AddParams.BandwidthPriority)
 	addCmd.Flag("cookies", "cookies").Short('k').PlaceHolder("<name>=<v>").StringMapVar(&args.AddParams.Cookies)
 	addCmd.Flag("download-dir", "download directory").Short('d').PlaceHolder("<dir>").StringVar(&args.AddParams.DownloadDir)
+	addCmd.Flag("label", "torrent label").PlaceHolder("<label>").StringsVar(&args.AddParams.Labels)
 	addCmd.Flag("paused", "start torrent paused").Short('P').BoolVar(&args.AddParams.Paused)
 	addCmd.Flag("peer-limit", "peer limit").Short('L').PlaceHolder("<limit>").Int64Var(&args.AddParams.PeerLimit)
 	addCmd.Flag("rm", "remove torrents after adding").IsSetByUser(&args.AddParams.RemoveWasSet).BoolVar(&args.AddParams.Remove)
@@ -363,6 +597,7 @@ func NewArgs(name, version string) (*Args, string, error) {
 		"queue up", "Move torrents up in queue",
 		"queue down", "Move torrents down in queue",
 		"peers get", "Get information about peers",
+		"peers stats", "Get connected peer counts by client, encryption, transport, and country",
 		"files get", "Get information about files",
 		"files set-priority", "Set torrent files' priority",
 		"files set-wanted", "Set torrent files as wanted",
@@ -372,6 +607,18 @@ func NewArgs(name, version string) (*Args, string, error) {
 		"trackers add", "Add tracker to torrents",
 		"trackers replace", "Replace tracker for torrents",
 		"trackers remove", "Remove tracker from torrents",
+		"trackers report", "Get tracker announce health by host",
+		"trackers dedupe", "Remove duplicate trackers from torrents",
+		"labels list", "List torrent labels",
+		"labels add", "Add labels to torrents",
+		"labels remove", "Remove labels from torrents",
+		"labels set", "Set torrent labels",
+		"limits torrent", "Set torrent share and speed limits",
+		"mode sequential", "Set torrents' sequential download",
+		"mode first-last", "Set torrents' first and last piece priority",
+		"mode force-start", "Set torrents' force start",
+		"mode super-seed", "Set torrents' super seeding",
+		"pieces", "Show torrent piece map",
 	}
 
 	cmds := map[string]*kingpin.CmdClause{
@@ -379,6 +626,9 @@ func NewArgs(name, version string) (*Args, string, error) {
 		"peers":    kingpin.Command("peers", "Retrieve information about peers"),
 		"files":    kingpin.Command("files", "Change priority and location of torrent files"),
 		"trackers": kingpin.Command("trackers", "Change torrent trackers"),
+		"labels":   kingpin.Command("labels", "Change torrent labels"),
+		"limits":   kingpin.Command("limits", "Change speed and share limits"),
+		"mode":     kingpin.Command("mode", "Change torrent download modes"),
 	}
 	for i := 0; i < len(commands); i += 2 {
 		f := kingpin.Command
@@ -393,9 +643,7 @@ func NewArgs(name, version string) (*Args, string, error) {
 		cmd.Flag("all", "list all torrents").Hidden().BoolVar(&args.Filter.ListAll)
 		cmd.Flag("recent", "recently active torrents").Short('R').BoolVar(&args.Filter.Recent)
 		cmd.Flag("active", "recently active torrents").Hidden().BoolVar(&args.Filter.Recent)
-		cmd.Flag("filter", "torrent filter").Short('f').PlaceHolder("<filter>").Default(
-			`id == identifier || name %% identifier || (strlen(identifier) >= 5 && hashString %^ identifier)`,
-		).IsSetByUser(&args.Filter.FilterWasSet).StringVar(&args.Filter.Filter)
+		cmd.Flag("filter", "torrent filter").Short('f').PlaceHolder("<filter>").Default(defaultFilter).IsSetByUser(&args.Filter.FilterWasSet).StringVar(&args.Filter.Filter)
 
 		switch commands[i] {
 		case "get":
@@ -416,6 +664,11 @@ func NewArgs(name, version string) (*Args, string, error) {
 
 		case "peers get":
 			args.addOutputFlags(cmd, "address", peersGetColumnNames...)
+			cmd.Flag("geoip-db", "MaxMind-format geoip database (mmdb) for peer countries").PlaceHolder("<file>").StringVar(&args.PeersParams.GeoIPDB)
+
+		case "peers stats":
+			args.addOutputFlags(cmd, "id", peersStatsColumnNames...)
+			cmd.Flag("geoip-db", "MaxMind-format geoip database (mmdb) for peer countries").PlaceHolder("<file>").StringVar(&args.PeersParams.GeoIPDB)
 
 		case "files get":
 			args.addOutputFlags(cmd, "name", filesGetColumnNames...)
@@ -434,17 +687,158 @@ func NewArgs(name, version string) (*Args, string, error) {
 		case "trackers get":
 			args.addOutputFlags(cmd, "id", trackersGetColumnNames...)
 
-		case "trackers add", "trackers remove":
+		case "trackers add":
+			cmd.Flag("tier", "tracker tier (default: new tier)").PlaceHolder("<tier>").IsSetByUser(&args.TrackersAddParams.TierWasSet).Int64Var(&args.TrackersAddParams.Tier)
+			cmd.Arg("tracker", "tracker url").Required().StringVar(&args.Tracker)
+
+		case "trackers remove":
 			cmd.Arg("tracker", "tracker url").Required().StringVar(&args.Tracker)
 
 		case "trackers replace":
+			cmd.Flag("regex", "tracker is a regexp, and replace url can use its submatches ($1, ...)").BoolVar(&args.TrackersReplaceParams.Regex)
 			cmd.Arg("tracker", "tracker url").Required().StringVar(&args.Tracker)
 			cmd.Arg("replace", "replace url").Required().StringVar(&args.TrackersReplaceParams.Replace)
+
+		case "trackers report":
+			args.addOutputFlags(cmd, "host", trackersReportColumnNames...)
+
+		case "labels list":
+			args.addOutputFlags(cmd, "name", labelsListColumnNames...)
+
+		case "mode sequential", "mode first-last", "mode force-start", "mode super-seed":
+			cmd.Arg("state", "mode state (on, off)").Required().EnumVar(&args.ModeParams.State, "on", "off")
+
+		case "pieces":
+			args.addOutputFlags(cmd, "torrent", piecesColumnNames...)
+			cmd.Flag("width", "piece map width").PlaceHolder("<width>").Default("64").IntVar(&args.PiecesParams.Width)
+			cmd.Flag("grid", "show each piece, in rows of the piece map width").BoolVar(&args.PiecesParams.Grid)
+
+		case "labels add", "labels remove":
+			cmd.Arg("labels", "comma separated labels").Required().StringVar(&args.LabelsParams.Labels)
+
+		case "labels set":
+			cmd.Arg("labels", "comma separated labels (empty to clear)").Required().StringVar(&args.LabelsParams.Labels)
+
+		case "limits torrent":
+			cmd.Flag("ratio", "seed ratio limit (e.g., 2, global, unlimited)").PlaceHolder("<ratio>").StringVar(&args.LimitsParams.Ratio)
+			cmd.Flag("idle", "seed idle limit (e.g., 7d, 12h, global, unlimited)").PlaceHolder("<duration>").StringVar(&args.LimitsParams.Idle)
+			cmd.Flag("seeding-time", "seeding time limit (e.g., 30d, global, unlimited)").PlaceHolder("<duration>").StringVar(&args.LimitsParams.SeedingTime)
+			cmd.Flag("down", "download limit (e.g., 1MiB, global, unlimited)").PlaceHolder("<speed>").StringVar(&args.LimitsParams.Down)
+			cmd.Flag("up", "upload limit (e.g., 500KiB, global, unlimited)").PlaceHolder("<speed>").StringVar(&args.LimitsParams.Up)
 		}
 
 		cmd.Arg("torrents", "torrent id, name, or hash").StringsVar(&args.Args)
 	}
 
+	// peers commands
+	peersAddCmd := cmds["peers"].Command("add", "Add peers to torrent")
+	peersAddCmd.Arg("torrent", "torrent id, name, or hash").Required().StringVar(&args.PeersParams.Torrent)
+	peersAddCmd.Arg("peers", "peer addresses (ip:port)").Required().StringsVar(&args.PeersParams.Peers)
+	peersBanCmd := cmds["peers"].Command("ban", "Ban peers")
+	peersBanCmd.Arg("peers", "peer addresses (ip or ip:port)").Required().StringsVar(&args.PeersParams.Peers)
+
+	// categories commands
+	categoriesCmd := kingpin.Command("categories", "Change torrent categories")
+	categoriesListCmd := categoriesCmd.Command("list", "List categories")
+	args.addOutputFlags(categoriesListCmd, "name", categoriesListColumnNames...)
+	categoriesCreateCmd := categoriesCmd.Command("create", "Create category")
+	categoriesCreateCmd.Flag("save-path", "category save path").PlaceHolder("<dir>").StringVar(&args.CategoriesParams.SavePath)
+	categoriesCreateCmd.Arg("name", "category name").Required().StringVar(&args.CategoriesParams.Name)
+	categoriesEditCmd := categoriesCmd.Command("edit", "Edit category")
+	categoriesEditCmd.Flag("save-path", "category save path").PlaceHolder("<dir>").StringVar(&args.CategoriesParams.SavePath)
+	categoriesEditCmd.Arg("name", "category name").Required().StringVar(&args.CategoriesParams.Name)
+	categoriesDeleteCmd := categoriesCmd.Command("delete", "Delete categories")
+	categoriesDeleteCmd.Arg("names", "category names").Required().StringsVar(&args.CategoriesParams.Names)
+
+	// rss commands
+	rssCmd := kingpin.Command("rss", "Manage rss feeds and auto-download rules")
+	rssFeedsCmd := rssCmd.Command("feeds", "Manage rss feeds")
+	rssFeedsListCmd := rssFeedsCmd.Command("list", "List rss feeds")
+	args.addOutputFlags(rssFeedsListCmd, "path", rssFeedsListColumnNames...)
+	rssFeedsArticlesCmd := rssFeedsCmd.Command("articles", "List rss feed articles")
+	args.addOutputFlags(rssFeedsArticlesCmd, "date", rssFeedsArticlesColumnNames...)
+	rssFeedsArticlesCmd.Arg("paths", "feed paths").StringsVar(&args.RSSParams.Paths)
+	rssFeedsAddCmd := rssFeedsCmd.Command("add", "Add rss feed")
+	rssFeedsAddCmd.Flag("path", "feed path (e.g., 'folder\\name')").PlaceHolder("<path>").StringVar(&args.RSSParams.Path)
+	rssFeedsAddCmd.Arg("url", "feed url").Required().StringVar(&args.RSSParams.URL)
+	rssFeedsAddFolderCmd := rssFeedsCmd.Command("add-folder", "Add rss folder")
+	rssFeedsAddFolderCmd.Arg("path", "folder path").Required().StringVar(&args.RSSParams.Path)
+	rssFeedsRemoveCmd := rssFeedsCmd.Command("remove", "Remove rss feeds or folders")
+	rssFeedsRemoveCmd.Arg("paths", "feed or folder paths").Required().StringsVar(&args.RSSParams.Paths)
+	rssFeedsMoveCmd := rssFeedsCmd.Command("move", "Move rss feed or folder")
+	rssFeedsMoveCmd.Arg("path", "feed or folder path").Required().StringVar(&args.RSSParams.Path)
+	rssFeedsMoveCmd.Arg("dest", "destination path").Required().StringVar(&args.RSSParams.Dest)
+	rssRulesCmd := rssCmd.Command("rules", "Manage rss auto-download rules")
+	rssRulesListCmd := rssRulesCmd.Command("list", "List rss rules")
+	args.addOutputFlags(rssRulesListCmd, "name", rssRulesListColumnNames...)
+	rssRulesExportCmd := rssRulesCmd.Command("export", "Export rss rules as yaml")
+	rssRulesExportCmd.Flag("file", "file to write (default: stdout)").Short('f').PlaceHolder("<file>").StringVar(&args.RSSParams.File)
+	rssRulesImportCmd := rssRulesCmd.Command("import", "Import rss rules from yaml")
+	rssRulesImportCmd.Arg("file", "file to read ('-' for stdin)").Required().StringVar(&args.RSSParams.File)
+	rssRulesRenameCmd := rssRulesCmd.Command("rename", "Rename rss rule")
+	rssRulesRenameCmd.Arg("name", "rule name").Required().StringVar(&args.RSSParams.Name)
+	rssRulesRenameCmd.Arg("new-name", "new rule name").Required().StringVar(&args.RSSParams.NewName)
+	rssRulesRemoveCmd := rssRulesCmd.Command("remove", "Remove rss rules")
+	rssRulesRemoveCmd.Arg("names", "rule names").Required().StringsVar(&args.RSSParams.Names)
+	rssTestRuleCmd := rssCmd.Command("test-rule", "Test rss rule against article titles")
+	args.addOutputFlags(rssTestRuleCmd, "id")
+	rssTestRuleCmd.Flag("file", "read rule from exported rules file").Short('f').PlaceHolder("<file>").StringVar(&args.RSSParams.File)
+	rssTestRuleCmd.Arg("rule", "rule name").Required().StringVar(&args.RSSParams.Name)
+	rssTestRuleCmd.Arg("titles", "titles to test (default: affected feed articles)").StringsVar(&args.RSSParams.Titles)
+
+	// limits commands
+	limitsCmd := cmds["limits"]
+	limitsGetCmd := limitsCmd.Command("get", "Get speed limits")
+	args.addOutputFlags(limitsGetCmd, "name")
+	limitsSetCmd := limitsCmd.Command("set", "Set speed limits")
+	limitsSetCmd.Flag("down", "download limit (e.g., 5MiB, unlimited)").PlaceHolder("<speed>").StringVar(&args.LimitsParams.Down)
+	limitsSetCmd.Flag("up", "upload limit (e.g., 1MiB, unlimited)").PlaceHolder("<speed>").StringVar(&args.LimitsParams.Up)
+	limitsSetCmd.Flag("alt-down", "alternate download limit").PlaceHolder("<speed>").StringVar(&args.LimitsParams.AltDown)
+	limitsSetCmd.Flag("alt-up", "alternate upload limit").PlaceHolder("<speed>").StringVar(&args.LimitsParams.AltUp)
+	limitsAltCmd := limitsCmd.Command("alt", "Turn alternate speed limits on or off")
+	limitsAltCmd.Arg("state", "on, off, or toggle").Required().EnumVar(&args.LimitsParams.Alt, "on", "off", "toggle")
+	limitsScheduleCmd := limitsCmd.Command("schedule", "Schedule alternate speed limits")
+	limitsScheduleCmd.Arg("schedule", "time range (e.g., 01:00-07:00), on, or off").Required().StringVar(&args.LimitsParams.Schedule)
+	limitsScheduleCmd.Arg("days", "days (all, weekdays, weekend, or comma separated days)").Default("all").StringVar(&args.LimitsParams.Days)
+
+	// search commands
+	searchCmd := kingpin.Command("search", "Search for torrents using search plugins")
+	searchQueryCmd := searchCmd.Command("query", "Search for torrents").Default()
+	args.addOutputFlags(searchQueryCmd, "id")
+	searchQueryCmd.Flag("plugin", "search plugin (all, enabled, or plugin name)").Default("all").PlaceHolder("<plugin>").StringsVar(&args.SearchParams.Plugins)
+	searchQueryCmd.Flag("category", "search category").Default("all").PlaceHolder("<category>").StringVar(&args.SearchParams.Category)
+	searchQueryCmd.Flag("wait", "max time to wait for search to complete").Default("30s").PlaceHolder("<dur>").DurationVar(&args.SearchParams.Wait)
+	searchQueryCmd.Flag("add", "add search result by id, url, or hash").PlaceHolder("<id>").StringVar(&args.SearchParams.Add)
+	searchQueryCmd.Flag("add-context", "config context to add search result to (default: current context)").PlaceHolder("<context>").StringVar(&args.SearchParams.AddContext)
+	searchQueryCmd.Flag("download-dir", "download directory for added result").Short('d').PlaceHolder("<dir>").StringVar(&args.AddParams.DownloadDir)
+	searchQueryCmd.Flag("label", "label for added result").PlaceHolder("<label>").StringsVar(&args.AddParams.Labels)
+	searchQueryCmd.Flag("paused", "start added result paused").Short('P').BoolVar(&args.AddParams.Paused)
+	searchQueryCmd.Arg("query", "search query").Required().StringVar(&args.SearchParams.Pattern)
+	searchPluginsCmd := searchCmd.Command("plugins", "Manage search plugins")
+	searchPluginsListCmd := searchPluginsCmd.Command("list", "List search plugins")
+	args.addOutputFlags(searchPluginsListCmd, "name")
+	searchPluginsInstallCmd := searchPluginsCmd.Command("install", "Install search plugins")
+	searchPluginsInstallCmd.Arg("sources", "plugin urls or file paths").Required().StringsVar(&args.SearchParams.Names)
+	searchPluginsUninstallCmd := searchPluginsCmd.Command("uninstall", "Uninstall search plugins")
+	searchPluginsUninstallCmd.Arg("names", "plugin names").Required().StringsVar(&args.SearchParams.Names)
+	searchPluginsEnableCmd := searchPluginsCmd.Command("enable", "Enable search plugins")
+	searchPluginsEnableCmd.Arg("names", "plugin names").Required().StringsVar(&args.SearchParams.Names)
+	searchPluginsDisableCmd := searchPluginsCmd.Command("disable", "Disable search plugins")
+	searchPluginsDisableCmd.Arg("names", "plugin names").Required().StringsVar(&args.SearchParams.Names)
+	_ = searchPluginsCmd.Command("update", "Update search plugins")
+
+	// logs commands
+	logsCmd := kingpin.Command("logs", "Show remote host logs")
+	logsMainCmd := logsCmd.Command("main", "Show remote host log messages").Default()
+	logsMainCmd.Flag("level", "minimum message level (normal, info, warning, critical)").Default("normal").PlaceHolder("<level>").EnumVar(&args.LogsParams.Level, "normal", "info", "warning", "critical")
+	logsPeersCmd := logsCmd.Command("peers", "Show banned and blocked peer log messages")
+	for _, cmd := range []*kingpin.CmdClause{logsMainCmd, logsPeersCmd} {
+		args.addOutputFlags(cmd, "id")
+		cmd.Flag("follow", "poll for and show new messages").Short('f').BoolVar(&args.LogsParams.Follow)
+		cmd.Flag("since", "only show messages newer than a relative duration (e.g., 1h)").PlaceHolder("<dur>").DurationVar(&args.LogsParams.Since)
+		cmd.Flag("interval", "poll interval when following").Default("2s").PlaceHolder("<dur>").DurationVar(&args.LogsParams.Interval)
+	}
+
 	// stats command
 	statsCmd := kingpin.Command("stats", "Get session statistics")
 	args.addOutputFlags(statsCmd, "name")
@@ -454,8 +848,7 @@ func NewArgs(name, version string) (*Args, string, error) {
 
 	// free-space command
 	freeSpaceCmd := kingpin.Command("free-space", "Retrieve free space")
-	freeSpaceCmd.Flag("human", "print sizes in powers of 1024 (e.g., 1023MiB) (default: true)").Default("true").StringVar(&args.Output.Human)
-	freeSpaceCmd.Flag("si", "print sizes in powers of 1000 (e.g., 1.1GB)").BoolVar(&args.Output.SI)
+	args.addOutputFlags(freeSpaceCmd, "location")
 	freeSpaceCmd.Arg("location", "location").StringsVar(&args.Args)
 
 	// blocklist-update command
@@ -464,6 +857,39 @@ func NewArgs(name, version string) (*Args, string, error) {
 	// port-test command
 	_ = kingpin.Command("port-test", "Check if external port is open")
 
+	// daemon command
+	daemonCmd := kingpin.Command("daemon", "Run scheduled jobs")
+	daemonCmd.Flag("log-format", "log format (logfmt, json)").Default("logfmt").EnumVar(&args.DaemonParams.LogFormat, "logfmt", "json")
+	daemonCmd.Flag("once", "run all jobs once and exit").BoolVar(&args.DaemonParams.Once)
+	daemonCmd.Flag("poll-interval", "torrent poll interval for event hooks").Default("30s").PlaceHolder("<dur>").DurationVar(&args.DaemonParams.PollInterval)
+	daemonCmd.Flag("shutdown-timeout", "time to wait for running jobs on shutdown").Default("30s").PlaceHolder("<dur>").DurationVar(&args.DaemonParams.ShutdownTimeout)
+
+	// watch-dir command
+	watchDirCmd := kingpin.Command("watch-dir", "Watch a directory for torrents to add")
+	watchDirCmd.Flag("download-dir", "download directory").Short('d').PlaceHolder("<dir>").StringVar(&args.AddParams.DownloadDir)
+	watchDirCmd.Flag("label", "torrent label").PlaceHolder("<label>").StringsVar(&args.AddParams.Labels)
+	watchDirCmd.Flag("paused", "start torrents paused").Short('P').BoolVar(&args.AddParams.Paused)
+	watchDirCmd.Flag("interval", "poll interval").Default("5s").PlaceHolder("<dur>").DurationVar(&args.WatchDirParams.Interval)
+	watchDirCmd.Flag("poll", "force polling instead of filesystem notifications").BoolVar(&args.WatchDirParams.Poll)
+	watchDirCmd.Flag("poll-interval", "torrent poll interval for event hooks").Default("30s").PlaceHolder("<dur>").DurationVar(&args.WatchDirParams.PollInterval)
+	watchDirCmd.Flag("log-format", "log format (logfmt, json)").Default("logfmt").EnumVar(&args.WatchDirParams.LogFormat, "logfmt", "json")
+	watchDirCmd.Arg("dir", "directory to watch").Required().StringVar(&args.WatchDirParams.Dir)
+
+	// exporter command
+	exporterCmd := kingpin.Command("exporter", "Serve Prometheus metrics")
+	exporterCmd.Flag("listen", "listen address").Short('l').Default(":9742").PlaceHolder("<addr>").StringVar(&args.ExporterParams.Listen)
+	exporterCmd.Flag("contexts", "config contexts to export (default: current context)").PlaceHolder("<context>").StringsVar(&args.ExporterParams.Contexts)
+	exporterCmd.Flag("torrent-metrics", "export per-torrent metrics").BoolVar(&args.ExporterParams.TorrentMetrics)
+	exporterCmd.Flag("log-format", "log format (logfmt, json)").Default("logfmt").EnumVar(&args.ExporterParams.LogFormat, "logfmt", "json")
+
+	// serve command
+	serveCmd := kingpin.Command("serve", "Serve a HTTP JSON API for the configured contexts")
+	serveCmd.Flag("listen", "listen address").Short('l').Default(":9999").PlaceHolder("<addr>").StringVar(&args.ServeParams.Listen)
+	serveCmd.Flag("versioned", "wrap json output with apiVersion and kind ("+APIVersion+")").IsSetByUser(&args.Output.VersionedWasSet).BoolVar(&args.Output.Versioned)
+	serveCmd.Flag("token", "bearer token required for requests").Envar("TRANSTOKEN").PlaceHolder("<token>").StringVar(&args.ServeParams.Token)
+	serveCmd.Flag("read-only", "disallow requests that change state").BoolVar(&args.ServeParams.ReadOnly)
+	serveCmd.Flag("log-format", "log format (logfmt, json)").Default("logfmt").EnumVar(&args.ServeParams.LogFormat, "logfmt", "json")
+
 	// add --version flag
 	kingpin.Flag("version", "display version and exit").PreAction(func(*kingpin.ParseContext) error {
 		fmt.Fprintln(os.Stdout, args.name, args.version)
@@ -497,6 +923,7 @@ func (args *Args) addOutputFlags(cmd *kingpin.CmdClause, sortBy string, columnNa
 	cmd.Flag("by", "sort output order by column").Hidden().PlaceHolder("<sort>").IsSetByUser(&args.Output.SortByWasSet).StringVar(&args.Output.SortBy)
 	cmd.Flag("sort-order", "sort output order (asc, desc; default: asc)").PlaceHolder("<order>").Default("asc").IsSetByUser(&args.Output.SortOrderWasSet).EnumVar(&args.Output.SortOrder, "asc", "desc")
 	cmd.Flag("order", "sort output order (asc, desc; default: asc)").Hidden().PlaceHolder("<order>").IsSetByUser(&args.Output.SortOrderWasSet).EnumVar(&args.Output.SortOrder, "asc", "desc")
+	cmd.Flag("versioned", "wrap json and yaml output with apiVersion and kind ("+APIVersion+")").IsSetByUser(&args.Output.VersionedWasSet).BoolVar(&args.Output.Versioned)
 }
 
 // loadConfig loads the configuration file from disk.
@@ -528,6 +955,9 @@ func (args *Args) loadConfig(cmd string) error {
 	if v := strings.ToLower(strings.TrimSpace(args.Config.GetKey("default.si"))); v != "" && !args.Output.SIWasSet {
 		args.Output.SI = v == "true" || v == "1"
 	}
+	if v := strings.ToLower(strings.TrimSpace(args.Config.GetKey("default.versioned"))); v != "" && !args.Output.VersionedWasSet {
+		args.Output.Versioned = v == "true" || v == "1"
+	}
 	if v := strings.ToLower(strings.TrimSpace(args.Config.GetKey("command.add.rm"))); v != "" && !args.AddParams.RemoveWasSet {
 		args.AddParams.Remove = v == "true" || v == "1"
 	}
@@ -543,6 +973,10 @@ func (args *Args) loadConfig(cmd string) error {
 	// check that either a name was passed, or that --all was specified
 	case "config":
 		switch {
+		case args.ConfigParams.Name == setCredentials && (args.ConfigParams.Remote || args.ConfigParams.Unset):
+			return ErrCannotSetCredentialsWithRemoteOrUnset
+		case args.ConfigParams.Name == setCredentials && args.ConfigParams.Value == "":
+			return ErrMustSpecifyContextToSetCredentials
 		case args.Filter.ListAll && args.ConfigParams.Unset:
 			return ErrCannotListAllOptionsAndUnset
 		case args.ConfigParams.Remote && args.ConfigParams.Unset:
@@ -558,8 +992,11 @@ func (args *Args) loadConfig(cmd string) error {
 	// check exactly one of --list, --recent, --filter, or len(args.Args) > 0 conditions
 	case "get", "set", "start", "stop", "move", "remove", "verify", "reannounce",
 		"peers get", "files get", "files set-priority", "files set-wanted", "files set-unwanted",
-		"trackers get", "trackers add", "trackers replace", "trackers remove",
-		"queue top", "queue bottom", "queue up", "queue down":
+		"trackers get", "trackers add", "trackers replace", "trackers remove", "trackers dedupe",
+		"queue top", "queue bottom", "queue up", "queue down",
+		"labels add", "labels remove", "labels set",
+		"limits torrent", "pieces",
+		"mode sequential", "mode first-last", "mode force-start", "mode super-seed":
 		switch {
 		case args.Filter.ListAll && args.Filter.Recent,
 			args.Filter.ListAll && len(args.Args) != 0,
@@ -569,6 +1006,28 @@ func (args *Args) loadConfig(cmd string) error {
 			!args.Filter.ListAll && !args.Filter.Recent && !args.Filter.FilterWasSet && len(args.Args) == 0:
 			return ErrMustSpecifyListRecentFilterOrAtLeastOneTorrent
 		}
+		// check labels were passed to add or remove
+		if (cmd == "labels add" || cmd == "labels remove") && len(splitLabels(args.LabelsParams.Labels)) == 0 {
+			return ErrMustSpecifyAtLeastOneLabel
+		}
+
+	// the torrent is the first argument when adding peers
+	case "peers add":
+		args.Args, args.Filter.Filter = []string{strings.TrimSpace(args.PeersParams.Torrent)}, defaultFilter
+
+	// default to all torrents when listing labels, peer stats, or tracker
+	// reports
+	case "labels list", "peers stats", "trackers report":
+		switch {
+		case args.Filter.ListAll && args.Filter.Recent,
+			args.Filter.ListAll && len(args.Args) != 0,
+			args.Filter.Recent && len(args.Args) != 0,
+			args.Filter.ListAll && args.Filter.FilterWasSet,
+			args.Filter.Recent && args.Filter.FilterWasSet:
+			return ErrMustSpecifyListRecentFilterOrAtLeastOneTorrent
+		case !args.Filter.Recent && !args.Filter.FilterWasSet && len(args.Args) == 0:
+			args.Filter.ListAll = true
+		}
 
 	// check that either a location was passed as an argument, or specified via
 	// config context options
@@ -629,8 +1088,44 @@ func (args *Args) getContextKey(name string) string {
 	return args.Config.GetKey("default." + name)
 }
 
-// newClient builds a transrpc client for use by other commands.
-func (args *Args) newClient() (*transrpc.Client, error) {
+// Remote holds the resolved remote host settings used by providers to build
+// their clients.
+type Remote struct {
+	// URL is the remote host url.
+	URL *url.URL
+
+	// Fallback are the fallback credentials (user, pass) to use when the
+	// remote host requires authentication.
+	Fallback []string
+
+	// Timeout is the remote host request timeout.
+	Timeout time.Duration
+
+	// UserAgent is the user agent to send to the remote host.
+	UserAgent string
+
+	// Strict toggles failing on unknown fields in responses.
+	Strict bool
+
+	// TLS is the TLS config, if any TLS options were specified.
+	TLS *tls.Config
+
+	// Dialer is the dialer, if a unix socket or proxy was specified.
+	Dialer *tctypes.Dialer
+
+	// Session is the session cache, if session caching was toggled.
+	Session *tctypes.SessionCache
+
+	// HAR is the HAR writer, if a trace file was specified. When set, requests
+	// and responses are written to the HAR writer instead of Logf.
+	HAR *tctypes.HARWriter
+
+	// Logf is the verbose logging func, if verbose is toggled.
+	Logf func(string, ...interface{})
+}
+
+// Remote resolves the remote host settings for the current context.
+func (args *Args) Remote() (*Remote, error) {
 	var err error
 
 	// choose specified url first
@@ -638,7 +1133,7 @@ func (args *Args) newClient() (*transrpc.Client, error) {
 
 	// check if host is specified
 	if u == nil && args.Host.Host != "" {
-		u, err = url.Parse(args.Host.Proto + "://" + args.Host.Host + args.Host.RpcPath)
+		u, err = url.Parse(args.Host.Proto + "://" + args.Host.Host + args.rpcPath())
 		if err != nil {
 			return nil, ErrInvalidProtoHostOrRpcPath
 		}
@@ -658,12 +1153,28 @@ func (args *Args) newClient() (*transrpc.Client, error) {
 		if host == "" {
 			host = "localhost:9091"
 		}
-		u, err = url.Parse(args.Host.Proto + "://" + host + args.Host.RpcPath)
+		u, err = url.Parse(args.Host.Proto + "://" + host + args.rpcPath())
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// copy, as the url may be shared between contexts
+	z := *u
+	u = &z
+
+	// unix socket, with the rpc path after the socket path
+	// (unix:///path/to/sock:/rpc/path), or the provider's rpc path
+	var socket string
+	if u.Scheme == "unix" {
+		path := args.rpcPath()
+		if i := strings.Index(u.Path, ":"); i != -1 {
+			u.Path, path = u.Path[:i], u.Path[i+1:]
+		}
+		socket = u.Path
+		u.Scheme, u.Host, u.Path, u.RawPath = "http", "localhost", path, ""
+	}
+
 	// add credentials
 	if u.User == nil && args.Host.CredentialsWasSet && args.Host.Credentials != "" {
 		creds := strings.SplitN(args.Host.Credentials, ":", 2)
@@ -682,24 +1193,29 @@ func (args *Args) newClient() (*transrpc.Client, error) {
 		}
 	}
 
-	// build options
-	opts := []transrpc.ClientOption{
-		transrpc.WithUserAgent(args.name + "/" + args.version + " (" + runtime.GOOS + "/" + runtime.GOARCH + ")"),
-		transrpc.WithURL(u.String()),
-		transrpc.WithTimeout(timeout),
+	remote := &Remote{
+		URL:       u,
+		Timeout:   timeout,
+		UserAgent: args.name + "/" + args.version + " (" + runtime.GOOS + "/" + runtime.GOARCH + ")",
+		Strict:    args.Host.Strict,
+	}
+
+	// load stored credentials
+	if !args.Host.CredentialsWasSet {
+		if remote.Fallback, err = args.storedCredentials(); err != nil {
+			return nil, err
+		}
 	}
 
 	// load netrc credentials
-	var setFallback bool
-	if !args.Host.NoNetrc && !args.Host.CredentialsWasSet {
+	if remote.Fallback == nil && !args.Host.NoNetrc && !args.Host.CredentialsWasSet {
 		fi, err := os.Stat(args.Host.NetrcFile)
 		if err == nil && !fi.IsDir() {
 			if n, err := netrc.Parse(args.Host.NetrcFile); err == nil {
 				if m := n.Machine(u.Hostname()); m != nil {
 					user, pass := m.Get("login"), m.Get("password")
 					if user != "" {
-						setFallback = true
-						opts = append(opts, transrpc.WithCredentialFallback(user, pass))
+						remote.Fallback = []string{user, pass}
 					}
 				}
 			}
@@ -707,20 +1223,144 @@ func (args *Args) newClient() (*transrpc.Client, error) {
 	}
 
 	// set fallback credentials for localhost when none were specified
-	if !setFallback && !args.Host.CredentialsWasSet && u.Hostname() == "localhost" {
-		opts = append(opts, transrpc.WithCredentialFallback("transmission", "transmission"))
+	if remote.Fallback == nil && !args.Host.CredentialsWasSet && u.Hostname() == "localhost" {
+		remote.Fallback = []string{"transmission", "transmission"}
+	}
+
+	// build tls config
+	if remote.TLS, err = args.tlsConfig(); err != nil {
+		return nil, err
+	}
+
+	// build dialer
+	proxy := args.Host.Proxy
+	if v := strings.TrimSpace(args.getContextKey("proxy")); proxy == nil && v != "" {
+		if proxy, err = url.Parse(v); err != nil {
+			return nil, err
+		}
+	}
+	if socket != "" || proxy != nil {
+		remote.Dialer = &tctypes.Dialer{
+			Socket:  socket,
+			Proxy:   proxy,
+			Timeout: timeout,
+		}
+	}
+
+	// session cache, keyed by context and url
+	cache := args.Host.SessionCache
+	if v := strings.ToLower(strings.TrimSpace(args.getContextKey("session-cache"))); !cache && v != "" {
+		cache = v == "true" || v == "1"
+	}
+	if cache {
+		context := args.Context
+		if context == "" {
+			context = args.Config.GetKey("default.context")
+		}
+		remote.Session = tctypes.NewSessionCache(args.sessionDir, context+"\x00"+u.String(), sessionMaxAge)
 	}
 
 	if args.Verbose {
-		opts = append(opts, transrpc.WithLogf(args.logf(os.Stderr)))
+		remote.Logf = args.logf(os.Stderr)
+	}
+	if args.TraceFile != "" {
+		if args.har == nil {
+			args.har = tctypes.NewHARWriter(args.TraceFile, args.name, args.version)
+		}
+		remote.HAR = args.har
 	}
 
-	return transrpc.NewClient(opts...), nil
+	return remote, nil
+}
+
+// Close closes the trace file, if any.
+func (args *Args) Close() error {
+	if args.har != nil {
+		return args.har.Close()
+	}
+	return nil
+}
+
+// tlsConfig builds the TLS config from the TLS flags, falling back to the
+// context's config keys. Returns nil when no TLS options were specified.
+func (args *Args) tlsConfig() (*tls.Config, error) {
+	opts := tctypes.TLSOptions{
+		CACert:   args.Host.CACert,
+		Cert:     args.Host.Cert,
+		Key:      args.Host.Key,
+		Pins:     args.Host.Pins,
+		Insecure: args.Host.Insecure,
+	}
+	if opts.CACert == "" {
+		opts.CACert = strings.TrimSpace(args.getContextKey("cacert"))
+	}
+	if opts.Cert == "" && opts.Key == "" {
+		opts.Cert = strings.TrimSpace(args.getContextKey("cert"))
+		opts.Key = strings.TrimSpace(args.getContextKey("key"))
+	}
+	if len(opts.Pins) == 0 {
+		opts.Pins = splitList(args.getContextKey("pin"))
+	}
+	if !opts.Insecure {
+		v := strings.ToLower(strings.TrimSpace(args.getContextKey("insecure")))
+		opts.Insecure = v == "true" || v == "1"
+	}
+	if opts.Empty() {
+		return nil, nil
+	}
+	return opts.Config()
 }
 
 // NewProvider creates a new provider based on the configured type of the remote host.
 func (args *Args) NewProvider() (Provider, error) {
-	return nil, nil
+	typ := args.providerType()
+	f, ok := providers[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider type %q", typ)
+	}
+	return f(args)
+}
+
+// providerType returns the provider type of the current context.
+func (args *Args) providerType() string {
+	if typ := strings.ToLower(strings.TrimSpace(args.getContextKey("type"))); typ != "" {
+		return typ
+	}
+	return "transmission"
+}
+
+// rpcPaths are the default rpc paths of the provider types.
+var rpcPaths = map[string]string{
+	"transmission": "/transmission/rpc/",
+	"qbittorrent":  "/api/v2",
+}
+
+// rpcPath returns the rpc path for building URLs, using the default rpc path
+// of the context's provider type when --rpc-path was not specified.
+func (args *Args) rpcPath() string {
+	if path, ok := rpcPaths[args.providerType()]; ok && !args.Host.RpcPathWasSet {
+		return path
+	}
+	return args.Host.RpcPath
+}
+
+// AddOptions returns the add options for the add params.
+func (args *Args) AddOptions() AddOptions {
+	return AddOptions{
+		Cookies:           args.AddParams.Cookies,
+		DownloadDir:       args.AddParams.DownloadDir,
+		Paused:            args.AddParams.Paused,
+		PeerLimit:         args.AddParams.PeerLimit,
+		BandwidthPriority: args.AddParams.BandwidthPriority,
+		Labels:            args.AddParams.Labels,
+	}
+}
+
+// WithContext returns a copy of the args using the named config context.
+func (args *Args) WithContext(name string) *Args {
+	z := *args
+	z.Context = name
+	return &z
 }
 
 // logf creates a new log func with the specified prefix.
@@ -742,9 +1382,10 @@ func (args *Args) formatBytes(x tctypes.ByteFormatter) string {
 	return strconv.FormatInt(x.Int64(), 10)
 }
 
-// ResultOptions builds result options for arguments.
+// ResultOptions builds result options for arguments. The passed options are
+// applied after the options from the arguments.
 func (args *Args) ResultOptions(opts ...ResultOption) []ResultOption {
-	return append(opts,
+	return append([]ResultOption{
 		Output(args.Output.Output),
 		SortBy(args.Output.SortBy, args.Output.SortByWasSet),
 		SortOrder(args.Output.SortOrder, args.Output.SortOrderWasSet),
@@ -752,5 +1393,6 @@ func (args *Args) ResultOptions(opts ...ResultOption) []ResultOption {
 		FormatBytes(args.formatBytes),
 		NoHeaders(args.Output.NoHeaders),
 		NoTotals(args.Output.NoTotals),
-	)
+		Versioned(args.Output.Versioned),
+	}, opts...)
 }
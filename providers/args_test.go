@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/knq/ini"
+)
+
+func TestRemoteRpcPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		urlstr  string
+		rpcPath string
+		exp     string
+		socket  string
+	}{
+		{"transmission unix", "transmission", "unix:///run/transmission.sock", "", "http://localhost/transmission/rpc/", "/run/transmission.sock"},
+		{"qbittorrent unix", "qbittorrent", "unix:///run/qbittorrent.sock", "", "http://localhost/api/v2", "/run/qbittorrent.sock"},
+		{"unix url path", "qbittorrent", "unix:///run/qbittorrent.sock:/qbt/api/v2", "", "http://localhost/qbt/api/v2", "/run/qbittorrent.sock"},
+		{"unix rpc path", "transmission", "unix:///run/transmission.sock", "/rpc", "http://localhost/rpc", "/run/transmission.sock"},
+		{"unix url path and rpc path", "transmission", "unix:///run/transmission.sock:/other/rpc", "/rpc", "http://localhost/other/rpc", "/run/transmission.sock"},
+		{"qbittorrent host", "qbittorrent", "", "", "http://localhost:9091/api/v2", ""},
+		{"transmission host", "", "", "", "http://localhost:9091/transmission/rpc/", ""},
+		{"url", "qbittorrent", "http://example.com:8080/api/v2", "", "http://example.com:8080/api/v2", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := ini.NewFile()
+			config.SetKey("context.test.type", test.typ)
+			config.SetKey("context.test.url", test.urlstr)
+			args := &Args{Config: config, Context: "test"}
+			args.Host.Proto, args.Host.RpcPath, args.Host.NoNetrc = "http", "/transmission/rpc/", true
+			if test.rpcPath != "" {
+				args.Host.RpcPath, args.Host.RpcPathWasSet = test.rpcPath, true
+			}
+			remote, err := args.Remote()
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s := remote.URL.String(); s != test.exp {
+				t.Errorf("expected url %q, got: %q", test.exp, s)
+			}
+			var socket string
+			if remote.Dialer != nil {
+				socket = remote.Dialer.Socket
+			}
+			if socket != test.socket {
+				t.Errorf("expected socket %q, got: %q", test.socket, socket)
+			}
+		})
+	}
+}
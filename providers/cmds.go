@@ -2,11 +2,12 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -14,40 +15,11 @@ import (
 	"github.com/kenshaw/transctl/transrpc"
 )
 
-/*
-	"config":             doConfig,
-	"add":                doAdd,
-	"get":                doGet,
-	"set":                doSet,
-	"start":              doReq(transrpc.TorrentStart),
-	"stop":               doReq(transrpc.TorrentStop),
-	"move":               doMove,
-	"remove":             doRemove,
-	"verify":             doReq(transrpc.TorrentVerify),
-	"reannounce":         doReq(transrpc.TorrentReannounce),
-	"queue top":          doReq(transrpc.QueueMoveTop),
-	"queue bottom":       doReq(transrpc.QueueMoveBottom),
-	"queue up":           doReq(transrpc.QueueMoveUp),
-	"queue down":         doReq(transrpc.QueueMoveDown),
-	"peers get":          doPeersGet,
-	"files get":          doFilesGet,
-	"files set-priority": doFilesSetPriority,
-	"files set-wanted":   doFilesSet("FilesWanted"),
-	"files set-unwanted": doFilesSet("FilesUnwanted"),
-	"files rename":       doFilesRename,
-	"trackers get":       doTrackersGet,
-	"trackers add":       doTrackersAdd,
-	"trackers replace":   doTrackersReplace,
-	"trackers remove":    doTrackersRemove,
-	"stats":              doStats,
-	"shutdown":           doShutdown,
-	"free-space":         doFreeSpace,
-	"blocklist-update":   doBlocklistUpdate,
-	"port-test":          doPortTest,
-*/
-
 // DoConfig is the high-level entry point for 'config'.
 func DoConfig(ctx context.Context, args *Args, cmd string) error {
+	if args.ConfigParams.Name == setCredentials {
+		return doSetCredentials(args, args.ConfigParams.Value)
+	}
 	var store ConfigStore = args.Config
 	if args.ConfigParams.Remote {
 		var err error
@@ -112,31 +84,8 @@ func DoAdd(ctx context.Context, args *Args, cmd string) error {
 		}
 	}
 
-	/*
-		result, err := p.Add(files...)
-			// build request
-			req := transrpc.TorrentAdd().
-				WithCookiesMap(args.AddParams.Cookies).
-				WithDownloadDir(args.AddParams.DownloadDir).
-				WithPaused(args.AddParams.Paused).
-				WithPeerLimit(args.AddParams.PeerLimit).
-				WithBandwidthPriority(args.AddParams.BandwidthPriority)
-
-			// execute
-			res, err := req.Do(ctx, cl)
-			if err != nil {
-				return err
-			}
-			if res.TorrentAdded != nil {
-				result = append(result, *res.TorrentAdded)
-			}
-			if res.TorrentDuplicate != nil {
-				result = append(result, *res.TorrentDuplicate)
-			}
-	*/
-
 	// execute
-	result, err := p.Add(ctx, files...)
+	result, err := p.Add(ctx, args.AddOptions(), files...)
 	if err != nil {
 		return err
 	}
@@ -145,6 +94,7 @@ func DoAdd(ctx context.Context, args *Args, cmd string) error {
 		WideColumns(defaultWideCols...),
 		FlatName("torrent"),
 		FlatIndex("shortHash"),
+		Kind(KindTorrentList),
 	)...).Encode(os.Stdout); err != nil {
 		return err
 	}
@@ -165,7 +115,7 @@ func DoAdd(ctx context.Context, args *Args, cmd string) error {
 
 var (
 	defaultTableCols = []string{"id", "name", "status", "eta", "rateDownload", "rateUpload", "haveValid", "percentDone", "shortHash"}
-	defaultWideCols  = []string{"id", "name", "peersConnected", "downloadDir", "addedDate", "status", "eta", "rateDownload", "rateUpload", "haveValid", "percentDone", "shortHash"}
+	defaultWideCols  = []string{"id", "name", "peersConnected", "downloadDir", "labels", "addedDate", "status", "eta", "rateDownload", "rateUpload", "haveValid", "percentDone", "shortHash"}
 )
 
 // DoGet is the high-level entry point for 'get'.
@@ -174,7 +124,7 @@ func DoGet(ctx context.Context, args *Args, cmd string) error {
 	if err != nil {
 		return err
 	}
-	torrents, err := p.FindTorrents(ctx)
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
@@ -189,9 +139,8 @@ func DoGet(ctx context.Context, args *Args, cmd string) error {
 		case strings.HasPrefix(args.Output.Output, "cols="):
 			fields = strings.Split(args.Output.Output[5:], ",")
 		}
-		req := transrpc.TorrentGet(p.ConvertTorrentIDs(torrents)...)
+		var cols []string
 		if len(fields) != 0 {
-			var cols []string
 			// inverse lookup
 			m := make(map[string]string)
 			for k, v := range args.Output.ColumnNames {
@@ -207,24 +156,26 @@ func DoGet(ctx context.Context, args *Args, cmd string) error {
 				}
 				cols = append(cols, col)
 			}
-			req = req.WithFields(cols...)
 		}
-		res, err := req.Do(ctx, p)
-		if err != nil {
+		if result, err = p.Get(ctx, cols, ConvertTorrentIDs(torrents)...); err != nil {
 			return err
 		}
-		result = res.Torrents
 	}
 	return NewResult(result, args.ResultOptions(
 		TableColumns(defaultTableCols...),
 		WideColumns(defaultWideCols...),
 		FlatName("torrent"),
 		FlatIndex("shortHash"),
+		Kind(KindTorrentList),
 	)...).Encode(os.Stdout)
 }
 
 // DoSet is the high-level entry point for 'set'.
 func DoSet(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
 	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
@@ -232,130 +183,131 @@ func DoSet(ctx context.Context, args *Args, cmd string) error {
 	if len(torrents) == 0 {
 		return nil
 	}
-	return doWithAndExecute(cl, transrpc.TorrentSet(convTorrentIDs(torrents)...), "torrent", args.ConfigParams.Name, args.ConfigParams.Value)
+	return p.Set(ctx, map[string]interface{}{
+		args.ConfigParams.Name: args.ConfigParams.Value,
+	}, ConvertTorrentIDs(torrents)...)
 }
 
-// DoReq creates the high-level entry points for general torrent manipulation
-// requests.
-func DoReq(ctx context.Context, f func(...interface{}) *transrpc.Request) func(*Args) error {
-	return func(args *Args) error {
-		p, err := args.NewProvider()
-		if err != nil {
-			return err
-		}
-		torrents, err := p.FindTorrents(ctx, args)
-		if err != nil {
-			return err
-		}
-		if len(torrents) == 0 {
-			return nil
-		}
-		return f(convTorrentIDs(torrents)...).Do(ctx, p)
+// DoReq is the high-level entry point for general torrent manipulation
+// requests ('start', 'stop', 'verify', 'reannounce', and 'queue').
+func DoReq(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
 	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	ids := ConvertTorrentIDs(torrents)
+	switch cmd {
+	case "start":
+		if args.StartParams.Now {
+			return startNow(ctx, p, ids...)
+		}
+		return p.Start(ctx, ids...)
+	case "stop":
+		return p.Stop(ctx, ids...)
+	case "verify":
+		return p.Verify(ctx, ids...)
+	case "reannounce":
+		return p.Reannounce(ctx, ids...)
+	case "queue top", "queue bottom", "queue up", "queue down":
+		return p.Queue(ctx, strings.TrimPrefix(cmd, "queue "), ids...)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// startNow starts the torrents now, bypassing the queue, using the same
+// download mode as 'mode force-start on'.
+func startNow(ctx context.Context, p Provider, ids ...interface{}) error {
+	mp, ok := p.(ModeProvider)
+	if !ok {
+		return fmt.Errorf("start now %w", ErrNotSupportedByProvider)
+	}
+	return mp.ModeSet(ctx, tctypes.DownloadModeForceStart, true, ids...)
 }
 
 // DoMove is the high-level entry point for 'move'.
-func DoMove(ctx context.Context, args *Args) error {
-	torrents, err := FindTorrents(ctx, args)
+func DoMove(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	return transrpc.TorrentSetLocation(
-		args.MoveParams.Dest, true, convTorrentIDs(torrents)...,
-	).Do(ctx, cl)
+	return p.Move(ctx, args.MoveParams.Dest, ConvertTorrentIDs(torrents)...)
 }
 
 // DoRemove is the high-level entry point for 'remove'.
-func DoRemove(ctx context.Context, args *Args) error {
-	torrents, err := FindTorrents(ctx, args)
+func DoRemove(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	return transrpc.TorrentRemove(
-		args.RemoveParams.Remove, convTorrentIDs(torrents)...,
-	).Do(ctx, cl)
+	return p.Remove(ctx, args.RemoveParams.Remove, ConvertTorrentIDs(torrents)...)
 }
 
 // DoPeersGet is the high-level entry point for 'peers get'.
-func DoPeersGet(ctx context.Context, args *Args) error {
-	torrents, err := FindTorrents(ctx, args)
+func DoPeersGet(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	var result []tctypes.Peer
 	if len(torrents) != 0 {
-		res, err := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("name", "hashString", "peers").Do(ctx, cl)
-		if err != nil {
+		if result, err = p.PeersGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
 			return err
 		}
-		for _, t := range res.Torrents {
-			for i, v := range t.Peers {
-				result = append(result, tctypes.Peer{
-					Address:            v.Address,
-					ClientName:         v.ClientName,
-					ClientIsChoked:     v.ClientIsChoked,
-					ClientIsInterested: v.ClientIsInterested,
-					FlagStr:            v.FlagStr,
-					IsDownloadingFrom:  v.IsDownloadingFrom,
-					IsEncrypted:        v.IsEncrypted,
-					IsIncoming:         v.IsIncoming,
-					IsUploadingTo:      v.IsUploadingTo,
-					IsUTP:              v.IsUTP,
-					PeerIsChoked:       v.PeerIsChoked,
-					PeerIsInterested:   v.PeerIsInterested,
-					Port:               v.Port,
-					Progress:           v.Progress,
-					RateToClient:       v.RateToClient,
-					RateToPeer:         v.RateToPeer,
-					ID:                 int64(i),
-					Torrent:            t.Name,
-					HashString:         t.HashString,
-				})
-			}
-		}
+	}
+	if err := args.lookupPeerCountries(result); err != nil {
+		return err
 	}
 	return NewResult(result, args.ResultOptions(
 		TableColumns("address", "clientName", "rateToClient", "rateToPeer", "progress", "shortHash"),
-		WideColumns("address", "port", "clientName", "flagStr", "clientIsInterested", "isEncrypted", "rateToClient", "rateToPeer", "progress", "shortHash"),
+		WideColumns("address", "port", "clientName", "flagStr", "clientIsInterested", "isEncrypted", "country", "rateToClient", "rateToPeer", "progress", "shortHash"),
 		YamlName("peers"),
 		FlatName("peers"),
 		FlatKey("id"),
 		FlatIndex("shortHash"),
+		Kind(KindPeerList),
 	)...).Encode(os.Stdout)
 }
 
 // DoFilesGet is the high-level entry point for 'files get'.
-func DoFilesGet(args *Args) error {
-	torrents, err := FindTorrents(ctx, args)
+func DoFilesGet(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	var result []tctypes.File
 	if len(torrents) != 0 {
-		res, err := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("name", "hashString", "files", "fileStats").Do(ctx, cl)
-		if err != nil {
+		if result, err = p.FilesGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
 			return err
 		}
-		for _, t := range res.Torrents {
-			for i, v := range t.Files {
-				result = append(result, tctypes.File{
-					BytesCompleted: v.BytesCompleted,
-					Length:         v.Length,
-					Name:           v.Name,
-					Wanted:         t.FileStats[i].Wanted,
-					Priority:       t.FileStats[i].Priority.String(),
-					ID:             int64(i),
-					Torrent:        t.Name,
-					HashString:     t.HashString,
-				})
-			}
-		}
 	}
 	return NewResult(result, args.ResultOptions(
 		TableColumns("name", "priority", "bytesCompleted", "percentDone", "shortHash"),
@@ -364,138 +316,106 @@ func DoFilesGet(args *Args) error {
 		FlatName("files"),
 		FlatKey("id"),
 		FlatIndex("shortHash"),
+		Kind(KindFileList),
 	)...).Encode(os.Stdout)
 }
 
-// DoFilesSet generates a torrent set request to change the specified field.
-func DoFilesSet(field string) func(args *Args) error {
-	var errmsg string
-	switch {
-	case strings.HasPrefix(field, "Files"):
-		errmsg = "files set-" + strings.ToLower(strings.TrimPrefix(field, "Files"))
-	case strings.HasPrefix(field, "Priority"):
-		errmsg = "files set-priority " + strings.ToLower(strings.TrimPrefix(field, "Priority"))
+// DoFilesSet is the high-level entry point for 'files set-wanted', 'files
+// set-unwanted', and 'files set-priority'.
+func DoFilesSet(ctx context.Context, args *Args, cmd string) error {
+	var field string
+	switch cmd {
+	case "files set-wanted":
+		field = "files-wanted"
+	case "files set-unwanted":
+		field = "files-unwanted"
+	case "files set-priority":
+		field = "priority-" + args.FilesSetPriorityParams.Priority
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	g, err := glob.Compile(args.FileMask)
+	if err != nil {
+		return err
 	}
-	return func(args *Args) error {
-		g, err := glob.Compile(args.FileMask)
-		if err != nil {
-			return err
-		}
-		p, torrents, err := findTorrents(args)
-		if err != nil {
-			return err
-		}
-		if len(torrents) == 0 {
-			return nil
-		}
-		res, err := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("hashString", "files").Do(ctx, cl)
-		if err != nil {
-			return nil
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	files, err := p.FilesGet(ctx, ConvertTorrentIDs(torrents)...)
+	if err != nil {
+		return err
+	}
+	// group matching file ids by torrent
+	var hashes []string
+	ids := make(map[string][]int64)
+	for _, f := range files {
+		if !g.Match(f.Name) {
+			continue
 		}
-		for _, t := range res.Torrents {
-			var files []string
-			for i := 0; i < len(t.Files); i++ {
-				if g.Match(t.Files[i].Name) {
-					files = append(files, strconv.Itoa(i))
-				}
-			}
-			if len(files) != 0 {
-				if err = doWithAndExecute(cl, transrpc.TorrentSet(t.HashString), errmsg, field, strings.Join(files, ",")); err != nil {
-					return err
-				}
-			}
+		if _, ok := ids[f.HashString]; !ok {
+			hashes = append(hashes, f.HashString)
 		}
-		return nil
+		ids[f.HashString] = append(ids[f.HashString], f.ID)
 	}
-}
-
-// DoFilesSetPriority is the high-level entry point for 'files set-priority'.
-func DoFilesSetPriority(args *Args) error {
-	switch args.FilesSetPriorityParams.Priority {
-	case "low":
-		return doFilesSet("PriorityLow")(args)
-	case "normal":
-		return doFilesSet("PriorityNormal")(args)
-	case "high":
-		return doFilesSet("PriorityHigh")(args)
+	for _, hash := range hashes {
+		if err := p.FilesSet(ctx, map[string]interface{}{field: ids[hash]}, hash); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 // DoFilesRename is the high-level entry point for 'files rename'.
-func DoFilesRename(args *Args) error {
-	p, torrents, err := findTorrents(args)
+func DoFilesRename(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	req := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("hashString", "files")
-	res, err := req.Do(ctx, cl)
+	files, err := p.FilesGet(ctx, ConvertTorrentIDs(torrents)...)
 	if err != nil {
 		return err
 	}
-	for _, t := range res.Torrents {
-		for _, f := range t.Files {
-			if f.Name == args.FilesRenameParams.OldPath {
-				if err = transrpc.TorrentRenamePath(
-					args.FilesRenameParams.OldPath, args.FilesRenameParams.NewPath, t.HashString,
-				).Do(ctx, cl); err != nil {
-					return err
-				}
-			}
+	for _, f := range files {
+		if f.Name != args.FilesRenameParams.OldPath {
+			continue
+		}
+		if err := p.FilesRename(ctx, args.FilesRenameParams.OldPath, args.FilesRenameParams.NewPath, f.HashString); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
 // DoTrackersGet is the high-level entry point for 'trackers get'.
-func DoTrackersGet(args *Args) error {
-	p, torrents, err := findTorrents(args)
+func DoTrackersGet(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	var result []tctypes.Tracker
 	if len(torrents) != 0 {
-		res, err := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("name", "hashString", "trackers", "trackerStats").Do(ctx, cl)
-		if err != nil {
+		if result, err = p.TrackersGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
 			return err
 		}
-		for _, t := range res.Torrents {
-			for i, v := range t.Trackers {
-				result = append(result, tctypes.Tracker{
-					Announce:              v.Announce,
-					ID:                    v.ID,
-					Scrape:                v.Scrape,
-					Tier:                  v.Tier,
-					AnnounceState:         t.TrackerStats[i].AnnounceState,
-					DownloadCount:         t.TrackerStats[i].DownloadCount,
-					HasAnnounced:          t.TrackerStats[i].HasAnnounced,
-					HasScraped:            t.TrackerStats[i].HasScraped,
-					Host:                  t.TrackerStats[i].Host,
-					IsBackup:              t.TrackerStats[i].IsBackup,
-					LastAnnouncePeerCount: t.TrackerStats[i].LastAnnouncePeerCount,
-					LastAnnounceResult:    t.TrackerStats[i].LastAnnounceResult,
-					LastAnnounceStartTime: t.TrackerStats[i].LastAnnounceStartTime,
-					LastAnnounceSucceeded: t.TrackerStats[i].LastAnnounceSucceeded,
-					LastAnnounceTime:      t.TrackerStats[i].LastAnnounceTime,
-					LastAnnounceTimedOut:  t.TrackerStats[i].LastAnnounceTimedOut,
-					LastScrapeResult:      t.TrackerStats[i].LastScrapeResult,
-					LastScrapeStartTime:   t.TrackerStats[i].LastScrapeStartTime,
-					LastScrapeSucceeded:   t.TrackerStats[i].LastScrapeSucceeded,
-					LastScrapeTime:        t.TrackerStats[i].LastScrapeTime,
-					LastScrapeTimedOut:    t.TrackerStats[i].LastScrapeTimedOut,
-					LeecherCount:          t.TrackerStats[i].LeecherCount,
-					NextAnnounceTime:      t.TrackerStats[i].NextAnnounceTime,
-					NextScrapeTime:        t.TrackerStats[i].NextScrapeTime,
-					ScrapeState:           t.TrackerStats[i].ScrapeState,
-					SeederCount:           t.TrackerStats[i].SeederCount,
-					Torrent:               t.Name,
-					HashString:            t.HashString,
-				})
-			}
-		}
 	}
 	return NewResult(result, args.ResultOptions(
 		TableColumns("announce", "lastAnnounceResult", "lastAnnouncePeerCount", "seederCount", "shortHash"),
@@ -504,91 +424,205 @@ func DoTrackersGet(args *Args) error {
 		FlatName("trackers"),
 		FlatKey("id"),
 		FlatIndex("shortHash"),
+		Kind(KindTrackerList),
 	)...).Encode(os.Stdout)
 }
 
 // DoTrackersAdd is the high-level entry point for 'trackers add'.
-func DoTrackersAdd(args *Args) error {
-	p, torrents, err := findTorrents(args)
+func DoTrackersAdd(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	return transrpc.TorrentSet(convTorrentIDs(torrents)...).
-		WithTrackerAdd(args.Tracker).Do(ctx, cl)
+	tier := int64(-1)
+	if args.TrackersAddParams.TierWasSet {
+		tier = args.TrackersAddParams.Tier
+	}
+	return p.TrackersAdd(ctx, args.Tracker, tier, ConvertTorrentIDs(torrents)...)
 }
 
 // DoTrackersReplace is the high-level entry point for 'trackers replace'.
-func DoTrackersReplace(args *Args) error {
-	p, torrents, err := findTorrents(args)
+//
+// When --regex is specified, the tracker is a regexp, and the replacement can
+// refer to its submatches ($1, ${name}, ...).
+func DoTrackersReplace(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	if args.TrackersReplaceParams.Regex {
+		return doTrackersReplaceRegexp(ctx, p, args.Tracker, args.TrackersReplaceParams.Replace, ConvertTorrentIDs(torrents))
+	}
+	return p.TrackersReplace(ctx, args.Tracker, args.TrackersReplaceParams.Replace, ConvertTorrentIDs(torrents)...)
+}
+
+// DoTrackersRemove is the high-level entry point for 'trackers remove'.
+func DoTrackersRemove(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	req := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("hashString", "trackers")
-	res, err := req.Do(ctx, cl)
+	return p.TrackersRemove(ctx, args.Tracker, ConvertTorrentIDs(torrents)...)
+}
+
+// DoLabelsList is the high-level entry point for 'labels list'.
+func DoLabelsList(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
-	for _, t := range res.Torrents {
-		for _, tracker := range t.Trackers {
-			if tracker.Announce == args.Tracker {
-				if err := transrpc.TorrentSet(t.HashString).WithTrackerReplace(
-					tracker.ID, args.TrackersReplaceParams.Replace,
-				).Do(ctx, cl); err != nil {
-					return fmt.Errorf("could not replace tracker %d (%s) with %s for %s: %w",
-						tracker.ID, args.Tracker, args.TrackersReplaceParams.Replace, t.HashString, err)
-				}
-			}
+	var result []tctypes.Label
+	if len(torrents) != 0 {
+		res, err := p.Get(ctx, []string{"hashString", "labels"}, ConvertTorrentIDs(torrents)...)
+		if err != nil {
+			return err
 		}
+		result = NewLabels(res)
 	}
-	return nil
+	return NewResult(result, args.ResultOptions(
+		TableColumns("name", "torrents"),
+		WideColumns("name", "torrents"),
+		FlatName("label"),
+		FlatIndex("name"),
+		Index("name"),
+		Kind(KindLabelList),
+	)...).Encode(os.Stdout)
 }
 
-// DoTrackersRemove is the high-level entry point for 'trackers remove'.
-func DoTrackersRemove(ctx context.Context, args *Args) error {
-	torrents, err := p.FindTorrents()
+// NewLabels builds the list of labels used by the torrents, sorted by name.
+func NewLabels(torrents []tctypes.Torrent) []tctypes.Label {
+	counts := make(map[string]int64)
+	for _, t := range torrents {
+		for _, label := range t.Labels {
+			counts[label]++
+		}
+	}
+	labels := make([]tctypes.Label, 0, len(counts))
+	for name, count := range counts {
+		labels = append(labels, tctypes.Label{Name: name, Torrents: count})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+// DoLabels is the high-level entry point for 'labels add', 'labels remove',
+// and 'labels set'.
+func DoLabels(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
 	if err != nil {
 		return err
 	}
 	if len(torrents) == 0 {
 		return nil
 	}
-	req := transrpc.TorrentGet(convTorrentIDs(torrents)...).WithFields("hashString", "trackers")
-	res, err := req.Do(ctx, cl)
+	labels, ids := splitLabels(args.LabelsParams.Labels), ConvertTorrentIDs(torrents)
+	switch cmd {
+	case "labels add":
+		return p.LabelsAdd(ctx, labels, ids...)
+	case "labels remove":
+		return p.LabelsRemove(ctx, labels, ids...)
+	case "labels set":
+		return p.LabelsSet(ctx, labels, ids...)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// splitLabels splits a comma separated list of labels, discarding empty and
+// duplicate labels.
+func splitLabels(s string) []string {
+	labels := []string{}
+	seen := make(map[string]bool)
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// DoCategoriesList is the high-level entry point for 'categories list'.
+func DoCategoriesList(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
 	if err != nil {
 		return err
 	}
-	for _, t := range res.Torrents {
-		for _, tracker := range t.Trackers {
-			if tracker.Announce == args.Tracker {
-				if err := transrpc.TorrentSet(t.HashString).WithTrackerRemove(
-					tracker.ID,
-				).Do(ctx, cl); err != nil {
-					return fmt.Errorf("could not remove tracker %d (%s) from %s: %w", tracker.ID, args.Tracker, t.HashString, err)
-				}
-			}
-		}
+	result, err := p.CategoriesGet(ctx)
+	if err != nil {
+		return err
 	}
-	return nil
+	return NewResult(result, args.ResultOptions(
+		TableColumns("name", "savePath"),
+		WideColumns("name", "savePath"),
+		FlatName("category"),
+		FlatIndex("name"),
+		Index("name"),
+		Kind(KindCategoryList),
+	)...).Encode(os.Stdout)
 }
 
-/*
-type keypair struct {
+// DoCategories is the high-level entry point for 'categories create',
+// 'categories edit', and 'categories delete'.
+func DoCategories(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "categories create":
+		return p.CategoriesCreate(ctx, args.CategoriesParams.Name, args.CategoriesParams.SavePath)
+	case "categories edit":
+		return p.CategoriesEdit(ctx, args.CategoriesParams.Name, args.CategoriesParams.SavePath)
+	case "categories delete":
+		return p.CategoriesDelete(ctx, args.CategoriesParams.Names...)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// Stat is a remote host stat.
+type Stat struct {
 	HashString string      `json:"-" yaml:"-"`
-	Name       string      `json:"name,omitempty" yaml:"name,omitempty"`
-	Key        string      `json:"-" yaml:"-"`
-	Value      interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	Name       string      `json:"name" yaml:"name"`
+	Key        string      `json:"key" yaml:"key"`
+	Value      interface{} `json:"value" yaml:"value"`
 	ID         int64       `json:"id" yaml:"id"`
 }
-*/
 
 // DoStats is the high-level entry point for 'stats'.
-func DoStats(ctx context.Context, args *Args) error {
+func DoStats(ctx context.Context, args *Args, cmd string) error {
 	p, err := args.NewProvider()
 	if err != nil {
 		return err
@@ -597,27 +631,8 @@ func DoStats(ctx context.Context, args *Args) error {
 	if err != nil {
 		return err
 	}
-	/*
-		[]keypair{
-			{"session-stats", "Active Torrent Count", "active-torrent-count", res.ActiveTorrentCount, 0},
-			{"session-stats", "Download Speed", "download-speed", res.DownloadSpeed, 1},
-			{"session-stats", "Paused Torrent Count", "paused-torrent-count", res.PausedTorrentCount, 2},
-			{"session-stats", "Torrent Count", "torrent-count", res.TorrentCount, 3},
-			{"session-stats", "Upload Speed", "upload-speed", res.UploadSpeed, 4},
-			{"session-stats", "Cumulative Uploaded", "cumulative-stats.uploaded-bytes", res.CumulativeStats.UploadedBytes, 5},
-			{"session-stats", "Cumulative Downloaded", "cumulative-stats.downloaded-bytes", res.CumulativeStats.DownloadedBytes, 6},
-			{"session-stats", "Cumulative Files Added", "cumulative-stats.files-added", res.CumulativeStats.FilesAdded, 7},
-			{"session-stats", "Cumulative Session Count", "cumulative-stats.session-count", res.CumulativeStats.SessionCount, 8},
-			{"session-stats", "Cumulative Seconds Active", "cumulative-stats.seconds-active", res.CumulativeStats.SecondsActive, 9},
-			{"session-stats", "Current Uploaded", "current-stats.uploaded-bytes", res.CurrentStats.UploadedBytes, 10},
-			{"session-stats", "Current Downloaded", "current-stats.downloaded-bytes", res.CurrentStats.DownloadedBytes, 11},
-			{"session-stats", "Current Files Added", "current-stats.files-added", res.CurrentStats.FilesAdded, 12},
-			{"session-stats", "Current Session Count", "current-stats.session-count", res.CurrentStats.SessionCount, 13},
-			{"session-stats", "Current Seconds Active", "current-stats.seconds-active", res.CurrentStats.SecondsActive, 14},
-		},
-	*/
 	return NewResult(
-		res,
+		NewStats(res),
 		args.ResultOptions(
 			TableColumns("name", "value"),
 			WideColumns("name", "key", "value"),
@@ -626,47 +641,89 @@ func DoStats(ctx context.Context, args *Args) error {
 			FlatKey("id"),
 			FlatIndex("hashString"),
 			NoTotals(true),
+			Kind(KindStatList),
 		)...,
 	).Encode(os.Stdout)
 }
 
+// NewStats converts the stats map to a list of stats, sorted by key.
+func NewStats(m map[string]interface{}) []Stat {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	stats := make([]Stat, len(keys))
+	for i, k := range keys {
+		name := strings.Title(strings.NewReplacer("-", " ", ".", " ").Replace(k))
+		stats[i] = Stat{HashString: "session-stats", Name: name, Key: k, Value: m[k], ID: int64(i)}
+	}
+	return stats
+}
+
 // DoShutdown is the high-level entry point for 'shutdown'.
-func DoShutdown(ctx context.Context, args *Args) error {
+func DoShutdown(ctx context.Context, args *Args, cmd string) error {
 	p, err := args.NewProvider()
 	if err != nil {
 		return err
 	}
-	return p.SessionClose(ctx)
+	return p.Shutdown(ctx)
 }
 
 // DoFreeSpace is the high-level entry point for 'free-space'.
-func DoFreeSpace(ctx context.Context, args *Args) error {
+func DoFreeSpace(ctx context.Context, args *Args, cmd string) error {
 	p, err := args.NewProvider()
 	if err != nil {
 		return err
 	}
-	for _, path := range args.Args {
-		size, err := p.FreeSpace(ctx, path)
-		var sz string
-		switch {
-		case err != nil:
-			if e, ok := err.(*transrpc.ErrRequestFailed); ok {
-				sz = "error: " + e.Err
+	return NewResult(
+		NewFreeSpace(ctx, p, args.Args...),
+		args.ResultOptions(
+			TableColumns("location", "freeSpace", "error"),
+			WideColumns("location", "freeSpace", "error"),
+			YamlName("free-space"),
+			FlatName("free-space"),
+			FlatKey("id"),
+			FlatIndex("hashString"),
+			NoTotals(true),
+			Kind(KindFreeSpaceList),
+		)...,
+	).Encode(os.Stdout)
+}
+
+// FreeSpace is the free space of a remote host location.
+type FreeSpace struct {
+	HashString string            `json:"-" yaml:"-"`
+	Location   string            `json:"location" yaml:"location"`
+	FreeSpace  tctypes.ByteCount `json:"freeSpace" yaml:"freeSpace"`
+	Error      string            `json:"error,omitempty" yaml:"error,omitempty"`
+	ID         int64             `json:"id" yaml:"id"`
+}
+
+// NewFreeSpace retrieves the free space of the locations, in order. Errors
+// are reported for each location, and do not stop the remaining locations
+// from being retrieved.
+func NewFreeSpace(ctx context.Context, p Provider, locations ...string) []FreeSpace {
+	res := make([]FreeSpace, len(locations))
+	for i, location := range locations {
+		res[i] = FreeSpace{HashString: "free-space", Location: location, ID: int64(i)}
+		size, err := p.FreeSpace(ctx, location)
+		if err != nil {
+			var e *transrpc.ErrRequestFailed
+			if errors.As(err, &e) {
+				res[i].Error = e.Err
 			} else {
-				sz = "error: " + err.Error()
+				res[i].Error = err.Error()
 			}
-		case args.Output.Human == "true" || args.Output.Human == "1" || args.Output.SI:
-			sz = size.Format(!args.Output.SI, 2)
-		default:
-			sz = strconv.FormatInt(int64(size), 10)
+			continue
 		}
-		fmt.Fprintf(os.Stdout, "%s\t%s\n", path, sz)
+		res[i].FreeSpace = size
 	}
-	return nil
+	return res
 }
 
 // DoBlocklistUpdate is the high-level entry point for 'blocklist-update'.
-func DoBlocklistUpdate(ctx context.Context, args *Args) error {
+func DoBlocklistUpdate(ctx context.Context, args *Args, cmd string) error {
 	p, err := args.NewProvider()
 	if err != nil {
 		return err
@@ -680,7 +737,7 @@ func DoBlocklistUpdate(ctx context.Context, args *Args) error {
 }
 
 // DoPortTest is the high-level entry point for 'port-test'.
-func DoPortTest(ctx context.Context, args *Args) error {
+func DoPortTest(ctx context.Context, args *Args, cmd string) error {
 	p, err := args.NewProvider()
 	if err != nil {
 		return err
@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// setCredentials is the config option name used to set a context's
+// credentials.
+const setCredentials = "set-credentials"
+
+// stdin is the shared reader for stdin, so that buffered input is not lost
+// between prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+// doSetCredentials stores the credentials for the context in the config file,
+// either as an encrypted password, or as a password command.
+func doSetCredentials(args *Args, context string) error {
+	key := "context." + context + "."
+
+	// determine user
+	user := args.ConfigParams.Username
+	if user == "" {
+		user = args.Config.GetKey(key + "user")
+	}
+	if user == "" {
+		var err error
+		if user, err = prompt("username: ", true); err != nil {
+			return err
+		}
+	}
+	args.Config.SetKey(key+"user", user)
+
+	// password command
+	if args.ConfigParams.PasswordCommand != "" {
+		args.Config.RemoveKey(key + "password")
+		args.Config.SetKey(key+"password-command", args.ConfigParams.PasswordCommand)
+		return args.Config.Write(args.ConfigFile)
+	}
+
+	// read password
+	var pass string
+	if args.ConfigParams.PasswordStdin {
+		buf, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		pass = strings.TrimRight(string(buf), "\r\n")
+	} else {
+		var err error
+		if pass, err = prompt("password: ", false); err != nil {
+			return err
+		}
+	}
+
+	// encrypt, using the secret key file of the context being set
+	passphrase, err := args.WithContext(context).secretPassphrase(true)
+	if err != nil {
+		return err
+	}
+	secret, err := tctypes.EncryptSecret([]byte(pass), passphrase)
+	if err != nil {
+		return err
+	}
+	args.Config.RemoveKey(key + "password-command")
+	args.Config.SetKey(key+"password", secret)
+	return args.Config.Write(args.ConfigFile)
+}
+
+// storedCredentials returns the credentials stored in the config for the
+// current context. Returns nil when no credentials have been stored.
+func (args *Args) storedCredentials() ([]string, error) {
+	user := strings.TrimSpace(args.getContextKey("user"))
+	pass := strings.TrimSpace(args.getContextKey("password"))
+	switch cmd := strings.TrimSpace(args.getContextKey("password-command")); {
+	case cmd != "":
+		var err error
+		if pass, err = runPasswordCommand(cmd); err != nil {
+			return nil, err
+		}
+	case tctypes.IsSecret(pass):
+		passphrase, err := args.secretPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := tctypes.DecryptSecret(pass, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		pass = string(buf)
+	}
+	if user == "" && pass == "" {
+		return nil, nil
+	}
+	return []string{user, pass}, nil
+}
+
+// secretPassphrase returns the passphrase used to encrypt and decrypt stored
+// passwords, reading it from the secret key file, the TRANSPASSPHRASE
+// environment variable, or prompting for it (twice, when confirm is true).
+func (args *Args) secretPassphrase(confirm bool) ([]byte, error) {
+	file := args.Host.SecretKeyFile
+	if file == "" {
+		file = strings.TrimSpace(args.getContextKey("secret-key-file"))
+	}
+	if file != "" {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimSpace(buf), nil
+	}
+	if v := os.Getenv("TRANSPASSPHRASE"); v != "" {
+		return []byte(v), nil
+	}
+	if !isTerminal(os.Stdin) {
+		return nil, ErrMustSpecifySecretKeyFileOrPassphrase
+	}
+	passphrase, err := prompt("passphrase: ", false)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		v, err := prompt("confirm passphrase: ", false)
+		if err != nil {
+			return nil, err
+		}
+		if v != passphrase {
+			return nil, ErrPassphrasesDoNotMatch
+		}
+	}
+	return []byte(passphrase), nil
+}
+
+// runPasswordCommand runs the password command, returning the first line of
+// its output (as with pass).
+func runPasswordCommand(cmd string) (string, error) {
+	c := exec.Command("sh", "-c", cmd)
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", cmd)
+	}
+	c.Stdin, c.Stderr = os.Stdin, os.Stderr
+	buf, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("password command: %w", err)
+	}
+	return strings.TrimRight(strings.SplitN(string(buf), "\n", 2)[0], "\r"), nil
+}
+
+// prompt prompts for a value on stdin, disabling echo when echo is false and
+// stdin is a terminal.
+func prompt(label string, echo bool) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	if !echo && isTerminal(os.Stdin) {
+		if err := stty("-echo"); err == nil {
+			defer func() {
+				_ = stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty runs stty with the setting on stdin.
+func stty(setting string) error {
+	c := exec.Command("stty", setting)
+	c.Stdin = os.Stdin
+	return c.Run()
+}
+
+// isTerminal determines if f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestSetCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transctl-creds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// only the context being set has a secret key file
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte("secret key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := ini.NewFile()
+	config.SetKey("default.context", "active")
+	config.SetKey("context.active.url", "http://active")
+	config.SetKey("context.other.secret-key-file", keyFile)
+	args := &Args{Config: config, ConfigFile: filepath.Join(dir, "config")}
+	args.ConfigParams.Username = "user"
+	args.ConfigParams.PasswordStdin = true
+	defer setStdin("pass\n")()
+	if err := doSetCredentials(args, "other"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// check written config
+	f, err := ini.LoadFile(args.ConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := f.GetKey("context.other.user"); s != "user" {
+		t.Errorf("expected user, got: %q", s)
+	}
+	pass := f.GetKey("context.other.password")
+	if !tctypes.IsSecret(pass) {
+		t.Fatalf("expected encrypted password, got: %q", pass)
+	}
+	if s := f.GetKey("context.active.password"); s != "" {
+		t.Errorf("expected no password for active context, got: %q", s)
+	}
+
+	// read back
+	creds, err := (&Args{Config: f, Context: "other"}).storedCredentials()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := []string{"user", "pass"}; !reflect.DeepEqual(creds, exp) {
+		t.Errorf("expected %v, got: %v", exp, creds)
+	}
+
+	// password command replaces the stored password
+	args.ConfigParams.PasswordCommand = "printf 'cmdpass\\nignored\\n'"
+	if err := doSetCredentials(args, "other"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := args.Config.GetKey("context.other.password"); s != "" {
+		t.Errorf("expected password to be removed, got: %q", s)
+	}
+	creds, err = args.WithContext("other").storedCredentials()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := []string{"user", "cmdpass"}; !reflect.DeepEqual(creds, exp) {
+		t.Errorf("expected %v, got: %v", exp, creds)
+	}
+}
+
+func TestRunPasswordCommand(t *testing.T) {
+	tests := []struct {
+		cmd string
+		exp string
+		err bool
+	}{
+		{"echo pass", "pass", false},
+		{"printf 'pass\\r\\nsecond\\n'", "pass", false},
+		{"printf ''", "", false},
+		{"exit 1", "", true},
+	}
+	for i, test := range tests {
+		pass, err := runPasswordCommand(test.cmd)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error, got nil", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case pass != test.exp:
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, pass)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	// consecutive prompts share buffered input
+	defer setStdin("user\npass\r\n")()
+	for _, exp := range []string{"user", "pass"} {
+		s, err := prompt("", true)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s != exp {
+			t.Errorf("expected %q, got: %q", exp, s)
+		}
+	}
+	if _, err := prompt("", true); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+// setStdin sets the shared stdin reader to read s, returning a func that
+// restores it.
+func setStdin(s string) func() {
+	prev := stdin
+	stdin = bufio.NewReader(strings.NewReader(s))
+	return func() {
+		stdin = prev
+	}
+}
@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule.
+type Schedule struct {
+	// minute, hour, dom, month, and dow are the bit sets of the matching
+	// values for each field.
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar are the toggles indicating the day of month and day
+	// of week fields were '*'.
+	domStar, dowStar bool
+
+	// every is the fixed interval for '@every <duration>' schedules.
+	every time.Duration
+}
+
+// scheduleDescriptors are the predefined schedule descriptors.
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a standard 5 field cron schedule spec (minute, hour,
+// day of month, month, day of week). Fields may be '*', values, ranges
+// ('1-5'), lists ('1,3,5') and steps ('*/15', '0-30/5'). Months and days of
+// the week may be specified by their abbreviated names ('jan', 'mon').
+//
+// Additionally supports the '@yearly', '@monthly', '@weekly', '@daily',
+// '@hourly' and '@every <duration>' descriptors.
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q", spec)
+		}
+		return &Schedule{every: d}, nil
+	}
+	if s, ok := scheduleDescriptors[strings.ToLower(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+	s := &Schedule{
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	if s.minute, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseScheduleField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+	// sunday may be specified as either 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// monthNames are the abbreviated month names.
+var monthNames = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+// dayNames are the abbreviated day names.
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseScheduleField parses a cron schedule field, returning the bit set of
+// matching values.
+func parseScheduleField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexRune(item, '/'); i != -1 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", item)
+			}
+			item = item[:i]
+		}
+		start, end := min, max
+		switch i := strings.IndexRune(item, '-'); {
+		case item == "*" || item == "?":
+		case i != -1:
+			var err error
+			if start, err = parseScheduleValue(item[:i], min, max, names); err != nil {
+				return 0, err
+			}
+			if end, err = parseScheduleValue(item[i+1:], min, max, names); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if start, err = parseScheduleValue(item, min, max, names); err != nil {
+				return 0, err
+			}
+			// 'a/n' is equivalent to 'a-max/n'
+			if step == 1 {
+				end = start
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", item)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// parseScheduleValue parses a single cron schedule value.
+func parseScheduleValue(s string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return i, nil
+}
+
+// Next returns the next activation time of the schedule after t. Returns the
+// zero time when the schedule cannot be satisfied within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every != 0 {
+		return t.Add(s.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + 5
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches determines if the day of month and day of week fields match t.
+// As with standard cron, when both fields are restricted, either field
+// matching is sufficient.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// 2021-03-10 is a wednesday
+	start := time.Date(2021, 3, 10, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		exp  []string
+	}{
+		// values
+		{"* * * * *", []string{"2021-03-10 10:31", "2021-03-10 10:32"}},
+		{"45 * * * *", []string{"2021-03-10 10:45", "2021-03-10 11:45"}},
+		{"0 12 * * *", []string{"2021-03-10 12:00", "2021-03-11 12:00"}},
+		{"30 10 * * *", []string{"2021-03-11 10:30", "2021-03-12 10:30"}},
+		// lists and ranges
+		{"0,30 9-11 * * *", []string{"2021-03-10 11:00", "2021-03-10 11:30", "2021-03-11 09:00"}},
+		{"0 22-23 * * *", []string{"2021-03-10 22:00", "2021-03-10 23:00", "2021-03-11 22:00"}},
+		// steps
+		{"*/15 * * * *", []string{"2021-03-10 10:45", "2021-03-10 11:00", "2021-03-10 11:15"}},
+		{"10-40/10 * * * *", []string{"2021-03-10 10:40", "2021-03-10 11:10"}},
+		{"50/5 * * * *", []string{"2021-03-10 10:50", "2021-03-10 10:55", "2021-03-10 11:50"}},
+		{"0 */6 * * *", []string{"2021-03-10 12:00", "2021-03-10 18:00", "2021-03-11 00:00"}},
+		// names
+		{"0 0 * * mon", []string{"2021-03-15 00:00", "2021-03-22 00:00"}},
+		{"0 0 * * MON-FRI", []string{"2021-03-11 00:00", "2021-03-12 00:00", "2021-03-15 00:00"}},
+		{"0 0 1 jan,jul *", []string{"2021-07-01 00:00", "2022-01-01 00:00"}},
+		{"0 0 1 JUN-aug *", []string{"2021-06-01 00:00", "2021-07-01 00:00", "2021-08-01 00:00", "2022-06-01 00:00"}},
+		// sunday as 0 or 7
+		{"0 0 * * 0", []string{"2021-03-14 00:00", "2021-03-21 00:00"}},
+		{"0 0 * * 7", []string{"2021-03-14 00:00", "2021-03-21 00:00"}},
+		// day of month
+		{"0 0 31 * *", []string{"2021-03-31 00:00", "2021-05-31 00:00", "2021-07-31 00:00"}},
+		{"0 0 29 2 *", []string{"2024-02-29 00:00", "2028-02-29 00:00"}},
+		// day of month or day of week, when both are restricted
+		{"0 0 13 * fri", []string{"2021-03-12 00:00", "2021-03-13 00:00", "2021-03-19 00:00"}},
+		// day of month and day of week, when either is '*'
+		{"0 0 * 3 fri", []string{"2021-03-12 00:00", "2021-03-19 00:00", "2021-03-26 00:00", "2022-03-04 00:00"}},
+		{"0 0 ? * fri", []string{"2021-03-12 00:00", "2021-03-19 00:00"}},
+		// descriptors
+		{"@hourly", []string{"2021-03-10 11:00", "2021-03-10 12:00"}},
+		{"@daily", []string{"2021-03-11 00:00", "2021-03-12 00:00"}},
+		{"@weekly", []string{"2021-03-14 00:00", "2021-03-21 00:00"}},
+		{"@monthly", []string{"2021-04-01 00:00", "2021-05-01 00:00"}},
+		{"@yearly", []string{"2022-01-01 00:00", "2023-01-01 00:00"}},
+		// never
+		{"0 0 30 2 *", []string{""}},
+		{"0 0 31 4,6 *", []string{""}},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			s, err := ParseSchedule(test.spec)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			next := start
+			for i, exp := range test.exp {
+				next = s.Next(next)
+				var v string
+				if !next.IsZero() {
+					v = next.Format("2006-01-02 15:04")
+				}
+				if v != exp {
+					t.Fatalf("%d expected %q, got: %q", i, exp, v)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleNextEvery(t *testing.T) {
+	s, err := ParseSchedule("@every 90s")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	start := time.Date(2021, 3, 10, 10, 30, 15, 0, time.UTC)
+	if next, exp := s.Next(start), start.Add(90*time.Second); !next.Equal(exp) {
+		t.Errorf("expected %v, got: %v", exp, next)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * foo *",
+		"@every 1ms",
+		"@every x",
+		"@often",
+	}
+	for _, test := range tests {
+		if _, err := ParseSchedule(test); err == nil {
+			t.Errorf("%q expected error, got nil", test)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// DaemonJob is a scheduled daemon job, as defined by a job section in the
+// config file:
+//
+//	[job.reannounce-stalled]
+//	schedule = */30 * * * *
+//	contexts = seedbox, home
+//	action = reannounce
+//	filter = isStalled
+//
+// A policy job applies torrent settings to the torrents matching its filter:
+//
+//	[job.seed-policy]
+//	schedule = 0 * * * *
+//	action = policy
+//	filter = uploadRatio < 1
+//	set = seedRatioLimit=2, seedRatioMode=1
+type DaemonJob struct {
+	// Name is the job name.
+	Name string
+
+	// Spec is the job's schedule spec.
+	Spec string
+
+	// Schedule is the parsed job schedule.
+	Schedule *Schedule
+
+	// Contexts are the config contexts the job is executed against.
+	Contexts []string
+
+	// Action is the job action.
+	Action string
+
+	// Filter is the torrent filter for torrent actions.
+	Filter string
+
+	// Dest is the move destination.
+	Dest string
+
+	// Remove is the remove downloaded files toggle.
+	Remove bool
+
+	// State is the alternate speed state.
+	State bool
+
+	// Locations are the free space locations.
+	Locations []string
+
+	// MinFree is the minimum free space before warning.
+	MinFree tctypes.ByteCount
+
+	// Settings are the torrent settings applied by a policy.
+	Settings map[string]interface{}
+}
+
+// daemonActions are the daemon job actions.
+var daemonActions = map[string]func(context.Context, *Args, Provider, *DaemonJob, *Logger) error{
+	"start":            doDaemonTorrents,
+	"stop":             doDaemonTorrents,
+	"verify":           doDaemonTorrents,
+	"reannounce":       doDaemonTorrents,
+	"remove":           doDaemonTorrents,
+	"move":             doDaemonTorrents,
+	"policy":           doDaemonTorrents,
+	"blocklist-update": doDaemonBlocklistUpdate,
+	"alt-speed":        doDaemonAltSpeed,
+	"free-space":       doDaemonFreeSpace,
+}
+
+// LoadDaemonJobs loads the daemon jobs from the config file.
+func LoadDaemonJobs(args *Args) ([]*DaemonJob, error) {
+	var names []string
+	for _, n := range args.Config.SectionNames() {
+		if strings.HasPrefix(n, "job.") {
+			names = append(names, strings.TrimPrefix(n, "job."))
+		}
+	}
+	sort.Strings(names)
+
+	defaultContext := args.Context
+	if defaultContext == "" {
+		defaultContext = strings.TrimSpace(args.Config.GetKey("default.context"))
+	}
+
+	var jobs []*DaemonJob
+	for _, name := range names {
+		get := func(key string) string {
+			return strings.TrimSpace(args.Config.GetKey("job." + name + "." + key))
+		}
+		job := &DaemonJob{
+			Name:   name,
+			Spec:   get("schedule"),
+			Action: strings.ToLower(get("action")),
+			Filter: get("filter"),
+			Dest:   get("dest"),
+		}
+		if job.Spec == "" {
+			return nil, fmt.Errorf("job %q: must specify schedule", name)
+		}
+		var err error
+		if job.Schedule, err = ParseSchedule(job.Spec); err != nil {
+			return nil, fmt.Errorf("job %q: %w", name, err)
+		}
+		if _, ok := daemonActions[job.Action]; !ok {
+			return nil, fmt.Errorf("job %q: invalid action %q", name, job.Action)
+		}
+		job.Contexts = splitList(get("contexts"))
+		if len(job.Contexts) == 0 {
+			job.Contexts = []string{defaultContext}
+		}
+		job.Locations = splitList(get("locations"))
+		v := strings.ToLower(get("rm"))
+		job.Remove = v == "true" || v == "1"
+		v = strings.ToLower(get("state"))
+		job.State = v == "on" || v == "true" || v == "1"
+		if v := get("min-free"); v != "" {
+			if job.MinFree, err = tctypes.ParseByteCount(v); err != nil {
+				return nil, fmt.Errorf("job %q: %w", name, err)
+			}
+		}
+		for _, kv := range splitList(get("set")) {
+			i := strings.Index(kv, "=")
+			if i <= 0 {
+				return nil, fmt.Errorf("job %q: invalid setting %q", name, kv)
+			}
+			if job.Settings == nil {
+				job.Settings = make(map[string]interface{})
+			}
+			job.Settings[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+
+		// check action specific options
+		switch job.Action {
+		case "reannounce":
+			if job.Filter == "" {
+				job.Filter = "isStalled"
+			}
+		case "start", "stop", "verify", "remove":
+			if job.Filter == "" {
+				return nil, fmt.Errorf("job %q: must specify filter", name)
+			}
+		case "move":
+			if job.Filter == "" || job.Dest == "" {
+				return nil, fmt.Errorf("job %q: must specify filter and dest", name)
+			}
+		case "policy":
+			if job.Filter == "" || len(job.Settings) == 0 {
+				return nil, fmt.Errorf("job %q: must specify filter and set", name)
+			}
+		case "alt-speed":
+			if v != "on" && v != "off" && v != "true" && v != "false" && v != "1" && v != "0" {
+				return nil, fmt.Errorf("job %q: must specify state (on, off)", name)
+			}
+		}
+
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// DoDaemon runs scheduled jobs and event hooks against the configured
+// contexts until signaled.
+func DoDaemon(ctx context.Context, args *Args, cmd string) error {
+	jobs, err := LoadDaemonJobs(args)
+	if err != nil {
+		return err
+	}
+	hooks, err := LoadHooks(args)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 && len(hooks) == 0 {
+		return ErrNoDaemonJobsOrHooksConfigured
+	}
+
+	logger := NewLogger(os.Stderr, args.DaemonParams.LogFormat)
+
+	// open providers, once per context
+	provs := make(map[string]Provider)
+	var contexts []string
+	for _, job := range jobs {
+		contexts = append(contexts, job.Contexts...)
+	}
+	hookContexts := make(map[string]bool)
+	for _, hook := range hooks {
+		for _, name := range hook.Contexts {
+			hookContexts[name] = true
+		}
+		contexts = append(contexts, hook.Contexts...)
+	}
+	for _, name := range contexts {
+		if _, ok := provs[name]; ok {
+			continue
+		}
+		p, err := args.WithContext(name).NewProvider()
+		if err != nil {
+			return fmt.Errorf("context %q: %w", name, err)
+		}
+		provs[name] = p
+	}
+
+	// run once
+	if args.DaemonParams.Once {
+		for _, job := range jobs {
+			runDaemonJob(args, provs, job, logger)
+		}
+		return nil
+	}
+
+	// cancel on signal
+	ctx, cancel := signalContext(ctx, logger)
+	defer cancel()
+
+	// start jobs
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		logger.Info("scheduled job", "job", job.Name, "action", job.Action, "schedule", job.Spec, "contexts", strings.Join(job.Contexts, ","))
+		wg.Add(1)
+		go func(job *DaemonJob) {
+			defer wg.Done()
+			scheduleDaemonJob(ctx, job, time.Now, func() {
+				runDaemonJob(args, provs, job, logger)
+			}, logger)
+		}(job)
+	}
+
+	// watch for events
+	for name := range hookContexts {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			watchEvents(ctx, args.WithContext(name), provs[name], hooks, args.DaemonParams.PollInterval, logger)
+		}(name)
+	}
+	logger.Info("started", "jobs", len(jobs), "hooks", len(hooks), "contexts", len(provs))
+
+	// wait for shutdown
+	<-ctx.Done()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.Info("stopped")
+	case <-time.After(args.DaemonParams.ShutdownTimeout):
+		logger.Warn("shutdown timeout exceeded, abandoning running jobs", "timeout", args.DaemonParams.ShutdownTimeout)
+	}
+	return nil
+}
+
+// scheduleDaemonJob calls run at each of the job's scheduled times, until the
+// context is closed or the schedule cannot be satisfied.
+func scheduleDaemonJob(ctx context.Context, job *DaemonJob, now func() time.Time, run func(), logger *Logger) {
+	for {
+		n := now()
+		next := job.Schedule.Next(n)
+		if next.IsZero() {
+			logger.Warn("job will never run", "job", job.Name, "schedule", job.Spec)
+			return
+		}
+		t := time.NewTimer(next.Sub(n))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+			run()
+		}
+	}
+}
+
+// runDaemonJob runs the job against each of its contexts, logging the
+// outcome.
+//
+// Jobs are not run with the daemon's context, so that a job that has started
+// when a shutdown is signaled may run to completion.
+func runDaemonJob(args *Args, provs map[string]Provider, job *DaemonJob, logger *Logger) {
+	for _, name := range job.Contexts {
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), args.Host.Timeout)
+		err := daemonActions[job.Action](ctx, args.WithContext(name), provs[name], job, logger)
+		cancel()
+		if err != nil {
+			logger.Error("job failed", "job", job.Name, "context", name, "action", job.Action, "err", err)
+			continue
+		}
+		logger.Info("job completed", "job", job.Name, "context", name, "action", job.Action, "duration", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// doDaemonTorrents runs a torrent action against the torrents matching the
+// job's filter.
+func doDaemonTorrents(ctx context.Context, args *Args, p Provider, job *DaemonJob, logger *Logger) error {
+	args.Filter.ListAll, args.Filter.Recent, args.Filter.Filter, args.Args = false, false, job.Filter, nil
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	ids := ConvertTorrentIDs(torrents)
+	logger.Info("matched torrents", "job", job.Name, "context", args.Context, "count", len(ids))
+	switch job.Action {
+	case "start":
+		return p.Start(ctx, ids...)
+	case "stop":
+		return p.Stop(ctx, ids...)
+	case "verify":
+		return p.Verify(ctx, ids...)
+	case "reannounce":
+		return p.Reannounce(ctx, ids...)
+	case "remove":
+		return p.Remove(ctx, job.Remove, ids...)
+	case "move":
+		return p.Move(ctx, job.Dest, ids...)
+	case "policy":
+		return p.Set(ctx, job.Settings, ids...)
+	}
+	return nil
+}
+
+// doDaemonBlocklistUpdate updates the remote host's blocklist.
+func doDaemonBlocklistUpdate(ctx context.Context, args *Args, p Provider, job *DaemonJob, logger *Logger) error {
+	size, err := p.BlocklistUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("blocklist updated", "job", job.Name, "context", args.Context, "size", size)
+	return nil
+}
+
+// doDaemonAltSpeed toggles the remote host's alternate speed limits.
+func doDaemonAltSpeed(ctx context.Context, args *Args, p Provider, job *DaemonJob, logger *Logger) error {
+	lp, ok := p.(LimitsProvider)
+	if !ok {
+		return fmt.Errorf("limits %w", ErrNotSupportedByProvider)
+	}
+	return lp.AltSpeedSet(ctx, job.State)
+}
+
+// doDaemonFreeSpace checks the free space of the job's locations, warning
+// when below the job's minimum.
+func doDaemonFreeSpace(ctx context.Context, args *Args, p Provider, job *DaemonJob, logger *Logger) error {
+	locations := job.Locations
+	if len(locations) == 0 {
+		locations = splitList(args.getContextKey("free-space"))
+	}
+	if len(locations) == 0 {
+		return ErrMustSpecifyAtLeastOneLocation
+	}
+	for _, location := range locations {
+		free, err := p.FreeSpace(ctx, location)
+		if err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+		if free < job.MinFree {
+			logger.Warn("free space below minimum", "job", job.Name, "context", args.Context, "location", location, "free", free.Format(true, 2), "min", job.MinFree.Format(true, 2))
+			continue
+		}
+		logger.Info("free space", "job", job.Name, "context", args.Context, "location", location, "free", free.Format(true, 2))
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, trimming whitespace and removing
+// empty values.
+func splitList(s string) []string {
+	var v []string
+	for _, x := range strings.Split(s, ",") {
+		if x = strings.TrimSpace(x); x != "" {
+			v = append(v, x)
+		}
+	}
+	return v
+}
@@ -0,0 +1,284 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestLoadDaemonJobs(t *testing.T) {
+	config, err := ini.LoadString(`[default]
+context = home
+
+[job.alt-speed]
+schedule = 0 8 * * mon-fri
+action = alt-speed
+state = on
+
+[job.seed-policy]
+schedule = @hourly
+contexts = home, seedbox
+action = policy
+filter = uploadRatio < 1
+set = seedRatioLimit=2, seedRatioMode = 1
+
+[job.stalled]
+schedule = */30 * * * *
+action = reannounce
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := LoadDaemonJobs(&Args{Config: config})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(jobs) != 3 {
+		t.Fatalf("expected 3 jobs, got: %d", len(jobs))
+	}
+	if job := jobs[0]; job.Name != "alt-speed" || !job.State || !reflect.DeepEqual(job.Contexts, []string{"home"}) {
+		t.Errorf("expected alt-speed job on home, got: %+v", job)
+	}
+	job := jobs[1]
+	if job.Action != "policy" || job.Filter != "uploadRatio < 1" || !reflect.DeepEqual(job.Contexts, []string{"home", "seedbox"}) {
+		t.Errorf("expected policy job on home and seedbox, got: %+v", job)
+	}
+	if exp := map[string]interface{}{"seedRatioLimit": "2", "seedRatioMode": "1"}; !reflect.DeepEqual(job.Settings, exp) {
+		t.Errorf("expected settings %v, got: %v", exp, job.Settings)
+	}
+	if job := jobs[2]; job.Filter != "isStalled" {
+		t.Errorf("expected reannounce to default to isStalled, got: %q", job.Filter)
+	}
+
+	// invalid
+	tests := []struct {
+		job string
+		err string
+	}{
+		{"schedule = @hourly\naction = policy\nset = a=b", "must specify filter and set"},
+		{"schedule = @hourly\naction = policy\nfilter = isStalled", "must specify filter and set"},
+		{"schedule = @hourly\naction = policy\nfilter = isStalled\nset = a", `invalid setting "a"`},
+		{"schedule = @hourly\naction = policy\nfilter = isStalled\nset = =b", `invalid setting "=b"`},
+		{"schedule = @hourly\naction = move\nfilter = isStalled", "must specify filter and dest"},
+		{"schedule = @hourly\naction = alt-speed", "must specify state"},
+		{"schedule = @hourly\naction = unknown", `invalid action "unknown"`},
+		{"action = start\nfilter = isStalled", "must specify schedule"},
+		{"schedule = 60 * * * *\naction = start\nfilter = isStalled", "invalid schedule"},
+	}
+	for i, test := range tests {
+		config, err := ini.LoadString("[job.test]\n" + test.job + "\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadDaemonJobs(&Args{Config: config}); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d expected error containing %q, got: %v", i, test.err, err)
+		}
+	}
+}
+
+func TestRunDaemonJob(t *testing.T) {
+	home, seedbox := newDaemonProvider(), newDaemonProvider()
+	provs := map[string]Provider{"home": home, "seedbox": seedbox}
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, "")
+	args := &Args{Config: ini.NewFile()}
+	args.Host.Timeout = 10 * time.Second
+
+	// policy
+	runDaemonJob(args, provs, &DaemonJob{
+		Name:     "seed-policy",
+		Contexts: []string{"home", "seedbox"},
+		Action:   "policy",
+		Filter:   `"linux" in labels`,
+		Settings: map[string]interface{}{"seedRatioLimit": "2"},
+	}, logger)
+	for _, p := range []*daemonProvider{home, seedbox} {
+		exp := []string{`set [hash1 hash2] map[seedRatioLimit:2]`}
+		if !reflect.DeepEqual(p.calls, exp) {
+			t.Errorf("expected %v, got: %v", exp, p.calls)
+		}
+	}
+
+	// reannounce, with only the matching torrent
+	runDaemonJob(args, provs, &DaemonJob{Name: "stalled", Contexts: []string{"home"}, Action: "reannounce", Filter: "isStalled"}, logger)
+	if exp := `reannounce [hash3]`; home.calls[len(home.calls)-1] != exp {
+		t.Errorf("expected %q, got: %q", exp, home.calls[len(home.calls)-1])
+	}
+
+	// no matches does not call the provider
+	n := len(home.calls)
+	runDaemonJob(args, provs, &DaemonJob{Name: "none", Contexts: []string{"home"}, Action: "stop", Filter: `name == "none"`}, logger)
+	if len(home.calls) != n {
+		t.Errorf("expected no calls, got: %v", home.calls[n:])
+	}
+
+	// alt speed and free space
+	runDaemonJob(args, provs, &DaemonJob{Name: "alt-speed", Contexts: []string{"home"}, Action: "alt-speed", State: true}, logger)
+	runDaemonJob(args, provs, &DaemonJob{Name: "free", Contexts: []string{"home"}, Action: "free-space", Locations: []string{"/data"}, MinFree: 2 << 30}, logger)
+	if exp := []string{"alt-speed true", "free-space /data"}; !reflect.DeepEqual(home.calls[n:], exp) {
+		t.Errorf("expected %v, got: %v", exp, home.calls[n:])
+	}
+
+	// check log
+	log := buf.String()
+	for _, s := range []string{
+		`msg="job completed" job=seed-policy context=home action=policy`,
+		`msg="job completed" job=seed-policy context=seedbox action=policy`,
+		`msg="matched torrents" job=stalled context=home count=1`,
+		`level=warn msg="free space below minimum" job=free context=home location=/data`,
+	} {
+		if !strings.Contains(log, s) {
+			t.Errorf("expected log to contain %q, got:\n%s", s, log)
+		}
+	}
+
+	// failures are logged, and do not stop other contexts
+	home.err = ErrNotSupportedByProvider
+	buf.Reset()
+	runDaemonJob(args, provs, &DaemonJob{Name: "alt-speed", Contexts: []string{"home", "seedbox"}, Action: "alt-speed"}, logger)
+	log = buf.String()
+	for _, s := range []string{
+		`level=error msg="job failed" job=alt-speed context=home action=alt-speed err="not supported by provider"`,
+		`msg="job completed" job=alt-speed context=seedbox`,
+	} {
+		if !strings.Contains(log, s) {
+			t.Errorf("expected log to contain %q, got:\n%s", s, log)
+		}
+	}
+
+	// alt speed requires a limits provider
+	provs["plain"] = struct{ Provider }{}
+	buf.Reset()
+	runDaemonJob(args, provs, &DaemonJob{Name: "alt-speed", Contexts: []string{"plain"}, Action: "alt-speed"}, logger)
+	log = buf.String()
+	for _, s := range []string{
+		`level=error msg="job failed" job=alt-speed context=plain action=alt-speed err="limits not supported by provider"`,
+	} {
+		if !strings.Contains(log, s) {
+			t.Errorf("expected log to contain %q, got:\n%s", s, log)
+		}
+	}
+}
+
+func TestScheduleDaemonJob(t *testing.T) {
+	logger := NewLogger(ioutil.Discard, "")
+
+	// runs on each activation until canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	var count int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduleDaemonJob(ctx, &DaemonJob{Schedule: &Schedule{every: time.Millisecond}}, time.Now, func() {
+			if count++; count == 3 {
+				cancel()
+			}
+		}, logger)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected job to stop after cancel")
+	}
+	if count != 3 {
+		t.Errorf("expected 3 runs, got: %d", count)
+	}
+
+	// waits until the next activation of now
+	now := time.Date(2021, 3, 10, 10, 59, 59, 990*int(time.Millisecond), time.UTC)
+	s, err := ParseSchedule("@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	start, ran := time.Now(), make(chan time.Duration, 1)
+	go scheduleDaemonJob(ctx, &DaemonJob{Schedule: s}, func() time.Time {
+		return now
+	}, func() {
+		select {
+		case ran <- time.Since(start):
+		default:
+		}
+		cancel()
+	}, logger)
+	select {
+	case d := <-ran:
+		if d < 10*time.Millisecond {
+			t.Errorf("expected job to wait for the next activation, ran after: %v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected job to run")
+	}
+
+	// never runs
+	buf := new(bytes.Buffer)
+	s, err = ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduleDaemonJob(context.Background(), &DaemonJob{Name: "never", Spec: "0 0 30 2 *", Schedule: s}, time.Now, func() {
+		t.Errorf("expected job to never run")
+	}, NewLogger(buf, ""))
+	if s := `level=warn msg="job will never run" job=never`; !strings.Contains(buf.String(), s) {
+		t.Errorf("expected log to contain %q, got: %s", s, buf.String())
+	}
+}
+
+// daemonProvider is a provider with a fixed torrent list, that records the
+// daemon actions called.
+type daemonProvider struct {
+	LimitsProvider
+	torrents []tctypes.Torrent
+	calls    []string
+	err      error
+	sync.Mutex
+}
+
+func newDaemonProvider() *daemonProvider {
+	return &daemonProvider{torrents: []tctypes.Torrent{
+		{ID: 1, HashString: "hash1", Name: "debian", Labels: []string{"linux"}},
+		{ID: 2, HashString: "hash2", Name: "ubuntu", Labels: []string{"linux"}},
+		{ID: 3, HashString: "hash3", Name: "other", IsStalled: true},
+	}}
+}
+
+func (p *daemonProvider) record(format string, v ...interface{}) error {
+	p.Lock()
+	defer p.Unlock()
+	p.calls = append(p.calls, strings.TrimSpace(fmt.Sprintf(format, v...)))
+	return p.err
+}
+
+func (p *daemonProvider) Get(context.Context, []string, ...interface{}) ([]tctypes.Torrent, error) {
+	return p.torrents, nil
+}
+
+func (p *daemonProvider) Set(_ context.Context, opts map[string]interface{}, ids ...interface{}) error {
+	return p.record("set %v %v", ids, opts)
+}
+
+func (p *daemonProvider) Stop(_ context.Context, ids ...interface{}) error {
+	return p.record("stop %v", ids)
+}
+
+func (p *daemonProvider) Reannounce(_ context.Context, ids ...interface{}) error {
+	return p.record("reannounce %v", ids)
+}
+
+func (p *daemonProvider) AltSpeedSet(_ context.Context, state bool) error {
+	return p.record("alt-speed %t", state)
+}
+
+func (p *daemonProvider) FreeSpace(_ context.Context, location string) (tctypes.ByteCount, error) {
+	return 1 << 30, p.record("free-space %s", location)
+}
@@ -1 +1,557 @@
 package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/delrpc"
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// DefaultPort is the default deluge daemon rpc port.
+const DefaultPort = "58846"
+
+func init() {
+	providers.Register("deluge", New)
+}
+
+// Provider is a Deluge rpc host provider.
+//
+// Only the core torrent commands (add, get, start, stop, move, remove,
+// verify, reannounce, queue) and labels are supported. Labels require the
+// daemon's Label plugin to be enabled, which allows only a single label per
+// torrent.
+type Provider struct {
+	args *providers.Args
+	cl   *delrpc.Client
+}
+
+// New creates a new Deluge rpc host provider.
+func New(args *providers.Args) (providers.Provider, error) {
+	remote, err := args.Remote()
+	if err != nil {
+		return nil, err
+	}
+
+	// deluge daemons do not have a default http port, so use the daemon port
+	u := *remote.URL
+	if u.Port() == "" {
+		u.Host = u.Hostname() + ":" + DefaultPort
+	}
+
+	// build options
+	opts := []delrpc.ClientOption{
+		delrpc.WithUserAgent(remote.UserAgent),
+		delrpc.WithURL(u.String()),
+		delrpc.WithTimeout(remote.Timeout),
+	}
+	if remote.Fallback != nil {
+		opts = append(opts, delrpc.WithCredentialFallback(remote.Fallback[0], remote.Fallback[1]))
+	}
+	if remote.TLS != nil {
+		cfg := remote.TLS
+		// deluge daemons use a self-signed certificate, so a pin alone
+		// verifies the daemon's key, and not the certificate chain
+		if cfg.VerifyPeerCertificate != nil && cfg.RootCAs == nil {
+			cfg = cfg.Clone()
+			cfg.InsecureSkipVerify = true
+		}
+		opts = append(opts, delrpc.WithTLSConfig(cfg))
+	}
+	if remote.Dialer != nil {
+		opts = append(opts, delrpc.WithDialer(remote.Dialer))
+	}
+	switch {
+	case remote.HAR != nil:
+		opts = append(opts, delrpc.WithHAR(remote.HAR))
+	case remote.Logf != nil:
+		opts = append(opts, delrpc.WithLogf(remote.Logf, remote.Logf))
+	}
+
+	return &Provider{args: args, cl: delrpc.NewClient(opts...)}, nil
+}
+
+// Add satisfies the Provider interface.
+func (p *Provider) Add(ctx context.Context, opts providers.AddOptions, files ...interface{}) ([]tctypes.Torrent, error) {
+	label, err := singleLabel(opts.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	// build options
+	options := map[string]interface{}{
+		"add_paused": opts.Paused,
+	}
+	if opts.DownloadDir != "" {
+		options["download_location"] = opts.DownloadDir
+	}
+	if opts.PeerLimit != 0 {
+		options["max_connections"] = opts.PeerLimit
+	}
+
+	var hashes []interface{}
+	for i, file := range files {
+		var hash string
+		var err error
+		switch v := file.(type) {
+		case []byte:
+			filedump := base64.StdEncoding.EncodeToString(v)
+			err = p.cl.Do(ctx, "core.add_torrent_file", []interface{}{"torrent" + strconv.Itoa(i) + ".torrent", filedump, options}, &hash)
+		case string:
+			if strings.HasPrefix(v, "magnet:") {
+				err = p.cl.Do(ctx, "core.add_torrent_magnet", []interface{}{v, options}, &hash)
+				break
+			}
+			headers := make(map[string]interface{})
+			if len(opts.Cookies) != 0 {
+				var cookies []string
+				for k, v := range opts.Cookies {
+					cookies = append(cookies, k+"="+v)
+				}
+				sort.Strings(cookies)
+				headers["Cookie"] = strings.Join(cookies, "; ")
+			}
+			err = p.cl.Do(ctx, "core.add_torrent_url", []interface{}{v, options, headers}, &hash)
+		default:
+			return nil, fmt.Errorf("invalid torrent type %T", file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	if label != "" {
+		if err := p.setLabel(ctx, label, toHashes(hashes)); err != nil {
+			return nil, err
+		}
+	}
+	return p.Get(ctx, nil, hashes...)
+}
+
+// statusKeys are the torrent status keys retrieved from the remote host.
+var statusKeys = []string{
+	"hash",
+	"name",
+	"state",
+	"save_path",
+	"progress",
+	"total_size",
+	"total_done",
+	"total_uploaded",
+	"download_payload_rate",
+	"upload_payload_rate",
+	"ratio",
+	"eta",
+	"time_added",
+	"queue",
+	"num_peers",
+	"message",
+	"label",
+}
+
+// status is a deluge torrent status.
+type status struct {
+	Hash                string  `json:"hash"`
+	Name                string  `json:"name"`
+	State               string  `json:"state"`
+	SavePath            string  `json:"save_path"`
+	Progress            float64 `json:"progress"`
+	TotalSize           float64 `json:"total_size"`
+	TotalDone           float64 `json:"total_done"`
+	TotalUploaded       float64 `json:"total_uploaded"`
+	DownloadPayloadRate float64 `json:"download_payload_rate"`
+	UploadPayloadRate   float64 `json:"upload_payload_rate"`
+	Ratio               float64 `json:"ratio"`
+	Eta                 float64 `json:"eta"`
+	TimeAdded           float64 `json:"time_added"`
+	Queue               int64   `json:"queue"`
+	NumPeers            int64   `json:"num_peers"`
+	Message             string  `json:"message"`
+	Label               string  `json:"label"`
+}
+
+// Get satisfies the Provider interface.
+//
+// Deluge does not have torrent ids, so all torrents are retrieved and
+// numbered in the order they were added, and then filtered by the provided
+// identifiers. The fields are ignored, as the same status keys are always
+// retrieved.
+func (p *Provider) Get(ctx context.Context, fields []string, ids ...interface{}) ([]tctypes.Torrent, error) {
+	res := make(map[string]status)
+	if err := p.cl.Do(ctx, "core.get_torrents_status", []interface{}{map[string]interface{}{}, statusKeys}, &res); err != nil {
+		return nil, err
+	}
+	list := make([]status, 0, len(res))
+	for _, s := range res {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TimeAdded != list[j].TimeAdded {
+			return list[i].TimeAdded < list[j].TimeAdded
+		}
+		return list[i].Hash < list[j].Hash
+	})
+
+	// build filter
+	var recent bool
+	hashes := make(map[string]bool)
+	for _, id := range ids {
+		switch v := id.(type) {
+		case string:
+			if v == "recently-active" {
+				recent = true
+			} else {
+				hashes[strings.ToLower(v)] = true
+			}
+		default:
+			return nil, fmt.Errorf("invalid torrent identifier %v", id)
+		}
+	}
+
+	var torrents []tctypes.Torrent
+	for i, s := range list {
+		switch {
+		case recent && s.DownloadPayloadRate == 0 && s.UploadPayloadRate == 0,
+			len(hashes) != 0 && !hashes[strings.ToLower(s.Hash)]:
+			continue
+		}
+		torrents = append(torrents, convertTorrent(int64(i+1), s))
+	}
+	return torrents, nil
+}
+
+// convertTorrent converts a deluge torrent status to a torrent.
+func convertTorrent(id int64, s status) tctypes.Torrent {
+	t := tctypes.Torrent{
+		ID:             id,
+		HashString:     s.Hash,
+		Name:           s.Name,
+		DownloadDir:    s.SavePath,
+		PercentDone:    tctypes.Percent(s.Progress / 100),
+		TotalSize:      tctypes.ByteCount(s.TotalSize),
+		SizeWhenDone:   tctypes.ByteCount(s.TotalSize),
+		LeftUntilDone:  tctypes.ByteCount(s.TotalSize - s.TotalDone),
+		DownloadedEver: tctypes.ByteCount(s.TotalDone),
+		UploadedEver:   tctypes.ByteCount(s.TotalUploaded),
+		RateDownload:   tctypes.Rate(s.DownloadPayloadRate),
+		RateUpload:     tctypes.Rate(s.UploadPayloadRate),
+		UploadRatio:    s.Ratio,
+		Eta:            tctypes.Duration(time.Duration(s.Eta) * time.Second),
+		AddedDate:      tctypes.Time(time.Unix(int64(s.TimeAdded), 0)),
+		QueuePosition:  s.Queue,
+		PeersConnected: s.NumPeers,
+		IsFinished:     s.Progress >= 100,
+		MagnetLink:     "magnet:?xt=urn:btih:" + s.Hash + "&dn=" + url.QueryEscape(s.Name),
+	}
+	if s.Label != "" {
+		t.Labels = []string{s.Label}
+	}
+	switch s.State {
+	case "Paused":
+		t.Status = tctypes.StatusStopped
+	case "Checking":
+		t.Status = tctypes.StatusChecking
+	case "Allocating", "Moving":
+		t.Status = tctypes.StatusCheckWait
+	case "Seeding":
+		t.Status = tctypes.StatusSeeding
+	case "Queued":
+		if s.Progress >= 100 {
+			t.Status = tctypes.StatusSeedWait
+		} else {
+			t.Status = tctypes.StatusDownloadWait
+		}
+	case "Error":
+		t.Status, t.ErrorString = tctypes.StatusStopped, s.Message
+	default:
+		t.Status = tctypes.StatusDownloading
+	}
+	return t
+}
+
+// Set satisfies the Provider interface.
+func (p *Provider) Set(context.Context, map[string]interface{}, ...interface{}) error {
+	return fmt.Errorf("set %w", providers.ErrNotSupportedByProvider)
+}
+
+// Start satisfies the Provider interface.
+func (p *Provider) Start(ctx context.Context, ids ...interface{}) error {
+	return p.cl.Do(ctx, "core.resume_torrent", []interface{}{toHashes(ids)}, nil)
+}
+
+// Stop satisfies the Provider interface.
+func (p *Provider) Stop(ctx context.Context, ids ...interface{}) error {
+	return p.cl.Do(ctx, "core.pause_torrent", []interface{}{toHashes(ids)}, nil)
+}
+
+// Move satisfies the Provider interface.
+func (p *Provider) Move(ctx context.Context, dest string, ids ...interface{}) error {
+	return p.cl.Do(ctx, "core.move_storage", []interface{}{toHashes(ids), dest}, nil)
+}
+
+// Remove satisfies the Provider interface.
+func (p *Provider) Remove(ctx context.Context, deleteLocalData bool, ids ...interface{}) error {
+	for _, hash := range toHashes(ids) {
+		if err := p.cl.Do(ctx, "core.remove_torrent", []interface{}{hash, deleteLocalData}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify satisfies the Provider interface.
+func (p *Provider) Verify(ctx context.Context, ids ...interface{}) error {
+	return p.cl.Do(ctx, "core.force_recheck", []interface{}{toHashes(ids)}, nil)
+}
+
+// Reannounce satisfies the Provider interface.
+func (p *Provider) Reannounce(ctx context.Context, ids ...interface{}) error {
+	return p.cl.Do(ctx, "core.force_reannounce", []interface{}{toHashes(ids)}, nil)
+}
+
+// Queue satisfies the Provider interface.
+func (p *Provider) Queue(ctx context.Context, dir string, ids ...interface{}) error {
+	switch dir {
+	case "top", "bottom", "up", "down":
+		return p.cl.Do(ctx, "core.queue_"+dir, []interface{}{toHashes(ids)}, nil)
+	}
+	return fmt.Errorf("invalid queue direction %q", dir)
+}
+
+// PeersGet satisfies the Provider interface.
+func (p *Provider) PeersGet(context.Context, ...interface{}) ([]tctypes.Peer, error) {
+	return nil, fmt.Errorf("peers %w", providers.ErrNotSupportedByProvider)
+}
+
+// PeersAdd satisfies the Provider interface.
+func (p *Provider) PeersAdd(context.Context, []string, ...interface{}) error {
+	return fmt.Errorf("peers add %w", providers.ErrNotSupportedByProvider)
+}
+
+// PeersBan satisfies the Provider interface.
+func (p *Provider) PeersBan(context.Context, ...string) error {
+	return fmt.Errorf("peers ban %w", providers.ErrNotSupportedByProvider)
+}
+
+// FilesGet satisfies the Provider interface.
+func (p *Provider) FilesGet(context.Context, ...interface{}) ([]tctypes.File, error) {
+	return nil, fmt.Errorf("files %w", providers.ErrNotSupportedByProvider)
+}
+
+// FilesSet satisfies the Provider interface.
+func (p *Provider) FilesSet(context.Context, map[string]interface{}, ...interface{}) error {
+	return fmt.Errorf("files set %w", providers.ErrNotSupportedByProvider)
+}
+
+// FilesRename satisfies the Provider interface.
+func (p *Provider) FilesRename(context.Context, string, string, ...interface{}) error {
+	return fmt.Errorf("files rename %w", providers.ErrNotSupportedByProvider)
+}
+
+// PiecesGet satisfies the Provider interface.
+func (p *Provider) PiecesGet(context.Context, ...interface{}) ([]tctypes.PieceMap, error) {
+	return nil, fmt.Errorf("pieces %w", providers.ErrNotSupportedByProvider)
+}
+
+// TrackersGet satisfies the Provider interface.
+func (p *Provider) TrackersGet(context.Context, ...interface{}) ([]tctypes.Tracker, error) {
+	return nil, fmt.Errorf("trackers %w", providers.ErrNotSupportedByProvider)
+}
+
+// TrackersAdd satisfies the Provider interface.
+func (p *Provider) TrackersAdd(context.Context, string, int64, ...interface{}) error {
+	return fmt.Errorf("trackers add %w", providers.ErrNotSupportedByProvider)
+}
+
+// TrackersReplace satisfies the Provider interface.
+func (p *Provider) TrackersReplace(context.Context, string, string, ...interface{}) error {
+	return fmt.Errorf("trackers replace %w", providers.ErrNotSupportedByProvider)
+}
+
+// TrackersRemove satisfies the Provider interface.
+func (p *Provider) TrackersRemove(context.Context, string, ...interface{}) error {
+	return fmt.Errorf("trackers remove %w", providers.ErrNotSupportedByProvider)
+}
+
+// TrackersRemoveIDs satisfies the Provider interface.
+func (p *Provider) TrackersRemoveIDs(context.Context, string, ...int64) error {
+	return fmt.Errorf("trackers remove %w", providers.ErrNotSupportedByProvider)
+}
+
+// LabelsAdd satisfies the Provider interface.
+//
+// Deluge torrents have at most one label, so the label replaces any existing
+// label.
+func (p *Provider) LabelsAdd(ctx context.Context, labels []string, ids ...interface{}) error {
+	label, err := singleLabel(labels)
+	if err != nil || label == "" {
+		return err
+	}
+	return p.setLabel(ctx, label, toHashes(ids))
+}
+
+// LabelsRemove satisfies the Provider interface.
+func (p *Provider) LabelsRemove(ctx context.Context, labels []string, ids ...interface{}) error {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	for _, t := range torrents {
+		for _, label := range t.Labels {
+			if contains(labels, label) {
+				hashes = append(hashes, t.HashString)
+			}
+		}
+	}
+	return p.setLabel(ctx, "", hashes)
+}
+
+// LabelsSet satisfies the Provider interface.
+func (p *Provider) LabelsSet(ctx context.Context, labels []string, ids ...interface{}) error {
+	label, err := singleLabel(labels)
+	if err != nil {
+		return err
+	}
+	return p.setLabel(ctx, label, toHashes(ids))
+}
+
+// setLabel sets the label for the hashes, creating the label if it does not
+// exist. An empty label removes the torrents' label.
+func (p *Provider) setLabel(ctx context.Context, label string, hashes []string) error {
+	if label != "" {
+		var labels []string
+		if err := p.cl.Do(ctx, "label.get_labels", nil, &labels); err != nil {
+			return err
+		}
+		if !contains(labels, label) {
+			if err := p.cl.Do(ctx, "label.add", []interface{}{label}, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for _, hash := range hashes {
+		if err := p.cl.Do(ctx, "label.set_torrent", []interface{}{hash, label}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CategoriesGet satisfies the Provider interface.
+func (p *Provider) CategoriesGet(context.Context) ([]tctypes.Category, error) {
+	return nil, fmt.Errorf("categories %w", providers.ErrNotSupportedByProvider)
+}
+
+// CategoriesCreate satisfies the Provider interface.
+func (p *Provider) CategoriesCreate(context.Context, string, string) error {
+	return fmt.Errorf("categories %w", providers.ErrNotSupportedByProvider)
+}
+
+// CategoriesEdit satisfies the Provider interface.
+func (p *Provider) CategoriesEdit(context.Context, string, string) error {
+	return fmt.Errorf("categories %w", providers.ErrNotSupportedByProvider)
+}
+
+// CategoriesDelete satisfies the Provider interface.
+func (p *Provider) CategoriesDelete(context.Context, ...string) error {
+	return fmt.Errorf("categories %w", providers.ErrNotSupportedByProvider)
+}
+
+// Stats satisfies the Provider interface.
+//
+// The stats are computed from the torrent list.
+func (p *Provider) Stats(ctx context.Context) (map[string]interface{}, error) {
+	torrents, err := p.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var active, paused int64
+	var download, upload tctypes.Rate
+	for _, t := range torrents {
+		switch {
+		case t.Status == tctypes.StatusStopped:
+			paused++
+		case t.RateDownload != 0 || t.RateUpload != 0:
+			active++
+		}
+		download, upload = download+t.RateDownload, upload+t.RateUpload
+	}
+	return map[string]interface{}{
+		"active-torrent-count": active,
+		"download-speed":       download,
+		"paused-torrent-count": paused,
+		"torrent-count":        int64(len(torrents)),
+		"upload-speed":         upload,
+	}, nil
+}
+
+// Shutdown satisfies the Provider interface.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.cl.Do(ctx, "daemon.shutdown", nil, nil)
+}
+
+// FreeSpace satisfies the Provider interface.
+func (p *Provider) FreeSpace(ctx context.Context, path string) (tctypes.ByteCount, error) {
+	var free int64
+	if err := p.cl.Do(ctx, "core.get_free_space", []interface{}{path}, &free); err != nil {
+		return 0, err
+	}
+	return tctypes.ByteCount(free), nil
+}
+
+// BlocklistUpdate satisfies the Provider interface.
+func (p *Provider) BlocklistUpdate(context.Context) (int64, error) {
+	return 0, fmt.Errorf("blocklist update %w", providers.ErrNotSupportedByProvider)
+}
+
+// PortTest satisfies the Provider interface.
+func (p *Provider) PortTest(context.Context) (bool, error) {
+	return false, fmt.Errorf("port test %w", providers.ErrNotSupportedByProvider)
+}
+
+// NewRemoteConfigStore satisfies the Provider interface.
+func (p *Provider) NewRemoteConfigStore(context.Context) (providers.ConfigStore, error) {
+	return nil, fmt.Errorf("remote config %w", providers.ErrNotSupportedByProvider)
+}
+
+// singleLabel returns the lower cased label of labels, as the Label plugin
+// allows only one (lower case) label per torrent.
+func singleLabel(labels []string) (string, error) {
+	switch len(labels) {
+	case 0:
+		return "", nil
+	case 1:
+		return strings.ToLower(labels[0]), nil
+	}
+	return "", fmt.Errorf("deluge torrents can have only one label, got: %d", len(labels))
+}
+
+// contains determines if v is contained in l, ignoring case.
+func contains(l []string, v string) bool {
+	for _, s := range l {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// toHashes converts ids to hashes.
+func toHashes(ids []interface{}) []string {
+	hashes := make([]string, len(ids))
+	for i, id := range ids {
+		hashes[i] = fmt.Sprintf("%v", id)
+	}
+	return hashes
+}
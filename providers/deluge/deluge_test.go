@@ -0,0 +1,316 @@
+package deluge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
+	"github.com/knq/ini"
+)
+
+const (
+	hash1 = "0123456789abcdef0123456789abcdef01234567"
+	hash2 = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestAddGet(t *testing.T) {
+	d, p := newTestProvider(t, nil)
+	defer d.Close()
+	ctx := context.Background()
+	opts := providers.AddOptions{DownloadDir: "/data", Paused: true}
+	added, err := p.Add(ctx, opts, "magnet:?xt=urn:btih:"+hash1+"&dn=one", []byte("torrent"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(added) != 2 {
+		t.Fatalf("expected 2 added torrents, got: %d", len(added))
+	}
+	for i, torrent := range added {
+		if torrent.Status != tctypes.StatusStopped {
+			t.Errorf("torrent %d expected status %v, got: %v", i, tctypes.StatusStopped, torrent.Status)
+		}
+		if torrent.DownloadDir != "/data" {
+			t.Errorf("torrent %d expected download dir /data, got: %q", i, torrent.DownloadDir)
+		}
+	}
+	if n := len(d.Torrents()); n != 2 {
+		t.Fatalf("expected 2 torrents on remote host, got: %d", n)
+	}
+
+	// labels are set with the label plugin, which allows only one label
+	if _, err := p.Add(ctx, providers.AddOptions{Labels: []string{"a", "b"}}, "magnet:?xt=urn:btih:"+hash2); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	added, err = p.Add(ctx, providers.AddOptions{Labels: []string{"Linux"}}, "magnet:?xt=urn:btih:"+hash2)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(added) != 1 || !reflect.DeepEqual(added[0].Labels, []string{"linux"}) {
+		t.Errorf("expected torrent with label linux, got: %v", added)
+	}
+
+	// get by hash
+	torrents, err := p.Get(ctx, nil, hash1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].HashString != hash1 {
+		t.Errorf("expected torrent %s, got: %v", hash1, torrents)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	d, p := newTestProvider(t, nil)
+	defer d.Close()
+	d.AddTorrent(hash1, "one")
+	d.AddTorrent(hash2, "two")
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		f    func() error
+		exp  [][]string
+	}{
+		{"add", func() error {
+			return p.LabelsAdd(ctx, []string{"linux"}, hash1, hash2)
+		}, [][]string{{"linux"}, {"linux"}}},
+		{"add replaces", func() error {
+			return p.LabelsAdd(ctx, []string{"ISO"}, hash2)
+		}, [][]string{{"linux"}, {"iso"}}},
+		{"remove other", func() error {
+			return p.LabelsRemove(ctx, []string{"linux"}, hash2)
+		}, [][]string{{"linux"}, {"iso"}}},
+		{"remove", func() error {
+			return p.LabelsRemove(ctx, []string{"linux"}, hash1, hash2)
+		}, [][]string{nil, {"iso"}}},
+		{"set", func() error {
+			return p.LabelsSet(ctx, []string{"linux"}, hash1)
+		}, [][]string{{"linux"}, {"iso"}}},
+		{"set empty", func() error {
+			return p.LabelsSet(ctx, nil, hash2)
+		}, [][]string{{"linux"}, nil}},
+	}
+	for _, test := range tests {
+		if err := test.f(); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", test.name, err)
+		}
+		torrents, err := p.Get(ctx, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		labels := make([][]string, len(torrents))
+		for i, torrent := range torrents {
+			labels[i] = torrent.Labels
+		}
+		if !reflect.DeepEqual(labels, test.exp) {
+			t.Errorf("%s: expected labels %v, got: %v", test.name, test.exp, labels)
+		}
+	}
+
+	// only one label per torrent, and only valid labels
+	if err := p.LabelsSet(ctx, []string{"a", "b"}, hash1); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if err := p.LabelsAdd(ctx, []string{"a b"}, hash1); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestStartStopVerifyRemove(t *testing.T) {
+	d, p := newTestProvider(t, nil)
+	defer d.Close()
+	d.AddTorrent(hash1, "one")
+	d.AddTorrent(hash2, "two")
+	ctx := context.Background()
+
+	// stop
+	if err := p.Stop(ctx, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusStopped, tctypes.StatusDownloading)
+
+	// start
+	if err := p.Start(ctx, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusDownloading, tctypes.StatusDownloading)
+
+	// verify
+	if err := p.Verify(ctx, hash2); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusDownloading, tctypes.StatusChecking)
+
+	// remove
+	if err := p.Remove(ctx, true, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if torrents := d.Torrents(); len(torrents) != 1 || torrents[hash2] == nil {
+		t.Errorf("expected only %s to remain, got: %v", hash2, torrents)
+	}
+}
+
+func TestStatsFreeSpace(t *testing.T) {
+	d, p := newTestProvider(t, nil)
+	defer d.Close()
+	d.AddTorrent(hash1, "one")
+	d.AddTorrent(hash2, "two")
+	ctx := context.Background()
+	if err := p.Stop(ctx, hash2); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stats, err := p.Stats(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := stats["torrent-count"]; n != int64(2) {
+		t.Errorf("expected torrent count 2, got: %v", n)
+	}
+	if n := stats["paused-torrent-count"]; n != int64(1) {
+		t.Errorf("expected paused torrent count 1, got: %v", n)
+	}
+	free, err := p.FreeSpace(ctx, "/")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if free != 1<<40 {
+		t.Errorf("expected %d, got: %d", int64(1<<40), free)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	d := transctltest.NewDeluge(transctltest.WithDelugeCredentials("user", "pass"))
+	defer d.Close()
+
+	// write ca bundle
+	f, err := ioutil.TempFile("", "deluge-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: d.Certificate().Raw}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := sha256.Sum256(d.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+	badPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	tests := []struct {
+		name string
+		keys map[string]string
+		err  bool
+	}{
+		// deluge daemons use a self-signed certificate, which by default
+		// is not verified
+		{"default", nil, false},
+		{"insecure", map[string]string{"insecure": "true"}, false},
+		{"cacert", map[string]string{"cacert": f.Name()}, false},
+		{"pin", map[string]string{"pin": pin}, false},
+		{"cacert and pin", map[string]string{"cacert": f.Name(), "pin": pin}, false},
+		{"bad pin", map[string]string{"pin": badPin}, true},
+		{"bad cacert", map[string]string{"cacert": f.Name(), "pin": badPin}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newProvider(t, d, test.keys)
+			_, err := p.Get(context.Background(), nil)
+			switch {
+			case test.err && err == nil:
+				t.Errorf("expected error, got nil")
+			case !test.err && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	d, p := newTestProvider(t, nil)
+	defer d.Close()
+	ctx := context.Background()
+	_, err1 := p.PeersGet(ctx, hash1)
+	_, err2 := p.FilesGet(ctx, hash1)
+	_, err3 := p.TrackersGet(ctx, hash1)
+	_, err4 := p.CategoriesGet(ctx)
+	_, err5 := p.NewRemoteConfigStore(ctx)
+	for i, err := range []error{
+		p.Set(ctx, map[string]interface{}{"name": "v"}, hash1),
+		err1, err2, err3, err4, err5,
+	} {
+		if !errors.Is(err, providers.ErrNotSupportedByProvider) {
+			t.Errorf("test %d expected not supported error, got: %v", i, err)
+		}
+	}
+	var v interface{} = p
+	if _, ok := v.(providers.LimitsProvider); ok {
+		t.Errorf("expected provider to not support limits")
+	}
+	if _, ok := v.(providers.ModeProvider); ok {
+		t.Errorf("expected provider to not support download modes")
+	}
+}
+
+// checkStatus checks the status of the torrents on the remote host.
+func checkStatus(t *testing.T, p *Provider, exp ...tctypes.Status) {
+	t.Helper()
+	torrents, err := p.Get(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != len(exp) {
+		t.Fatalf("expected %d torrents, got: %d", len(exp), len(torrents))
+	}
+	for i, status := range exp {
+		if s := torrents[i].Status; s != status {
+			t.Errorf("torrent %d expected status %v, got: %v", i, status, s)
+		}
+	}
+}
+
+// newTestProvider creates a fake Deluge server, and a provider for it, using
+// the context config keys. The caller must close the server.
+func newTestProvider(t *testing.T, keys map[string]string) (*transctltest.Deluge, *Provider) {
+	t.Helper()
+	d := transctltest.NewDeluge(transctltest.WithDelugeCredentials("user", "pass"))
+	return d, newProvider(t, d, keys)
+}
+
+// newProvider creates a provider for the fake Deluge server, using the
+// context config keys.
+func newProvider(t *testing.T, d *transctltest.Deluge, keys map[string]string) *Provider {
+	t.Helper()
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	u.User = url.UserPassword("user", "pass")
+	config := ini.NewFile()
+	config.SetKey("default.context", "deluge")
+	config.SetKey("context.deluge.type", "deluge")
+	for k, v := range keys {
+		config.SetKey("context.deluge."+k, v)
+	}
+	args := &providers.Args{Config: config}
+	args.Host.URL = u
+	args.Host.Timeout = 10 * time.Second
+	args.Host.NoNetrc = true
+	args.Host.Strict = true
+	p, err := New(args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return p.(*Provider)
+}
@@ -49,6 +49,10 @@ const (
 	// error.
 	ErrInvalidOutputOptionSpecified Error = "invalid --output option specified"
 
+	// ErrVersionedOutputNotSupported is the versioned output not supported
+	// error.
+	ErrVersionedOutputNotSupported Error = "versioned output not supported"
+
 	// ErrSortByNotInColumnList is the sort by not in column list error.
 	ErrSortByNotInColumnList Error = "--sort-by not in column list"
 
@@ -60,4 +64,41 @@ const (
 
 	// ErrInvalidStrlenArguments is the invalid strlen arguments error.
 	ErrInvalidStrlenArguments Error = "invalid strlen() arguments"
+
+	// ErrNoDaemonJobsOrHooksConfigured is the no daemon jobs or hooks
+	// configured error.
+	ErrNoDaemonJobsOrHooksConfigured Error = "no daemon jobs or hooks configured"
+
+	// ErrCannotSetCredentialsWithRemoteOrUnset is the cannot set credentials
+	// with remote or unset error.
+	ErrCannotSetCredentialsWithRemoteOrUnset Error = "cannot set-credentials with --remote or --unset"
+
+	// ErrMustSpecifyContextToSetCredentials is the must specify context to set
+	// credentials error.
+	ErrMustSpecifyContextToSetCredentials Error = "must specify context to set-credentials"
+
+	// ErrMustSpecifySecretKeyFileOrPassphrase is the must specify secret key
+	// file or passphrase error.
+	ErrMustSpecifySecretKeyFileOrPassphrase Error = "must specify --secret-key-file or TRANSPASSPHRASE"
+
+	// ErrPassphrasesDoNotMatch is the passphrases do not match error.
+	ErrPassphrasesDoNotMatch Error = "passphrases do not match"
+
+	// ErrProviderNotImplemented is the provider not implemented error.
+	ErrProviderNotImplemented Error = "provider not implemented"
+
+	// ErrNotSupportedByProvider is the not supported by provider error.
+	ErrNotSupportedByProvider Error = "not supported by provider"
+
+	// ErrMustSpecifyAtLeastOneLabel is the must specify at least one label
+	// error.
+	ErrMustSpecifyAtLeastOneLabel Error = "must specify at least one label"
+
+	// ErrMustSpecifyAtLeastOneLimit is the must specify at least one limit
+	// error.
+	ErrMustSpecifyAtLeastOneLimit Error = "must specify --down, --up, --alt-down, or --alt-up"
+
+	// ErrMustSpecifyAtLeastOneTorrentLimit is the must specify at least one
+	// torrent limit error.
+	ErrMustSpecifyAtLeastOneTorrentLimit Error = "must specify --ratio, --idle, --seeding-time, --down, or --up"
 )
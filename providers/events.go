@@ -0,0 +1,335 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// Event types.
+const (
+	EventAdded        = "added"
+	EventCompleted    = "completed"
+	EventErrored      = "errored"
+	EventStalled      = "stalled"
+	EventRemoved      = "removed"
+	EventTrackerError = "tracker-error"
+)
+
+// eventTypes are the valid event types.
+var eventTypes = map[string]bool{
+	EventAdded:        true,
+	EventCompleted:    true,
+	EventErrored:      true,
+	EventStalled:      true,
+	EventRemoved:      true,
+	EventTrackerError: true,
+}
+
+// eventFields are the torrent fields retrieved for snapshots.
+var eventFields = []string{
+	"hashString", "name", "downloadDir", "percentDone",
+	"error", "errorString", "isStalled", "trackerStats",
+}
+
+// Event is a torrent state change event.
+type Event struct {
+	Type        string    `json:"type" yaml:"type"`
+	Time        time.Time `json:"time" yaml:"time"`
+	Context     string    `json:"context,omitempty" yaml:"context,omitempty"`
+	Hash        string    `json:"hash" yaml:"hash"`
+	Name        string    `json:"name" yaml:"name"`
+	DownloadDir string    `json:"downloadDir,omitempty" yaml:"downloadDir,omitempty"`
+	Error       string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Tracker     string    `json:"tracker,omitempty" yaml:"tracker,omitempty"`
+}
+
+// newEvent creates a new event for the torrent.
+func newEvent(typ string, t tctypes.Torrent) Event {
+	return Event{
+		Type:        typ,
+		Hash:        t.HashString,
+		Name:        t.Name,
+		DownloadDir: t.DownloadDir,
+	}
+}
+
+// DiffTorrents diffs two successive torrent snapshots, returning the events
+// for the changes between them. Torrents are matched by their hash.
+func DiffTorrents(prev, cur []tctypes.Torrent) []Event {
+	old := make(map[string]tctypes.Torrent, len(prev))
+	for _, t := range prev {
+		old[t.HashString] = t
+	}
+	var events []Event
+	seen := make(map[string]bool, len(cur))
+	for _, t := range cur {
+		seen[t.HashString] = true
+		p, ok := old[t.HashString]
+		if !ok {
+			events = append(events, newEvent(EventAdded, t))
+			continue
+		}
+		if p.PercentDone < 1 && t.PercentDone >= 1 {
+			events = append(events, newEvent(EventCompleted, t))
+		}
+		if p.Error == 0 && t.Error != 0 {
+			ev := newEvent(EventErrored, t)
+			ev.Error = t.ErrorString
+			events = append(events, ev)
+		}
+		if !p.IsStalled && t.IsStalled {
+			events = append(events, newEvent(EventStalled, t))
+		}
+		failing, errs := trackerErrors(p), trackerErrors(t)
+		for _, announce := range sortedKeys(errs) {
+			if _, ok := failing[announce]; ok {
+				continue
+			}
+			ev := newEvent(EventTrackerError, t)
+			ev.Tracker, ev.Error = announce, errs[announce]
+			events = append(events, ev)
+		}
+	}
+	for _, t := range prev {
+		if !seen[t.HashString] {
+			events = append(events, newEvent(EventRemoved, t))
+		}
+	}
+	return events
+}
+
+// trackerErrors returns the failing trackers' announce urls and last announce
+// results for the torrent.
+func trackerErrors(t tctypes.Torrent) map[string]string {
+	m := make(map[string]string)
+	for _, tracker := range t.TrackerStats {
+		if tracker.HasAnnounced && !tracker.LastAnnounceSucceeded {
+			m[tracker.Announce] = tracker.LastAnnounceResult
+		}
+	}
+	return m
+}
+
+// sortedKeys returns the sorted keys of m.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Hook is an event hook, as defined by a hook section in the config file:
+//
+//	[hook.notify]
+//	events = completed, errored
+//	command = notify-send "$TRANSCTL_EVENT" "$TRANSCTL_NAME"
+//	url = https://example.com/webhook
+//
+// When events is empty, the hook is run for all events. Hooks are run by
+// 'daemon', and by 'watch-dir' for the watched context.
+type Hook struct {
+	// Name is the hook name.
+	Name string
+
+	// Events are the event types the hook is run for.
+	Events map[string]bool
+
+	// Contexts are the config contexts the hook is run for.
+	Contexts []string
+
+	// Command is the shell command to run.
+	Command string
+
+	// URL is the webhook URL to POST the event to, as JSON.
+	URL string
+
+	// Timeout is the hook timeout.
+	Timeout time.Duration
+}
+
+// LoadHooks loads the event hooks from the config file.
+func LoadHooks(args *Args) ([]*Hook, error) {
+	var names []string
+	for _, n := range args.Config.SectionNames() {
+		if strings.HasPrefix(n, "hook.") {
+			names = append(names, strings.TrimPrefix(n, "hook."))
+		}
+	}
+	sort.Strings(names)
+
+	defaultContext := args.Context
+	if defaultContext == "" {
+		defaultContext = strings.TrimSpace(args.Config.GetKey("default.context"))
+	}
+
+	var hooks []*Hook
+	for _, name := range names {
+		get := func(key string) string {
+			return strings.TrimSpace(args.Config.GetKey("hook." + name + "." + key))
+		}
+		hook := &Hook{
+			Name:     name,
+			Events:   make(map[string]bool),
+			Contexts: splitList(get("contexts")),
+			Command:  get("command"),
+			URL:      get("url"),
+			Timeout:  args.Host.Timeout,
+		}
+		if hook.Command == "" && hook.URL == "" {
+			return nil, fmt.Errorf("hook %q: must specify command or url", name)
+		}
+		for _, typ := range splitList(strings.ToLower(get("events"))) {
+			if !eventTypes[typ] {
+				return nil, fmt.Errorf("hook %q: invalid event %q", name, typ)
+			}
+			hook.Events[typ] = true
+		}
+		if len(hook.Contexts) == 0 {
+			hook.Contexts = []string{defaultContext}
+		}
+		if v := get("timeout"); v != "" {
+			var err error
+			if hook.Timeout, err = time.ParseDuration(v); err != nil {
+				return nil, fmt.Errorf("hook %q: invalid timeout %q", name, v)
+			}
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+// Matches determines if the hook should be run for the event.
+func (h *Hook) Matches(ev Event) bool {
+	if len(h.Events) != 0 && !h.Events[ev.Type] {
+		return false
+	}
+	for _, name := range h.Contexts {
+		if name == ev.Context {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the hook's command and webhook for the event.
+func (h *Hook) Run(ctx context.Context, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	if h.Command != "" {
+		if err := h.runCommand(ctx, ev); err != nil {
+			return err
+		}
+	}
+	if h.URL != "" {
+		if err := h.post(ctx, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCommand runs the hook's shell command, passing the event via
+// environment variables.
+func (h *Hook) runCommand(ctx context.Context, ev Event) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", h.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"TRANSCTL_EVENT="+ev.Type,
+		"TRANSCTL_CONTEXT="+ev.Context,
+		"TRANSCTL_HASH="+ev.Hash,
+		"TRANSCTL_NAME="+ev.Name,
+		"TRANSCTL_DOWNLOAD_DIR="+ev.DownloadDir,
+		"TRANSCTL_ERROR="+ev.Error,
+		"TRANSCTL_TRACKER="+ev.Tracker,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if s := strings.TrimSpace(string(out)); s != "" {
+			return fmt.Errorf("command: %w: %s", err, s)
+		}
+		return fmt.Errorf("command: %w", err)
+	}
+	return nil
+}
+
+// post posts the event as JSON to the hook's webhook url.
+func (h *Hook) post(ctx context.Context, ev Event) error {
+	buf, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned status %d", h.URL, res.StatusCode)
+	}
+	return nil
+}
+
+// watchEvents polls the provider for torrent snapshots every interval,
+// running the matching hooks for the events between successive snapshots,
+// until the context is closed.
+func watchEvents(ctx context.Context, args *Args, p Provider, hooks []*Hook, interval time.Duration, logger *Logger) {
+	var prev []tctypes.Torrent
+	snapshot := func() {
+		reqCtx, cancel := context.WithTimeout(ctx, args.Host.Timeout)
+		defer cancel()
+		cur, err := p.Get(reqCtx, eventFields)
+		if err != nil {
+			logger.Error("snapshot failed", "context", args.Context, "err", err)
+			return
+		}
+		if prev != nil {
+			for _, ev := range DiffTorrents(prev, cur) {
+				ev.Time, ev.Context = time.Now(), args.Context
+				logger.Info("event", "context", ev.Context, "event", ev.Type, "hash", ev.Hash, "name", ev.Name)
+				for _, hook := range hooks {
+					if !hook.Matches(ev) {
+						continue
+					}
+					if err := hook.Run(context.Background(), ev); err != nil {
+						logger.Error("hook failed", "hook", hook.Name, "event", ev.Type, "hash", ev.Hash, "err", err)
+					}
+				}
+			}
+		}
+		if cur == nil {
+			cur = []tctypes.Torrent{}
+		}
+		prev = cur
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		snapshot()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestDiffTorrents(t *testing.T) {
+	prev := []byte(`[
+		{"hashString": "a", "name": "a", "percentDone": 0.5},
+		{"hashString": "b", "name": "b"},
+		{"hashString": "c", "name": "c"},
+		{"hashString": "d", "name": "d"},
+		{"hashString": "f", "name": "f", "trackerStats": [{"announce": "http://x/announce", "hasAnnounced": true}]}
+	]`)
+	cur := []byte(`[
+		{"hashString": "a", "name": "a", "percentDone": 1},
+		{"hashString": "b", "name": "b", "error": 3, "errorString": "no data found"},
+		{"hashString": "c", "name": "c", "isStalled": true},
+		{"hashString": "e", "name": "e"},
+		{"hashString": "f", "name": "f", "trackerStats": [
+			{"announce": "http://x/announce", "hasAnnounced": true},
+			{"announce": "http://y/announce", "hasAnnounced": true, "lastAnnounceResult": "unregistered torrent"}
+		]}
+	]`)
+	var a, b []tctypes.Torrent
+	if err := json.Unmarshal(prev, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(cur, &b); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"completed a",
+		"errored b no data found",
+		"stalled c",
+		"added e",
+		"tracker-error f unregistered torrent http://y/announce",
+		"removed d",
+	}
+	events := DiffTorrents(a, b)
+	if len(events) != len(exp) {
+		t.Fatalf("expected %d events, got: %d (%+v)", len(exp), len(events), events)
+	}
+	for i, ev := range events {
+		s := strings.Join(strings.Fields(ev.Type+" "+ev.Hash+" "+ev.Error+" "+ev.Tracker), " ")
+		if s != exp[i] {
+			t.Errorf("event %d expected %q, got: %q", i, exp[i], s)
+		}
+	}
+	if events := DiffTorrents(b, b); len(events) != 0 {
+		t.Errorf("expected no events, got: %+v", events)
+	}
+}
+
+func TestHookRun(t *testing.T) {
+	ev := Event{Type: EventCompleted, Time: time.Now(), Context: "home", Hash: "abcdef", Name: "ubuntu.iso", DownloadDir: "/downloads"}
+	t.Run("webhook", func(t *testing.T) {
+		events := make(chan Event, 1)
+		s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if req.Method != "POST" || req.Header.Get("Content-Type") != "application/json" {
+				http.Error(res, "bad request", http.StatusBadRequest)
+				return
+			}
+			var v Event
+			if err := json.NewDecoder(req.Body).Decode(&v); err != nil {
+				http.Error(res, err.Error(), http.StatusBadRequest)
+				return
+			}
+			events <- v
+		}))
+		defer s.Close()
+		h := &Hook{Name: "test", URL: s.URL, Timeout: 5 * time.Second}
+		if err := h.Run(context.Background(), ev); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if v := <-events; v.Type != ev.Type || v.Hash != ev.Hash || v.Name != ev.Name || v.DownloadDir != ev.DownloadDir || v.Context != ev.Context {
+			t.Errorf("expected %+v, got: %+v", ev, v)
+		}
+	})
+	t.Run("webhook status", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			http.Error(res, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer s.Close()
+		h := &Hook{Name: "test", URL: s.URL, Timeout: 5 * time.Second}
+		if err := h.Run(context.Background(), ev); err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+	t.Run("command", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("command test requires /bin/sh")
+		}
+		dir, err := ioutil.TempDir("", "transctl-hook")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "out")
+		h := &Hook{Name: "test", Command: `echo "$TRANSCTL_EVENT $TRANSCTL_HASH $TRANSCTL_NAME $TRANSCTL_DOWNLOAD_DIR" > ` + out, Timeout: 5 * time.Second}
+		if err := h.Run(context.Background(), ev); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		buf, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, exp := strings.TrimSpace(string(buf)), "completed abcdef ubuntu.iso /downloads"; s != exp {
+			t.Errorf("expected %q, got: %q", exp, s)
+		}
+	})
+}
@@ -0,0 +1,4 @@
+package providers
+
+// Golden exports golden for the external tests.
+var Golden = golden
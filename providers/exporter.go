@@ -0,0 +1,331 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/snaker"
+)
+
+// metricsContentType is the Prometheus text exposition format content type.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// exporterTorrentFields are the torrent fields retrieved for metrics.
+var exporterTorrentFields = []string{
+	"hashString", "name", "status", "trackerStats",
+}
+
+// exporterTorrentMetricFields are the additional torrent fields retrieved
+// for per-torrent metrics.
+var exporterTorrentMetricFields = []string{
+	"uploadRatio", "totalSize", "haveValid", "peersConnected",
+	"percentDone", "rateDownload", "rateUpload",
+}
+
+// metricSample is a single labeled metric value.
+type metricSample struct {
+	labels []string
+	value  float64
+}
+
+// metricFamily is a named metric and its samples.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+// MetricSet is a set of gauge metric families, written in the Prometheus
+// text exposition format.
+type MetricSet struct {
+	families map[string]*metricFamily
+	sync.Mutex
+}
+
+// NewMetricSet creates a new metric set.
+func NewMetricSet() *MetricSet {
+	return &MetricSet{
+		families: make(map[string]*metricFamily),
+	}
+}
+
+// Add adds a gauge sample to the named metric family, with the label key
+// value pairs in labels.
+func (m *MetricSet) Add(name, help string, value float64, labels ...string) {
+	if len(labels)%2 != 0 {
+		panic("invalid labels")
+	}
+	m.Lock()
+	defer m.Unlock()
+	f, ok := m.families[name]
+	if !ok {
+		f = &metricFamily{name: name, help: help}
+		m.families[name] = f
+	}
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// WriteTo satisfies the io.WriterTo interface.
+func (m *MetricSet) WriteTo(w io.Writer) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, name := range names {
+		f := m.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeMetricHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", f.name)
+		sort.SliceStable(f.samples, func(i, j int) bool {
+			return strings.Join(f.samples[i].labels, "\x00") < strings.Join(f.samples[j].labels, "\x00")
+		})
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			if len(s.labels) != 0 {
+				bw.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i != 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(s.labels[i] + `="` + escapeMetricLabel(s.labels[i+1]) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// countWriter wraps a writer, counting the bytes written.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write satisfies the io.Writer interface.
+func (cw *countWriter) Write(buf []byte) (int, error) {
+	n, err := cw.w.Write(buf)
+	cw.n += int64(n)
+	return n, err
+}
+
+// escapeMetricHelp escapes a metric help string.
+func escapeMetricHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeMetricLabel escapes a metric label value.
+func escapeMetricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+// metricName converts s to a valid metric name.
+func metricName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// metricValue converts a stats value to a metric value.
+func metricValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case tctypes.Duration:
+		return time.Duration(x).Seconds(), true
+	case time.Duration:
+		return x.Seconds(), true
+	case tctypes.ByteFormatter:
+		return float64(x.Int64()), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// Exporter is a metrics exporter for the remote hosts of one or more config
+// contexts.
+type Exporter struct {
+	args     *Args
+	contexts []string
+	provs    map[string]Provider
+}
+
+// NewExporter creates a new metrics exporter for the named config contexts.
+func NewExporter(args *Args, contexts ...string) (*Exporter, error) {
+	e := &Exporter{
+		args:     args,
+		contexts: contexts,
+		provs:    make(map[string]Provider),
+	}
+	for _, name := range contexts {
+		p, err := args.WithContext(name).NewProvider()
+		if err != nil {
+			return nil, fmt.Errorf("context %q: %w", name, err)
+		}
+		e.provs[name] = p
+	}
+	return e, nil
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (e *Exporter) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), e.args.Host.Timeout)
+	defer cancel()
+	m := e.Collect(ctx)
+	res.Header().Set("Content-Type", metricsContentType)
+	_, _ = m.WriteTo(res)
+}
+
+// Collect collects the metrics for each context concurrently.
+func (e *Exporter) Collect(ctx context.Context) *MetricSet {
+	m := NewMetricSet()
+	var wg sync.WaitGroup
+	for _, name := range e.contexts {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			start := time.Now()
+			err := e.collect(ctx, m, e.args.WithContext(name), e.provs[name])
+			up := 1.0
+			if err != nil {
+				up = 0
+			}
+			m.Add("transctl_up", "whether the remote host could be reached", up, "context", name)
+			m.Add("transctl_scrape_duration_seconds", "duration of the remote host scrape", time.Since(start).Seconds(), "context", name)
+		}(name)
+	}
+	wg.Wait()
+	return m
+}
+
+// collect collects the metrics for a context.
+func (e *Exporter) collect(ctx context.Context, m *MetricSet, args *Args, p Provider) error {
+	name := args.Context
+
+	// session stats
+	stats, err := p.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	for k, v := range stats {
+		if f, ok := metricValue(v); ok {
+			m.Add("transctl_session_"+metricName(k), "session stat "+k, f, "context", name)
+		}
+	}
+
+	// torrents
+	fields := exporterTorrentFields
+	if e.args.ExporterParams.TorrentMetrics {
+		fields = append(fields[:len(fields):len(fields)], exporterTorrentMetricFields...)
+	}
+	torrents, err := p.Get(ctx, fields)
+	if err != nil {
+		return err
+	}
+	statuses := make(map[tctypes.Status]int)
+	trackers := make(map[string]int)
+	for _, t := range torrents {
+		statuses[t.Status]++
+		for announce := range trackerErrors(t) {
+			trackers[trackerHost(announce)]++
+		}
+		if !e.args.ExporterParams.TorrentMetrics {
+			continue
+		}
+		labels := []string{"context", name, "hash", t.HashString, "name", t.Name}
+		m.Add("transctl_torrent_ratio", "torrent upload ratio", t.UploadRatio, labels...)
+		m.Add("transctl_torrent_size_bytes", "torrent total size", float64(t.TotalSize), labels...)
+		m.Add("transctl_torrent_have_bytes", "torrent verified bytes", float64(t.HaveValid), labels...)
+		m.Add("transctl_torrent_peers", "torrent connected peers", float64(t.PeersConnected), labels...)
+		m.Add("transctl_torrent_percent_done", "torrent completion (0-1)", float64(t.PercentDone), labels...)
+		m.Add("transctl_torrent_download_rate_bytes", "torrent download rate", float64(t.RateDownload), labels...)
+		m.Add("transctl_torrent_upload_rate_bytes", "torrent upload rate", float64(t.RateUpload), labels...)
+	}
+	for s := tctypes.StatusStopped; s <= tctypes.StatusSeeding; s++ {
+		m.Add("transctl_torrents", "torrent count by status", float64(statuses[s]), "context", name, "status", snaker.CamelToSnake(s.String()))
+	}
+	for host, count := range trackers {
+		m.Add("transctl_tracker_announce_failures", "torrents with a failing tracker announce", float64(count), "context", name, "tracker", host)
+	}
+
+	// free space
+	for _, location := range splitList(args.getContextKey("free-space")) {
+		free, err := p.FreeSpace(ctx, location)
+		if err != nil {
+			return err
+		}
+		m.Add("transctl_free_space_bytes", "free space at location", float64(free), "context", name, "location", location)
+	}
+	return nil
+}
+
+// DoExporter is the high-level entry point for 'exporter'.
+func DoExporter(ctx context.Context, args *Args, cmd string) error {
+	contexts := splitList(strings.Join(args.ExporterParams.Contexts, ","))
+	if len(contexts) == 0 {
+		name := args.Context
+		if name == "" {
+			name = strings.TrimSpace(args.Config.GetKey("default.context"))
+		}
+		contexts = []string{name}
+	}
+	e, err := NewExporter(args, contexts...)
+	if err != nil {
+		return err
+	}
+	logger := NewLogger(os.Stderr, args.ExporterParams.LogFormat)
+
+	// cancel on signal
+	ctx, cancel := signalContext(ctx, logger)
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	s := &http.Server{
+		Addr:    args.ExporterParams.Listen,
+		Handler: mux,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), args.Host.Timeout)
+		defer cancel()
+		_ = s.Shutdown(shutdownCtx)
+	}()
+	logger.Info("listening", "addr", s.Addr, "contexts", strings.Join(contexts, ","))
+	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
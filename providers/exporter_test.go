@@ -0,0 +1,83 @@
+package providers_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	_ "github.com/kenshaw/transctl/providers/qbittorrent"
+	_ "github.com/kenshaw/transctl/providers/transmission"
+	"github.com/kenshaw/transctl/transctltest"
+	"github.com/knq/ini"
+)
+
+func TestExporterGolden(t *testing.T) {
+	const (
+		hash1 = "0123456789abcdef0123456789abcdef01234567"
+		hash2 = "89abcdef0123456789abcdef0123456789abcdef"
+		hash3 = "fedcba9876543210fedcba9876543210fedcba98"
+	)
+
+	// transmission, with a failing tracker announce and a name needing
+	// label escaping
+	tr := transctltest.NewTransmission()
+	defer tr.Close()
+	tr.AddTorrent(hash1, "debian")
+	tr.AddTorrent(hash2, "a \"quoted\"\\name\nwith a newline")
+	tr.AddTracker(hash1, "http://tracker.example.com:6969/announce", 0, 0, "Tracker gave HTTP response code 404")
+	tr.AddTracker(hash2, "http://tracker.example.com:6969/announce", 0, 0, "Connection failed")
+	tr.AddTracker(hash2, "udp://ok.example.org:1337/announce", 1, 12, "")
+
+	// qbittorrent
+	q := transctltest.NewQBittorrent()
+	defer q.Close()
+	q.AddTorrent(hash3, "ubuntu")
+
+	// a remote host that fails every request
+	down := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		http.Error(res, "unavailable", http.StatusBadRequest)
+	}))
+	defer down.Close()
+
+	qu, err := url.Parse(q.URL + "/api/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qu.User = url.UserPassword("admin", "adminadmin")
+	config := ini.NewFile()
+	config.SetKey("context.trans.url", tr.URL+"/transmission/rpc")
+	config.SetKey("context.trans.free-space", "/data, /media")
+	config.SetKey("context.qbt.url", qu.String())
+	config.SetKey("context.qbt.type", "qbittorrent")
+	config.SetKey("context.down.url", down.URL+"/transmission/rpc")
+	args := &providers.Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.Host.NoNetrc = true
+	args.ExporterParams.TorrentMetrics = true
+	e, err := providers.NewExporter(args, "trans", "qbt", "down")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// scrape
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil).WithContext(context.Background()))
+	if ct, exp := res.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; ct != exp {
+		t.Errorf("expected content type %q, got: %q", exp, ct)
+	}
+
+	// scrape durations vary between runs
+	re := regexp.MustCompile(`(?m)^(transctl_scrape_duration_seconds\{[^}]*\}) .*$`)
+	buf := re.ReplaceAll(res.Body.Bytes(), []byte("$1 0"))
+	if !bytes.Contains(buf, []byte(`transctl_up{context="down"} 0`)) {
+		t.Errorf("expected down context to not be up")
+	}
+	providers.Golden(t, filepath.Join("testdata", "exporter.golden"), buf)
+}
@@ -16,56 +16,56 @@ import (
 )
 
 // FindTorrents finds torrents based on args from the provider.
-func FindTorrents(ctx context.Context, args *Args) ([]tctypes.Torrent, error) {
-	var req *TorrentGetRequest
+func FindTorrents(ctx context.Context, args *Args, p Provider) ([]tctypes.Torrent, error) {
+	var err error
+	var fieldnames []string
 	var fields map[string][]string
+	var ids []interface{}
 	switch {
 	case args.Filter.Recent:
-		req = p.TorrentGet([]string{"hashString"}, "recently-active")
+		fieldnames, ids = []string{"hashString"}, []interface{}{"recently-active"}
 	case args.Filter.ListAll:
-		req = p.TorrentGet([]string{"hashString"})
+		fieldnames = []string{"hashString"}
 	case args.Filter.Filter != "":
 		// evaluate filter expression to build field names
 		fields, err = extractVars(args)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		var fieldnames []string
 		for k := range fields {
 			fieldnames = append(fieldnames, k)
 		}
 		sort.Strings(fieldnames)
-		req = p.TorrentGet(fieldnames)
 	default:
-		return nil, nil, ErrMustSpecifyListRecentFilterOrAtLeastOneTorrent
+		return nil, ErrMustSpecifyListRecentFilterOrAtLeastOneTorrent
 	}
 
 	// execute
-	res, err := req.Do(ctx, cl)
+	res, err := p.Get(ctx, fieldnames, ids...)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if args.Filter.ListAll || args.Filter.Recent {
-		return cl, res.Torrents, nil
+		return res, nil
 	}
 
 	l := buildQueryLanguage()
 
 	// filter
 	var torrents []tctypes.Torrent
-	for _, t := range res.Torrents {
+	for _, t := range res {
 		m := buildJSONMap(t, fields)
 		if len(args.Args) == 0 {
 			torrents, err = appendMatch(torrents, args, t, m, l)
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 		} else {
 			for _, identifier := range args.Args {
 				m["identifier"] = identifier
 				torrents, err = appendMatch(torrents, args, t, m, l)
 				if err != nil {
-					return nil, nil, err
+					return nil, err
 				}
 			}
 		}
@@ -116,6 +116,9 @@ func extractVars(args *Args) (map[string][]string, error) {
 				if !ok {
 					return nil, fmt.Errorf("unknown filter field or method %q", key)
 				}
+				if f.Kind() == reflect.Slice {
+					return []interface{}{}, nil
+				}
 				return reflect.Zero(f).Interface(), nil
 			}
 		}),
@@ -169,6 +172,14 @@ func buildJSONMap(v interface{}, fields map[string][]string) map[string]interfac
 		case reflect.Struct:
 			y = buildJSONMap(f, fields)
 		case reflect.Slice:
+			// string slices (such as labels) can be used with the in operator
+			if x, ok := f.([]string); ok {
+				z := make([]interface{}, len(x))
+				for j, s := range x {
+					z[j] = s
+				}
+				y = z
+			}
 		default:
 			y = f
 		}
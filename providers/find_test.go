@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestFilterLabels(t *testing.T) {
+	torrents := torrentsFixture(t).([]tctypes.Torrent)
+	tests := []struct {
+		filter string
+		exp    []int64
+	}{
+		{`"linux" in labels`, []int64{1, 2}},
+		{`"debian" in labels`, []int64{1}},
+		{`!("linux" in labels)`, []int64{3}},
+		{`"linux" in labels && status == 4`, []int64{2}},
+		{`"tv" in labels`, nil},
+	}
+	for i, test := range tests {
+		args := &Args{}
+		args.Filter.Filter = test.filter
+		fields, err := extractVars(args)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if _, ok := fields["labels"]; !ok {
+			t.Errorf("test %d expected labels field, got: %v", i, fields)
+		}
+		l := buildQueryLanguage()
+		var res []tctypes.Torrent
+		for _, torrent := range torrents {
+			if res, err = appendMatch(res, args, torrent, buildJSONMap(torrent, fields), l); err != nil {
+				t.Fatalf("test %d expected no error, got: %v", i, err)
+			}
+		}
+		if len(res) != len(test.exp) {
+			t.Fatalf("test %d expected %d torrents, got: %d", i, len(test.exp), len(res))
+		}
+		for j, torrent := range res {
+			if torrent.ID != test.exp[j] {
+				t.Errorf("test %d expected torrent %d to be %d, got: %d", i, j, test.exp[j], torrent.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// LimitsProvider is the interface for providers that support global and per
+// torrent speed limits.
+type LimitsProvider interface {
+	Provider
+
+	// LimitsGet returns the remote host's global and alternate speed limits.
+	LimitsGet(context.Context) (tctypes.SpeedLimits, error)
+
+	// LimitsSet sets the remote host's global and alternate speed limits.
+	LimitsSet(context.Context, tctypes.SpeedLimits) error
+
+	// TorrentLimitsSet sets the share and speed limits for the provided
+	// identifiers.
+	TorrentLimitsSet(context.Context, tctypes.TorrentLimits, ...interface{}) error
+
+	// AltSpeedSet toggles the remote host's alternate speed limits.
+	AltSpeedSet(context.Context, bool) error
+}
+
+// newLimitsProvider creates the provider for the args, checking that it
+// supports speed limits.
+func newLimitsProvider(args *Args) (LimitsProvider, error) {
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	lp, ok := p.(LimitsProvider)
+	if !ok {
+		return nil, fmt.Errorf("limits %w", ErrNotSupportedByProvider)
+	}
+	return lp, nil
+}
+
+// DoLimitsGet is the high-level entry point for 'limits get'.
+func DoLimitsGet(ctx context.Context, args *Args, cmd string) error {
+	p, err := newLimitsProvider(args)
+	if err != nil {
+		return err
+	}
+	limits, err := p.LimitsGet(ctx)
+	if err != nil {
+		return err
+	}
+	stats := NewStats(map[string]interface{}{
+		"download-limit":     limits.DownloadLimit,
+		"download-limited":   limits.DownloadLimited,
+		"upload-limit":       limits.UploadLimit,
+		"upload-limited":     limits.UploadLimited,
+		"alt-enabled":        limits.AltEnabled,
+		"alt-download-limit": limits.AltDownloadLimit,
+		"alt-upload-limit":   limits.AltUploadLimit,
+		"schedule-enabled":   limits.ScheduleEnabled,
+		"schedule-begin":     limits.ScheduleBegin,
+		"schedule-end":       limits.ScheduleEnd,
+		"schedule-days":      limits.ScheduleDays,
+	})
+	for i := range stats {
+		stats[i].HashString = "speed-limits"
+	}
+	return NewResult(stats, args.ResultOptions(
+		TableColumns("name", "value"),
+		WideColumns("name", "key", "value"),
+		YamlName("speed-limits"),
+		FlatName("speed-limits"),
+		FlatKey("id"),
+		FlatIndex("hashString"),
+		NoTotals(true),
+		Kind(KindStatList),
+	)...).Encode(os.Stdout)
+}
+
+// DoLimits is the high-level entry point for 'limits set', 'limits alt', and
+// 'limits schedule'.
+//
+// Limits are read from the remote host, changed, and then written back.
+// Speeds are parsed as byte counts per second (5MiB, 500kB/s, ...), with
+// 'unlimited' (or 0) removing the limit.
+func DoLimits(ctx context.Context, args *Args, cmd string) error {
+	p, err := newLimitsProvider(args)
+	if err != nil {
+		return err
+	}
+	limits, err := p.LimitsGet(ctx)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "limits set":
+		if err := applyLimits(&limits, args); err != nil {
+			return err
+		}
+	case "limits alt":
+		switch args.LimitsParams.Alt {
+		case "on":
+			limits.AltEnabled = true
+		case "off":
+			limits.AltEnabled = false
+		case "toggle":
+			limits.AltEnabled = !limits.AltEnabled
+		}
+		return p.AltSpeedSet(ctx, limits.AltEnabled)
+	case "limits schedule":
+		if err := applySchedule(&limits, args.LimitsParams.Schedule, args.LimitsParams.Days); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return p.LimitsSet(ctx, limits)
+}
+
+// applyLimits applies the --down, --up, --alt-down, and --alt-up limits.
+func applyLimits(limits *tctypes.SpeedLimits, args *Args) error {
+	params := args.LimitsParams
+	if params.Down == "" && params.Up == "" && params.AltDown == "" && params.AltUp == "" {
+		return ErrMustSpecifyAtLeastOneLimit
+	}
+	var err error
+	if params.Down != "" {
+		if limits.DownloadLimit, limits.DownloadLimited, err = parseLimit(params.Down); err != nil {
+			return err
+		}
+	}
+	if params.Up != "" {
+		if limits.UploadLimit, limits.UploadLimited, err = parseLimit(params.Up); err != nil {
+			return err
+		}
+	}
+	if params.AltDown != "" {
+		if limits.AltDownloadLimit, _, err = parseLimit(params.AltDown); err != nil {
+			return err
+		}
+	}
+	if params.AltUp != "" {
+		if limits.AltUploadLimit, _, err = parseLimit(params.AltUp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLimit parses a speed limit (5MiB, 500kB/s, unlimited), returning the
+// rate and whether it is limited.
+func parseLimit(s string) (tctypes.Rate, bool, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	switch strings.ToLower(s) {
+	case "unlimited", "none", "off":
+		return 0, false, nil
+	}
+	bc, err := tctypes.ParseByteCount(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid speed limit %q: %w", s, err)
+	}
+	return tctypes.Rate(bc), bc != 0, nil
+}
+
+// applySchedule applies the alternate speed schedule (01:00-07:00, on, or
+// off) and days.
+func applySchedule(limits *tctypes.SpeedLimits, schedule, days string) error {
+	switch strings.ToLower(schedule) {
+	case "on":
+		limits.ScheduleEnabled = true
+		return nil
+	case "off":
+		limits.ScheduleEnabled = false
+		return nil
+	}
+	i := strings.Index(schedule, "-")
+	if i == -1 {
+		return fmt.Errorf("invalid schedule %q", schedule)
+	}
+	begin, err := tctypes.ParseTimeOfDay(schedule[:i])
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	end, err := tctypes.ParseTimeOfDay(schedule[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	d, err := tctypes.ParseDays(days)
+	if err != nil {
+		return fmt.Errorf("invalid schedule days %q: %w", days, err)
+	}
+	limits.ScheduleEnabled, limits.ScheduleBegin, limits.ScheduleEnd, limits.ScheduleDays = true, begin, end, d
+	return nil
+}
+
+// DoLimitsTorrent is the high-level entry point for 'limits torrent'.
+//
+// Each limit can be a value, 'global' (use the remote host's global limit),
+// or 'unlimited'. Limits that are not specified are left unchanged.
+func DoLimitsTorrent(ctx context.Context, args *Args, cmd string) error {
+	limits, err := buildTorrentLimits(args)
+	if err != nil {
+		return err
+	}
+	p, err := newLimitsProvider(args)
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	return p.TorrentLimitsSet(ctx, limits, ConvertTorrentIDs(torrents)...)
+}
+
+// buildTorrentLimits builds the torrent limits from the --ratio, --idle,
+// --seeding-time, --down, and --up limits.
+func buildTorrentLimits(args *Args) (tctypes.TorrentLimits, error) {
+	params := args.LimitsParams
+	var limits tctypes.TorrentLimits
+	if params.Ratio == "" && params.Idle == "" && params.SeedingTime == "" && params.Down == "" && params.Up == "" {
+		return limits, ErrMustSpecifyAtLeastOneTorrentLimit
+	}
+	var err error
+	if params.Ratio != "" {
+		if limits.RatioMode, limits.RatioLimit, err = parseRatioLimit(params.Ratio); err != nil {
+			return limits, err
+		}
+	}
+	if params.Idle != "" {
+		if limits.IdleMode, limits.IdleLimit, err = parseTimeLimit(params.Idle); err != nil {
+			return limits, err
+		}
+	}
+	if params.SeedingTime != "" {
+		if limits.SeedingTimeMode, limits.SeedingTimeLimit, err = parseTimeLimit(params.SeedingTime); err != nil {
+			return limits, err
+		}
+	}
+	if params.Down != "" {
+		if limits.DownloadMode, limits.DownloadLimit, err = parseSpeedLimit(params.Down); err != nil {
+			return limits, err
+		}
+	}
+	if params.Up != "" {
+		if limits.UploadMode, limits.UploadLimit, err = parseSpeedLimit(params.Up); err != nil {
+			return limits, err
+		}
+	}
+	return limits, nil
+}
+
+// parseLimitMode parses the 'global' and 'unlimited' limit modes.
+func parseLimitMode(s string) (tctypes.Mode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "global", "default":
+		return tctypes.ModeGlobal, true
+	case "unlimited", "none", "off":
+		return tctypes.ModeUnlimited, true
+	}
+	return tctypes.ModeSingle, false
+}
+
+// parseRatioLimit parses a seed ratio limit (2, 1.5, global, unlimited).
+func parseRatioLimit(s string) (*tctypes.Mode, float64, error) {
+	if mode, ok := parseLimitMode(s); ok {
+		return &mode, 0, nil
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || ratio < 0 {
+		return nil, 0, fmt.Errorf("invalid ratio limit %q", s)
+	}
+	mode := tctypes.ModeSingle
+	return &mode, ratio, nil
+}
+
+// parseTimeLimit parses a time limit (7d, 12h, 90m, global, unlimited),
+// rounding up to the nearest minute.
+func parseTimeLimit(s string) (*tctypes.Mode, time.Duration, error) {
+	if mode, ok := parseLimitMode(s); ok {
+		return &mode, 0, nil
+	}
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	var err error
+	if strings.HasSuffix(s, "d") {
+		var days float64
+		days, err = strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		d = time.Duration(days * float64(24*time.Hour))
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return nil, 0, fmt.Errorf("invalid time limit %q", s)
+	}
+	if r := d % time.Minute; r != 0 {
+		d += time.Minute - r
+	}
+	mode := tctypes.ModeSingle
+	return &mode, d, nil
+}
+
+// parseSpeedLimit parses a torrent speed limit (1MiB, 500kB/s, global,
+// unlimited).
+func parseSpeedLimit(s string) (*tctypes.Mode, tctypes.Rate, error) {
+	if mode, ok := parseLimitMode(s); ok {
+		return &mode, 0, nil
+	}
+	rate, limited, err := parseLimit(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	mode := tctypes.ModeSingle
+	if !limited {
+		mode = tctypes.ModeUnlimited
+	}
+	return &mode, rate, nil
+}
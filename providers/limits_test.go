@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		exp     tctypes.Rate
+		limited bool
+		err     bool
+	}{
+		{"5MiB", 5 * 1024 * 1024, true, false},
+		{"5 MiB/s", 5 * 1024 * 1024, true, false},
+		{"500kB/s", 500 * 1000, true, false},
+		{"1024", 1024, true, false},
+		{"0", 0, false, false},
+		{"unlimited", 0, false, false},
+		{"off", 0, false, false},
+		{"fast", 0, false, true},
+	}
+	for i, test := range tests {
+		rate, limited, err := parseLimit(test.s)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case rate != test.exp || limited != test.limited:
+			t.Errorf("test %d expected %d (%t), got: %d (%t)", i, test.exp, test.limited, rate, limited)
+		}
+	}
+}
+
+func TestApplySchedule(t *testing.T) {
+	tests := []struct {
+		schedule, days string
+		begin, end     string
+		exp            string
+		err            bool
+	}{
+		{"01:00-07:00", "all", "01:00", "07:00", "all", false},
+		{"22:30-06:15", "weekdays", "22:30", "06:15", "weekdays", false},
+		{"00:00-23:59", "sat,sun", "00:00", "23:59", "weekend", false},
+		{"01:00-07:00", "mon,wednesday", "01:00", "07:00", "mon,wed", false},
+		{"01:00-07:00", "someday", "", "", "", true},
+		{"01:00", "all", "", "", "", true},
+		{"25:00-07:00", "all", "", "", "", true},
+	}
+	for i, test := range tests {
+		var limits tctypes.SpeedLimits
+		err := applySchedule(&limits, test.schedule, test.days)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+			continue
+		case test.err:
+			continue
+		case err != nil:
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if !limits.ScheduleEnabled {
+			t.Errorf("test %d expected schedule to be enabled", i)
+		}
+		if s := limits.ScheduleBegin.String(); s != test.begin {
+			t.Errorf("test %d expected begin %s, got: %s", i, test.begin, s)
+		}
+		if s := limits.ScheduleEnd.String(); s != test.end {
+			t.Errorf("test %d expected end %s, got: %s", i, test.end, s)
+		}
+		if s := limits.ScheduleDays.String(); s != test.exp {
+			t.Errorf("test %d expected days %s, got: %s", i, test.exp, s)
+		}
+	}
+
+	// off
+	limits := tctypes.SpeedLimits{ScheduleEnabled: true, ScheduleDays: tctypes.DaysWeekdays}
+	if err := applySchedule(&limits, "off", "all"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if limits.ScheduleEnabled || limits.ScheduleDays != tctypes.DaysWeekdays {
+		t.Errorf("expected schedule to be disabled and unchanged, got: %+v", limits)
+	}
+}
+
+func TestBuildTorrentLimits(t *testing.T) {
+	global, single, unlimited := tctypes.ModeGlobal, tctypes.ModeSingle, tctypes.ModeUnlimited
+	tests := []struct {
+		ratio, idle, seedingTime, down, up string
+		exp                                tctypes.TorrentLimits
+		err                                bool
+	}{
+		{"", "", "", "", "", tctypes.TorrentLimits{}, true},
+		{"2", "", "", "", "", tctypes.TorrentLimits{RatioMode: &single, RatioLimit: 2}, false},
+		{"global", "unlimited", "", "", "", tctypes.TorrentLimits{RatioMode: &global, IdleMode: &unlimited}, false},
+		{"", "7d", "30d", "", "", tctypes.TorrentLimits{IdleMode: &single, IdleLimit: 7 * 24 * time.Hour, SeedingTimeMode: &single, SeedingTimeLimit: 30 * 24 * time.Hour}, false},
+		{"", "90s", "1.5d", "", "", tctypes.TorrentLimits{IdleMode: &single, IdleLimit: 2 * time.Minute, SeedingTimeMode: &single, SeedingTimeLimit: 36 * time.Hour}, false},
+		{"", "", "", "1MiB", "500KiB/s", tctypes.TorrentLimits{DownloadMode: &single, DownloadLimit: 1024 * 1024, UploadMode: &single, UploadLimit: 500 * 1024}, false},
+		{"", "", "", "0", "global", tctypes.TorrentLimits{DownloadMode: &unlimited, UploadMode: &global}, false},
+		{"-1", "", "", "", "", tctypes.TorrentLimits{}, true},
+		{"", "7w", "", "", "", tctypes.TorrentLimits{}, true},
+		{"", "", "0d", "", "", tctypes.TorrentLimits{}, true},
+		{"", "", "", "fast", "", tctypes.TorrentLimits{}, true},
+	}
+	for i, test := range tests {
+		args := &Args{}
+		args.LimitsParams.Ratio, args.LimitsParams.Idle, args.LimitsParams.SeedingTime = test.ratio, test.idle, test.seedingTime
+		args.LimitsParams.Down, args.LimitsParams.Up = test.down, test.up
+		limits, err := buildTorrentLimits(args)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+			continue
+		case test.err:
+			continue
+		case err != nil:
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if s, exp := formatTorrentLimits(limits), formatTorrentLimits(test.exp); s != exp {
+			t.Errorf("test %d expected %s, got: %s", i, exp, s)
+		}
+	}
+}
+
+// formatTorrentLimits formats the torrent limits for comparison.
+func formatTorrentLimits(limits tctypes.TorrentLimits) string {
+	mode := func(m *tctypes.Mode) string {
+		if m == nil {
+			return "<nil>"
+		}
+		return m.String()
+	}
+	return fmt.Sprintf("ratio=%s/%v idle=%s/%v seedingTime=%s/%v down=%s/%d up=%s/%d",
+		mode(limits.RatioMode), limits.RatioLimit,
+		mode(limits.IdleMode), limits.IdleLimit,
+		mode(limits.SeedingTimeMode), limits.SeedingTimeLimit,
+		mode(limits.DownloadMode), limits.DownloadLimit,
+		mode(limits.UploadMode), limits.UploadLimit,
+	)
+}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is a structured logger for long running commands, writing each
+// entry as a single logfmt or JSON line.
+type Logger struct {
+	w      io.Writer
+	format string
+	now    func() time.Time
+	sync.Mutex
+}
+
+// NewLogger creates a new structured logger, writing entries in the format
+// (logfmt, json) to w.
+func NewLogger(w io.Writer, format string) *Logger {
+	return &Logger{
+		w:      w,
+		format: format,
+		now:    time.Now,
+	}
+}
+
+// Info logs an info message with the key value pairs in kv.
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.log("info", msg, kv...)
+}
+
+// Warn logs a warning message with the key value pairs in kv.
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.log("warn", msg, kv...)
+}
+
+// Error logs an error message with the key value pairs in kv.
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.log("error", msg, kv...)
+}
+
+// log writes the log entry.
+func (l *Logger) log(level, msg string, kv ...interface{}) {
+	if len(kv)%2 != 0 {
+		panic("invalid kv")
+	}
+	keys := []string{"time", "level", "msg"}
+	vals := []interface{}{l.now().UTC().Format(time.RFC3339), level, msg}
+	for i := 0; i < len(kv); i += 2 {
+		v := kv[i+1]
+		switch x := v.(type) {
+		case error:
+			v = x.Error()
+		case time.Duration:
+			v = x.String()
+		case fmt.Stringer:
+			v = x.String()
+		}
+		keys, vals = append(keys, fmt.Sprintf("%v", kv[i])), append(vals, v)
+	}
+	var buf []byte
+	switch l.format {
+	case "json":
+		buf = append(buf, '{')
+		for i, k := range keys {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			b, _ := json.Marshal(k)
+			buf = append(buf, b...)
+			buf = append(buf, ':')
+			if b, err := json.Marshal(vals[i]); err == nil {
+				buf = append(buf, b...)
+			} else {
+				buf = strconv.AppendQuote(buf, fmt.Sprintf("%v", vals[i]))
+			}
+		}
+		buf = append(buf, '}')
+	default:
+		for i, k := range keys {
+			if i != 0 {
+				buf = append(buf, ' ')
+			}
+			s := fmt.Sprintf("%v", vals[i])
+			if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+				s = strconv.Quote(s)
+			}
+			buf = append(buf, k+"="+s...)
+		}
+	}
+	buf = append(buf, '\n')
+	l.Lock()
+	defer l.Unlock()
+	_, _ = l.w.Write(buf)
+}
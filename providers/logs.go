@@ -0,0 +1,167 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// logLevels are the log levels for --level.
+var logLevels = map[string]tctypes.LogLevel{
+	"normal":   tctypes.LogLevelNormal,
+	"info":     tctypes.LogLevelInfo,
+	"warning":  tctypes.LogLevelWarning,
+	"critical": tctypes.LogLevelCritical,
+}
+
+// LogProvider is the interface for providers that support retrieving the
+// remote host's logs.
+type LogProvider interface {
+	Provider
+
+	// LogGet returns the remote host's log messages with the level or higher,
+	// and an id greater than the last known id (-1 for all messages).
+	LogGet(context.Context, tctypes.LogLevel, int64) ([]tctypes.LogEntry, error)
+
+	// LogPeersGet returns the remote host's peer log messages with an id
+	// greater than the last known id (-1 for all messages).
+	LogPeersGet(context.Context, int64) ([]tctypes.PeerLogEntry, error)
+}
+
+// newLogProvider creates the provider for the args, checking that it
+// supports logs.
+func newLogProvider(args *Args) (LogProvider, error) {
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	lp, ok := p.(LogProvider)
+	if !ok {
+		return nil, fmt.Errorf("logs %w", ErrNotSupportedByProvider)
+	}
+	return lp, nil
+}
+
+// DoLogs is the high-level entry point for 'logs main' and 'logs peers'.
+//
+// Messages are retrieved incrementally (after the last retrieved message id),
+// and when following, the remote host is polled for new messages until
+// interrupted.
+func DoLogs(ctx context.Context, args *Args, cmd string) error {
+	p, err := newLogProvider(args)
+	if err != nil {
+		return err
+	}
+	if args.LogsParams.Follow {
+		// cancel on signal
+		var cancel context.CancelFunc
+		ctx, cancel = signalContext(ctx, NewLogger(ioutil.Discard, ""))
+		defer cancel()
+	}
+	var since time.Time
+	if args.LogsParams.Since > 0 {
+		since = time.Now().Add(-args.LogsParams.Since)
+	}
+
+	// build poll
+	lastID := int64(-1)
+	var poll func(context.Context) (interface{}, int, error)
+	var opts []ResultOption
+	switch cmd {
+	case "logs main":
+		level := logLevels[args.LogsParams.Level]
+		poll = func(ctx context.Context) (interface{}, int, error) {
+			entries, err := p.LogGet(ctx, level, lastID)
+			if err != nil {
+				return nil, 0, err
+			}
+			entries, lastID = filterLogEntries(entries, since, lastID)
+			return entries, len(entries), nil
+		}
+		opts = []ResultOption{
+			TableColumns("time", "level", "message"),
+			WideColumns("id", "time", "level", "message"),
+			FlatName("log"),
+			Kind(KindLogList),
+		}
+	case "logs peers":
+		poll = func(ctx context.Context) (interface{}, int, error) {
+			entries, err := p.LogPeersGet(ctx, lastID)
+			if err != nil {
+				return nil, 0, err
+			}
+			entries, lastID = filterPeerLogEntries(entries, since, lastID)
+			return entries, len(entries), nil
+		}
+		opts = []ResultOption{
+			TableColumns("time", "address", "blocked", "reason"),
+			WideColumns("id", "time", "address", "blocked", "reason"),
+			FlatName("peer-log"),
+			Kind(KindPeerLogList),
+		}
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	opts = append(opts, FlatIndex("id"), Index("id"), NoTotals(true))
+
+	for {
+		reqCtx, cancel := context.WithTimeout(ctx, args.Host.Timeout)
+		v, n, err := poll(reqCtx)
+		cancel()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+		if !args.LogsParams.Follow {
+			return NewResult(v, args.ResultOptions(opts...)...).Encode(os.Stdout)
+		}
+		if n != 0 {
+			if err := NewResult(v, args.ResultOptions(opts...)...).Encode(os.Stdout); err != nil {
+				return err
+			}
+			// only output table headers once
+			args.Output.NoHeaders = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(args.LogsParams.Interval):
+		}
+	}
+}
+
+// filterLogEntries returns the log messages newer than since, and the last
+// message id.
+func filterLogEntries(entries []tctypes.LogEntry, since time.Time, lastID int64) ([]tctypes.LogEntry, int64) {
+	res := make([]tctypes.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ID > lastID {
+			lastID = entry.ID
+		}
+		if !time.Time(entry.Time).Before(since) {
+			res = append(res, entry)
+		}
+	}
+	return res, lastID
+}
+
+// filterPeerLogEntries returns the peer log messages newer than since, and
+// the last message id.
+func filterPeerLogEntries(entries []tctypes.PeerLogEntry, since time.Time, lastID int64) ([]tctypes.PeerLogEntry, int64) {
+	res := make([]tctypes.PeerLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ID > lastID {
+			lastID = entry.ID
+		}
+		if !time.Time(entry.Time).Before(since) {
+			res = append(res, entry)
+		}
+	}
+	return res, lastID
+}
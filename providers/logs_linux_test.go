@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestDoLogsFollowSignal(t *testing.T) {
+	p := &logsProvider{polls: make(chan int, 10)}
+	Register("test-logs-follow", func(*Args) (Provider, error) {
+		return p, nil
+	})
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-logs-follow")
+	args := &Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.LogsParams.Follow = true
+	args.LogsParams.Interval = 10 * time.Millisecond
+	done := make(chan error, 1)
+	go func() {
+		done <- DoLogs(context.Background(), args, "logs main")
+	}()
+
+	// interrupt while the second poll is in progress
+	for n := 0; n != 2; {
+		select {
+		case n = <-p.polls:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected logs to be polled")
+		}
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected logs to stop on signal")
+	}
+}
+
+// logsProvider is a log provider without messages, that blocks on the second
+// poll until canceled.
+type logsProvider struct {
+	Provider
+	polls chan int
+	n     int
+}
+
+func (p *logsProvider) LogGet(ctx context.Context, _ tctypes.LogLevel, _ int64) ([]tctypes.LogEntry, error) {
+	p.n++
+	p.polls <- p.n
+	if p.n == 2 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, nil
+}
+
+func (p *logsProvider) LogPeersGet(context.Context, int64) ([]tctypes.PeerLogEntry, error) {
+	return nil, nil
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestFilterLogEntries(t *testing.T) {
+	now := time.Now()
+	entries := []tctypes.LogEntry{
+		{ID: 3, Time: tctypes.MilliTime(now.Add(-2 * time.Hour)), Message: "a"},
+		{ID: 4, Time: tctypes.MilliTime(now.Add(-30 * time.Minute)), Message: "b"},
+		{ID: 6, Time: tctypes.MilliTime(now), Message: "c"},
+	}
+	tests := []struct {
+		since  time.Time
+		lastID int64
+		exp    string
+		expID  int64
+	}{
+		{time.Time{}, -1, "a b c", 6},
+		{now.Add(-time.Hour), -1, "b c", 6},
+		{now.Add(time.Hour), 2, "", 6},
+		{time.Time{}, 10, "a b c", 10},
+	}
+	for i, test := range tests {
+		res, lastID := filterLogEntries(entries, test.since, test.lastID)
+		var s string
+		for j, entry := range res {
+			if j != 0 {
+				s += " "
+			}
+			s += entry.Message
+		}
+		if s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+		if lastID != test.expID {
+			t.Errorf("test %d expected last id %d, got: %d", i, test.expID, lastID)
+		}
+	}
+}
+
+func TestFilterPeerLogEntries(t *testing.T) {
+	now := time.Now()
+	entries := []tctypes.PeerLogEntry{
+		{ID: 0, Time: tctypes.MilliTime(now.Add(-2 * time.Hour)), Address: "1.2.3.4"},
+		{ID: 1, Time: tctypes.MilliTime(now), Address: "5.6.7.8", Blocked: true},
+	}
+	res, lastID := filterPeerLogEntries(entries, now.Add(-time.Hour), -1)
+	if s := fmt.Sprintf("%d %d %s", len(res), lastID, res[0].Address); s != "1 1 5.6.7.8" {
+		t.Errorf("expected %q, got: %q", "1 1 5.6.7.8", s)
+	}
+}
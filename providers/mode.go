@@ -0,0 +1,62 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// downloadModes are the download modes for the mode commands.
+var downloadModes = map[string]tctypes.DownloadMode{
+	"mode sequential":  tctypes.DownloadModeSequential,
+	"mode first-last":  tctypes.DownloadModeFirstLast,
+	"mode force-start": tctypes.DownloadModeForceStart,
+	"mode super-seed":  tctypes.DownloadModeSuperSeed,
+}
+
+// ModeProvider is the interface for providers that support download modes.
+type ModeProvider interface {
+	Provider
+
+	// ModeSet turns a download mode on or off for the provided identifiers.
+	ModeSet(context.Context, tctypes.DownloadMode, bool, ...interface{}) error
+}
+
+// newModeProvider creates the provider for the args, checking that it
+// supports download modes.
+func newModeProvider(args *Args) (ModeProvider, error) {
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	mp, ok := p.(ModeProvider)
+	if !ok {
+		return nil, fmt.Errorf("download modes %w", ErrNotSupportedByProvider)
+	}
+	return mp, nil
+}
+
+// DoMode is the high-level entry point for 'mode sequential', 'mode
+// first-last', 'mode force-start', and 'mode super-seed'.
+//
+// Modes are set (not toggled), so torrents already in the mode state are left
+// unchanged.
+func DoMode(ctx context.Context, args *Args, cmd string) error {
+	mode, ok := downloadModes[cmd]
+	if !ok {
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	p, err := newModeProvider(args)
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	return p.ModeSet(ctx, mode, args.ModeParams.State == "on", ConvertTorrentIDs(torrents)...)
+}
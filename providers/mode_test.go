@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestDoMode(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	p := &modeProvider{serveProvider: &serveProvider{torrents: []tctypes.Torrent{
+		{ID: 1, HashString: hash, Name: "one"},
+	}}}
+	Register("test-mode", func(*Args) (Provider, error) {
+		return p, nil
+	})
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-mode")
+	tests := []struct {
+		cmd   string
+		state string
+		now   bool
+		exp   string
+	}{
+		{"mode sequential", "on", false, "Sequential on"},
+		{"mode first-last", "off", false, "FirstLast off"},
+		{"mode force-start", "on", false, "ForceStart on"},
+		{"mode super-seed", "on", false, "SuperSeed on"},
+		{"start", "", true, "ForceStart on"},
+		{"start", "", false, "start"},
+	}
+	for _, test := range tests {
+		t.Run(test.cmd+" "+test.exp, func(t *testing.T) {
+			p.calls = nil
+			args := &Args{Config: config}
+			args.Filter.ListAll = true
+			args.ModeParams.State = test.state
+			args.StartParams.Now = test.now
+			var err error
+			switch test.cmd {
+			case "start":
+				err = DoReq(context.Background(), args, test.cmd)
+			default:
+				err = DoMode(context.Background(), args, test.cmd)
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if exp := []string{test.exp + " " + hash}; !reflect.DeepEqual(p.calls, exp) {
+				t.Errorf("expected %v, got: %v", exp, p.calls)
+			}
+		})
+	}
+}
+
+func TestStartNowNotSupported(t *testing.T) {
+	Register("test-start-now", func(*Args) (Provider, error) {
+		return &serveProvider{torrents: []tctypes.Torrent{
+			{ID: 1, HashString: "0123456789abcdef0123456789abcdef01234567", Name: "one"},
+		}}, nil
+	})
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-start-now")
+	args := &Args{Config: config}
+	args.Filter.ListAll = true
+	args.StartParams.Now = true
+	err := DoReq(context.Background(), args, "start")
+	if !errors.Is(err, ErrNotSupportedByProvider) {
+		t.Fatalf("expected ErrNotSupportedByProvider, got: %v", err)
+	}
+	if exp := "start now " + ErrNotSupportedByProvider.Error(); err.Error() != exp {
+		t.Errorf("expected %q, got: %q", exp, err.Error())
+	}
+}
+
+// modeProvider is a provider that records starts and download mode changes.
+type modeProvider struct {
+	*serveProvider
+	calls []string
+}
+
+func (p *modeProvider) Start(_ context.Context, ids ...interface{}) error {
+	for _, id := range ids {
+		p.calls = append(p.calls, "start "+id.(string))
+	}
+	return nil
+}
+
+func (p *modeProvider) ModeSet(_ context.Context, mode tctypes.DownloadMode, on bool, ids ...interface{}) error {
+	state := "off"
+	if on {
+		state = "on"
+	}
+	for _, id := range ids {
+		p.calls = append(p.calls, mode.String()+" "+state+" "+id.(string))
+	}
+	return nil
+}
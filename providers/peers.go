@@ -0,0 +1,234 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DoPeersAdd is the high-level entry point for 'peers add'.
+func DoPeersAdd(ctx context.Context, args *Args, cmd string) error {
+	peers := make([]string, len(args.PeersParams.Peers))
+	for i, s := range args.PeersParams.Peers {
+		var err error
+		if peers[i], err = parsePeerAddress(s, true); err != nil {
+			return err
+		}
+	}
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	return p.PeersAdd(ctx, peers, ConvertTorrentIDs(torrents)...)
+}
+
+// DoPeersBan is the high-level entry point for 'peers ban'.
+func DoPeersBan(ctx context.Context, args *Args, cmd string) error {
+	peers := make([]string, len(args.PeersParams.Peers))
+	for i, s := range args.PeersParams.Peers {
+		var err error
+		if peers[i], err = parsePeerAddress(s, false); err != nil {
+			return err
+		}
+	}
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	return p.PeersBan(ctx, peers...)
+}
+
+// DoPeersStats is the high-level entry point for 'peers stats'.
+//
+// Connected peers of all the torrents are grouped by client, encryption,
+// transport, and (when known) country.
+func DoPeersStats(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	var peers []tctypes.Peer
+	if len(torrents) != 0 {
+		if peers, err = p.PeersGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
+			return err
+		}
+	}
+	if err := args.lookupPeerCountries(peers); err != nil {
+		return err
+	}
+	return NewResult(NewPeerStats(peers), args.ResultOptions(
+		TableColumns("group", "name", "peers", "rateToClient", "rateToPeer"),
+		WideColumns("group", "name", "peers", "rateToClient", "rateToPeer"),
+		FlatName("peer-stat"),
+		FlatIndex("id"),
+		Index("id"),
+		NoTotals(true),
+		Kind(KindPeerStatList),
+	)...).Encode(os.Stdout)
+}
+
+// peerStatGroups are the peer stat groups, and the func returning the name
+// of a peer's group.
+var peerStatGroups = []struct {
+	group string
+	name  func(tctypes.Peer) string
+}{
+	{"client", func(peer tctypes.Peer) string {
+		if peer.ClientName == "" {
+			return "unknown"
+		}
+		return peer.ClientName
+	}},
+	{"encryption", func(peer tctypes.Peer) string {
+		if peer.IsEncrypted {
+			return "encrypted"
+		}
+		return "plaintext"
+	}},
+	{"transport", func(peer tctypes.Peer) string {
+		if peer.IsUTP {
+			return "utp"
+		}
+		return "tcp"
+	}},
+	{"country", func(peer tctypes.Peer) string {
+		if peer.Country == "" {
+			return "unknown"
+		}
+		return peer.Country
+	}},
+}
+
+// NewPeerStats groups the peers by client, encryption, transport, and
+// country (when any peer's country is known), ordered by the most peers in
+// each group. Peers are counted once per torrent they are connected to.
+func NewPeerStats(peers []tctypes.Peer) []tctypes.PeerStat {
+	var hasCountry bool
+	for _, peer := range peers {
+		hasCountry = hasCountry || peer.Country != ""
+	}
+	var stats []tctypes.PeerStat
+	for _, g := range peerStatGroups {
+		if g.group == "country" && !hasCountry {
+			continue
+		}
+		m := make(map[string]*tctypes.PeerStat)
+		for _, peer := range peers {
+			name := g.name(peer)
+			stat, ok := m[name]
+			if !ok {
+				stat = &tctypes.PeerStat{Group: g.group, Name: name}
+				m[name] = stat
+			}
+			stat.Peers++
+			stat.RateToClient += peer.RateToClient
+			stat.RateToPeer += peer.RateToPeer
+		}
+		group := make([]tctypes.PeerStat, 0, len(m))
+		for _, stat := range m {
+			group = append(group, *stat)
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Peers != group[j].Peers {
+				return group[i].Peers > group[j].Peers
+			}
+			return group[i].Name < group[j].Name
+		})
+		stats = append(stats, group...)
+	}
+	for i := range stats {
+		stats[i].ID = int64(i)
+	}
+	return stats
+}
+
+// lookupPeerCountries sets the country of the peers using the --geoip-db
+// (or geoip-db config option) database, when specified.
+func (args *Args) lookupPeerCountries(peers []tctypes.Peer) error {
+	path := args.PeersParams.GeoIPDB
+	if path == "" {
+		path = strings.TrimSpace(args.getContextKey("geoip-db"))
+	}
+	if path == "" {
+		return nil
+	}
+	return lookupCountries(path, peers)
+}
+
+// lookupCountries sets the country of the peers from an offline
+// MaxMind-format (mmdb) database, such as GeoLite2-Country or GeoLite2-City.
+func lookupCountries(path string, peers []tctypes.Peer) error {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open geoip database %s: %w", path, err)
+	}
+	defer db.Close()
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		RegisteredCountry struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"registered_country"`
+	}
+	for i := range peers {
+		ip := net.ParseIP(peers[i].Address)
+		if ip == nil {
+			continue
+		}
+		record.Country.ISOCode, record.RegisteredCountry.ISOCode = "", ""
+		if err := db.Lookup(ip, &record); err != nil {
+			return fmt.Errorf("unable to lookup %s: %w", peers[i].Address, err)
+		}
+		switch {
+		case record.Country.ISOCode != "":
+			peers[i].Country = record.Country.ISOCode
+		case record.RegisteredCountry.ISOCode != "":
+			peers[i].Country = record.RegisteredCountry.ISOCode
+		}
+	}
+	return nil
+}
+
+// parsePeerAddress parses a peer address (ip:port, or ip when the port is
+// not required), returning it as host:port. A missing port is returned as
+// port 0.
+func parsePeerAddress(s string, portRequired bool) (string, error) {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")); ip != nil {
+		if portRequired {
+			return "", fmt.Errorf("invalid peer %q: missing port", s)
+		}
+		return net.JoinHostPort(ip.String(), "0"), nil
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer %q", s)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", fmt.Errorf("invalid peer %q: invalid ip address", s)
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return "", fmt.Errorf("invalid peer %q: invalid port", s)
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
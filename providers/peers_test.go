@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestParsePeerAddress(t *testing.T) {
+	tests := []struct {
+		s            string
+		portRequired bool
+		exp          string
+		err          bool
+	}{
+		{"1.2.3.4:6881", true, "1.2.3.4:6881", false},
+		{" 1.2.3.4:6881 ", false, "1.2.3.4:6881", false},
+		{"1.2.3.4", false, "1.2.3.4:0", false},
+		{"1.2.3.4", true, "", true},
+		{"[2001:db8::1]:6881", true, "[2001:db8::1]:6881", false},
+		{"2001:db8::1", false, "[2001:db8::1]:0", false},
+		{"[2001:db8::1]", false, "[2001:db8::1]:0", false},
+		{"example.com:6881", true, "", true},
+		{"1.2.3.4:0", true, "", true},
+		{"1.2.3.4:65536", true, "", true},
+		{"1.2.3", false, "", true},
+	}
+	for i, test := range tests {
+		s, err := parsePeerAddress(test.s, test.portRequired)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case s != test.exp:
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestNewPeerStats(t *testing.T) {
+	peers := []tctypes.Peer{
+		{Address: "1.1.1.1", ClientName: "qBittorrent 4.2.5", IsEncrypted: true, RateToClient: 100},
+		{Address: "2.2.2.2", ClientName: "Transmission 2.94", IsUTP: true, RateToPeer: 50},
+		{Address: "3.3.3.3", ClientName: "qBittorrent 4.2.5", IsEncrypted: true, IsUTP: true, RateToClient: 10},
+		{Address: "4.4.4.4"},
+	}
+	exp := []string{
+		"0 client qBittorrent 4.2.5 2 110 0",
+		"1 client Transmission 2.94 1 0 50",
+		"2 client unknown 1 0 0",
+		"3 encryption encrypted 2 110 0",
+		"4 encryption plaintext 2 0 50",
+		"5 transport tcp 2 100 0",
+		"6 transport utp 2 10 50",
+	}
+	if s := formatPeerStats(NewPeerStats(peers)); s != strings.Join(exp, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), s)
+	}
+
+	// country
+	peers[0].Country, peers[2].Country = "AU", "AU"
+	stats := NewPeerStats(peers)
+	exp = append(exp, "7 country AU 2 110 0", "8 country unknown 2 0 50")
+	if s := formatPeerStats(stats); s != strings.Join(exp, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), s)
+	}
+}
+
+// formatPeerStats formats the peer stats for comparison.
+func formatPeerStats(stats []tctypes.PeerStat) string {
+	var lines []string
+	for _, stat := range stats {
+		lines = append(lines, fmt.Sprintf("%d %s %s %d %d %d", stat.ID, stat.Group, stat.Name, stat.Peers, stat.RateToClient, stat.RateToPeer))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLookupCountries(t *testing.T) {
+	peers := []tctypes.Peer{
+		{Address: "1.2.3.4"},
+		{Address: "2.3.4.5"},
+		{Address: "5.6.7.8"},
+		{Address: "9.9.9.9"},
+		{Address: "invalid"},
+	}
+	if err := lookupCountries("testdata/geoip.mmdb", peers); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i, exp := range []string{"AU", "FR", "DE", "", ""} {
+		if peers[i].Country != exp {
+			t.Errorf("test %d expected %q, got: %q", i, exp, peers[i].Country)
+		}
+	}
+	if err := lookupCountries("testdata/missing.mmdb", peers); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,242 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/olekukonko/tablewriter"
+)
+
+// DoPieces is the high-level entry point for 'pieces'.
+//
+// Table output renders a piece map of each torrent, followed by the piece
+// coverage of the torrent's files. Other output formats contain the have,
+// downloading, and missing piece ranges.
+func DoPieces(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	var maps []tctypes.PieceMap
+	if len(torrents) != 0 {
+		if maps, err = p.PiecesGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
+			return err
+		}
+	}
+	for i := range maps {
+		countPieces(&maps[i])
+	}
+	if args.Output.Output == "table" || args.Output.Output == "wide" {
+		return renderPieceMaps(os.Stdout, maps, args.PiecesParams.Width, args.PiecesParams.Grid, args.Output.NoHeaders)
+	}
+	return NewResult(maps, args.ResultOptions(
+		TableColumns("torrent", "pieceCount", "pieceSize", "shortHash"),
+		WideColumns("torrent", "pieceCount", "pieceSize", "hashString"),
+		FlatName("pieces"),
+		FlatIndex("shortHash"),
+		NoTotals(true),
+		Kind(KindPieceMapList),
+	)...).Encode(os.Stdout)
+}
+
+// countPieces sets the have, downloading, and missing piece ranges of the
+// piece map, and the piece counts of its files.
+func countPieces(m *tctypes.PieceMap) {
+	m.Have = pieceRanges(m.States, tctypes.PieceStateHave)
+	m.Downloading = pieceRanges(m.States, tctypes.PieceStateDownloading)
+	m.Missing = pieceRanges(m.States, tctypes.PieceStateMissing)
+	for i := range m.Files {
+		f := &m.Files[i]
+		f.Have, f.Downloading, f.Missing = 0, 0, 0
+		for j := f.FirstPiece; j <= f.LastPiece && j < int64(len(m.States)); j++ {
+			switch m.States[j] {
+			case tctypes.PieceStateHave:
+				f.Have++
+			case tctypes.PieceStateDownloading:
+				f.Downloading++
+			default:
+				f.Missing++
+			}
+		}
+	}
+}
+
+// pieceRanges returns the ranges of pieces in the state.
+func pieceRanges(states []tctypes.PieceState, state tctypes.PieceState) []tctypes.PieceRange {
+	ranges := make([]tctypes.PieceRange, 0)
+	for i := 0; i < len(states); i++ {
+		if states[i] != state {
+			continue
+		}
+		j := i
+		for j+1 < len(states) && states[j+1] == state {
+			j++
+		}
+		ranges = append(ranges, tctypes.PieceRange{int64(i), int64(j)})
+		i = j
+	}
+	return ranges
+}
+
+// piece map glyphs.
+const (
+	pieceHave        = '█'
+	piecePartial     = '▓'
+	pieceDownloading = '▒'
+	pieceMissing     = '░'
+)
+
+// maxPieceRanges is the maximum number of missing or downloading ranges
+// rendered for a piece map.
+const maxPieceRanges = 10
+
+// renderPieceMaps renders the piece maps. A bar of the width is rendered for
+// each piece map (and each of its files), with each glyph representing one or
+// more pieces. When grid is true, each piece is instead rendered as a glyph,
+// in rows of the width.
+func renderPieceMaps(w io.Writer, maps []tctypes.PieceMap, width int, grid, noHeaders bool) error {
+	if width < 1 {
+		width = 1
+	}
+	for i, m := range maps {
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%s)\n", m.Torrent, m.ShortHash())
+		if grid {
+			for j := 0; j < len(m.States); j += width {
+				end := j + width
+				if end > len(m.States) {
+					end = len(m.States)
+				}
+				fmt.Fprintln(w, pieceBar(m.States[j:end], end-j))
+			}
+		} else {
+			fmt.Fprintln(w, pieceBar(m.States, width))
+		}
+		have, downloading, missing := countRanges(m.Have), countRanges(m.Downloading), countRanges(m.Missing)
+		fmt.Fprintf(w, "%d pieces of %s: %d have (%s), %d downloading, %d missing\n", m.PieceCount, m.PieceSize, have, piecePercent(have, m.PieceCount), downloading, missing)
+		if downloading != 0 {
+			fmt.Fprintf(w, "downloading: %s\n", formatPieceRanges(m.Downloading, maxPieceRanges))
+		}
+		if missing != 0 {
+			fmt.Fprintf(w, "missing: %s\n", formatPieceRanges(m.Missing, maxPieceRanges))
+		}
+		if len(m.Files) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		tbl := tablewriter.NewWriter(w)
+		if !noHeaders {
+			tbl.SetHeader([]string{"FILE", "PIECES", "HAVE", "DONE", "MAP"})
+		}
+		tbl.SetAutoWrapText(false)
+		tbl.SetAutoFormatHeaders(true)
+		tbl.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		tbl.SetAlignment(tablewriter.ALIGN_LEFT)
+		tbl.SetCenterSeparator("")
+		tbl.SetColumnSeparator("")
+		tbl.SetRowSeparator("")
+		tbl.SetHeaderLine(false)
+		tbl.SetBorder(false)
+		tbl.SetTablePadding("\t") // pad with tabs
+		tbl.SetNoWhiteSpace(true)
+		for _, f := range m.Files {
+			var states []tctypes.PieceState
+			if f.FirstPiece <= f.LastPiece && f.LastPiece < int64(len(m.States)) {
+				states = m.States[f.FirstPiece : f.LastPiece+1]
+			}
+			n := f.Have + f.Downloading + f.Missing
+			tbl.Append([]string{
+				f.Name,
+				formatPieceRanges([]tctypes.PieceRange{{f.FirstPiece, f.LastPiece}}, 1),
+				fmt.Sprintf("%d/%d", f.Have, n),
+				piecePercent(f.Have, n),
+				pieceBar(states, 16),
+			})
+		}
+		tbl.Render()
+	}
+	if len(maps) != 0 && !noHeaders {
+		fmt.Fprintf(w, "\n%c have  %c partial  %c downloading  %c missing\n", pieceHave, piecePartial, pieceDownloading, pieceMissing)
+	}
+	return nil
+}
+
+// pieceBar renders the piece states as a bar of at most width glyphs. When
+// there are more pieces than the width, each glyph represents multiple
+// pieces, and is rendered as have or missing only when all its pieces are.
+func pieceBar(states []tctypes.PieceState, width int) string {
+	n := len(states)
+	if n < width {
+		width = n
+	}
+	var sb strings.Builder
+	for i := 0; i < width; i++ {
+		var have, downloading int
+		start, end := i*n/width, (i+1)*n/width
+		for _, state := range states[start:end] {
+			switch state {
+			case tctypes.PieceStateHave:
+				have++
+			case tctypes.PieceStateDownloading:
+				downloading++
+			}
+		}
+		switch {
+		case have == end-start:
+			sb.WriteRune(pieceHave)
+		case downloading != 0:
+			sb.WriteRune(pieceDownloading)
+		case have != 0:
+			sb.WriteRune(piecePartial)
+		default:
+			sb.WriteRune(pieceMissing)
+		}
+	}
+	return sb.String()
+}
+
+// countRanges returns the number of pieces in the ranges.
+func countRanges(ranges []tctypes.PieceRange) int64 {
+	var n int64
+	for _, r := range ranges {
+		n += r[1] - r[0] + 1
+	}
+	return n
+}
+
+// piecePercent formats the percentage of n pieces of total, rounding down so
+// that an incomplete torrent is never shown as 100%.
+func piecePercent(n, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", math.Floor(float64(n)/float64(total)*1000)/10)
+}
+
+// formatPieceRanges formats at most max piece ranges (0-99, 101, ...).
+func formatPieceRanges(ranges []tctypes.PieceRange, max int) string {
+	var s []string
+	for i, r := range ranges {
+		if i == max {
+			s = append(s, fmt.Sprintf("... (%d more)", len(ranges)-max))
+			break
+		}
+		if r[0] == r[1] {
+			s = append(s, fmt.Sprintf("%d", r[0]))
+		} else {
+			s = append(s, fmt.Sprintf("%d-%d", r[0], r[1]))
+		}
+	}
+	return strings.Join(s, ", ")
+}
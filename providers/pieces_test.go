@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestCountPieces(t *testing.T) {
+	m := tctypes.PieceMap{
+		PieceCount: 10,
+		Files: []tctypes.FilePieces{
+			{Name: "a", FirstPiece: 0, LastPiece: 2},
+			{Name: "b", FirstPiece: 2, LastPiece: 9},
+		},
+		States: parsePieceStates("2220012220"),
+	}
+	countPieces(&m)
+	if s := fmt.Sprint(m.Have, m.Downloading, m.Missing); s != "[[0 2] [6 8]] [[5 5]] [[3 4] [9 9]]" {
+		t.Errorf("expected [[0 2] [6 8]] [[5 5]] [[3 4] [9 9]], got: %s", s)
+	}
+	for i, exp := range []string{"a 3 0 0", "b 4 1 3"} {
+		f := m.Files[i]
+		if s := fmt.Sprintf("%s %d %d %d", f.Name, f.Have, f.Downloading, f.Missing); s != exp {
+			t.Errorf("test %d expected %q, got: %q", i, exp, s)
+		}
+	}
+}
+
+func TestPieceBar(t *testing.T) {
+	tests := []struct {
+		states string
+		width  int
+		exp    string
+	}{
+		{"", 8, ""},
+		{"2201", 8, "██░▒"},
+		{"22220000", 4, "██░░"},
+		{"22202010", 4, "█▓▓▒"},
+		{"2222222220", 3, "██▓"},
+	}
+	for i, test := range tests {
+		if s := pieceBar(parsePieceStates(test.states), test.width); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestPiecePercent(t *testing.T) {
+	tests := []struct {
+		n, total int64
+		exp      string
+	}{
+		{0, 0, "0%"},
+		{0, 10, "0.0%"},
+		{1, 3, "33.3%"},
+		{2, 3, "66.6%"},
+		{999, 1000, "99.9%"},
+		{9999, 10000, "99.9%"},
+		{10, 10, "100.0%"},
+	}
+	for i, test := range tests {
+		if s := piecePercent(test.n, test.total); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestFormatPieceRanges(t *testing.T) {
+	ranges := []tctypes.PieceRange{{0, 2}, {5, 5}, {7, 9}}
+	if s := formatPieceRanges(ranges, 10); s != "0-2, 5, 7-9" {
+		t.Errorf("expected %q, got: %q", "0-2, 5, 7-9", s)
+	}
+	if s := formatPieceRanges(ranges, 2); s != "0-2, 5, ... (1 more)" {
+		t.Errorf("expected %q, got: %q", "0-2, 5, ... (1 more)", s)
+	}
+}
+
+func TestRenderPieceMaps(t *testing.T) {
+	m := tctypes.PieceMap{
+		Torrent:    "debian-10.3.0-amd64-netinst.iso",
+		HashString: "0123456789abcdef0123456789abcdef01234567",
+		PieceCount: 4,
+		PieceSize:  262144,
+		Files: []tctypes.FilePieces{
+			{Name: "debian.iso", FirstPiece: 0, LastPiece: 3},
+		},
+		States: parsePieceStates("2221"),
+	}
+	countPieces(&m)
+	buf := new(bytes.Buffer)
+	if err := renderPieceMaps(buf, []tctypes.PieceMap{m}, 64, false, false); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	for _, exp := range []string{
+		"debian-10.3.0-amd64-netinst.iso (0123456) ███▒ 4 pieces of 256.00 KiB: 3 have (75.0%), 1 downloading, 0 missing downloading: 3",
+		"FILE PIECES HAVE DONE MAP debian.iso 0-3 3/4 75.0% ███▒",
+	} {
+		if !strings.Contains(s, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, buf.String())
+		}
+	}
+}
+
+// parsePieceStates parses piece states from a string of '0' (missing), '1'
+// (downloading), and '2' (have).
+func parsePieceStates(s string) []tctypes.PieceState {
+	states := make([]tctypes.PieceState, len(s))
+	for i, c := range s {
+		switch c {
+		case '1':
+			states[i] = tctypes.PieceStateDownloading
+		case '2':
+			states[i] = tctypes.PieceStateHave
+		}
+	}
+	return states
+}
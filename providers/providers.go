@@ -12,17 +12,15 @@ type Provider interface {
 	// NewRemoteConfigStore creates a config store for the remote host.
 	NewRemoteConfigStore(context.Context) (ConfigStore, error)
 
-	// Find finds torrents.
-	Find(context.Context) ([]interface{}, error)
-
-	// Add adds a torrent ([]byte) or magnet link (string).
-	Add(context.Context, ...interface{}) ([]tctypes.Torrent, error)
+	// Add adds a torrent ([]byte) or magnet link (string), using the add
+	// options.
+	Add(context.Context, AddOptions, ...interface{}) ([]tctypes.Torrent, error)
 
 	// Get returns a semi-populated torrent list, with the provided fields.
 	Get(context.Context, []string, ...interface{}) ([]tctypes.Torrent, error)
 
-	// Set sets a configuration option on a torrent.
-	Set(context.Context, map[string]interface{}) error
+	// Set sets configuration options on the provided identifiers.
+	Set(context.Context, map[string]interface{}, ...interface{}) error
 
 	// Start starts the provided identifiers.
 	Start(context.Context, ...interface{}) error
@@ -48,6 +46,12 @@ type Provider interface {
 	// PeersGet returns the peers for the provided identifiers.
 	PeersGet(context.Context, ...interface{}) ([]tctypes.Peer, error)
 
+	// PeersAdd adds peers (host:port) to the provided identifiers.
+	PeersAdd(context.Context, []string, ...interface{}) error
+
+	// PeersBan bans peers (host:port) on the remote host.
+	PeersBan(context.Context, ...string) error
+
 	// FilesGet returns the files for the provided identifiers.
 	FilesGet(context.Context, ...interface{}) ([]tctypes.File, error)
 
@@ -57,11 +61,16 @@ type Provider interface {
 	// FilesRename renames a file on the provided identifiers.
 	FilesRename(context.Context, string, string, ...interface{}) error
 
+	// PiecesGet returns the piece states and the files' piece ranges for the
+	// provided identifiers.
+	PiecesGet(context.Context, ...interface{}) ([]tctypes.PieceMap, error)
+
 	// TrackersGet returns the trackers for the provided identifiers.
 	TrackersGet(context.Context, ...interface{}) ([]tctypes.Tracker, error)
 
-	// TrackersAdd adds a tracker to the provided identifiers.
-	TrackersAdd(context.Context, string, ...interface{}) error
+	// TrackersAdd adds a tracker to the provided identifiers, in the
+	// provided tier (or a new tier, when the tier is less than 0).
+	TrackersAdd(context.Context, string, int64, ...interface{}) error
 
 	// TrackersReplace replaces a tracker on the provided identifiers.
 	TrackersReplace(context.Context, string, string, ...interface{}) error
@@ -69,6 +78,32 @@ type Provider interface {
 	// TrackersRemove removes a tracker from the provided identifiers.
 	TrackersRemove(context.Context, string, ...interface{}) error
 
+	// TrackersRemoveIDs removes the trackers with the provided tracker ids (as
+	// returned by TrackersGet) from a torrent.
+	TrackersRemoveIDs(context.Context, string, ...int64) error
+
+	// LabelsAdd adds labels to the provided identifiers.
+	LabelsAdd(context.Context, []string, ...interface{}) error
+
+	// LabelsRemove removes labels from the provided identifiers.
+	LabelsRemove(context.Context, []string, ...interface{}) error
+
+	// LabelsSet sets the labels for the provided identifiers, replacing any
+	// existing labels.
+	LabelsSet(context.Context, []string, ...interface{}) error
+
+	// CategoriesGet returns the remote host's categories.
+	CategoriesGet(context.Context) ([]tctypes.Category, error)
+
+	// CategoriesCreate creates a category with the save path.
+	CategoriesCreate(context.Context, string, string) error
+
+	// CategoriesEdit changes the save path of a category.
+	CategoriesEdit(context.Context, string, string) error
+
+	// CategoriesDelete deletes categories.
+	CategoriesDelete(context.Context, ...string) error
+
 	// Stats returns the stats for the remote host.
 	Stats(context.Context) (map[string]interface{}, error)
 
@@ -94,12 +129,22 @@ func Register(name string, f func(*Args) (Provider, error)) {
 }
 
 func init() {
-	providers = make(map[string]func() Provider)
+	providers = make(map[string]func(*Args) (Provider, error))
 	if err := snaker.AddInitialisms("UTP"); err != nil {
 		panic(err)
 	}
 }
 
+// AddOptions are the options for adding torrents.
+type AddOptions struct {
+	Cookies           map[string]string
+	DownloadDir       string
+	Paused            bool
+	PeerLimit         int64
+	BandwidthPriority int64
+	Labels            []string
+}
+
 // ConfigStore is the interface for config stores.
 type ConfigStore interface {
 	GetKey(string) string
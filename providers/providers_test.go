@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/knq/ini"
+)
+
+func TestOptionalProviders(t *testing.T) {
+	Register("test-basic", func(*Args) (Provider, error) {
+		return &serveProvider{}, nil
+	})
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-basic")
+	args := &Args{Config: config}
+	tests := []struct {
+		name string
+		f    func(*Args) error
+	}{
+		{"limits", func(args *Args) error { _, err := newLimitsProvider(args); return err }},
+		{"download modes", func(args *Args) error { _, err := newModeProvider(args); return err }},
+		{"logs", func(args *Args) error { _, err := newLogProvider(args); return err }},
+		{"rss", func(args *Args) error { _, err := newRSSProvider(args); return err }},
+		{"search", func(args *Args) error { _, err := newSearchProvider(args); return err }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.f(args)
+			if !errors.Is(err, ErrNotSupportedByProvider) {
+				t.Fatalf("expected ErrNotSupportedByProvider, got: %v", err)
+			}
+			if exp := test.name + " " + ErrNotSupportedByProvider.Error(); err.Error() != exp {
+				t.Errorf("expected %q, got: %q", exp, err.Error())
+			}
+		})
+	}
+}
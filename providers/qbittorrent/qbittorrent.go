@@ -1 +1,842 @@
 package qbittorrent
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/qbtweb"
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func init() {
+	providers.Register("qbittorrent", New)
+}
+
+// Provider is a qBittorrent web api host provider.
+type Provider struct {
+	args *providers.Args
+	cl   *qbtweb.Client
+}
+
+// New creates a new qBittorrent web api host provider.
+func New(args *providers.Args) (providers.Provider, error) {
+	remote, err := args.Remote()
+	if err != nil {
+		return nil, err
+	}
+
+	// build options
+	opts := []qbtweb.ClientOption{
+		qbtweb.WithUserAgent(remote.UserAgent),
+		qbtweb.WithURL(remote.URL.String()),
+		qbtweb.WithTimeout(remote.Timeout),
+	}
+	if remote.Fallback != nil {
+		opts = append(opts, qbtweb.WithCredentialFallback(remote.Fallback[0], remote.Fallback[1]))
+	}
+	if remote.TLS != nil {
+		opts = append(opts, qbtweb.WithTLSConfig(remote.TLS))
+	}
+	if remote.Dialer != nil {
+		opts = append(opts, qbtweb.WithDialer(remote.Dialer))
+	}
+	if remote.Session != nil {
+		opts = append(opts, qbtweb.WithSessionCache(remote.Session))
+	}
+	switch {
+	case remote.HAR != nil:
+		opts = append(opts, qbtweb.WithHAR(remote.HAR))
+	case remote.Logf != nil:
+		opts = append(opts, qbtweb.WithLogf(remote.Logf))
+	}
+	if !remote.Strict {
+		opts = append(opts, qbtweb.WithLenient(remote.Logf))
+	}
+
+	return &Provider{args: args, cl: qbtweb.NewClient(opts...)}, nil
+}
+
+// Add satisfies the Provider interface.
+//
+// As qBittorrent does not return the added torrents, the added torrents are
+// determined by comparing the torrent list before and after adding.
+func (p *Provider) Add(ctx context.Context, opts providers.AddOptions, files ...interface{}) ([]tctypes.Torrent, error) {
+	prev, err := p.torrents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		// build request
+		req := qbtweb.TorrentsAdd().
+			WithSavepath(opts.DownloadDir).
+			WithPaused(opts.Paused)
+		for k, v := range opts.Cookies {
+			if req.Cookie == nil {
+				req.Cookie = make(url.Values)
+			}
+			req.Cookie.Add(k, v)
+		}
+		switch v := file.(type) {
+		case []byte:
+			req = req.WithTorrent("torrent"+strconv.Itoa(i)+".torrent", v)
+		case string:
+			req = req.WithURLs([]string{v})
+		default:
+			return nil, fmt.Errorf("invalid torrent type %T", file)
+		}
+
+		// execute
+		if err := req.Do(ctx, p.cl); err != nil {
+			return nil, err
+		}
+	}
+
+	// determine added
+	torrents, err := p.torrents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.Torrent
+	var hashes []string
+	for _, t := range torrents {
+		if _, ok := prev[t.HashString]; !ok {
+			result, hashes = append(result, t), append(hashes, t.HashString)
+		}
+	}
+
+	// set labels
+	if len(hashes) != 0 && len(opts.Labels) != 0 {
+		if err := qbtweb.TorrentsAddTags(hashes...).WithTags(opts.Labels).Do(ctx, p.cl); err != nil {
+			return nil, err
+		}
+		for i := range result {
+			result[i].Labels = append(result[i].Labels, opts.Labels...)
+		}
+	}
+	return result, nil
+}
+
+// torrents returns all the torrents on the remote host, keyed by hash.
+func (p *Provider) torrents(ctx context.Context) (map[string]tctypes.Torrent, error) {
+	res, err := p.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]tctypes.Torrent, len(res))
+	for _, t := range res {
+		m[t.HashString] = t
+	}
+	return m, nil
+}
+
+// Get satisfies the Provider interface.
+//
+// qBittorrent does not have torrent ids, so all torrents are retrieved and
+// numbered in the order they were added, and then filtered by the provided
+// identifiers. The fields are ignored, as the web api always returns all
+// fields.
+func (p *Provider) Get(ctx context.Context, fields []string, ids ...interface{}) ([]tctypes.Torrent, error) {
+	res, err := qbtweb.TorrentsInfo().Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return time.Time(res[i].AddedOn).Before(time.Time(res[j].AddedOn))
+	})
+
+	// build filter
+	var recent bool
+	hashes := make(map[string]bool)
+	for _, id := range ids {
+		switch v := id.(type) {
+		case string:
+			if v == "recently-active" {
+				recent = true
+			} else {
+				hashes[strings.ToLower(v)] = true
+			}
+		default:
+			return nil, fmt.Errorf("invalid torrent identifier %v", id)
+		}
+	}
+
+	var torrents []tctypes.Torrent
+	for i, t := range res {
+		switch {
+		case recent && t.Dlspeed == 0 && t.Upspeed == 0,
+			len(hashes) != 0 && !hashes[strings.ToLower(t.Hash)]:
+			continue
+		}
+		torrents = append(torrents, convertTorrent(int64(i+1), t))
+	}
+	return torrents, nil
+}
+
+// Set satisfies the Provider interface.
+func (p *Provider) Set(context.Context, map[string]interface{}, ...interface{}) error {
+	return fmt.Errorf("set %w", providers.ErrNotSupportedByProvider)
+}
+
+// Start satisfies the Provider interface.
+func (p *Provider) Start(ctx context.Context, ids ...interface{}) error {
+	return qbtweb.TorrentsResume(toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Stop satisfies the Provider interface.
+func (p *Provider) Stop(ctx context.Context, ids ...interface{}) error {
+	return qbtweb.TorrentsPause(toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Move satisfies the Provider interface.
+func (p *Provider) Move(ctx context.Context, dest string, ids ...interface{}) error {
+	return qbtweb.TorrentsSetLocation(dest, toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Remove satisfies the Provider interface.
+func (p *Provider) Remove(ctx context.Context, deleteLocalData bool, ids ...interface{}) error {
+	return qbtweb.TorrentsDelete(deleteLocalData, toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Verify satisfies the Provider interface.
+func (p *Provider) Verify(ctx context.Context, ids ...interface{}) error {
+	return qbtweb.TorrentsRecheck(toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Reannounce satisfies the Provider interface.
+func (p *Provider) Reannounce(ctx context.Context, ids ...interface{}) error {
+	return qbtweb.TorrentsReannounce(toHashes(ids)...).Do(ctx, p.cl)
+}
+
+// Queue satisfies the Provider interface.
+func (p *Provider) Queue(ctx context.Context, dir string, ids ...interface{}) error {
+	switch dir {
+	case "top":
+		return qbtweb.TorrentsTopPrio(toHashes(ids)...).Do(ctx, p.cl)
+	case "bottom":
+		return qbtweb.TorrentsBottomPrio(toHashes(ids)...).Do(ctx, p.cl)
+	case "up":
+		return qbtweb.TorrentsIncreasePrio(toHashes(ids)...).Do(ctx, p.cl)
+	case "down":
+		return qbtweb.TorrentsDecreasePrio(toHashes(ids)...).Do(ctx, p.cl)
+	}
+	return fmt.Errorf("invalid queue direction %q", dir)
+}
+
+// PeersGet satisfies the Provider interface.
+func (p *Provider) PeersGet(ctx context.Context, ids ...interface{}) ([]tctypes.Peer, error) {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.Peer
+	for _, t := range torrents {
+		res, err := qbtweb.SyncTorrentPeers(t.HashString).Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]string, 0, len(res.Peers))
+		for addr := range res.Peers {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		for i, addr := range addrs {
+			peer := convertPeer(res.Peers[addr])
+			peer.ID, peer.Torrent, peer.HashString = int64(i), t.Name, t.HashString
+			result = append(result, peer)
+		}
+	}
+	return result, nil
+}
+
+// PeersAdd satisfies the Provider interface.
+func (p *Provider) PeersAdd(ctx context.Context, peers []string, ids ...interface{}) error {
+	return qbtweb.TorrentsAddPeers(toHashes(ids)...).WithPeers(peers).Do(ctx, p.cl)
+}
+
+// PeersBan satisfies the Provider interface.
+func (p *Provider) PeersBan(ctx context.Context, peers ...string) error {
+	return qbtweb.TransferBanPeers(peers...).Do(ctx, p.cl)
+}
+
+// FilesGet satisfies the Provider interface.
+func (p *Provider) FilesGet(ctx context.Context, ids ...interface{}) ([]tctypes.File, error) {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.File
+	for _, t := range torrents {
+		files, err := qbtweb.TorrentsFiles(t.HashString).Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range files {
+			result = append(result, tctypes.File{
+				BytesCompleted: tctypes.ByteCount(float64(v.Size) * float64(v.Progress)),
+				Length:         v.Size,
+				Name:           v.Name,
+				Wanted:         v.Priority != qbtweb.FilePriorityDoNotDownload,
+				Priority:       filePriorityString(v.Priority),
+				ID:             int64(i),
+				Torrent:        t.Name,
+				HashString:     t.HashString,
+			})
+		}
+	}
+	return result, nil
+}
+
+// FilesSet satisfies the Provider interface.
+func (p *Provider) FilesSet(ctx context.Context, opts map[string]interface{}, ids ...interface{}) error {
+	for k, v := range opts {
+		var priority qbtweb.FilePriority
+		switch k {
+		case "files-wanted", "priority-normal", "priority-low":
+			priority = qbtweb.FilePriorityNormal
+		case "files-unwanted":
+			priority = qbtweb.FilePriorityDoNotDownload
+		case "priority-high":
+			priority = qbtweb.FilePriorityHigh
+		default:
+			return fmt.Errorf("files option %q %w", k, providers.ErrNotSupportedByProvider)
+		}
+		fileIDs, ok := v.([]int64)
+		if !ok {
+			return fmt.Errorf("invalid file ids %T", v)
+		}
+		id := make([]string, len(fileIDs))
+		for i, fileID := range fileIDs {
+			id[i] = strconv.FormatInt(fileID, 10)
+		}
+		for _, hash := range toHashes(ids) {
+			if err := qbtweb.TorrentsFilePrio(hash, priority, id...).Do(ctx, p.cl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FilesRename satisfies the Provider interface.
+func (p *Provider) FilesRename(context.Context, string, string, ...interface{}) error {
+	return fmt.Errorf("files rename %w", providers.ErrNotSupportedByProvider)
+}
+
+// PiecesGet satisfies the Provider interface.
+func (p *Provider) PiecesGet(ctx context.Context, ids ...interface{}) ([]tctypes.PieceMap, error) {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.PieceMap
+	for _, t := range torrents {
+		props, err := qbtweb.TorrentsProperties(t.HashString).Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		states, err := qbtweb.TorrentsPieceStates(t.HashString).Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		files, err := qbtweb.TorrentsFiles(t.HashString).Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		m := tctypes.PieceMap{
+			Torrent:    t.Name,
+			HashString: t.HashString,
+			PieceCount: int64(len(states)),
+			PieceSize:  tctypes.ByteCount(props.PieceSize),
+			States:     make([]tctypes.PieceState, len(states)),
+		}
+		for i, v := range states {
+			m.States[i] = convertPieceState(v)
+		}
+		for _, v := range files {
+			f := tctypes.FilePieces{
+				Name:   v.Name,
+				Length: tctypes.ByteCount(v.Size),
+			}
+			if len(v.PieceRange) == 2 {
+				f.FirstPiece, f.LastPiece = v.PieceRange[0], v.PieceRange[1]
+			}
+			m.Files = append(m.Files, f)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// TrackersGet satisfies the Provider interface.
+func (p *Provider) TrackersGet(ctx context.Context, ids ...interface{}) ([]tctypes.Tracker, error) {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.Tracker
+	for _, t := range torrents {
+		trackers, err := p.trackers(ctx, t.HashString)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range trackers {
+			var host string
+			if u, err := url.Parse(v.URL); err == nil {
+				host = u.Host
+			}
+			result = append(result, tctypes.Tracker{
+				Announce:              v.URL,
+				ID:                    int64(i),
+				Tier:                  v.Tier,
+				AnnounceState:         trackerState(v.Status),
+				DownloadCount:         v.NumDownloaded,
+				HasAnnounced:          v.Status == qbtweb.TrackerContactedAndWorking || v.Status == qbtweb.TrackerNotWorking,
+				Host:                  host,
+				LastAnnouncePeerCount: v.NumPeers,
+				LastAnnounceResult:    v.Msg,
+				LastAnnounceSucceeded: v.Status == qbtweb.TrackerContactedAndWorking,
+				LeecherCount:          v.NumLeeches,
+				SeederCount:           v.NumSeeds,
+				Torrent:               t.Name,
+				HashString:            t.HashString,
+			})
+		}
+	}
+	return result, nil
+}
+
+// trackers returns the trackers for the torrent, excluding the DHT, PeX, and
+// LSD pseudo trackers.
+func (p *Provider) trackers(ctx context.Context, hash string) ([]qbtweb.Tracker, error) {
+	res, err := qbtweb.TorrentsTrackers(hash).Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var trackers []qbtweb.Tracker
+	for _, v := range res {
+		if !strings.HasPrefix(v.URL, "** [") {
+			trackers = append(trackers, v)
+		}
+	}
+	return trackers, nil
+}
+
+// TrackersAdd satisfies the Provider interface.
+func (p *Provider) TrackersAdd(ctx context.Context, tracker string, tier int64, ids ...interface{}) error {
+	if tier >= 0 {
+		return fmt.Errorf("tracker tiers %w", providers.ErrNotSupportedByProvider)
+	}
+	for _, hash := range toHashes(ids) {
+		if err := qbtweb.TorrentsAddTrackers(hash, tracker).Do(ctx, p.cl); err != nil {
+			return fmt.Errorf("could not add tracker %s to %s: %w", tracker, hash, err)
+		}
+	}
+	return nil
+}
+
+// TrackersReplace satisfies the Provider interface.
+func (p *Provider) TrackersReplace(ctx context.Context, tracker, replace string, ids ...interface{}) error {
+	return p.trackersEach(ctx, tracker, ids, func(hash string) error {
+		if err := qbtweb.TorrentsEditTracker(hash, tracker, replace).Do(ctx, p.cl); err != nil {
+			return fmt.Errorf("could not replace tracker %s with %s for %s: %w", tracker, replace, hash, err)
+		}
+		return nil
+	})
+}
+
+// TrackersRemove satisfies the Provider interface.
+func (p *Provider) TrackersRemove(ctx context.Context, tracker string, ids ...interface{}) error {
+	return p.trackersEach(ctx, tracker, ids, func(hash string) error {
+		if err := qbtweb.TorrentsRemoveTrackers(hash, tracker).Do(ctx, p.cl); err != nil {
+			return fmt.Errorf("could not remove tracker %s from %s: %w", tracker, hash, err)
+		}
+		return nil
+	})
+}
+
+// TrackersRemoveIDs satisfies the Provider interface.
+func (p *Provider) TrackersRemoveIDs(ctx context.Context, hash string, ids ...int64) error {
+	trackers, err := p.trackers(ctx, hash)
+	if err != nil {
+		return err
+	}
+	var urls []string
+	for _, id := range ids {
+		if id < 0 || id >= int64(len(trackers)) {
+			return fmt.Errorf("could not remove tracker %d from %s: invalid id", id, hash)
+		}
+		urls = append(urls, trackers[id].URL)
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	if err := qbtweb.TorrentsRemoveTrackers(hash, urls...).Do(ctx, p.cl); err != nil {
+		return fmt.Errorf("could not remove trackers %s from %s: %w", strings.Join(urls, ", "), hash, err)
+	}
+	return nil
+}
+
+// trackersEach calls f with the hash of each of the torrents having the
+// tracker.
+func (p *Provider) trackersEach(ctx context.Context, tracker string, ids []interface{}, f func(string) error) error {
+	for _, hash := range toHashes(ids) {
+		trackers, err := p.trackers(ctx, hash)
+		if err != nil {
+			return err
+		}
+		for _, v := range trackers {
+			if v.URL != tracker {
+				continue
+			}
+			if err := f(hash); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// LabelsAdd satisfies the Provider interface.
+func (p *Provider) LabelsAdd(ctx context.Context, labels []string, ids ...interface{}) error {
+	return qbtweb.TorrentsAddTags(toHashes(ids)...).WithTags(labels).Do(ctx, p.cl)
+}
+
+// LabelsRemove satisfies the Provider interface.
+func (p *Provider) LabelsRemove(ctx context.Context, labels []string, ids ...interface{}) error {
+	return qbtweb.TorrentsRemoveTags(toHashes(ids)...).WithTags(labels).Do(ctx, p.cl)
+}
+
+// LabelsSet satisfies the Provider interface.
+func (p *Provider) LabelsSet(ctx context.Context, labels []string, ids ...interface{}) error {
+	torrents, err := p.Get(ctx, nil, ids...)
+	if err != nil {
+		return err
+	}
+	for _, t := range torrents {
+		var remove []string
+		for _, label := range t.Labels {
+			if !contains(labels, label) {
+				remove = append(remove, label)
+			}
+		}
+		if len(remove) != 0 {
+			if err := qbtweb.TorrentsRemoveTags(t.HashString).WithTags(remove).Do(ctx, p.cl); err != nil {
+				return err
+			}
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return p.LabelsAdd(ctx, labels, ids...)
+}
+
+// CategoriesGet satisfies the Provider interface.
+func (p *Provider) CategoriesGet(ctx context.Context) ([]tctypes.Category, error) {
+	res, err := qbtweb.TorrentsCategories().Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var categories []tctypes.Category
+	for name, v := range res {
+		categories = append(categories, tctypes.Category{
+			Name:     name,
+			SavePath: v.SavePath,
+		})
+	}
+	return categories, nil
+}
+
+// CategoriesCreate satisfies the Provider interface.
+func (p *Provider) CategoriesCreate(ctx context.Context, name, savePath string) error {
+	return qbtweb.TorrentsCreateCategory(name, savePath).Do(ctx, p.cl)
+}
+
+// CategoriesEdit satisfies the Provider interface.
+func (p *Provider) CategoriesEdit(ctx context.Context, name, savePath string) error {
+	return qbtweb.TorrentsEditCategory(name, savePath).Do(ctx, p.cl)
+}
+
+// CategoriesDelete satisfies the Provider interface.
+func (p *Provider) CategoriesDelete(ctx context.Context, names ...string) error {
+	return qbtweb.TorrentsRemoveCategories(names...).Do(ctx, p.cl)
+}
+
+// Stats satisfies the Provider interface.
+func (p *Provider) Stats(ctx context.Context) (map[string]interface{}, error) {
+	res, err := qbtweb.TransferInfo().Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	torrents, err := p.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var active, paused int64
+	for _, t := range torrents {
+		switch {
+		case t.Status == tctypes.StatusStopped:
+			paused++
+		case t.RateDownload != 0 || t.RateUpload != 0:
+			active++
+		}
+	}
+	return map[string]interface{}{
+		"active-torrent-count":           active,
+		"download-speed":                 res.DlInfoSpeed,
+		"paused-torrent-count":           paused,
+		"torrent-count":                  int64(len(torrents)),
+		"upload-speed":                   res.UpInfoSpeed,
+		"current-stats.uploaded-bytes":   res.UpInfoData,
+		"current-stats.downloaded-bytes": res.DlInfoData,
+		"connection-status":              string(res.ConnectionStatus),
+		"dht-nodes":                      res.DhtNodes,
+	}, nil
+}
+
+// Shutdown satisfies the Provider interface.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return qbtweb.AppShutdown().Do(ctx, p.cl)
+}
+
+// FreeSpace satisfies the Provider interface.
+func (p *Provider) FreeSpace(context.Context, string) (tctypes.ByteCount, error) {
+	return 0, fmt.Errorf("free-space %w", providers.ErrNotSupportedByProvider)
+}
+
+// BlocklistUpdate satisfies the Provider interface.
+func (p *Provider) BlocklistUpdate(context.Context) (int64, error) {
+	return 0, fmt.Errorf("blocklist-update %w", providers.ErrNotSupportedByProvider)
+}
+
+// PortTest satisfies the Provider interface.
+func (p *Provider) PortTest(context.Context) (bool, error) {
+	return false, fmt.Errorf("port-test %w", providers.ErrNotSupportedByProvider)
+}
+
+// AltSpeedSet satisfies the LimitsProvider interface.
+func (p *Provider) AltSpeedSet(ctx context.Context, enabled bool) error {
+	// speed limits mode can only be toggled
+	mode, err := qbtweb.TransferSpeedLimitsMode().Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	if mode == enabled {
+		return nil
+	}
+	return qbtweb.TransferToggleSpeedLimitsMode().Do(ctx, p.cl)
+}
+
+// LimitsGet satisfies the LimitsProvider interface.
+func (p *Provider) LimitsGet(ctx context.Context) (tctypes.SpeedLimits, error) {
+	prefs, err := qbtweb.AppPreferences().Do(ctx, p.cl)
+	if err != nil {
+		return tctypes.SpeedLimits{}, err
+	}
+	mode, err := qbtweb.TransferSpeedLimitsMode().Do(ctx, p.cl)
+	if err != nil {
+		return tctypes.SpeedLimits{}, err
+	}
+	return tctypes.SpeedLimits{
+		DownloadLimit:    kiLimitRate(prefs.DlLimit),
+		DownloadLimited:  prefs.DlLimit > 0,
+		UploadLimit:      kiLimitRate(prefs.UpLimit),
+		UploadLimited:    prefs.UpLimit > 0,
+		AltEnabled:       mode,
+		AltDownloadLimit: kiLimitRate(prefs.AltDlLimit),
+		AltUploadLimit:   kiLimitRate(prefs.AltUpLimit),
+		ScheduleEnabled:  prefs.SchedulerEnabled,
+		ScheduleBegin:    tctypes.TimeOfDay(prefs.ScheduleFromHour*60 + prefs.ScheduleFromMin),
+		ScheduleEnd:      tctypes.TimeOfDay(prefs.ScheduleToHour*60 + prefs.ScheduleToMin),
+		ScheduleDays:     convertDaySchedule(prefs.SchedulerDays),
+	}, nil
+}
+
+// LimitsSet satisfies the LimitsProvider interface.
+func (p *Provider) LimitsSet(ctx context.Context, limits tctypes.SpeedLimits) error {
+	days, err := toDaySchedule(limits.ScheduleDays)
+	if err != nil {
+		return err
+	}
+	var dl, ul qbtweb.KiLimit
+	if limits.DownloadLimited {
+		dl = toKiLimit(limits.DownloadLimit)
+	}
+	if limits.UploadLimited {
+		ul = toKiLimit(limits.UploadLimit)
+	}
+	if err := qbtweb.AppSetPreferences().
+		WithDlLimit(dl).
+		WithUpLimit(ul).
+		WithAltDlLimit(toKiLimit(limits.AltDownloadLimit)).
+		WithAltUpLimit(toKiLimit(limits.AltUploadLimit)).
+		WithSchedulerEnabled(limits.ScheduleEnabled).
+		WithScheduleFromHour(int64(limits.ScheduleBegin/60)).
+		WithScheduleFromMin(int64(limits.ScheduleBegin%60)).
+		WithScheduleToHour(int64(limits.ScheduleEnd/60)).
+		WithScheduleToMin(int64(limits.ScheduleEnd%60)).
+		WithSchedulerDays(days).
+		Do(ctx, p.cl); err != nil {
+		return err
+	}
+	return p.AltSpeedSet(ctx, limits.AltEnabled)
+}
+
+// TorrentLimitsSet satisfies the LimitsProvider interface.
+//
+// qBittorrent's global speed limits always apply, so torrent speed limits
+// cannot be unlimited. Share limits are set together, so unchanged share
+// limits are read from the torrents.
+func (p *Provider) TorrentLimitsSet(ctx context.Context, limits tctypes.TorrentLimits, ids ...interface{}) error {
+	hashes := toHashes(ids)
+	var dl, ul *qbtweb.Rate
+	var err error
+	if limits.DownloadMode != nil {
+		if dl, err = toTorrentRate(*limits.DownloadMode, limits.DownloadLimit); err != nil {
+			return err
+		}
+	}
+	if limits.UploadMode != nil {
+		if ul, err = toTorrentRate(*limits.UploadMode, limits.UploadLimit); err != nil {
+			return err
+		}
+	}
+
+	// share limits
+	if limits.RatioMode != nil || limits.IdleMode != nil || limits.SeedingTimeMode != nil {
+		if err := p.shareLimitsSet(ctx, limits, hashes); err != nil {
+			return err
+		}
+	}
+
+	// speed limits
+	if dl != nil {
+		if err := qbtweb.TorrentsSetDownloadLimit(*dl, hashes...).Do(ctx, p.cl); err != nil {
+			return err
+		}
+	}
+	if ul != nil {
+		if err := qbtweb.TorrentsSetUploadLimit(*ul, hashes...).Do(ctx, p.cl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shareLimitsSet sets the share limits for the hashes, using each torrent's
+// current share limits for unchanged limits.
+func (p *Provider) shareLimitsSet(ctx context.Context, limits tctypes.TorrentLimits, hashes []string) error {
+	req := qbtweb.TorrentsSetShareLimits(hashes...)
+	if limits.RatioMode != nil && limits.IdleMode != nil && limits.SeedingTimeMode != nil {
+		return applyShareLimits(req, limits).Do(ctx, p.cl)
+	}
+	res, err := qbtweb.TorrentsInfo().Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range res {
+		if !contains(hashes, strings.ToLower(t.Hash)) {
+			continue
+		}
+		req := qbtweb.TorrentsSetShareLimits(t.Hash).
+			WithRatioLimit(t.RatioLimit).
+			WithSeedingTimeLimit(t.SeedingTimeLimit).
+			WithInactiveSeedingTimeLimit(t.InactiveSeedingTimeLimit)
+		if err := applyShareLimits(req, limits).Do(ctx, p.cl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ModeSet satisfies the ModeProvider interface.
+//
+// Sequential download and first and last piece priority can only be toggled,
+// so only the torrents not already in the mode state are toggled.
+func (p *Provider) ModeSet(ctx context.Context, mode tctypes.DownloadMode, on bool, ids ...interface{}) error {
+	hashes := toHashes(ids)
+	switch mode {
+	case tctypes.DownloadModeForceStart:
+		return qbtweb.TorrentsSetForceStart(on, hashes...).Do(ctx, p.cl)
+	case tctypes.DownloadModeSuperSeed:
+		return qbtweb.TorrentsSetSuperSeeding(on, hashes...).Do(ctx, p.cl)
+	case tctypes.DownloadModeSequential, tctypes.DownloadModeFirstLast:
+	default:
+		return fmt.Errorf("download mode %v %w", mode, providers.ErrNotSupportedByProvider)
+	}
+	res, err := qbtweb.TorrentsInfo().Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	var toggle []string
+	for _, t := range res {
+		state := t.SeqDl
+		if mode == tctypes.DownloadModeFirstLast {
+			state = t.FLPiecePrio
+		}
+		if state != on && contains(hashes, strings.ToLower(t.Hash)) {
+			toggle = append(toggle, t.Hash)
+		}
+	}
+	switch {
+	case len(toggle) == 0:
+		return nil
+	case mode == tctypes.DownloadModeFirstLast:
+		return qbtweb.TorrentsToggleFirstLastPiecePrio(toggle...).Do(ctx, p.cl)
+	}
+	return qbtweb.TorrentsToggleSequentialDownload(toggle...).Do(ctx, p.cl)
+}
+
+// LogGet satisfies the LogProvider interface.
+func (p *Provider) LogGet(ctx context.Context, level tctypes.LogLevel, lastKnownID int64) ([]tctypes.LogEntry, error) {
+	res, err := qbtweb.LogMain().
+		WithNormal(level <= tctypes.LogLevelNormal).
+		WithInfo(level <= tctypes.LogLevelInfo).
+		WithWarning(level <= tctypes.LogLevelWarning).
+		WithLastKnownID(lastKnownID).
+		Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]tctypes.LogEntry, len(res))
+	for i, v := range res {
+		entries[i] = tctypes.LogEntry{
+			ID:      v.ID,
+			Time:    v.Timestamp,
+			Level:   convertLogType(v.Type),
+			Message: v.Message,
+		}
+	}
+	return entries, nil
+}
+
+// LogPeersGet satisfies the LogProvider interface.
+func (p *Provider) LogPeersGet(ctx context.Context, lastKnownID int64) ([]tctypes.PeerLogEntry, error) {
+	res, err := qbtweb.LogPeers().WithLastKnownID(lastKnownID).Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]tctypes.PeerLogEntry, len(res))
+	for i, v := range res {
+		entries[i] = tctypes.PeerLogEntry{
+			ID:      v.ID,
+			Time:    v.Timestamp,
+			Address: v.Ip,
+			Blocked: v.Blocked,
+			Reason:  v.Reason,
+		}
+	}
+	return entries, nil
+}
+
+// NewRemoteConfigStore satisfies the Provider interface.
+func (p *Provider) NewRemoteConfigStore(context.Context) (providers.ConfigStore, error) {
+	return nil, fmt.Errorf("remote config %w", providers.ErrNotSupportedByProvider)
+}
@@ -0,0 +1,391 @@
+package qbittorrent
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
+	"github.com/knq/ini"
+)
+
+const (
+	hash1 = "0123456789abcdef0123456789abcdef01234567"
+	hash2 = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestAddGet(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	ctx := context.Background()
+	added, err := p.Add(ctx, providers.AddOptions{}, "magnet:?xt=urn:btih:"+hash1+"&dn=one", "magnet:?xt=urn:btih:"+hash2+"&dn=two")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(added) != 2 {
+		t.Fatalf("expected 2 added torrents, got: %d", len(added))
+	}
+	if n := len(q.Torrents()); n != 2 {
+		t.Fatalf("expected 2 torrents on remote host, got: %d", n)
+	}
+
+	// get all
+	torrents, err := p.Get(ctx, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("expected 2 torrents, got: %d", len(torrents))
+	}
+	for i, exp := range []struct {
+		hash, name string
+	}{
+		{hash1, "one"},
+		{hash2, "two"},
+	} {
+		if id := torrents[i].ID; id != int64(i+1) {
+			t.Errorf("torrent %d expected id %d, got: %d", i, i+1, id)
+		}
+		if s := torrents[i].HashString; s != exp.hash {
+			t.Errorf("torrent %d expected hash %q, got: %q", i, exp.hash, s)
+		}
+		if s := torrents[i].Name; s != exp.name {
+			t.Errorf("torrent %d expected name %q, got: %q", i, exp.name, s)
+		}
+		if s := torrents[i].Status; s != tctypes.StatusDownloading {
+			t.Errorf("torrent %d expected status %v, got: %v", i, tctypes.StatusDownloading, s)
+		}
+	}
+
+	// get by hash
+	torrents, err = p.Get(ctx, nil, hash2)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].HashString != hash2 || torrents[0].ID != 2 {
+		t.Errorf("expected torrent 2 (%s), got: %v", hash2, torrents)
+	}
+}
+
+func TestStartStopMoveRemove(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	q.AddTorrent(hash1, "one")
+	q.AddTorrent(hash2, "two")
+	ctx := context.Background()
+
+	// stop
+	if err := p.Stop(ctx, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusStopped, tctypes.StatusDownloading)
+
+	// start
+	if err := p.Start(ctx, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusDownloading, tctypes.StatusDownloading)
+
+	// verify
+	if err := p.Verify(ctx, hash2); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkStatus(t, p, tctypes.StatusDownloading, tctypes.StatusChecking)
+
+	// move
+	if err := p.Move(ctx, "/data/", hash1, hash2); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i, torrent := range q.Torrents() {
+		if s := torrent["save_path"]; s != "/data/" {
+			t.Errorf("torrent %d expected save path /data/, got: %v", i, s)
+		}
+	}
+
+	// remove
+	if err := p.Remove(ctx, true, hash1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	torrents := q.Torrents()
+	if len(torrents) != 1 || torrents[0]["hash"] != hash2 {
+		t.Errorf("expected only %s to remain, got: %v", hash2, torrents)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	q.AddTorrent(hash1, "one")
+	q.AddTorrent(hash2, "two")
+	ctx := context.Background()
+	tests := []struct {
+		f   func() error
+		exp [][]string
+	}{
+		{func() error { return p.LabelsAdd(ctx, []string{"tv", "hd"}, hash1, hash2) }, [][]string{{"hd", "tv"}, {"hd", "tv"}}},
+		{func() error { return p.LabelsRemove(ctx, []string{"hd"}, hash1) }, [][]string{{"tv"}, {"hd", "tv"}}},
+		{func() error { return p.LabelsSet(ctx, []string{"movies", "tv"}, hash2) }, [][]string{{"tv"}, {"movies", "tv"}}},
+		{func() error { return p.LabelsSet(ctx, nil, hash1) }, [][]string{nil, {"movies", "tv"}}},
+	}
+	for i, test := range tests {
+		if err := test.f(); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		torrents, err := p.Get(ctx, nil)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		for j, exp := range test.exp {
+			if s := strings.Join(torrents[j].Labels, ","); s != strings.Join(exp, ",") {
+				t.Errorf("test %d torrent %d expected labels %v, got: %v", i, j, exp, torrents[j].Labels)
+			}
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	ctx := context.Background()
+	if err := p.CategoriesCreate(ctx, "tv", "/data/tv"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := p.CategoriesCreate(ctx, "movies", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := p.CategoriesCreate(ctx, "tv", ""); err == nil {
+		t.Errorf("expected error creating existing category")
+	}
+	if err := p.CategoriesEdit(ctx, "movies", "/data/movies"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := p.CategoriesEdit(ctx, "music", "/data/music"); err == nil {
+		t.Errorf("expected error editing missing category")
+	}
+	checkCategories(t, p, "movies:/data/movies", "tv:/data/tv")
+	if err := p.CategoriesDelete(ctx, "tv", "movies"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkCategories(t, p)
+}
+
+func TestStats(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	q.AddTorrent(hash1, "one")
+	q.AddTorrent(hash2, "two")
+	ctx := context.Background()
+	if err := p.Stop(ctx, hash2); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	stats, err := p.Stats(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for k, exp := range map[string]interface{}{
+		"torrent-count":        int64(2),
+		"paused-torrent-count": int64(1),
+		"active-torrent-count": int64(0),
+		"connection-status":    "connected",
+	} {
+		if v := stats[k]; v != exp {
+			t.Errorf("expected %s to be %v, got: %v", k, exp, v)
+		}
+	}
+}
+
+func TestPeers(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	q.AddTorrent(hash1, "one")
+	q.AddPeer(hash1, "10.0.0.2:51413", "Transmission 3.00", "μTP", "D E")
+	q.AddPeer(hash1, "10.0.0.1:6881", "qBittorrent 4.3.1", "BT", "I")
+	ctx := context.Background()
+	peers, err := p.PeersGet(ctx, hash1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 peers, got: %d", len(peers))
+	}
+	for i, exp := range []tctypes.Peer{
+		{Address: "10.0.0.1", Port: 6881, ClientName: "qBittorrent 4.3.1", FlagStr: "I", IsIncoming: true},
+		{Address: "10.0.0.2", Port: 51413, ClientName: "Transmission 3.00", FlagStr: "DE", IsEncrypted: true, IsUTP: true},
+	} {
+		peer := peers[i]
+		if peer.Address != exp.Address || peer.Port != exp.Port || peer.ClientName != exp.ClientName {
+			t.Errorf("peer %d expected %s:%d (%s), got: %s:%d (%s)", i, exp.Address, exp.Port, exp.ClientName, peer.Address, peer.Port, peer.ClientName)
+		}
+		if peer.FlagStr != exp.FlagStr || peer.IsIncoming != exp.IsIncoming || peer.IsEncrypted != exp.IsEncrypted || peer.IsUTP != exp.IsUTP {
+			t.Errorf("peer %d expected flags %q (incoming: %t, encrypted: %t, utp: %t), got: %q (incoming: %t, encrypted: %t, utp: %t)", i, exp.FlagStr, exp.IsIncoming, exp.IsEncrypted, exp.IsUTP, peer.FlagStr, peer.IsIncoming, peer.IsEncrypted, peer.IsUTP)
+		}
+		if peer.HashString != hash1 || peer.Torrent != "one" {
+			t.Errorf("peer %d expected torrent %s (one), got: %s (%s)", i, hash1, peer.HashString, peer.Torrent)
+		}
+	}
+	if err := p.PeersBan(ctx, "10.0.0.2:51413"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if banned := q.BannedPeers(); len(banned) != 1 || banned[0] != "10.0.0.2:51413" {
+		t.Errorf("expected 10.0.0.2:51413 to be banned, got: %v", banned)
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	ctx := context.Background()
+	_, err1 := p.FreeSpace(ctx, "/")
+	_, err2 := p.BlocklistUpdate(ctx)
+	_, err3 := p.PortTest(ctx)
+	_, err4 := p.NewRemoteConfigStore(ctx)
+	for i, err := range []error{
+		p.Set(ctx, map[string]interface{}{"name": "v"}, hash1),
+		p.FilesRename(ctx, "a", "b", hash1),
+		err1, err2, err3, err4,
+	} {
+		if !errors.Is(err, providers.ErrNotSupportedByProvider) {
+			t.Errorf("test %d expected not supported error, got: %v", i, err)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	var v interface{} = p
+	if _, ok := v.(providers.LimitsProvider); !ok {
+		t.Errorf("expected provider to support limits")
+	}
+	if _, ok := v.(providers.ModeProvider); !ok {
+		t.Errorf("expected provider to support download modes")
+	}
+	if _, ok := v.(providers.LogProvider); !ok {
+		t.Errorf("expected provider to support logs")
+	}
+	if _, ok := v.(providers.RSSProvider); !ok {
+		t.Errorf("expected provider to support rss")
+	}
+	if _, ok := v.(providers.SearchProvider); !ok {
+		t.Errorf("expected provider to support search")
+	}
+}
+
+// checkStatus checks the status of the torrents on the remote host.
+func checkStatus(t *testing.T, p *Provider, exp ...tctypes.Status) {
+	t.Helper()
+	torrents, err := p.Get(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != len(exp) {
+		t.Fatalf("expected %d torrents, got: %d", len(exp), len(torrents))
+	}
+	for i, status := range exp {
+		if s := torrents[i].Status; s != status {
+			t.Errorf("torrent %d expected status %v, got: %v", i, status, s)
+		}
+	}
+}
+
+// checkCategories checks the categories on the remote host.
+func checkCategories(t *testing.T, p *Provider, exp ...string) {
+	t.Helper()
+	categories, err := p.CategoriesGet(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var s []string
+	for _, category := range categories {
+		s = append(s, category.Name+":"+category.SavePath)
+	}
+	sort.Strings(s)
+	if strings.Join(s, " ") != strings.Join(exp, " ") {
+		t.Errorf("expected categories %v, got: %v", exp, s)
+	}
+}
+
+// newTestProvider creates a fake qBittorrent server, and a provider for it.
+// The caller must close the server.
+func newTestProvider(t *testing.T) (*transctltest.QBittorrent, *Provider) {
+	t.Helper()
+	q := transctltest.NewQBittorrent()
+	u, err := url.Parse(q.URL + "/api/v2")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	u.User = url.UserPassword("admin", "adminadmin")
+	args := &providers.Args{Config: ini.NewFile()}
+	args.Host.URL = u
+	args.Host.Timeout = 10 * time.Second
+	args.Host.NoNetrc = true
+	args.Host.Strict = true
+	p, err := New(args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return q, p.(*Provider)
+}
+
+func TestModeSet(t *testing.T) {
+	q, p := newTestProvider(t)
+	defer q.Close()
+	q.AddTorrent(hash1, "one")
+	q.AddTorrent(hash2, "two")
+	ctx := context.Background()
+	tests := []struct {
+		mode   tctypes.DownloadMode
+		on     bool
+		method string
+		key    string
+	}{
+		{tctypes.DownloadModeSequential, true, "torrents/toggleSequentialDownload", "seq_dl"},
+		{tctypes.DownloadModeFirstLast, true, "torrents/toggleFirstLastPiecePrio", "f_l_piece_prio"},
+		{tctypes.DownloadModeForceStart, true, "torrents/setForceStart", "force_start"},
+		{tctypes.DownloadModeSuperSeed, true, "torrents/setSuperSeeding", "super_seeding"},
+		{tctypes.DownloadModeSequential, false, "torrents/toggleSequentialDownload", "seq_dl"},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			// setting twice only toggles once, as the state is read before
+			// toggling
+			n := len(q.Requests())
+			for i := 0; i < 2; i++ {
+				if err := p.ModeSet(ctx, test.mode, test.on, hash1); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+			var count int
+			var read bool
+			for _, method := range q.Requests()[n:] {
+				switch {
+				case method == "torrents/info":
+					read = true
+				case method == test.method:
+					if !read && strings.Contains(method, "toggle") {
+						t.Errorf("expected state to be read before %s", method)
+					}
+					count++
+				}
+			}
+			switch {
+			case strings.Contains(test.method, "toggle") && count != 1:
+				t.Errorf("expected 1 %s request, got: %d", test.method, count)
+			case count == 0:
+				t.Errorf("expected %s request", test.method)
+			}
+			for _, torrent := range q.Torrents() {
+				exp := test.on && torrent["hash"] == hash1
+				if v := torrent[test.key]; v != exp {
+					t.Errorf("torrent %v expected %s %t, got: %v", torrent["hash"], test.key, exp, v)
+				}
+			}
+		})
+	}
+}
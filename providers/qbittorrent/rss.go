@@ -0,0 +1,145 @@
+package qbittorrent
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/kenshaw/transctl/qbtweb"
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// rssPathSeparator is the separator used in rss item paths.
+const rssPathSeparator = `\`
+
+// RSSFeedsGet satisfies the RSSProvider interface.
+func (p *Provider) RSSFeedsGet(ctx context.Context, withArticles bool) ([]tctypes.RSSFeed, error) {
+	res, err := qbtweb.RssItems(withArticles).Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var feeds []tctypes.RSSFeed
+	if err := appendRSSFeeds(&feeds, "", res); err != nil {
+		return nil, err
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		return feeds[i].Path < feeds[j].Path
+	})
+	return feeds, nil
+}
+
+// appendRSSFeeds flattens the nested rss items, appending the feeds.
+func appendRSSFeeds(feeds *[]tctypes.RSSFeed, prefix string, items map[string]interface{}) error {
+	for name, v := range items {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := prefix + name
+		if !isRSSFeed(item) {
+			if err := appendRSSFeeds(feeds, path+rssPathSeparator, item); err != nil {
+				return err
+			}
+			continue
+		}
+		// round trip the item to decode it
+		buf, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		var feed tctypes.RSSFeed
+		if err := json.Unmarshal(buf, &feed); err != nil {
+			return err
+		}
+		feed.Path = path
+		*feeds = append(*feeds, feed)
+	}
+	return nil
+}
+
+// isRSSFeed determines if the rss item is a feed (and not a folder).
+func isRSSFeed(item map[string]interface{}) bool {
+	_, uid := item["uid"].(string)
+	_, url := item["url"].(string)
+	return uid && url
+}
+
+// RSSFeedAdd satisfies the RSSProvider interface.
+func (p *Provider) RSSFeedAdd(ctx context.Context, urlstr, path string) error {
+	return qbtweb.RssAddFeed(urlstr).WithPath(path).Do(ctx, p.cl)
+}
+
+// RSSFolderAdd satisfies the RSSProvider interface.
+func (p *Provider) RSSFolderAdd(ctx context.Context, path string) error {
+	return qbtweb.RssAddFolder(path).Do(ctx, p.cl)
+}
+
+// RSSItemRemove satisfies the RSSProvider interface.
+func (p *Provider) RSSItemRemove(ctx context.Context, path string) error {
+	return qbtweb.RssRemoveItem(path).Do(ctx, p.cl)
+}
+
+// RSSItemMove satisfies the RSSProvider interface.
+func (p *Provider) RSSItemMove(ctx context.Context, path, dest string) error {
+	return qbtweb.RssMoveItem(path, dest).Do(ctx, p.cl)
+}
+
+// RSSRulesGet satisfies the RSSProvider interface.
+func (p *Provider) RSSRulesGet(ctx context.Context) ([]tctypes.RSSRule, error) {
+	res, err := qbtweb.RssRules().Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]tctypes.RSSRule, 0, len(res))
+	for name, v := range res {
+		rules = append(rules, tctypes.RSSRule{
+			Name:                      name,
+			Enabled:                   v.Enabled,
+			MustContain:               v.MustContain,
+			MustNotContain:            v.MustNotContain,
+			UseRegex:                  v.UseRegex,
+			EpisodeFilter:             v.EpisodeFilter,
+			SmartFilter:               v.SmartFilter,
+			PreviouslyMatchedEpisodes: v.PreviouslyMatchedEpisodes,
+			AffectedFeeds:             v.AffectedFeeds,
+			IgnoreDays:                v.IgnoreDays,
+			LastMatch:                 v.LastMatch,
+			AddPaused:                 v.AddPaused,
+			AssignedCategory:          v.AssignedCategory,
+			SavePath:                  v.SavePath,
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// RSSRuleSet satisfies the RSSProvider interface.
+func (p *Provider) RSSRuleSet(ctx context.Context, rule tctypes.RSSRule) error {
+	return qbtweb.RssSetRule(rule.Name, qbtweb.Rule{
+		Enabled:                   rule.Enabled,
+		MustContain:               rule.MustContain,
+		MustNotContain:            rule.MustNotContain,
+		UseRegex:                  rule.UseRegex,
+		EpisodeFilter:             rule.EpisodeFilter,
+		SmartFilter:               rule.SmartFilter,
+		PreviouslyMatchedEpisodes: rule.PreviouslyMatchedEpisodes,
+		AffectedFeeds:             rule.AffectedFeeds,
+		IgnoreDays:                rule.IgnoreDays,
+		LastMatch:                 rule.LastMatch,
+		AddPaused:                 rule.AddPaused,
+		AssignedCategory:          rule.AssignedCategory,
+		SavePath:                  rule.SavePath,
+	}).Do(ctx, p.cl)
+}
+
+// RSSRuleRename satisfies the RSSProvider interface.
+func (p *Provider) RSSRuleRename(ctx context.Context, name, newName string) error {
+	return qbtweb.RssRenameRule(name, newName).Do(ctx, p.cl)
+}
+
+// RSSRuleRemove satisfies the RSSProvider interface.
+func (p *Provider) RSSRuleRemove(ctx context.Context, name string) error {
+	return qbtweb.RssRemoveRule(name).Do(ctx, p.cl)
+}
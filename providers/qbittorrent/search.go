@@ -0,0 +1,111 @@
+package qbittorrent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kenshaw/transctl/qbtweb"
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// SearchStart satisfies the SearchProvider interface.
+func (p *Provider) SearchStart(ctx context.Context, pattern string, plugins []string, category string) (int64, error) {
+	res, err := qbtweb.SearchStart().
+		WithPattern(pattern).
+		WithPlugins(plugins).
+		WithCategory(category).
+		Do(ctx, p.cl)
+	if err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// SearchStatus satisfies the SearchProvider interface.
+func (p *Provider) SearchStatus(ctx context.Context, id int64) (bool, int64, error) {
+	res, err := qbtweb.SearchStatus(id).Do(ctx, p.cl)
+	if err != nil {
+		return false, 0, err
+	}
+	for _, status := range res {
+		if status.ID == id {
+			return status.Status == "Running", status.Total, nil
+		}
+	}
+	return false, 0, fmt.Errorf("search %d not found", id)
+}
+
+// SearchResults satisfies the SearchProvider interface.
+func (p *Provider) SearchResults(ctx context.Context, id int64) ([]tctypes.SearchResult, error) {
+	res, err := qbtweb.SearchResults(id).Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]tctypes.SearchResult, len(res.Results))
+	for i, r := range res.Results {
+		results[i] = tctypes.SearchResult{
+			Name:     r.FileName,
+			Size:     tctypes.ByteCount(r.FileSize),
+			Seeders:  r.NbSeeders,
+			Leechers: r.NbLeechers,
+			Site:     r.SiteUrl,
+			Link:     r.DescrLink,
+			URL:      r.FileUrl,
+		}
+	}
+	return results, nil
+}
+
+// SearchStop satisfies the SearchProvider interface.
+func (p *Provider) SearchStop(ctx context.Context, id int64) error {
+	return qbtweb.SearchStop(id).Do(ctx, p.cl)
+}
+
+// SearchDelete satisfies the SearchProvider interface.
+func (p *Provider) SearchDelete(ctx context.Context, id int64) error {
+	return qbtweb.SearchDelete(id).Do(ctx, p.cl)
+}
+
+// SearchPluginsGet satisfies the SearchProvider interface.
+func (p *Provider) SearchPluginsGet(ctx context.Context) ([]tctypes.SearchPlugin, error) {
+	res, err := qbtweb.SearchPlugins().Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	plugins := make([]tctypes.SearchPlugin, len(res))
+	for i, plugin := range res {
+		plugins[i] = tctypes.SearchPlugin{
+			Name:       plugin.Name,
+			FullName:   plugin.FullName,
+			Version:    plugin.Version,
+			Enabled:    plugin.Enabled,
+			URL:        plugin.URL,
+			Categories: plugin.SupportedCategories,
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins, nil
+}
+
+// SearchPluginsInstall satisfies the SearchProvider interface.
+func (p *Provider) SearchPluginsInstall(ctx context.Context, sources ...string) error {
+	return qbtweb.SearchInstallPlugin(sources...).Do(ctx, p.cl)
+}
+
+// SearchPluginsUninstall satisfies the SearchProvider interface.
+func (p *Provider) SearchPluginsUninstall(ctx context.Context, names ...string) error {
+	return qbtweb.SearchUninstallPlugin(names...).Do(ctx, p.cl)
+}
+
+// SearchPluginsEnable satisfies the SearchProvider interface.
+func (p *Provider) SearchPluginsEnable(ctx context.Context, enable bool, names ...string) error {
+	return qbtweb.SearchEnablePlugin(enable, names...).Do(ctx, p.cl)
+}
+
+// SearchPluginsUpdate satisfies the SearchProvider interface.
+func (p *Provider) SearchPluginsUpdate(ctx context.Context) error {
+	return qbtweb.SearchUpdatePlugins().Do(ctx, p.cl)
+}
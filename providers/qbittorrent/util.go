@@ -0,0 +1,265 @@
+package qbittorrent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/qbtweb"
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// etaInfinity is the eta qBittorrent reports when the eta is unknown.
+const etaInfinity = 8640000 * time.Second
+
+// convertTorrent converts a qBittorrent torrent to a torrent.
+func convertTorrent(id int64, t qbtweb.Torrent) tctypes.Torrent {
+	torrent := tctypes.Torrent{
+		ActivityDate:       t.LastActivity,
+		AddedDate:          t.AddedOn,
+		DoneDate:           t.CompletionOn,
+		DownloadDir:        t.SavePath,
+		DownloadedEver:     t.Downloaded,
+		DownloadLimit:      tctypes.Limit(t.DlLimit / 1000),
+		DownloadLimited:    t.DlLimit > 0,
+		Eta:                t.Eta,
+		HashString:         strings.ToLower(t.Hash),
+		HaveValid:          t.Completed,
+		ID:                 id,
+		IsFinished:         t.Progress >= 1,
+		LeftUntilDone:      t.AmountLeft,
+		MagnetLink:         t.MagnetURI,
+		Name:               t.Name,
+		PeersConnected:     t.NumSeeds + t.NumLeechs,
+		PeersGettingFromUs: t.NumLeechs,
+		PeersSendingToUs:   t.NumSeeds,
+		PercentDone:        t.Progress,
+		QueuePosition:      t.Priority,
+		RateDownload:       t.Dlspeed,
+		RateUpload:         t.Upspeed,
+		SeedRatioLimit:     float64(t.RatioLimit),
+		SequentialDownload: t.SeqDl,
+		SizeWhenDone:       t.Size,
+		Status:             convertState(t.State, t.Progress),
+		TotalSize:          t.TotalSize,
+		UploadedEver:       t.Uploaded,
+		UploadLimit:        tctypes.Limit(t.UpLimit / 1000),
+		UploadLimited:      t.UpLimit > 0,
+		UploadRatio:        float64(t.Ratio),
+	}
+	switch {
+	case time.Duration(t.Eta) >= etaInfinity:
+		torrent.Eta = tctypes.Duration(-2 * time.Second)
+	case t.Progress >= 1:
+		torrent.Eta = tctypes.Duration(-1 * time.Second)
+	}
+	if t.State == qbtweb.StateError || t.State == qbtweb.StateMissingFiles {
+		torrent.ErrorString = string(t.State)
+	}
+	for _, tag := range strings.Split(t.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			torrent.Labels = append(torrent.Labels, tag)
+		}
+	}
+	return torrent
+}
+
+// convertState converts a qBittorrent torrent state to a torrent status.
+func convertState(state qbtweb.State, progress tctypes.Percent) tctypes.Status {
+	switch state {
+	case qbtweb.StatePausedUP, qbtweb.StatePausedDL, qbtweb.StateError, qbtweb.StateMissingFiles:
+		return tctypes.StatusStopped
+	case qbtweb.StateCheckingUP, qbtweb.StateCheckingDL, qbtweb.StateCheckingResumeData:
+		return tctypes.StatusChecking
+	case qbtweb.StateQueuedDL:
+		return tctypes.StatusDownloadWait
+	case qbtweb.StateQueuedUP:
+		return tctypes.StatusSeedWait
+	case qbtweb.StateUploading, qbtweb.StateStalledUP, qbtweb.StateForcedUP:
+		return tctypes.StatusSeeding
+	case qbtweb.StateDownloading, qbtweb.StateMetaDL, qbtweb.StateStalledDL, qbtweb.StateForceDL, qbtweb.StateAllocating:
+		return tctypes.StatusDownloading
+	}
+	if progress >= 1 {
+		return tctypes.StatusSeeding
+	}
+	return tctypes.StatusDownloading
+}
+
+// filePriorityString returns the file priority as a string, using the same
+// names as transmission.
+func filePriorityString(priority qbtweb.FilePriority) string {
+	switch priority {
+	case qbtweb.FilePriorityHigh, qbtweb.FilePriorityMaximal:
+		return tctypes.PriorityHigh.String()
+	}
+	return tctypes.PriorityNormal.String()
+}
+
+// trackerState converts a qBittorrent tracker status to a tracker announce
+// state.
+func trackerState(status qbtweb.TrackerStatus) tctypes.State {
+	switch status {
+	case qbtweb.TrackerDisabled:
+		return tctypes.StateInactive
+	case qbtweb.TrackerUpdating:
+		return tctypes.StateActive
+	}
+	return tctypes.StateWaiting
+}
+
+// convertPieceState converts a qBittorrent piece state.
+func convertPieceState(state qbtweb.PieceState) tctypes.PieceState {
+	switch state {
+	case qbtweb.PieceStateDownloading:
+		return tctypes.PieceStateDownloading
+	case qbtweb.PieceStateDone:
+		return tctypes.PieceStateHave
+	}
+	return tctypes.PieceStateMissing
+}
+
+// convertLogType converts a qBittorrent log message type.
+func convertLogType(typ qbtweb.LogType) tctypes.LogLevel {
+	switch typ {
+	case qbtweb.LogInfo:
+		return tctypes.LogLevelInfo
+	case qbtweb.LogWarning:
+		return tctypes.LogLevelWarning
+	case qbtweb.LogCritical:
+		return tctypes.LogLevelCritical
+	}
+	return tctypes.LogLevelNormal
+}
+
+// kiLimitRate converts a KiB/s limit to a rate, treating negative limits as
+// unlimited.
+func kiLimitRate(limit qbtweb.KiLimit) tctypes.Rate {
+	if limit <= 0 {
+		return 0
+	}
+	return tctypes.Rate(limit * 1024)
+}
+
+// toKiLimit converts the rate to a KiB/s limit, rounding up so that non-zero
+// rates are never converted to 0 (unlimited).
+func toKiLimit(rate tctypes.Rate) qbtweb.KiLimit {
+	return qbtweb.KiLimit((rate + 1023) / 1024)
+}
+
+// daySchedules are the qBittorrent day schedules for days.
+var daySchedules = map[qbtweb.DaySchedule]tctypes.Days{
+	qbtweb.EveryDay:       tctypes.DaysAll,
+	qbtweb.EveryWeekday:   tctypes.DaysWeekdays,
+	qbtweb.EveryWeekend:   tctypes.DaysWeekend,
+	qbtweb.EveryMonday:    tctypes.DayMonday,
+	qbtweb.EveryTuesday:   tctypes.DayTuesday,
+	qbtweb.EveryWednesday: tctypes.DayWednesday,
+	qbtweb.EveryThursday:  tctypes.DayThursday,
+	qbtweb.EveryFriday:    tctypes.DayFriday,
+	qbtweb.EverySaturday:  tctypes.DaySaturday,
+	qbtweb.EverySunday:    tctypes.DaySunday,
+}
+
+// convertDaySchedule converts a qBittorrent day schedule to days.
+func convertDaySchedule(schedule qbtweb.DaySchedule) tctypes.Days {
+	if days, ok := daySchedules[schedule]; ok {
+		return days
+	}
+	return tctypes.DaysAll
+}
+
+// toDaySchedule converts days to a qBittorrent day schedule, which only
+// supports every day, weekdays, weekends, or a single day.
+func toDaySchedule(days tctypes.Days) (qbtweb.DaySchedule, error) {
+	if days == 0 {
+		return qbtweb.EveryDay, nil
+	}
+	for schedule, d := range daySchedules {
+		if d == days {
+			return schedule, nil
+		}
+	}
+	return 0, fmt.Errorf("schedule days %s %w", days, providers.ErrNotSupportedByProvider)
+}
+
+// toHashes converts the torrent identifiers to hashes.
+func toHashes(ids []interface{}) []string {
+	hashes := make([]string, len(ids))
+	for i, id := range ids {
+		hashes[i] = fmt.Sprintf("%v", id)
+	}
+	return hashes
+}
+
+// convertPeer converts a qBittorrent torrent peer to a peer.
+//
+// qBittorrent peer flags are space separated, and use 'E' and 'e' for
+// encrypted connections, and 'I' for incoming connections.
+func convertPeer(peer qbtweb.TorrentPeer) tctypes.Peer {
+	flags := strings.Replace(peer.Flags, " ", "", -1)
+	return tctypes.Peer{
+		Address:           peer.IP,
+		ClientName:        peer.Client,
+		FlagStr:           flags,
+		IsDownloadingFrom: peer.DlSpeed > 0,
+		IsEncrypted:       strings.ContainsAny(flags, "Ee"),
+		IsIncoming:        strings.Contains(flags, "I"),
+		IsUploadingTo:     peer.UpSpeed > 0,
+		IsUTP:             strings.HasSuffix(peer.Connection, "TP"),
+		Port:              peer.Port,
+		Progress:          peer.Progress,
+		RateToClient:      peer.DlSpeed,
+		RateToPeer:        peer.UpSpeed,
+		Country:           strings.ToUpper(peer.CountryCode),
+	}
+}
+
+// toTorrentRate converts a torrent speed limit to a qBittorrent torrent speed
+// limit, where 0 uses the global limit.
+func toTorrentRate(mode tctypes.Mode, rate tctypes.Rate) (*qbtweb.Rate, error) {
+	switch mode {
+	case tctypes.ModeGlobal:
+		rate = 0
+	case tctypes.ModeUnlimited:
+		return nil, fmt.Errorf("unlimited torrent speed %w", providers.ErrNotSupportedByProvider)
+	}
+	return &rate, nil
+}
+
+// applyShareLimits applies the changed share limits to the request.
+func applyShareLimits(req *qbtweb.TorrentsSetShareLimitsRequest, limits tctypes.TorrentLimits) *qbtweb.TorrentsSetShareLimitsRequest {
+	if limits.RatioMode != nil {
+		req = req.WithRatioLimit(qbtweb.Percent(toShareLimit(*limits.RatioMode, limits.RatioLimit)))
+	}
+	if limits.SeedingTimeMode != nil {
+		req = req.WithSeedingTimeLimit(int64(toShareLimit(*limits.SeedingTimeMode, limits.SeedingTimeLimit.Minutes())))
+	}
+	if limits.IdleMode != nil {
+		req = req.WithInactiveSeedingTimeLimit(int64(toShareLimit(*limits.IdleMode, limits.IdleLimit.Minutes())))
+	}
+	return req
+}
+
+// toShareLimit converts a share limit to a qBittorrent share limit, where -2
+// uses the global limit and -1 is unlimited.
+func toShareLimit(mode tctypes.Mode, v float64) float64 {
+	switch mode {
+	case tctypes.ModeGlobal:
+		return -2
+	case tctypes.ModeUnlimited:
+		return -1
+	}
+	return v
+}
+
+// contains determines if s contains v.
+func contains(s []string, v string) bool {
+	for _, z := range s {
+		if z == v {
+			return true
+		}
+	}
+	return false
+}
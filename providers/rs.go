@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/kenshaw/transctl/tctypes"
-	"github.com/kenshaw/transctl/transrpc"
 	"github.com/knq/snaker"
 	"github.com/olekukonko/tablewriter"
 	"gopkg.in/yaml.v3"
@@ -69,6 +68,12 @@ type Result struct {
 
 	// noTotals is the no totals output toggle.
 	noTotals bool
+
+	// kind is the versioned output kind.
+	kind string
+
+	// versioned is the versioned json and yaml output toggle.
+	versioned bool
 }
 
 // NewResult creates a new reflection result for v.
@@ -98,8 +103,12 @@ func (res *Result) Encode(w io.Writer) error {
 			return err
 		}
 		f = res.encodeTable(cols...)
+	case res.output == "json" && res.versioned:
+		f = res.encodeVersionedJSON
 	case res.output == "json":
 		f = res.encodeJSON
+	case res.output == "yaml" && res.versioned:
+		f = res.encodeVersionedYaml
 	case res.output == "yaml":
 		f = res.encodeYaml
 	case res.output == "flat":
@@ -160,51 +169,10 @@ func (res *Result) encodeTable(columns ...string) func(w io.Writer) error {
 			return ErrMustSpecifyAtLeastOneOutputColumn
 		}
 
-		// build column mappings
-		inverseCols := make(map[string]string, len(res.columnNames))
-		for k, v := range res.columnNames {
-			inverseCols[v] = k
-		}
-		headers := make([]string, len(cols))
-		colnames := make([]string, len(cols))
-		sortByField := ""
-		sortBy := strings.TrimSpace(res.sortBy)
-		for i := 0; i < len(cols); i++ {
-			if c, ok := inverseCols[cols[i]]; ok {
-				cols[i] = c
-			}
-			headers[i] = cols[i]
-			if h, ok := res.columnNames[cols[i]]; ok {
-				headers[i] = h
-			}
-			headers[i] = strings.ToUpper(strings.ReplaceAll(snaker.CamelToSnake(headers[i]), "_", " "))
-			colnames[i] = snaker.ForceCamelIdentifier(cols[i])
-			if sortBy == cols[i] || strings.EqualFold(sortBy, headers[i]) {
-				sortByField = colnames[i]
-			}
-		}
-
-		// determine sort by and order
-		switch {
-		case sortByField == "" && !res.sortByWasSet:
-			sortByField = colnames[0]
-		case sortByField == "":
-			return ErrSortByNotInColumnList
-		}
-		dir := res.sortOrder
-		if !res.sortOrderWasSet {
-			typ, ok := readFieldOrMethodType(res.res.Type().Elem(), sortByField)
-			if ok {
-				z := reflect.Zero(typ).Interface()
-				if _, ok = z.(tctypes.ByteFormatter); ok {
-					dir = "desc"
-				}
-				if _, ok = z.(Percent); ok {
-					dir = "desc"
-				}
-			}
+		headers, colnames, err := res.sortColumns(cols)
+		if err != nil {
+			return err
 		}
-		res.sort(sortByField, dir == "desc")
 
 		// tablewriter package is temporary until tblfmt is fixed
 		tbl := tablewriter.NewWriter(w)
@@ -225,7 +193,7 @@ func (res *Result) encodeTable(columns ...string) func(w io.Writer) error {
 
 		// process
 		hasTotals := false
-		display, totals := make([]bool, len(cols)), make([]ByteFormatter, len(cols))
+		display, totals := make([]bool, len(cols)), make([]tctypes.ByteFormatter, len(cols))
 		for j := 0; j < res.res.Len(); j++ {
 			row := make([]string, len(cols))
 			for i := 0; i < len(cols); i++ {
@@ -233,7 +201,11 @@ func (res *Result) encodeTable(columns ...string) func(w io.Writer) error {
 				if err != nil {
 					return err
 				}
-				x, ok := v.(ByteFormatter)
+				if x, ok := v.([]string); ok {
+					row[i] = strings.Join(x, ",")
+					continue
+				}
+				x, ok := v.(tctypes.ByteFormatter)
 				if !ok {
 					row[i] = fmt.Sprintf("%v", v)
 					continue
@@ -241,9 +213,9 @@ func (res *Result) encodeTable(columns ...string) func(w io.Writer) error {
 				row[i] = res.formatBytes(x)
 				if !res.noTotals {
 					if totals[i] == nil {
-						totals[i] = reflect.Zero(reflect.TypeOf(x)).Interface().(ByteFormatter)
+						totals[i] = reflect.Zero(reflect.TypeOf(x)).Interface().(tctypes.ByteFormatter)
 					}
-					totals[i] = totals[i].Add(x).(ByteFormatter)
+					totals[i] = totals[i].Add(x).(tctypes.ByteFormatter)
 					hasTotals, display[i] = true, true
 				}
 			}
@@ -266,12 +238,83 @@ func (res *Result) encodeTable(columns ...string) func(w io.Writer) error {
 	}
 }
 
+// sortColumns determines the headers and field names for the columns,
+// mapping any changed column names back to their original names in cols, and
+// sorts the results by the sort by column (or the first column, when sort by
+// was not set).
+func (res *Result) sortColumns(cols []string) ([]string, []string, error) {
+	// build column mappings
+	inverseCols := make(map[string]string, len(res.columnNames))
+	for k, v := range res.columnNames {
+		inverseCols[v] = k
+	}
+	headers := make([]string, len(cols))
+	colnames := make([]string, len(cols))
+	sortByField := ""
+	sortBy := strings.TrimSpace(res.sortBy)
+	for i := 0; i < len(cols); i++ {
+		if c, ok := inverseCols[cols[i]]; ok {
+			cols[i] = c
+		}
+		headers[i] = cols[i]
+		if h, ok := res.columnNames[cols[i]]; ok {
+			headers[i] = h
+		}
+		headers[i] = strings.ToUpper(strings.ReplaceAll(snaker.CamelToSnake(headers[i]), "_", " "))
+		colnames[i] = snaker.ForceCamelIdentifier(cols[i])
+		if sortBy == cols[i] || strings.EqualFold(sortBy, headers[i]) {
+			sortByField = colnames[i]
+		}
+	}
+
+	// determine sort by and order
+	switch {
+	case sortByField == "" && !res.sortByWasSet:
+		sortByField = colnames[0]
+	case sortByField == "":
+		return nil, nil, ErrSortByNotInColumnList
+	}
+	dir := res.sortOrder
+	if !res.sortOrderWasSet {
+		typ, ok := readFieldOrMethodType(res.res.Type().Elem(), sortByField)
+		if ok {
+			z := reflect.Zero(typ).Interface()
+			if _, ok = z.(tctypes.ByteFormatter); ok {
+				dir = "desc"
+			}
+			if _, ok = z.(tctypes.Percent); ok {
+				dir = "desc"
+			}
+		}
+	}
+	res.sort(sortByField, dir == "desc")
+	return headers, colnames, nil
+}
+
+// sortItems sorts the results for versioned output, using the same order as
+// table output. Any column may be used to sort by.
+func (res *Result) sortItems() error {
+	if len(res.tableCols) == 0 {
+		return nil
+	}
+	cols := []string{res.tableCols[0]}
+	if res.sortByWasSet {
+		all, err := res.buildAllColumns()
+		if err != nil {
+			return err
+		}
+		cols = append(cols, all...)
+	}
+	_, _, err := res.sortColumns(cols)
+	return err
+}
+
 // sort sorts the results based on the the specified sort by field.
 func (res *Result) sort(sortBy string, sortDesc bool) {
 	if res.res.Len() == 0 {
 		return
 	}
-	sort.Slice(res.res.Interface(), func(i, j int) bool {
+	sort.SliceStable(res.res.Interface(), func(i, j int) bool {
 		a, err := readFieldOrMethod(res.res.Index(i), sortBy)
 		if err != nil {
 			panic(err)
@@ -280,68 +323,41 @@ func (res *Result) sort(sortBy string, sortDesc bool) {
 		if err != nil {
 			panic(err)
 		}
-		switch x := a.(type) {
-		case string:
-			if sortDesc {
-				return x > b.(string)
-			}
-			return x < b.(string)
-		case int64:
-			if sortDesc {
-				return x > b.(int64)
-			}
-			return x < b.(int64)
-		case float64:
-			if sortDesc {
-				return x > b.(float64)
-			}
-			return x < b.(float64)
-		case ByteFormatter:
-			if sortDesc {
-				return x.Int64() > b.(ByteFormatter).Int64()
-			}
-			return x.Int64() < b.(ByteFormatter).Int64()
-		case Percent:
-			if sortDesc {
-				return x > b.(Percent)
-			}
-			return x < b.(Percent)
-		case transrpc.Status:
-			if sortDesc {
-				return x > b.(transrpc.Status)
-			}
-			return x < b.(transrpc.Status)
-		case transrpc.Priority:
-			if sortDesc {
-				return x > b.(transrpc.Priority)
-			}
-			return x < b.(transrpc.Priority)
-		case transrpc.Encryption:
-			if sortDesc {
-				return x > b.(transrpc.Encryption)
-			}
-			return x < b.(transrpc.Encryption)
-		case transrpc.Duration:
-			if sortDesc {
-				return x > b.(transrpc.Duration)
-			}
-			return x < b.(transrpc.Duration)
-		case transrpc.Time:
-			if sortDesc {
-				return time.Time(x).After(time.Time(b.(transrpc.Time)))
-			}
-			return time.Time(x).Before(time.Time(b.(transrpc.Time)))
-		case transrpc.Bool:
-			if sortDesc {
-				return x != b.(transrpc.Bool)
-			}
-			return x == b.(transrpc.Bool)
-		default:
-			panic(fmt.Sprintf("unknown comparison type %T", a))
+		if sortDesc {
+			return less(b, a)
 		}
+		return less(a, b)
 	})
 }
 
+// less compares a and b, which must be the same type.
+func less(a, b interface{}) bool {
+	switch x := a.(type) {
+	case tctypes.ByteFormatter:
+		return x.Int64() < b.(tctypes.ByteFormatter).Int64()
+	case tctypes.Time:
+		return time.Time(x).Before(time.Time(b.(tctypes.Time)))
+	case tctypes.MilliTime:
+		return time.Time(x).Before(time.Time(b.(tctypes.MilliTime)))
+	case []string:
+		return strings.Join(x, ",") < strings.Join(b.([]string), ",")
+	}
+	x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch x.Kind() {
+	case reflect.String:
+		return x.String() < y.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return x.Int() < y.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return x.Uint() < y.Uint()
+	case reflect.Float32, reflect.Float64:
+		return x.Float() < y.Float()
+	case reflect.Bool:
+		return !x.Bool() && y.Bool()
+	}
+	panic(fmt.Sprintf("unknown comparison type %T", a))
+}
+
 // encodeJSON encodes the results to the writer as JSON.
 func (res *Result) encodeJSON(w io.Writer) error {
 	if res.res.Len() == 0 {
@@ -403,9 +419,36 @@ func (res *Result) encodeYaml(w io.Writer) error {
 		return yaml.NewEncoder(w).Encode(m)
 	}
 
+	m := make(map[string]interface{})
 	for i := 0; i < res.res.Len(); i++ {
+		v := res.res.Index(i)
+		key, err := readFieldOrMethodString(v, res.index)
+		if err != nil {
+			return err
+		}
+		m[key] = v.Interface()
 	}
-	return nil
+	return yaml.NewEncoder(w).Encode(m)
+}
+
+// encodeVersionedJSON encodes the results to the writer as versioned JSON.
+func (res *Result) encodeVersionedJSON(w io.Writer) error {
+	env, err := res.newEnvelope()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(env)
+}
+
+// encodeVersionedYaml encodes the results to the writer as versioned YAML.
+func (res *Result) encodeVersionedYaml(w io.Writer) error {
+	env, err := res.newEnvelope()
+	if err != nil {
+		return err
+	}
+	return yaml.NewEncoder(w).Encode(env)
 }
 
 // encodeFlat encodes the results to the writer as a flat key map.
@@ -435,7 +478,7 @@ func (res *Result) encodeFlat(w io.Writer) error {
 			}
 			prefix = s + "."
 		}
-		addFieldsToMap(m, prefix, res.res.Index(i))
+		AddFieldsToMap(m, prefix, res.res.Index(i))
 		var keys []string
 		for k := range m {
 			keys = append(keys, k)
@@ -545,6 +588,21 @@ func NoTotals(noTotals bool) ResultOption {
 	}
 }
 
+// Kind is a result option to set the versioned output kind.
+func Kind(kind string) ResultOption {
+	return func(res *Result) {
+		res.kind = kind
+	}
+}
+
+// Versioned is a result option to set the versioned json and yaml output
+// toggle.
+func Versioned(versioned bool) ResultOption {
+	return func(res *Result) {
+		res.versioned = versioned
+	}
+}
+
 // Index sets the index field to use.
 func Index(index string) ResultOption {
 	return func(res *Result) {
@@ -567,7 +625,8 @@ func readFieldOrMethodType(x reflect.Type, name string) (reflect.Type, bool) {
 
 // readFieldOrMethod returns the field or method name declared on x.
 func readFieldOrMethod(x reflect.Value, name string) (interface{}, error) {
-	name, v = snaker.ForceCamelIdentifier(name), x.FieldByName(name)
+	name = snaker.ForceCamelIdentifier(name)
+	v := x.FieldByName(name)
 	if v.Kind() == reflect.Invalid {
 		v = x.MethodByName(name)
 		if v.Kind() == reflect.Invalid {
@@ -0,0 +1,353 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestResultGolden(t *testing.T) {
+	time.Local = time.UTC
+	tests := []struct {
+		name        string
+		v           func(*testing.T) interface{}
+		columnNames []string
+		sortBy      string
+		sortCol     string
+		cols        string
+		opts        []ResultOption
+	}{
+		{
+			"torrents", torrentsFixture, getColumnNames, "id", "name", "name,id",
+			[]ResultOption{
+				TableColumns(defaultTableCols...),
+				WideColumns(defaultWideCols...),
+				FlatName("torrent"),
+				FlatIndex("shortHash"),
+				Kind(KindTorrentList),
+			},
+		},
+		{
+			"files", filesFixture, filesGetColumnNames, "name", "name", "name,id",
+			[]ResultOption{
+				TableColumns("name", "priority", "bytesCompleted", "percentDone", "shortHash"),
+				WideColumns("name", "priority", "wanted", "bytesCompleted", "length", "percentDone", "id", "shortHash"),
+				YamlName("files"),
+				FlatName("files"),
+				FlatKey("id"),
+				FlatIndex("shortHash"),
+				Kind(KindFileList),
+			},
+		},
+		{
+			"peers", peersFixture, peersGetColumnNames, "address", "client", "client,id",
+			[]ResultOption{
+				TableColumns("address", "clientName", "rateToClient", "rateToPeer", "progress", "shortHash"),
+				WideColumns("address", "port", "clientName", "flagStr", "clientIsInterested", "isEncrypted", "rateToClient", "rateToPeer", "progress", "shortHash"),
+				YamlName("peers"),
+				FlatName("peers"),
+				FlatKey("id"),
+				FlatIndex("shortHash"),
+				Kind(KindPeerList),
+			},
+		},
+		{
+			"trackers", trackersFixture, trackersGetColumnNames, "id", "announce", "announce,id",
+			[]ResultOption{
+				TableColumns("announce", "lastAnnounceResult", "lastAnnouncePeerCount", "seederCount", "shortHash"),
+				WideColumns("announce", "announceState", "lastAnnounceResult", "lastAnnounceTime", "nextAnnounceTime", "lastAnnouncePeerCount", "seederCount", "tier", "shortHash"),
+				YamlName("trackers"),
+				FlatName("trackers"),
+				FlatKey("id"),
+				FlatIndex("shortHash"),
+				Kind(KindTrackerList),
+			},
+		},
+		{
+			"labels", labelsFixture, labelsListColumnNames, "name", "name", "count,name",
+			[]ResultOption{
+				TableColumns("name", "torrents"),
+				WideColumns("name", "torrents"),
+				FlatName("label"),
+				FlatIndex("name"),
+				Index("name"),
+				Kind(KindLabelList),
+			},
+		},
+		{
+			"stats", statsFixture, nil, "name", "name", "name,id",
+			[]ResultOption{
+				TableColumns("name", "value"),
+				WideColumns("name", "key", "value"),
+				YamlName("session-stats"),
+				FlatName("session-stats"),
+				FlatKey("id"),
+				FlatIndex("hashString"),
+				NoTotals(true),
+				Kind(KindStatList),
+			},
+		},
+		{
+			"free-space", freeSpaceFixture, nil, "location", "location", "location,error",
+			[]ResultOption{
+				TableColumns("location", "freeSpace", "error"),
+				WideColumns("location", "freeSpace", "error"),
+				YamlName("free-space"),
+				FlatName("free-space"),
+				FlatKey("id"),
+				FlatIndex("hashString"),
+				NoTotals(true),
+				Kind(KindFreeSpaceList),
+			},
+		},
+	}
+	cases := []struct {
+		name   string
+		output string
+		f      func(*Args, string)
+	}{
+		{"table", "table", nil},
+		{"table, sort by column desc", "table", sortBy("desc")},
+		{"table, sort by column asc", "table", sortBy("asc")},
+		{"table, si, no headers, no totals", "table", func(args *Args, _ string) {
+			args.Output.SI, args.Output.NoHeaders, args.Output.NoTotals = true, true, true
+		}},
+		{"wide", "wide", nil},
+		{"wide, not human", "wide", func(args *Args, _ string) { args.Output.Human = "false" }},
+		{"all", "all", nil},
+		{"cols", "cols=", nil},
+		{"json", "json", nil},
+		{"json, versioned", "json", versioned},
+		{"json, versioned, sort by column desc", "json", func(args *Args, col string) {
+			versioned(args, col)
+			sortBy("desc")(args, col)
+		}},
+		{"yaml", "yaml", nil},
+		{"yaml, versioned", "yaml", versioned},
+		{"flat", "flat", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			for _, c := range cases {
+				args := &Args{}
+				args.Output.Output = c.output
+				args.Output.Human = "true"
+				args.Output.SortBy, args.Output.SortOrder = test.sortBy, "asc"
+				args.Output.ColumnNames = make(map[string]string)
+				for _, s := range test.columnNames {
+					kv := strings.SplitN(s, "=", 2)
+					args.Output.ColumnNames[kv[0]] = kv[1]
+				}
+				if c.output == "cols=" {
+					args.Output.Output += test.cols
+				}
+				if c.f != nil {
+					c.f(args, test.sortCol)
+				}
+				fmt.Fprintf(buf, "### %s\n", c.name)
+				if err := NewResult(test.v(t), args.ResultOptions(test.opts...)...).Encode(buf); err != nil {
+					t.Fatalf("case %q expected no error, got: %v", c.name, err)
+				}
+				fmt.Fprintln(buf)
+			}
+			golden(t, filepath.Join("testdata", test.name+".golden"), buf.Bytes())
+		})
+	}
+}
+
+func TestResultVersionedEmpty(t *testing.T) {
+	for _, output := range []string{"json", "yaml"} {
+		buf := new(bytes.Buffer)
+		if err := NewResult([]tctypes.Torrent(nil), Output(output), Versioned(true), Kind(KindTorrentList)).Encode(buf); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s := buf.String(); !strings.Contains(s, APIVersion) || !strings.Contains(s, KindTorrentList) || !strings.Contains(s, "items: []") && !strings.Contains(s, `"items": []`) {
+			t.Errorf("%s expected empty versioned envelope, got:\n%s", output, s)
+		}
+	}
+}
+
+func TestResultVersionedFields(t *testing.T) {
+	// every field of a result item must be in its v1 output item, so that
+	// fields added to the result types are not silently dropped
+	for _, v := range []interface{}{
+		tctypes.Torrent{}, tctypes.File{}, tctypes.Peer{}, tctypes.PeerStat{},
+		tctypes.Tracker{}, tctypes.TrackerReport{}, tctypes.PieceMap{},
+		tctypes.LogEntry{}, tctypes.PeerLogEntry{}, Stat{}, FreeSpace{}, tctypes.Label{},
+		tctypes.Category{}, tctypes.RSSFeed{}, tctypes.RSSArticle{},
+		tctypes.RSSRule{}, RSSRuleMatch{}, tctypes.SearchResult{},
+		tctypes.SearchPlugin{},
+	} {
+		item, err := newItemV1(v)
+		if err != nil {
+			t.Fatalf("%T expected no error, got: %v", v, err)
+		}
+		exp, got := jsonNames(reflect.TypeOf(v)), jsonNames(reflect.TypeOf(item))
+		for name := range exp {
+			if !got[name] {
+				t.Errorf("%T expected v1 item %T to have field %q", v, item, name)
+			}
+		}
+	}
+	if _, err := newItemV1(struct{}{}); !errors.Is(err, ErrVersionedOutputNotSupported) {
+		t.Errorf("expected ErrVersionedOutputNotSupported, got: %v", err)
+	}
+}
+
+// jsonNames returns the json names (or all tag names) of the struct type's
+// fields.
+func jsonNames(typ reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			name = f.Tag.Get("all")
+		}
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func TestResultSortByNotInColumnList(t *testing.T) {
+	args := &Args{}
+	args.Output.Output, args.Output.SortBy, args.Output.SortByWasSet = "table", "comment", true
+	err := NewResult(torrentsFixture(t), args.ResultOptions(TableColumns(defaultTableCols...))...).Encode(ioutil.Discard)
+	if err != ErrSortByNotInColumnList {
+		t.Errorf("expected ErrSortByNotInColumnList, got: %v", err)
+	}
+}
+
+// sortBy returns a func setting the sort by column and sort order on args.
+func sortBy(sortOrder string) func(*Args, string) {
+	return func(args *Args, col string) {
+		args.Output.SortBy, args.Output.SortByWasSet = col, true
+		args.Output.SortOrder, args.Output.SortOrderWasSet = sortOrder, true
+	}
+}
+
+// versioned sets the versioned output toggle on args.
+func versioned(args *Args, _ string) {
+	args.Output.Versioned = true
+}
+
+// golden compares buf to the golden file at path, writing buf to the file
+// when -update was passed.
+func golden(t *testing.T, path string, buf []byte) {
+	t.Helper()
+	if *update {
+		if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return
+	}
+	exp, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !bytes.Equal(exp, buf) {
+		t.Errorf("output does not match %s (run with -update to regenerate), got:\n%s", path, buf)
+	}
+}
+
+func torrentsFixture(t *testing.T) interface{} {
+	var v []tctypes.Torrent
+	unmarshal(t, `[
+		{"id": 1, "name": "debian-10.3.0-amd64-netinst.iso", "hashString": "5a8062c076fa85e8056451c0d9aa04349ae27909", "status": 6, "eta": -1, "rateUpload": 12288, "haveValid": 351272960, "sizeWhenDone": 351272960, "percentDone": 1, "downloadDir": "/data/iso", "addedDate": 1583020800, "peersConnected": 4, "labels": ["linux", "debian"], "uploadRatio": 2.5, "comment": "Debian CD from cdimage.debian.org"},
+		{"id": 2, "name": "ubuntu-20.04-desktop-amd64.iso", "hashString": "9fc20b9e98ea98b4a35e6223041a5ef94ea27809", "status": 4, "eta": 754, "rateDownload": 5242880, "rateUpload": 1024, "haveValid": 1374389534, "sizeWhenDone": 2715254784, "percentDone": 0.5062, "downloadDir": "/data/iso", "addedDate": 1587686400, "peersConnected": 52, "labels": ["linux"]},
+		{"id": 3, "name": "archlinux-2020.05.01-x86_64.iso", "hashString": "02a7e1ff0e9cfc3d5b8e4b82d2d4f2a16b2a1e35", "status": 0, "eta": -2, "haveValid": 0, "sizeWhenDone": 683671552, "downloadDir": "/data/downloads", "addedDate": 1588291200, "error": 3, "errorString": "No data found!"}
+	]`, &v)
+	return v
+}
+
+// fixtureOwners are the torrent hash and name of the file, peer, and tracker
+// fixtures.
+var fixtureOwners = [][2]string{
+	{"9fc20b9e98ea98b4a35e6223041a5ef94ea27809", "ubuntu-20.04-desktop-amd64.iso"},
+	{"9fc20b9e98ea98b4a35e6223041a5ef94ea27809", "ubuntu-20.04-desktop-amd64.iso"},
+	{"5a8062c076fa85e8056451c0d9aa04349ae27909", "debian-10.3.0-amd64-netinst.iso"},
+}
+
+func filesFixture(t *testing.T) interface{} {
+	var v []tctypes.File
+	unmarshal(t, `[
+		{"id": 0, "name": "ubuntu/ubuntu-20.04-desktop-amd64.iso", "length": 2715254784, "bytesCompleted": 1374389534, "wanted": true, "priority": "normal"},
+		{"id": 1, "name": "ubuntu/SHA256SUMS", "length": 202, "bytesCompleted": 202, "wanted": true, "priority": "high"},
+		{"id": 0, "name": "debian-10.3.0-amd64-netinst.iso", "length": 351272960, "bytesCompleted": 351272960, "wanted": true, "priority": "normal"}
+	]`, &v)
+	for i := range v {
+		v[i].HashString, v[i].Torrent = fixtureOwners[i][0], fixtureOwners[i][1]
+	}
+	return v
+}
+
+func peersFixture(t *testing.T) interface{} {
+	var v []tctypes.Peer
+	unmarshal(t, `[
+		{"id": 0, "address": "192.0.2.10", "port": 51413, "clientName": "Transmission 2.94", "flagStr": "TDEI", "isEncrypted": true, "clientIsInterested": true, "rateToClient": 2097152, "progress": 1},
+		{"id": 1, "address": "198.51.100.7", "port": 6881, "clientName": "qBittorrent 4.2.5", "flagStr": "uEX", "rateToPeer": 1024, "progress": 0.25},
+		{"id": 0, "address": "203.0.113.99", "port": 49152, "clientName": "Deluge 2.0.3", "flagStr": "UE", "rateToPeer": 12288, "progress": 0.1}
+	]`, &v)
+	for i := range v {
+		v[i].HashString, v[i].Torrent = fixtureOwners[i][0], fixtureOwners[i][1]
+	}
+	return v
+}
+
+func trackersFixture(t *testing.T) interface{} {
+	var v []tctypes.Tracker
+	unmarshal(t, `[
+		{"id": 0, "announce": "http://torrent.ubuntu.com:6969/announce", "announceState": 1, "lastAnnounceResult": "Success", "lastAnnounceTime": 1588291200, "nextAnnounceTime": 1588293000, "lastAnnouncePeerCount": 50, "seederCount": 2144, "tier": 0, "host": "http://torrent.ubuntu.com:6969"},
+		{"id": 1, "announce": "http://ipv6.torrent.ubuntu.com:6969/announce", "announceState": 0, "lastAnnounceResult": "Connection failed", "tier": 1, "host": "http://ipv6.torrent.ubuntu.com:6969"},
+		{"id": 0, "announce": "http://bttracker.debian.org:6969/announce", "announceState": 1, "lastAnnounceResult": "Success", "lastAnnounceTime": 1588291500, "nextAnnounceTime": 1588293300, "lastAnnouncePeerCount": 12, "seederCount": 801, "tier": 0, "host": "http://bttracker.debian.org:6969"}
+	]`, &v)
+	for i := range v {
+		v[i].HashString, v[i].Torrent = fixtureOwners[i][0], fixtureOwners[i][1]
+	}
+	return v
+}
+
+func labelsFixture(t *testing.T) interface{} {
+	return NewLabels(torrentsFixture(t).([]tctypes.Torrent))
+}
+
+func statsFixture(t *testing.T) interface{} {
+	return NewStats(map[string]interface{}{
+		"active-torrent-count":              int64(2),
+		"download-speed":                    tctypes.Rate(5242880),
+		"paused-torrent-count":              int64(1),
+		"torrent-count":                     int64(3),
+		"upload-speed":                      tctypes.Rate(13312),
+		"cumulative-stats.downloaded-bytes": tctypes.ByteCount(53687091200),
+		"cumulative-stats.uploaded-bytes":   tctypes.ByteCount(107374182400),
+	})
+}
+
+func freeSpaceFixture(t *testing.T) interface{} {
+	return []FreeSpace{
+		{HashString: "free-space", Location: "/downloads", FreeSpace: 107374182400, ID: 0},
+		{HashString: "free-space", Location: "/missing", Error: "no such file or directory", ID: 1},
+	}
+}
+
+func unmarshal(t *testing.T, s string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
@@ -0,0 +1,587 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"gopkg.in/yaml.v3"
+)
+
+// RSSProvider is the interface for providers that support rss feeds and
+// auto-download rules.
+type RSSProvider interface {
+	// RSSFeedsGet returns the rss feeds, optionally with their articles.
+	RSSFeedsGet(context.Context, bool) ([]tctypes.RSSFeed, error)
+
+	// RSSFeedAdd adds a rss feed url at the path.
+	RSSFeedAdd(context.Context, string, string) error
+
+	// RSSFolderAdd adds a rss folder.
+	RSSFolderAdd(context.Context, string) error
+
+	// RSSItemRemove removes a rss feed or folder.
+	RSSItemRemove(context.Context, string) error
+
+	// RSSItemMove moves a rss feed or folder.
+	RSSItemMove(context.Context, string, string) error
+
+	// RSSRulesGet returns the rss auto-download rules.
+	RSSRulesGet(context.Context) ([]tctypes.RSSRule, error)
+
+	// RSSRuleSet creates or replaces a rss auto-download rule.
+	RSSRuleSet(context.Context, tctypes.RSSRule) error
+
+	// RSSRuleRename renames a rss auto-download rule.
+	RSSRuleRename(context.Context, string, string) error
+
+	// RSSRuleRemove removes a rss auto-download rule.
+	RSSRuleRemove(context.Context, string) error
+}
+
+// newRSSProvider creates the provider for the args, checking that it
+// supports rss.
+func newRSSProvider(args *Args) (RSSProvider, error) {
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	rp, ok := p.(RSSProvider)
+	if !ok {
+		return nil, fmt.Errorf("rss %w", ErrNotSupportedByProvider)
+	}
+	return rp, nil
+}
+
+// DoRSSFeedsList is the high-level entry point for 'rss feeds list'.
+func DoRSSFeedsList(ctx context.Context, args *Args, cmd string) error {
+	p, err := newRSSProvider(args)
+	if err != nil {
+		return err
+	}
+	result, err := p.RSSFeedsGet(ctx, true)
+	if err != nil {
+		return err
+	}
+	for i := range result {
+		result[i].Articles = nil
+	}
+	return NewResult(result, args.ResultOptions(
+		TableColumns("path", "title", "url"),
+		WideColumns("path", "title", "url", "isLoading", "hasError"),
+		FlatName("feed"),
+		FlatIndex("path"),
+		Index("path"),
+		Kind(KindRSSFeedList),
+	)...).Encode(os.Stdout)
+}
+
+// DoRSSFeedsArticles is the high-level entry point for 'rss feeds articles'.
+func DoRSSFeedsArticles(ctx context.Context, args *Args, cmd string) error {
+	p, err := newRSSProvider(args)
+	if err != nil {
+		return err
+	}
+	feeds, err := p.RSSFeedsGet(ctx, true)
+	if err != nil {
+		return err
+	}
+	var result []tctypes.RSSArticle
+	for _, feed := range feeds {
+		if len(args.RSSParams.Paths) != 0 && !containsString(args.RSSParams.Paths, feed.Path) {
+			continue
+		}
+		for _, article := range feed.Articles {
+			article.Feed = feed.Path
+			result = append(result, article)
+		}
+	}
+	return NewResult(result, args.ResultOptions(
+		TableColumns("feed", "date", "title"),
+		WideColumns("feed", "date", "title", "isRead", "torrentURL"),
+		FlatName("article"),
+		FlatIndex("id"),
+		Index("id"),
+		Kind(KindRSSArticleList),
+	)...).Encode(os.Stdout)
+}
+
+// DoRSSFeeds is the high-level entry point for 'rss feeds add', 'rss feeds
+// add-folder', 'rss feeds remove', and 'rss feeds move'.
+func DoRSSFeeds(ctx context.Context, args *Args, cmd string) error {
+	p, err := newRSSProvider(args)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "rss feeds add":
+		return p.RSSFeedAdd(ctx, args.RSSParams.URL, args.RSSParams.Path)
+	case "rss feeds add-folder":
+		return p.RSSFolderAdd(ctx, args.RSSParams.Path)
+	case "rss feeds remove":
+		for _, path := range args.RSSParams.Paths {
+			if err := p.RSSItemRemove(ctx, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "rss feeds move":
+		return p.RSSItemMove(ctx, args.RSSParams.Path, args.RSSParams.Dest)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// DoRSSRulesList is the high-level entry point for 'rss rules list'.
+func DoRSSRulesList(ctx context.Context, args *Args, cmd string) error {
+	p, err := newRSSProvider(args)
+	if err != nil {
+		return err
+	}
+	result, err := p.RSSRulesGet(ctx)
+	if err != nil {
+		return err
+	}
+	return NewResult(result, args.ResultOptions(
+		TableColumns("name", "enabled", "mustContain", "mustNotContain", "episodeFilter"),
+		WideColumns("name", "enabled", "mustContain", "mustNotContain", "useRegex", "episodeFilter", "smartFilter", "affectedFeeds", "assignedCategory", "savePath"),
+		FlatName("rule"),
+		FlatIndex("name"),
+		Index("name"),
+		Kind(KindRSSRuleList),
+	)...).Encode(os.Stdout)
+}
+
+// DoRSSRules is the high-level entry point for 'rss rules export', 'rss rules
+// import', 'rss rules rename', and 'rss rules remove'.
+//
+// Rules are exported and imported as a yaml map of rule names to rule
+// definitions.
+func DoRSSRules(ctx context.Context, args *Args, cmd string) error {
+	p, err := newRSSProvider(args)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "rss rules export":
+		rules, err := p.RSSRulesGet(ctx)
+		if err != nil {
+			return err
+		}
+		m := make(map[string]tctypes.RSSRule, len(rules))
+		for _, rule := range rules {
+			m[rule.Name] = rule
+		}
+		buf, err := yaml.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if args.RSSParams.File == "" || args.RSSParams.File == "-" {
+			_, err = os.Stdout.Write(buf)
+			return err
+		}
+		return ioutil.WriteFile(args.RSSParams.File, buf, 0644)
+	case "rss rules import":
+		rules, err := readRSSRules(args.RSSParams.File)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := p.RSSRuleSet(ctx, rule); err != nil {
+				return fmt.Errorf("could not set rule %q: %w", rule.Name, err)
+			}
+		}
+		return nil
+	case "rss rules rename":
+		return p.RSSRuleRename(ctx, args.RSSParams.Name, args.RSSParams.NewName)
+	case "rss rules remove":
+		for _, name := range args.RSSParams.Names {
+			if err := p.RSSRuleRemove(ctx, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+// readRSSRules reads the yaml map of rule names to rule definitions from the
+// file (or stdin, when '-'), returning the rules sorted by name.
+func readRSSRules(name string) ([]tctypes.RSSRule, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	m := make(map[string]tctypes.RSSRule)
+	if err := yaml.NewDecoder(r).Decode(&m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not read rules from %s: %w", name, err)
+	}
+	rules := make([]tctypes.RSSRule, 0, len(m))
+	for k, rule := range m {
+		rule.Name = k
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// RSSRuleMatch is the result of testing a rss auto-download rule against an
+// article title.
+type RSSRuleMatch struct {
+	Rule    string `json:"rule" yaml:"rule"`
+	Feed    string `json:"feed,omitempty" yaml:"feed,omitempty"`
+	Title   string `json:"title" yaml:"title"`
+	Matched bool   `json:"matched" yaml:"matched"`
+	Reason  string `json:"reason" yaml:"reason"`
+	ID      int64  `json:"id" yaml:"id"`
+}
+
+// DoRSSTestRule is the high-level entry point for 'rss test-rule'.
+//
+// The rule is read from the remote host, or from the --file rules (as written
+// by 'rss rules export'). When no titles are passed, the rule is tested
+// against the articles of the rule's affected feeds.
+func DoRSSTestRule(ctx context.Context, args *Args, cmd string) error {
+	var p RSSProvider
+	var rules []tctypes.RSSRule
+	var err error
+	if args.RSSParams.File != "" {
+		rules, err = readRSSRules(args.RSSParams.File)
+	} else {
+		if p, err = newRSSProvider(args); err == nil {
+			rules, err = p.RSSRulesGet(ctx)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	var rule *tctypes.RSSRule
+	for i := range rules {
+		if rules[i].Name == args.RSSParams.Name {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return fmt.Errorf("rss rule %q not found", args.RSSParams.Name)
+	}
+
+	// build titles
+	type title struct {
+		feed, title string
+	}
+	var titles []title
+	for _, s := range args.RSSParams.Titles {
+		titles = append(titles, title{title: s})
+	}
+	if len(titles) == 0 {
+		if p == nil {
+			if p, err = newRSSProvider(args); err != nil {
+				return err
+			}
+		}
+		feeds, err := p.RSSFeedsGet(ctx, true)
+		if err != nil {
+			return err
+		}
+		for _, feed := range feeds {
+			if !containsString(rule.AffectedFeeds, feed.URL) {
+				continue
+			}
+			for _, article := range feed.Articles {
+				titles = append(titles, title{feed: feed.Path, title: article.Title})
+			}
+		}
+	}
+
+	// test
+	now := time.Now()
+	result := make([]RSSRuleMatch, len(titles))
+	for i, t := range titles {
+		matched, reason, err := MatchRSSRule(*rule, t.title, now)
+		if err != nil {
+			return err
+		}
+		result[i] = RSSRuleMatch{
+			Rule:    rule.Name,
+			Feed:    t.feed,
+			Title:   t.title,
+			Matched: matched,
+			Reason:  reason,
+			ID:      int64(i),
+		}
+	}
+	return NewResult(result, args.ResultOptions(
+		TableColumns("feed", "title", "matched", "reason"),
+		WideColumns("rule", "feed", "title", "matched", "reason"),
+		FlatName("match"),
+		FlatIndex("id"),
+		Index("id"),
+		NoTotals(true),
+		Kind(KindRSSRuleMatchList),
+	)...).Encode(os.Stdout)
+}
+
+// MatchRSSRule determines if a title is matched by the rss auto-download
+// rule, returning the reason the title was or was not matched.
+//
+// Matching follows qBittorrent's auto-downloader: when not using regular
+// expressions, mustContain and mustNotContain are lists of expressions
+// separated by '|', where each expression is a list of whitespace separated
+// wildcards ('*' and '?') that must all match the title. When using regular
+// expressions, mustContain and mustNotContain are each a single expression.
+// All matching is case-insensitive. The smart episode filter uses
+// qBittorrent's default episode formats, and assumes repacks and propers of
+// previously matched episodes are downloaded (the qBittorrent default).
+func MatchRSSRule(rule tctypes.RSSRule, title string, now time.Time) (bool, string, error) {
+	// must contain
+	if rule.MustContain != "" {
+		ok, err := matchRSSExpressions(rule.MustContain, rule.UseRegex, title)
+		switch {
+		case err != nil:
+			return false, "", err
+		case !ok:
+			return false, "does not match must contain", nil
+		}
+	}
+
+	// must not contain
+	if rule.MustNotContain != "" {
+		ok, err := matchRSSExpressions(rule.MustNotContain, rule.UseRegex, title)
+		switch {
+		case err != nil:
+			return false, "", err
+		case ok:
+			return false, "matches must not contain", nil
+		}
+	}
+
+	// episode filter
+	if rule.EpisodeFilter != "" {
+		ok, err := matchRSSEpisodeFilter(rule.EpisodeFilter, title)
+		switch {
+		case err != nil:
+			return false, "", err
+		case !ok:
+			return false, "does not match episode filter", nil
+		}
+	}
+
+	// smart filter
+	if rule.SmartFilter {
+		if episode := rssEpisodeName(title); episode != "" && containsString(rule.PreviouslyMatchedEpisodes, episode) {
+			isRepack := strings.Contains(strings.ToUpper(title), "REPACK")
+			isProper := strings.Contains(strings.ToUpper(title), "PROPER")
+			full := episode
+			if isRepack {
+				full += "-REPACK"
+			}
+			if isProper {
+				full += "-PROPER"
+			}
+			if (!isRepack && !isProper) || containsString(rule.PreviouslyMatchedEpisodes, full) {
+				return false, fmt.Sprintf("episode %s previously matched", full), nil
+			}
+		}
+	}
+
+	// ignore days
+	if rule.IgnoreDays > 0 && rule.LastMatch != "" {
+		if last, ok := parseRSSTime(rule.LastMatch); ok {
+			if until := last.AddDate(0, 0, int(rule.IgnoreDays)); now.Before(until) {
+				return false, fmt.Sprintf("ignoring matches until %s", until.Format(time.RFC3339)), nil
+			}
+		}
+	}
+
+	if !rule.Enabled {
+		return false, "matches, but rule is disabled", nil
+	}
+	return true, "matches", nil
+}
+
+// matchRSSExpressions determines if the title matches the rule expressions.
+func matchRSSExpressions(expr string, useRegex bool, title string) (bool, error) {
+	if useRegex {
+		return matchRSSExpression(expr, true, title)
+	}
+	// only one expression has to match when using wildcards
+	for _, s := range strings.Split(expr, "|") {
+		ok, err := matchRSSExpression(s, false, title)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// matchRSSExpression determines if the title matches a single rule
+// expression. An empty expression always matches.
+func matchRSSExpression(expr string, useRegex bool, title string) (bool, error) {
+	if expr == "" {
+		return true, nil
+	}
+	if useRegex {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid expression %q: %w", expr, err)
+		}
+		return re.MatchString(title), nil
+	}
+	// all wildcards must match
+	for _, wildcard := range strings.Fields(expr) {
+		re, err := regexp.Compile("(?i)" + wildcardToRegexp(wildcard))
+		if err != nil {
+			return false, fmt.Errorf("invalid expression %q: %w", expr, err)
+		}
+		if !re.MatchString(title) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// wildcardToRegexp converts a wildcard to an unanchored regular expression.
+func wildcardToRegexp(wildcard string) string {
+	var sb strings.Builder
+	for _, r := range wildcard {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// rssEpisodeFilterRE matches a rss episode filter (for example, '1x2;8-15;20-;').
+var rssEpisodeFilterRE = regexp.MustCompile(`^(\d{1,4})x(.*;)$`)
+
+// rssEpisodePartialREs match the season and episode in a title.
+var rssEpisodePartialREs = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bs0?(\d{1,4})[ -_\.]?e(0?\d{1,4})(?:\D|\b)`),
+	regexp.MustCompile(`(?i)\b(\d{1,4})x(0?\d{1,4})(?:\D|\b)`),
+}
+
+// matchRSSEpisodeFilter determines if the title matches the episode filter.
+// An invalid episode filter never matches.
+func matchRSSEpisodeFilter(filter, title string) (bool, error) {
+	m := rssEpisodeFilterRE.FindStringSubmatch(filter)
+	if m == nil {
+		return false, nil
+	}
+	season := m[1]
+	seasonOurs, _ := strconv.Atoi(season)
+	for _, episode := range strings.Split(m[2], ";") {
+		if episode == "" {
+			continue
+		}
+		// trim leading zeros
+		for len(episode) > 1 && episode[0] == '0' {
+			episode = episode[1:]
+		}
+		if !strings.Contains(episode, "-") {
+			re, err := regexp.Compile(`(?i)\b(?:s0?` + season + `[ -_\.]?e0?` + regexp.QuoteMeta(episode) + `|` + season + `x0?` + regexp.QuoteMeta(episode) + `)(?:\D|\b)`)
+			if err != nil {
+				return false, fmt.Errorf("invalid episode filter %q: %w", filter, err)
+			}
+			if re.MatchString(title) {
+				return true, nil
+			}
+			continue
+		}
+
+		// range
+		var sm []string
+		for _, re := range rssEpisodePartialREs {
+			if sm = re.FindStringSubmatch(title); sm != nil {
+				break
+			}
+		}
+		if sm == nil {
+			continue
+		}
+		seasonTheirs, _ := strconv.Atoi(sm[1])
+		episodeTheirs, _ := strconv.Atoi(sm[2])
+		if strings.HasSuffix(episode, "-") {
+			episodeOurs, _ := strconv.Atoi(strings.TrimSuffix(episode, "-"))
+			if (seasonTheirs == seasonOurs && episodeTheirs >= episodeOurs) || seasonTheirs > seasonOurs {
+				return true, nil
+			}
+			continue
+		}
+		r := strings.SplitN(episode, "-", 2)
+		first, _ := strconv.Atoi(r[0])
+		last, _ := strconv.Atoi(r[1])
+		if first <= last && seasonTheirs == seasonOurs && first <= episodeTheirs && episodeTheirs <= last {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rssSmartEpisodeRE matches the episode formats used by the smart episode
+// filter.
+var rssSmartEpisodeRE = regexp.MustCompile(`(?i)s(\d+)e(\d+)|(\d+)x(\d+)|(\d{4}[.\-]\d{1,2}[.\-]\d{1,2})|(\d{1,2}[.\-]\d{1,2}[.\-]\d{4})`)
+
+// rssEpisodeName returns the smart episode filter episode name for the title
+// (for example, '1x2' for 'Show S01E02').
+func rssEpisodeName(title string) string {
+	m := rssSmartEpisodeRE.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+	var s []string
+	for _, v := range m[1:] {
+		if v == "" {
+			continue
+		}
+		if i, err := strconv.Atoi(v); err == nil {
+			v = strconv.Itoa(i)
+		}
+		s = append(s, v)
+	}
+	return strings.Join(s, "x")
+}
+
+// parseRSSTime parses a rule's last match time.
+func parseRSSTime(s string) (time.Time, bool) {
+	for _, layout := range []string{
+		"02 Jan 2006 15:04:05 -0700",
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// containsString determines if s contains v.
+func containsString(s []string, v string) bool {
+	for _, z := range s {
+		if z == v {
+			return true
+		}
+	}
+	return false
+}
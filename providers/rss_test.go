@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestMatchRSSRule(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rule  tctypes.RSSRule
+		title string
+		exp   bool
+	}{
+		{tctypes.RSSRule{}, "Anything", true},
+		{tctypes.RSSRule{MustContain: "show"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: "show 720p"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: "show 1080p"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{MustContain: "other|show"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: "the*s01"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: "sh?w"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: "show", MustNotContain: "720p"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{MustContain: "show", MustNotContain: "1080p|2160p"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: `^the\.show\.s\d+`, UseRegex: true}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{MustContain: `^show`, UseRegex: true}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{EpisodeFilter: "1x2;"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{EpisodeFilter: "1x3;"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{EpisodeFilter: "1x1-5;"}, "The.Show.S01E02.720p", true},
+		{tctypes.RSSRule{EpisodeFilter: "1x3-5;"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{EpisodeFilter: "1x2-;"}, "The.Show.S02E01.720p", true},
+		{tctypes.RSSRule{EpisodeFilter: "2x2-;"}, "The.Show.S02E01.720p", false},
+		{tctypes.RSSRule{EpisodeFilter: "1x2;"}, "The Show 1x02", true},
+		{tctypes.RSSRule{EpisodeFilter: "bad"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{SmartFilter: true, PreviouslyMatchedEpisodes: []string{"1x2"}}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{SmartFilter: true, PreviouslyMatchedEpisodes: []string{"1x2"}}, "The.Show.S01E03.720p", true},
+		{tctypes.RSSRule{SmartFilter: true, PreviouslyMatchedEpisodes: []string{"1x2"}}, "The.Show.S01E02.REPACK.720p", true},
+		{tctypes.RSSRule{SmartFilter: true, PreviouslyMatchedEpisodes: []string{"1x2", "1x2-REPACK"}}, "The.Show.S01E02.REPACK.720p", false},
+		{tctypes.RSSRule{IgnoreDays: 7, LastMatch: "05 Jan 2020 00:00:00 +0000"}, "The.Show.S01E02.720p", false},
+		{tctypes.RSSRule{IgnoreDays: 7, LastMatch: "01 Jan 2020 00:00:00 +0000"}, "The.Show.S01E02.720p", true},
+	}
+	for i, test := range tests {
+		test.rule.Enabled = true
+		matched, reason, err := MatchRSSRule(test.rule, test.title, now)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if matched != test.exp {
+			t.Errorf("test %d expected %t, got: %t (%s)", i, test.exp, matched, reason)
+		}
+	}
+}
+
+func TestMatchRSSRuleDisabled(t *testing.T) {
+	matched, reason, err := MatchRSSRule(tctypes.RSSRule{MustContain: "show"}, "The.Show.S01E02", time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if matched {
+		t.Errorf("expected disabled rule to not match")
+	}
+	if exp := "matches, but rule is disabled"; reason != exp {
+		t.Errorf("expected reason %q, got: %q", exp, reason)
+	}
+}
+
+func TestMatchRSSRuleInvalidRegexp(t *testing.T) {
+	if _, _, err := MatchRSSRule(tctypes.RSSRule{MustContain: "(", UseRegex: true}, "title", time.Now()); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,1014 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// APIVersion is the api version of versioned json and yaml output.
+//
+// Versioned output wraps results in an envelope with apiVersion, kind, and
+// items fields, where items are encoded in the order displayed by table
+// output. Item fields use the same names and encoding as unversioned output,
+// with the torrent and hashString fields added to file, peer, and tracker
+// items. Fields in an api version are only ever added, never renamed or
+// removed.
+//
+// Items are converted to the explicit v1 item types below, and not encoded
+// directly, so that changes to the provider types do not change the output of
+// an api version.
+const APIVersion = "transctl/v1"
+
+// Versioned output kinds.
+const (
+	// KindTorrentList is the kind for torrent lists (get, add).
+	KindTorrentList = "TorrentList"
+
+	// KindFileList is the kind for file lists (files get).
+	KindFileList = "FileList"
+
+	// KindPeerList is the kind for peer lists (peers get).
+	KindPeerList = "PeerList"
+
+	// KindPeerStatList is the kind for peer stats (peers stats).
+	KindPeerStatList = "PeerStatList"
+
+	// KindTrackerList is the kind for tracker lists (trackers get).
+	KindTrackerList = "TrackerList"
+
+	// KindTrackerReportList is the kind for tracker reports (trackers report).
+	KindTrackerReportList = "TrackerReportList"
+
+	// KindPieceMapList is the kind for torrent piece maps (pieces).
+	KindPieceMapList = "PieceMapList"
+
+	// KindLogList is the kind for remote host log messages (logs).
+	KindLogList = "LogList"
+
+	// KindPeerLogList is the kind for remote host peer log messages (logs
+	// peers).
+	KindPeerLogList = "PeerLogList"
+
+	// KindStatList is the kind for remote host stats (stats).
+	KindStatList = "StatList"
+
+	// KindFreeSpaceList is the kind for remote host free space (free-space).
+	KindFreeSpaceList = "FreeSpaceList"
+
+	// KindLabelList is the kind for label lists (labels list).
+	KindLabelList = "LabelList"
+
+	// KindCategoryList is the kind for category lists (categories list).
+	KindCategoryList = "CategoryList"
+
+	// KindRSSFeedList is the kind for rss feed lists (rss feeds list).
+	KindRSSFeedList = "RSSFeedList"
+
+	// KindRSSArticleList is the kind for rss article lists (rss feeds
+	// articles).
+	KindRSSArticleList = "RSSArticleList"
+
+	// KindRSSRuleList is the kind for rss rule lists (rss rules list).
+	KindRSSRuleList = "RSSRuleList"
+
+	// KindRSSRuleMatchList is the kind for rss rule test results (rss
+	// test-rule).
+	KindRSSRuleMatchList = "RSSRuleMatchList"
+
+	// KindSearchResultList is the kind for search result lists (search).
+	KindSearchResultList = "SearchResultList"
+
+	// KindSearchPluginList is the kind for search plugin lists (search
+	// plugins list).
+	KindSearchPluginList = "SearchPluginList"
+)
+
+// envelope is the versioned output envelope.
+type envelope struct {
+	APIVersion string        `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string        `json:"kind" yaml:"kind"`
+	Items      []interface{} `json:"items" yaml:"items"`
+}
+
+// newEnvelope creates the versioned output envelope for the result.
+func (res *Result) newEnvelope() (*envelope, error) {
+	if err := res.sortItems(); err != nil {
+		return nil, err
+	}
+	kind := res.kind
+	if kind == "" {
+		kind = res.res.Type().Elem().Name() + "List"
+	}
+	items := make([]interface{}, res.res.Len())
+	for i := 0; i < res.res.Len(); i++ {
+		var err error
+		if items[i], err = newItemV1(res.res.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return &envelope{
+		APIVersion: APIVersion,
+		Kind:       kind,
+		Items:      items,
+	}, nil
+}
+
+// newItemV1 converts a result item to its v1 output item.
+func newItemV1(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case tctypes.Torrent:
+		return newTorrentV1(x), nil
+	case tctypes.File:
+		return newFileV1(x), nil
+	case tctypes.Peer:
+		return newPeerV1(x), nil
+	case tctypes.PeerStat:
+		return newPeerStatV1(x), nil
+	case tctypes.Tracker:
+		return newTrackerV1(x), nil
+	case tctypes.TrackerReport:
+		return newTrackerReportV1(x), nil
+	case tctypes.PieceMap:
+		return newPieceMapV1(x), nil
+	case tctypes.LogEntry:
+		return newLogEntryV1(x), nil
+	case tctypes.PeerLogEntry:
+		return newPeerLogEntryV1(x), nil
+	case Stat:
+		return newStatV1(x), nil
+	case FreeSpace:
+		return newFreeSpaceV1(x), nil
+	case tctypes.Label:
+		return newLabelV1(x), nil
+	case tctypes.Category:
+		return newCategoryV1(x), nil
+	case tctypes.RSSFeed:
+		return newRSSFeedV1(x), nil
+	case tctypes.RSSArticle:
+		return newRSSArticleV1(x), nil
+	case tctypes.RSSRule:
+		return newRSSRuleV1(x), nil
+	case RSSRuleMatch:
+		return newRSSRuleMatchV1(x), nil
+	case tctypes.SearchResult:
+		return newSearchResultV1(x), nil
+	case tctypes.SearchPlugin:
+		return newSearchPluginV1(x), nil
+	}
+	return nil, fmt.Errorf("%T: %w", v, ErrVersionedOutputNotSupported)
+}
+
+// torrentV1 is a torrent.
+type torrentV1 struct {
+	ActivityDate            tctypes.Time            `json:"activityDate,omitempty" yaml:"activityDate,omitempty"`
+	AddedDate               tctypes.Time            `json:"addedDate,omitempty" yaml:"addedDate,omitempty"`
+	Availability            []int64                 `json:"availability,omitempty" yaml:"availability,omitempty"`
+	BandwidthPriority       tctypes.Priority        `json:"bandwidthPriority,omitempty" yaml:"bandwidthPriority,omitempty"`
+	Comment                 string                  `json:"comment,omitempty" yaml:"comment,omitempty"`
+	CorruptEver             tctypes.ByteCount       `json:"corruptEver,omitempty" yaml:"corruptEver,omitempty"`
+	Creator                 string                  `json:"creator,omitempty" yaml:"creator,omitempty"`
+	DateCreated             tctypes.Time            `json:"dateCreated,omitempty" yaml:"dateCreated,omitempty"`
+	DesiredAvailable        tctypes.ByteCount       `json:"desiredAvailable,omitempty" yaml:"desiredAvailable,omitempty"`
+	DoneDate                tctypes.Time            `json:"doneDate,omitempty" yaml:"doneDate,omitempty"`
+	DownloadDir             string                  `json:"downloadDir,omitempty" yaml:"downloadDir,omitempty"`
+	DownloadedEver          tctypes.ByteCount       `json:"downloadedEver,omitempty" yaml:"downloadedEver,omitempty"`
+	DownloadLimit           tctypes.Limit           `json:"downloadLimit,omitempty" yaml:"downloadLimit,omitempty"`
+	DownloadLimited         bool                    `json:"downloadLimited,omitempty" yaml:"downloadLimited,omitempty"`
+	EditDate                tctypes.Time            `json:"editDate,omitempty" yaml:"editDate,omitempty"`
+	Error                   tctypes.ErrNo           `json:"error,omitempty" yaml:"error,omitempty"`
+	ErrorString             string                  `json:"errorString,omitempty" yaml:"errorString,omitempty"`
+	Eta                     tctypes.Duration        `json:"eta,omitempty" yaml:"eta,omitempty"`
+	EtaIdle                 tctypes.Duration        `json:"etaIdle,omitempty" yaml:"etaIdle,omitempty"`
+	Files                   []torrentFileV1         `json:"files,omitempty" yaml:"files,omitempty"`
+	FileStats               []torrentFileStatsV1    `json:"fileStats,omitempty" yaml:"fileStats,omitempty"`
+	FileCount               int64                   `json:"file-count,omitempty" yaml:"file-count,omitempty"`
+	Group                   string                  `json:"group,omitempty" yaml:"group,omitempty"`
+	HashString              string                  `json:"hashString,omitempty" yaml:"hashString,omitempty"`
+	HaveUnchecked           tctypes.ByteCount       `json:"haveUnchecked,omitempty" yaml:"haveUnchecked,omitempty"`
+	HaveValid               tctypes.ByteCount       `json:"haveValid,omitempty" yaml:"haveValid,omitempty"`
+	HonorsSessionLimits     bool                    `json:"honorsSessionLimits,omitempty" yaml:"honorsSessionLimits,omitempty"`
+	ID                      int64                   `json:"id,omitempty" yaml:"id,omitempty"`
+	IsFinished              bool                    `json:"isFinished,omitempty" yaml:"isFinished,omitempty"`
+	IsPrivate               bool                    `json:"isPrivate,omitempty" yaml:"isPrivate,omitempty"`
+	IsStalled               bool                    `json:"isStalled,omitempty" yaml:"isStalled,omitempty"`
+	Labels                  []string                `json:"labels,omitempty" yaml:"labels,omitempty"`
+	LeftUntilDone           tctypes.ByteCount       `json:"leftUntilDone,omitempty" yaml:"leftUntilDone,omitempty"`
+	MagnetLink              string                  `json:"magnetLink,omitempty" yaml:"magnetLink,omitempty"`
+	ManualAnnounceTime      tctypes.Time            `json:"manualAnnounceTime,omitempty" yaml:"manualAnnounceTime,omitempty"`
+	MaxConnectedPeers       int64                   `json:"maxConnectedPeers,omitempty" yaml:"maxConnectedPeers,omitempty"`
+	MetadataPercentComplete tctypes.Percent         `json:"metadataPercentComplete,omitempty" yaml:"metadataPercentComplete,omitempty"`
+	Name                    string                  `json:"name,omitempty" yaml:"name,omitempty"`
+	PeerLimit               int64                   `json:"peer-limit,omitempty" yaml:"peer-limit,omitempty"`
+	Peers                   []torrentPeerV1         `json:"peers,omitempty" yaml:"peers,omitempty"`
+	PeersConnected          int64                   `json:"peersConnected,omitempty" yaml:"peersConnected,omitempty"`
+	PeersFrom               torrentPeersFromV1      `json:"peersFrom,omitempty" yaml:"peersFrom,omitempty"`
+	PeersGettingFromUs      int64                   `json:"peersGettingFromUs,omitempty" yaml:"peersGettingFromUs,omitempty"`
+	PeersSendingToUs        int64                   `json:"peersSendingToUs,omitempty" yaml:"peersSendingToUs,omitempty"`
+	PercentDone             tctypes.Percent         `json:"percentDone,omitempty" yaml:"percentDone,omitempty"`
+	Pieces                  []byte                  `json:"pieces,omitempty" yaml:"pieces,omitempty"`
+	PieceCount              int64                   `json:"pieceCount,omitempty" yaml:"pieceCount,omitempty"`
+	PieceSize               tctypes.ByteCount       `json:"pieceSize,omitempty" yaml:"pieceSize,omitempty"`
+	Priorities              []tctypes.Priority      `json:"priorities,omitempty" yaml:"priorities,omitempty"`
+	PrimaryMimeType         string                  `json:"primary-mime-type,omitempty" yaml:"primary-mime-type,omitempty"`
+	QueuePosition           int64                   `json:"queuePosition,omitempty" yaml:"queuePosition,omitempty"`
+	RateDownload            tctypes.Rate            `json:"rateDownload,omitempty" yaml:"rateDownload,omitempty"`
+	RateUpload              tctypes.Rate            `json:"rateUpload,omitempty" yaml:"rateUpload,omitempty"`
+	RecheckProgress         tctypes.Percent         `json:"recheckProgress,omitempty" yaml:"recheckProgress,omitempty"`
+	SecondsDownloading      tctypes.Duration        `json:"secondsDownloading,omitempty" yaml:"secondsDownloading,omitempty"`
+	SecondsSeeding          tctypes.Duration        `json:"secondsSeeding,omitempty" yaml:"secondsSeeding,omitempty"`
+	SeedIdleLimit           int64                   `json:"seedIdleLimit,omitempty" yaml:"seedIdleLimit,omitempty"`
+	SeedIdleMode            tctypes.Mode            `json:"seedIdleMode,omitempty" yaml:"seedIdleMode,omitempty"`
+	SeedRatioLimit          float64                 `json:"seedRatioLimit,omitempty" yaml:"seedRatioLimit,omitempty"`
+	SeedRatioMode           tctypes.Mode            `json:"seedRatioMode,omitempty" yaml:"seedRatioMode,omitempty"`
+	SequentialDownload      bool                    `json:"sequentialDownload,omitempty" yaml:"sequentialDownload,omitempty"`
+	SizeWhenDone            tctypes.ByteCount       `json:"sizeWhenDone,omitempty" yaml:"sizeWhenDone,omitempty"`
+	StartDate               tctypes.Time            `json:"startDate,omitempty" yaml:"startDate,omitempty"`
+	Status                  tctypes.Status          `json:"status,omitempty" yaml:"status,omitempty"`
+	Trackers                []torrentTrackerV1      `json:"trackers,omitempty" yaml:"trackers,omitempty"`
+	TrackerStats            []torrentTrackerStatsV1 `json:"trackerStats,omitempty" yaml:"trackerStats,omitempty"`
+	TrackerList             string                  `json:"trackerList,omitempty" yaml:"trackerList,omitempty"`
+	TotalSize               tctypes.ByteCount       `json:"totalSize,omitempty" yaml:"totalSize,omitempty"`
+	TorrentFile             string                  `json:"torrentFile,omitempty" yaml:"torrentFile,omitempty"`
+	UploadedEver            tctypes.ByteCount       `json:"uploadedEver,omitempty" yaml:"uploadedEver,omitempty"`
+	UploadLimit             tctypes.Limit           `json:"uploadLimit,omitempty" yaml:"uploadLimit,omitempty"`
+	UploadLimited           bool                    `json:"uploadLimited,omitempty" yaml:"uploadLimited,omitempty"`
+	UploadRatio             float64                 `json:"uploadRatio,omitempty" yaml:"uploadRatio,omitempty"`
+	Wanted                  []tctypes.Bool          `json:"wanted,omitempty" yaml:"wanted,omitempty"`
+	Webseeds                []string                `json:"webseeds,omitempty" yaml:"webseeds,omitempty"`
+	WebseedsSendingToUs     int64                   `json:"webseedsSendingToUs,omitempty" yaml:"webseedsSendingToUs,omitempty"`
+}
+
+// fileV1 is a torrent file.
+type fileV1 struct {
+	BytesCompleted tctypes.ByteCount `json:"bytesCompleted,omitempty" yaml:"bytesCompleted,omitempty"`
+	Length         tctypes.ByteCount `json:"length,omitempty" yaml:"length,omitempty"`
+	Name           string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Wanted         bool              `json:"wanted,omitempty" yaml:"wanted,omitempty"`
+	Priority       string            `json:"priority,omitempty" yaml:"priority,omitempty"`
+	ID             int64             `json:"id" yaml:"id"`
+	Torrent        string            `json:"torrent" yaml:"torrent"`
+	HashString     string            `json:"hashString" yaml:"hashString"`
+}
+
+// peerV1 is a torrent peer.
+type peerV1 struct {
+	Address            string          `json:"address,omitempty" yaml:"address,omitempty"`
+	ClientName         string          `json:"clientName,omitempty" yaml:"clientName,omitempty"`
+	ClientIsChoked     bool            `json:"clientIsChoked,omitempty" yaml:"clientIsChoked,omitempty"`
+	ClientIsInterested bool            `json:"clientIsInterested,omitempty" yaml:"clientIsInterested,omitempty"`
+	FlagStr            string          `json:"flagStr,omitempty" yaml:"flagStr,omitempty"`
+	IsDownloadingFrom  bool            `json:"isDownloadingFrom,omitempty" yaml:"isDownloadingFrom,omitempty"`
+	IsEncrypted        bool            `json:"isEncrypted,omitempty" yaml:"isEncrypted,omitempty"`
+	IsIncoming         bool            `json:"isIncoming,omitempty" yaml:"isIncoming,omitempty"`
+	IsUploadingTo      bool            `json:"isUploadingTo,omitempty" yaml:"isUploadingTo,omitempty"`
+	IsUTP              bool            `json:"isUTP,omitempty" yaml:"isUTP,omitempty"`
+	PeerIsChoked       bool            `json:"peerIsChoked,omitempty" yaml:"peerIsChoked,omitempty"`
+	PeerIsInterested   bool            `json:"peerIsInterested,omitempty" yaml:"peerIsInterested,omitempty"`
+	Port               int64           `json:"port,omitempty" yaml:"port,omitempty"`
+	Progress           tctypes.Percent `json:"progress,omitempty" yaml:"progress,omitempty"`
+	RateToClient       tctypes.Rate    `json:"rateToClient,omitempty" yaml:"rateToClient,omitempty"`
+	RateToPeer         tctypes.Rate    `json:"rateToPeer,omitempty" yaml:"rateToPeer,omitempty"`
+	Country            string          `json:"country,omitempty" yaml:"country,omitempty"`
+	ID                 int64           `json:"id" yaml:"id"`
+	Torrent            string          `json:"torrent" yaml:"torrent"`
+	HashString         string          `json:"hashString" yaml:"hashString"`
+}
+
+// peerStatV1 is a peer stat group.
+type peerStatV1 struct {
+	Group        string       `json:"group" yaml:"group"`
+	Name         string       `json:"name" yaml:"name"`
+	Peers        int64        `json:"peers" yaml:"peers"`
+	RateToClient tctypes.Rate `json:"rateToClient" yaml:"rateToClient"`
+	RateToPeer   tctypes.Rate `json:"rateToPeer" yaml:"rateToPeer"`
+	ID           int64        `json:"id" yaml:"id"`
+}
+
+// trackerV1 is a torrent tracker.
+type trackerV1 struct {
+	Announce              string        `json:"announce,omitempty" yaml:"announce,omitempty"`
+	ID                    int64         `json:"id" yaml:"id"`
+	Scrape                string        `json:"scrape,omitempty" yaml:"scrape,omitempty"`
+	Tier                  int64         `json:"tier,omitempty" yaml:"tier,omitempty"`
+	AnnounceState         tctypes.State `json:"announceState,omitempty" yaml:"announceState,omitempty"`
+	DownloadCount         int64         `json:"downloadCount,omitempty" yaml:"downloadCount,omitempty"`
+	HasAnnounced          bool          `json:"hasAnnounced,omitempty" yaml:"hasAnnounced,omitempty"`
+	HasScraped            bool          `json:"hasScraped,omitempty" yaml:"hasScraped,omitempty"`
+	Host                  string        `json:"host,omitempty" yaml:"host,omitempty"`
+	IsBackup              bool          `json:"isBackup,omitempty" yaml:"isBackup,omitempty"`
+	LastAnnouncePeerCount int64         `json:"lastAnnouncePeerCount,omitempty" yaml:"lastAnnouncePeerCount,omitempty"`
+	LastAnnounceResult    string        `json:"lastAnnounceResult,omitempty" yaml:"lastAnnounceResult,omitempty"`
+	LastAnnounceStartTime tctypes.Time  `json:"lastAnnounceStartTime,omitempty" yaml:"lastAnnounceStartTime,omitempty"`
+	LastAnnounceSucceeded bool          `json:"lastAnnounceSucceeded,omitempty" yaml:"lastAnnounceSucceeded,omitempty"`
+	LastAnnounceTime      tctypes.Time  `json:"lastAnnounceTime,omitempty" yaml:"lastAnnounceTime,omitempty"`
+	LastAnnounceTimedOut  bool          `json:"lastAnnounceTimedOut,omitempty" yaml:"lastAnnounceTimedOut,omitempty"`
+	LastScrapeResult      string        `json:"lastScrapeResult,omitempty" yaml:"lastScrapeResult,omitempty"`
+	LastScrapeStartTime   tctypes.Time  `json:"lastScrapeStartTime,omitempty" yaml:"lastScrapeStartTime,omitempty"`
+	LastScrapeSucceeded   bool          `json:"lastScrapeSucceeded,omitempty" yaml:"lastScrapeSucceeded,omitempty"`
+	LastScrapeTime        tctypes.Time  `json:"lastScrapeTime,omitempty" yaml:"lastScrapeTime,omitempty"`
+	LastScrapeTimedOut    int64         `json:"lastScrapeTimedOut,omitempty" yaml:"lastScrapeTimedOut,omitempty"`
+	LeecherCount          int64         `json:"leecherCount,omitempty" yaml:"leecherCount,omitempty"`
+	NextAnnounceTime      tctypes.Time  `json:"nextAnnounceTime,omitempty" yaml:"nextAnnounceTime,omitempty"`
+	NextScrapeTime        tctypes.Time  `json:"nextScrapeTime,omitempty" yaml:"nextScrapeTime,omitempty"`
+	ScrapeState           tctypes.State `json:"scrapeState,omitempty" yaml:"scrapeState,omitempty"`
+	SeederCount           int64         `json:"seederCount,omitempty" yaml:"seederCount,omitempty"`
+	Torrent               string        `json:"torrent" yaml:"torrent"`
+	HashString            string        `json:"hashString" yaml:"hashString"`
+}
+
+// trackerReportV1 is a tracker report.
+type trackerReportV1 struct {
+	Host         string          `json:"host" yaml:"host"`
+	Torrents     int64           `json:"torrents" yaml:"torrents"`
+	Announced    int64           `json:"announced" yaml:"announced"`
+	Succeeded    int64           `json:"succeeded" yaml:"succeeded"`
+	SuccessRate  tctypes.Percent `json:"successRate" yaml:"successRate"`
+	AvgPeers     float64         `json:"avgPeers" yaml:"avgPeers"`
+	Errors       []string        `json:"errors,omitempty" yaml:"errors,omitempty"`
+	TorrentNames []string        `json:"torrentNames,omitempty" yaml:"torrentNames,omitempty"`
+	ID           int64           `json:"id" yaml:"id"`
+}
+
+// pieceMapV1 is a torrent piece map.
+type pieceMapV1 struct {
+	Torrent     string               `json:"torrent" yaml:"torrent"`
+	HashString  string               `json:"hashString" yaml:"hashString"`
+	PieceCount  int64                `json:"pieceCount" yaml:"pieceCount"`
+	PieceSize   tctypes.ByteCount    `json:"pieceSize" yaml:"pieceSize"`
+	Have        []tctypes.PieceRange `json:"have" yaml:"have"`
+	Downloading []tctypes.PieceRange `json:"downloading" yaml:"downloading"`
+	Missing     []tctypes.PieceRange `json:"missing" yaml:"missing"`
+	Files       []filePiecesV1       `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// logEntryV1 is a remote host log message.
+type logEntryV1 struct {
+	ID      int64             `json:"id" yaml:"id"`
+	Time    tctypes.MilliTime `json:"time" yaml:"time"`
+	Level   tctypes.LogLevel  `json:"level" yaml:"level"`
+	Message string            `json:"message" yaml:"message"`
+}
+
+// peerLogEntryV1 is a remote host peer log message.
+type peerLogEntryV1 struct {
+	ID      int64             `json:"id" yaml:"id"`
+	Time    tctypes.MilliTime `json:"time" yaml:"time"`
+	Address string            `json:"address" yaml:"address"`
+	Blocked bool              `json:"blocked" yaml:"blocked"`
+	Reason  string            `json:"reason" yaml:"reason"`
+}
+
+// statV1 is a remote host stat.
+type statV1 struct {
+	Name  string      `json:"name" yaml:"name"`
+	Key   string      `json:"key" yaml:"key"`
+	Value interface{} `json:"value" yaml:"value"`
+	ID    int64       `json:"id" yaml:"id"`
+}
+
+// freeSpaceV1 is the free space of a remote host location.
+type freeSpaceV1 struct {
+	Location  string            `json:"location" yaml:"location"`
+	FreeSpace tctypes.ByteCount `json:"freeSpace" yaml:"freeSpace"`
+	Error     string            `json:"error,omitempty" yaml:"error,omitempty"`
+	ID        int64             `json:"id" yaml:"id"`
+}
+
+// labelV1 is a label.
+type labelV1 struct {
+	Name     string `json:"name" yaml:"name"`
+	Torrents int64  `json:"torrents" yaml:"torrents"`
+}
+
+// categoryV1 is a category.
+type categoryV1 struct {
+	Name     string `json:"name" yaml:"name"`
+	SavePath string `json:"savePath,omitempty" yaml:"savePath,omitempty"`
+}
+
+// rssFeedV1 is a rss feed.
+type rssFeedV1 struct {
+	Path          string         `json:"path" yaml:"path"`
+	UID           string         `json:"uid,omitempty" yaml:"uid,omitempty"`
+	URL           string         `json:"url" yaml:"url"`
+	Title         string         `json:"title,omitempty" yaml:"title,omitempty"`
+	LastBuildDate string         `json:"lastBuildDate,omitempty" yaml:"lastBuildDate,omitempty"`
+	IsLoading     bool           `json:"isLoading,omitempty" yaml:"isLoading,omitempty"`
+	HasError      bool           `json:"hasError,omitempty" yaml:"hasError,omitempty"`
+	Articles      []rssArticleV1 `json:"articles,omitempty" yaml:"articles,omitempty"`
+}
+
+// rssArticleV1 is a rss article.
+type rssArticleV1 struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Date        string `json:"date,omitempty" yaml:"date,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Author      string `json:"author,omitempty" yaml:"author,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	TorrentURL  string `json:"torrentURL,omitempty" yaml:"torrentURL,omitempty"`
+	Link        string `json:"link,omitempty" yaml:"link,omitempty"`
+	IsRead      bool   `json:"isRead,omitempty" yaml:"isRead,omitempty"`
+	Feed        string `json:"feed,omitempty" yaml:"feed,omitempty"`
+}
+
+// rssRuleV1 is a rss auto-download rule.
+type rssRuleV1 struct {
+	Name                      string   `json:"name" yaml:"name"`
+	Enabled                   bool     `json:"enabled" yaml:"enabled"`
+	MustContain               string   `json:"mustContain,omitempty" yaml:"mustContain,omitempty"`
+	MustNotContain            string   `json:"mustNotContain,omitempty" yaml:"mustNotContain,omitempty"`
+	UseRegex                  bool     `json:"useRegex,omitempty" yaml:"useRegex,omitempty"`
+	EpisodeFilter             string   `json:"episodeFilter,omitempty" yaml:"episodeFilter,omitempty"`
+	SmartFilter               bool     `json:"smartFilter,omitempty" yaml:"smartFilter,omitempty"`
+	PreviouslyMatchedEpisodes []string `json:"previouslyMatchedEpisodes,omitempty" yaml:"previouslyMatchedEpisodes,omitempty"`
+	AffectedFeeds             []string `json:"affectedFeeds,omitempty" yaml:"affectedFeeds,omitempty"`
+	IgnoreDays                int64    `json:"ignoreDays,omitempty" yaml:"ignoreDays,omitempty"`
+	LastMatch                 string   `json:"lastMatch,omitempty" yaml:"lastMatch,omitempty"`
+	AddPaused                 bool     `json:"addPaused,omitempty" yaml:"addPaused,omitempty"`
+	AssignedCategory          string   `json:"assignedCategory,omitempty" yaml:"assignedCategory,omitempty"`
+	SavePath                  string   `json:"savePath,omitempty" yaml:"savePath,omitempty"`
+}
+
+// rssRuleMatchV1 is a rss rule test result.
+type rssRuleMatchV1 struct {
+	Rule    string `json:"rule" yaml:"rule"`
+	Feed    string `json:"feed,omitempty" yaml:"feed,omitempty"`
+	Title   string `json:"title" yaml:"title"`
+	Matched bool   `json:"matched" yaml:"matched"`
+	Reason  string `json:"reason" yaml:"reason"`
+	ID      int64  `json:"id" yaml:"id"`
+}
+
+// searchResultV1 is a search result.
+type searchResultV1 struct {
+	ID       int64             `json:"id" yaml:"id"`
+	Name     string            `json:"name" yaml:"name"`
+	Size     tctypes.ByteCount `json:"size" yaml:"size"`
+	Seeders  int64             `json:"seeders" yaml:"seeders"`
+	Leechers int64             `json:"leechers" yaml:"leechers"`
+	Site     string            `json:"site,omitempty" yaml:"site,omitempty"`
+	Link     string            `json:"link,omitempty" yaml:"link,omitempty"`
+	URL      string            `json:"url" yaml:"url"`
+}
+
+// searchPluginV1 is a search plugin.
+type searchPluginV1 struct {
+	Name       string   `json:"name" yaml:"name"`
+	FullName   string   `json:"fullName,omitempty" yaml:"fullName,omitempty"`
+	Version    string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Enabled    bool     `json:"enabled" yaml:"enabled"`
+	URL        string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// filePiecesV1 is a file's piece coverage.
+type filePiecesV1 struct {
+	Name        string            `json:"name" yaml:"name"`
+	Length      tctypes.ByteCount `json:"length" yaml:"length"`
+	FirstPiece  int64             `json:"firstPiece" yaml:"firstPiece"`
+	LastPiece   int64             `json:"lastPiece" yaml:"lastPiece"`
+	Have        int64             `json:"have" yaml:"have"`
+	Downloading int64             `json:"downloading" yaml:"downloading"`
+	Missing     int64             `json:"missing" yaml:"missing"`
+}
+
+// torrentFileV1 is a torrent's file.
+type torrentFileV1 struct {
+	BytesCompleted tctypes.ByteCount `json:"bytesCompleted,omitempty" yaml:"bytesCompleted,omitempty"`
+	Length         tctypes.ByteCount `json:"length,omitempty" yaml:"length,omitempty"`
+	Name           string            `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// torrentFileStatsV1 is a torrent's file stats.
+type torrentFileStatsV1 struct {
+	BytesCompleted tctypes.ByteCount `json:"bytesCompleted,omitempty" yaml:"bytesCompleted,omitempty"`
+	Wanted         bool              `json:"wanted,omitempty" yaml:"wanted,omitempty"`
+	Priority       tctypes.Priority  `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// torrentPeerV1 is a torrent's peer.
+type torrentPeerV1 struct {
+	Address            string          `json:"address,omitempty" yaml:"address,omitempty"`
+	ClientName         string          `json:"clientName,omitempty" yaml:"clientName,omitempty"`
+	ClientIsChoked     bool            `json:"clientIsChoked,omitempty" yaml:"clientIsChoked,omitempty"`
+	ClientIsInterested bool            `json:"clientIsInterested,omitempty" yaml:"clientIsInterested,omitempty"`
+	FlagStr            string          `json:"flagStr,omitempty" yaml:"flagStr,omitempty"`
+	IsDownloadingFrom  bool            `json:"isDownloadingFrom,omitempty" yaml:"isDownloadingFrom,omitempty"`
+	IsEncrypted        bool            `json:"isEncrypted,omitempty" yaml:"isEncrypted,omitempty"`
+	IsIncoming         bool            `json:"isIncoming,omitempty" yaml:"isIncoming,omitempty"`
+	IsUploadingTo      bool            `json:"isUploadingTo,omitempty" yaml:"isUploadingTo,omitempty"`
+	IsUTP              bool            `json:"isUTP,omitempty" yaml:"isUTP,omitempty"`
+	PeerIsChoked       bool            `json:"peerIsChoked,omitempty" yaml:"peerIsChoked,omitempty"`
+	PeerIsInterested   bool            `json:"peerIsInterested,omitempty" yaml:"peerIsInterested,omitempty"`
+	Port               int64           `json:"port,omitempty" yaml:"port,omitempty"`
+	Progress           tctypes.Percent `json:"progress,omitempty" yaml:"progress,omitempty"`
+	RateToClient       tctypes.Rate    `json:"rateToClient,omitempty" yaml:"rateToClient,omitempty"`
+	RateToPeer         tctypes.Rate    `json:"rateToPeer,omitempty" yaml:"rateToPeer,omitempty"`
+}
+
+// torrentPeersFromV1 is a torrent's peer sources.
+type torrentPeersFromV1 struct {
+	FromCache    int64 `json:"fromCache,omitempty" yaml:"fromCache,omitempty"`
+	FromDht      int64 `json:"fromDht,omitempty" yaml:"fromDht,omitempty"`
+	FromIncoming int64 `json:"fromIncoming,omitempty" yaml:"fromIncoming,omitempty"`
+	FromLpd      int64 `json:"fromLpd,omitempty" yaml:"fromLpd,omitempty"`
+	FromLtep     int64 `json:"fromLtep,omitempty" yaml:"fromLtep,omitempty"`
+	FromPex      int64 `json:"fromPex,omitempty" yaml:"fromPex,omitempty"`
+	FromTracker  int64 `json:"fromTracker,omitempty" yaml:"fromTracker,omitempty"`
+}
+
+// torrentTrackerV1 is a torrent's tracker.
+type torrentTrackerV1 struct {
+	Announce string `json:"announce,omitempty" yaml:"announce,omitempty"`
+	ID       int64  `json:"id,omitempty" yaml:"id,omitempty"`
+	Scrape   string `json:"scrape,omitempty" yaml:"scrape,omitempty"`
+	Tier     int64  `json:"tier,omitempty" yaml:"tier,omitempty"`
+}
+
+// torrentTrackerStatsV1 is a torrent's tracker stats.
+type torrentTrackerStatsV1 struct {
+	Announce              string        `json:"announce,omitempty" yaml:"announce,omitempty"`
+	AnnounceState         tctypes.State `json:"announceState,omitempty" yaml:"announceState,omitempty"`
+	DownloadCount         int64         `json:"downloadCount,omitempty" yaml:"downloadCount,omitempty"`
+	HasAnnounced          bool          `json:"hasAnnounced,omitempty" yaml:"hasAnnounced,omitempty"`
+	HasScraped            bool          `json:"hasScraped,omitempty" yaml:"hasScraped,omitempty"`
+	Host                  string        `json:"host,omitempty" yaml:"host,omitempty"`
+	ID                    int64         `json:"id,omitempty" yaml:"id,omitempty"`
+	IsBackup              bool          `json:"isBackup,omitempty" yaml:"isBackup,omitempty"`
+	LastAnnouncePeerCount int64         `json:"lastAnnouncePeerCount,omitempty" yaml:"lastAnnouncePeerCount,omitempty"`
+	LastAnnounceResult    string        `json:"lastAnnounceResult,omitempty" yaml:"lastAnnounceResult,omitempty"`
+	LastAnnounceStartTime tctypes.Time  `json:"lastAnnounceStartTime,omitempty" yaml:"lastAnnounceStartTime,omitempty"`
+	LastAnnounceSucceeded bool          `json:"lastAnnounceSucceeded,omitempty" yaml:"lastAnnounceSucceeded,omitempty"`
+	LastAnnounceTime      tctypes.Time  `json:"lastAnnounceTime,omitempty" yaml:"lastAnnounceTime,omitempty"`
+	LastAnnounceTimedOut  bool          `json:"lastAnnounceTimedOut,omitempty" yaml:"lastAnnounceTimedOut,omitempty"`
+	LastScrapeResult      string        `json:"lastScrapeResult,omitempty" yaml:"lastScrapeResult,omitempty"`
+	LastScrapeStartTime   tctypes.Time  `json:"lastScrapeStartTime,omitempty" yaml:"lastScrapeStartTime,omitempty"`
+	LastScrapeSucceeded   bool          `json:"lastScrapeSucceeded,omitempty" yaml:"lastScrapeSucceeded,omitempty"`
+	LastScrapeTime        tctypes.Time  `json:"lastScrapeTime,omitempty" yaml:"lastScrapeTime,omitempty"`
+	LastScrapeTimedOut    int64         `json:"lastScrapeTimedOut,omitempty" yaml:"lastScrapeTimedOut,omitempty"`
+	LeecherCount          int64         `json:"leecherCount,omitempty" yaml:"leecherCount,omitempty"`
+	NextAnnounceTime      tctypes.Time  `json:"nextAnnounceTime,omitempty" yaml:"nextAnnounceTime,omitempty"`
+	NextScrapeTime        tctypes.Time  `json:"nextScrapeTime,omitempty" yaml:"nextScrapeTime,omitempty"`
+	Scrape                string        `json:"scrape,omitempty" yaml:"scrape,omitempty"`
+	ScrapeState           tctypes.State `json:"scrapeState,omitempty" yaml:"scrapeState,omitempty"`
+	SeederCount           int64         `json:"seederCount,omitempty" yaml:"seederCount,omitempty"`
+	Tier                  int64         `json:"tier,omitempty" yaml:"tier,omitempty"`
+}
+
+// newTorrentV1 converts a torrent to its v1 output item.
+func newTorrentV1(x tctypes.Torrent) torrentV1 {
+	v := torrentV1{
+		ActivityDate:            x.ActivityDate,
+		AddedDate:               x.AddedDate,
+		Availability:            x.Availability,
+		BandwidthPriority:       x.BandwidthPriority,
+		Comment:                 x.Comment,
+		CorruptEver:             x.CorruptEver,
+		Creator:                 x.Creator,
+		DateCreated:             x.DateCreated,
+		DesiredAvailable:        x.DesiredAvailable,
+		DoneDate:                x.DoneDate,
+		DownloadDir:             x.DownloadDir,
+		DownloadedEver:          x.DownloadedEver,
+		DownloadLimit:           x.DownloadLimit,
+		DownloadLimited:         x.DownloadLimited,
+		EditDate:                x.EditDate,
+		Error:                   x.Error,
+		ErrorString:             x.ErrorString,
+		Eta:                     x.Eta,
+		EtaIdle:                 x.EtaIdle,
+		FileCount:               x.FileCount,
+		Group:                   x.Group,
+		HashString:              x.HashString,
+		HaveUnchecked:           x.HaveUnchecked,
+		HaveValid:               x.HaveValid,
+		HonorsSessionLimits:     x.HonorsSessionLimits,
+		ID:                      x.ID,
+		IsFinished:              x.IsFinished,
+		IsPrivate:               x.IsPrivate,
+		IsStalled:               x.IsStalled,
+		Labels:                  x.Labels,
+		LeftUntilDone:           x.LeftUntilDone,
+		MagnetLink:              x.MagnetLink,
+		ManualAnnounceTime:      x.ManualAnnounceTime,
+		MaxConnectedPeers:       x.MaxConnectedPeers,
+		MetadataPercentComplete: x.MetadataPercentComplete,
+		Name:                    x.Name,
+		PeerLimit:               x.PeerLimit,
+		PeersConnected:          x.PeersConnected,
+		PeersFrom: torrentPeersFromV1{
+			FromCache:    x.PeersFrom.FromCache,
+			FromDht:      x.PeersFrom.FromDht,
+			FromIncoming: x.PeersFrom.FromIncoming,
+			FromLpd:      x.PeersFrom.FromLpd,
+			FromLtep:     x.PeersFrom.FromLtep,
+			FromPex:      x.PeersFrom.FromPex,
+			FromTracker:  x.PeersFrom.FromTracker,
+		},
+		PeersGettingFromUs:  x.PeersGettingFromUs,
+		PeersSendingToUs:    x.PeersSendingToUs,
+		PercentDone:         x.PercentDone,
+		Pieces:              x.Pieces,
+		PieceCount:          x.PieceCount,
+		PieceSize:           x.PieceSize,
+		Priorities:          x.Priorities,
+		PrimaryMimeType:     x.PrimaryMimeType,
+		QueuePosition:       x.QueuePosition,
+		RateDownload:        x.RateDownload,
+		RateUpload:          x.RateUpload,
+		RecheckProgress:     x.RecheckProgress,
+		SecondsDownloading:  x.SecondsDownloading,
+		SecondsSeeding:      x.SecondsSeeding,
+		SeedIdleLimit:       x.SeedIdleLimit,
+		SeedIdleMode:        x.SeedIdleMode,
+		SeedRatioLimit:      x.SeedRatioLimit,
+		SeedRatioMode:       x.SeedRatioMode,
+		SequentialDownload:  x.SequentialDownload,
+		SizeWhenDone:        x.SizeWhenDone,
+		StartDate:           x.StartDate,
+		Status:              x.Status,
+		TrackerList:         x.TrackerList,
+		TotalSize:           x.TotalSize,
+		TorrentFile:         x.TorrentFile,
+		UploadedEver:        x.UploadedEver,
+		UploadLimit:         x.UploadLimit,
+		UploadLimited:       x.UploadLimited,
+		UploadRatio:         x.UploadRatio,
+		Wanted:              x.Wanted,
+		Webseeds:            x.Webseeds,
+		WebseedsSendingToUs: x.WebseedsSendingToUs,
+	}
+	if x.Files != nil {
+		v.Files = make([]torrentFileV1, len(x.Files))
+		for i, z := range x.Files {
+			v.Files[i] = torrentFileV1{
+				BytesCompleted: z.BytesCompleted,
+				Length:         z.Length,
+				Name:           z.Name,
+			}
+		}
+	}
+	if x.FileStats != nil {
+		v.FileStats = make([]torrentFileStatsV1, len(x.FileStats))
+		for i, z := range x.FileStats {
+			v.FileStats[i] = torrentFileStatsV1{
+				BytesCompleted: z.BytesCompleted,
+				Wanted:         z.Wanted,
+				Priority:       z.Priority,
+			}
+		}
+	}
+	if x.Peers != nil {
+		v.Peers = make([]torrentPeerV1, len(x.Peers))
+		for i, z := range x.Peers {
+			v.Peers[i] = torrentPeerV1{
+				Address:            z.Address,
+				ClientName:         z.ClientName,
+				ClientIsChoked:     z.ClientIsChoked,
+				ClientIsInterested: z.ClientIsInterested,
+				FlagStr:            z.FlagStr,
+				IsDownloadingFrom:  z.IsDownloadingFrom,
+				IsEncrypted:        z.IsEncrypted,
+				IsIncoming:         z.IsIncoming,
+				IsUploadingTo:      z.IsUploadingTo,
+				IsUTP:              z.IsUTP,
+				PeerIsChoked:       z.PeerIsChoked,
+				PeerIsInterested:   z.PeerIsInterested,
+				Port:               z.Port,
+				Progress:           z.Progress,
+				RateToClient:       z.RateToClient,
+				RateToPeer:         z.RateToPeer,
+			}
+		}
+	}
+	if x.Trackers != nil {
+		v.Trackers = make([]torrentTrackerV1, len(x.Trackers))
+		for i, z := range x.Trackers {
+			v.Trackers[i] = torrentTrackerV1{
+				Announce: z.Announce,
+				ID:       z.ID,
+				Scrape:   z.Scrape,
+				Tier:     z.Tier,
+			}
+		}
+	}
+	if x.TrackerStats != nil {
+		v.TrackerStats = make([]torrentTrackerStatsV1, len(x.TrackerStats))
+		for i, z := range x.TrackerStats {
+			v.TrackerStats[i] = torrentTrackerStatsV1{
+				Announce:              z.Announce,
+				AnnounceState:         z.AnnounceState,
+				DownloadCount:         z.DownloadCount,
+				HasAnnounced:          z.HasAnnounced,
+				HasScraped:            z.HasScraped,
+				Host:                  z.Host,
+				ID:                    z.ID,
+				IsBackup:              z.IsBackup,
+				LastAnnouncePeerCount: z.LastAnnouncePeerCount,
+				LastAnnounceResult:    z.LastAnnounceResult,
+				LastAnnounceStartTime: z.LastAnnounceStartTime,
+				LastAnnounceSucceeded: z.LastAnnounceSucceeded,
+				LastAnnounceTime:      z.LastAnnounceTime,
+				LastAnnounceTimedOut:  z.LastAnnounceTimedOut,
+				LastScrapeResult:      z.LastScrapeResult,
+				LastScrapeStartTime:   z.LastScrapeStartTime,
+				LastScrapeSucceeded:   z.LastScrapeSucceeded,
+				LastScrapeTime:        z.LastScrapeTime,
+				LastScrapeTimedOut:    z.LastScrapeTimedOut,
+				LeecherCount:          z.LeecherCount,
+				NextAnnounceTime:      z.NextAnnounceTime,
+				NextScrapeTime:        z.NextScrapeTime,
+				Scrape:                z.Scrape,
+				ScrapeState:           z.ScrapeState,
+				SeederCount:           z.SeederCount,
+				Tier:                  z.Tier,
+			}
+		}
+	}
+	return v
+}
+
+// newFileV1 converts a torrent file to its v1 output item.
+func newFileV1(x tctypes.File) fileV1 {
+	return fileV1{
+		BytesCompleted: x.BytesCompleted,
+		Length:         x.Length,
+		Name:           x.Name,
+		Wanted:         x.Wanted,
+		Priority:       x.Priority,
+		ID:             x.ID,
+		Torrent:        x.Torrent,
+		HashString:     x.HashString,
+	}
+}
+
+// newPeerV1 converts a torrent peer to its v1 output item.
+func newPeerV1(x tctypes.Peer) peerV1 {
+	return peerV1{
+		Address:            x.Address,
+		ClientName:         x.ClientName,
+		ClientIsChoked:     x.ClientIsChoked,
+		ClientIsInterested: x.ClientIsInterested,
+		FlagStr:            x.FlagStr,
+		IsDownloadingFrom:  x.IsDownloadingFrom,
+		IsEncrypted:        x.IsEncrypted,
+		IsIncoming:         x.IsIncoming,
+		IsUploadingTo:      x.IsUploadingTo,
+		IsUTP:              x.IsUTP,
+		PeerIsChoked:       x.PeerIsChoked,
+		PeerIsInterested:   x.PeerIsInterested,
+		Port:               x.Port,
+		Progress:           x.Progress,
+		RateToClient:       x.RateToClient,
+		RateToPeer:         x.RateToPeer,
+		Country:            x.Country,
+		ID:                 x.ID,
+		Torrent:            x.Torrent,
+		HashString:         x.HashString,
+	}
+}
+
+// newPeerStatV1 converts a peer stat group to its v1 output item.
+func newPeerStatV1(x tctypes.PeerStat) peerStatV1 {
+	return peerStatV1{
+		Group:        x.Group,
+		Name:         x.Name,
+		Peers:        x.Peers,
+		RateToClient: x.RateToClient,
+		RateToPeer:   x.RateToPeer,
+		ID:           x.ID,
+	}
+}
+
+// newTrackerV1 converts a torrent tracker to its v1 output item.
+func newTrackerV1(x tctypes.Tracker) trackerV1 {
+	return trackerV1{
+		Announce:              x.Announce,
+		ID:                    x.ID,
+		Scrape:                x.Scrape,
+		Tier:                  x.Tier,
+		AnnounceState:         x.AnnounceState,
+		DownloadCount:         x.DownloadCount,
+		HasAnnounced:          x.HasAnnounced,
+		HasScraped:            x.HasScraped,
+		Host:                  x.Host,
+		IsBackup:              x.IsBackup,
+		LastAnnouncePeerCount: x.LastAnnouncePeerCount,
+		LastAnnounceResult:    x.LastAnnounceResult,
+		LastAnnounceStartTime: x.LastAnnounceStartTime,
+		LastAnnounceSucceeded: x.LastAnnounceSucceeded,
+		LastAnnounceTime:      x.LastAnnounceTime,
+		LastAnnounceTimedOut:  x.LastAnnounceTimedOut,
+		LastScrapeResult:      x.LastScrapeResult,
+		LastScrapeStartTime:   x.LastScrapeStartTime,
+		LastScrapeSucceeded:   x.LastScrapeSucceeded,
+		LastScrapeTime:        x.LastScrapeTime,
+		LastScrapeTimedOut:    x.LastScrapeTimedOut,
+		LeecherCount:          x.LeecherCount,
+		NextAnnounceTime:      x.NextAnnounceTime,
+		NextScrapeTime:        x.NextScrapeTime,
+		ScrapeState:           x.ScrapeState,
+		SeederCount:           x.SeederCount,
+		Torrent:               x.Torrent,
+		HashString:            x.HashString,
+	}
+}
+
+// newTrackerReportV1 converts a tracker report to its v1 output item.
+func newTrackerReportV1(x tctypes.TrackerReport) trackerReportV1 {
+	return trackerReportV1{
+		Host:         x.Host,
+		Torrents:     x.Torrents,
+		Announced:    x.Announced,
+		Succeeded:    x.Succeeded,
+		SuccessRate:  x.SuccessRate,
+		AvgPeers:     x.AvgPeers,
+		Errors:       x.Errors,
+		TorrentNames: x.TorrentNames,
+		ID:           x.ID,
+	}
+}
+
+// newPieceMapV1 converts a torrent piece map to its v1 output item.
+func newPieceMapV1(x tctypes.PieceMap) pieceMapV1 {
+	v := pieceMapV1{
+		Torrent:     x.Torrent,
+		HashString:  x.HashString,
+		PieceCount:  x.PieceCount,
+		PieceSize:   x.PieceSize,
+		Have:        x.Have,
+		Downloading: x.Downloading,
+		Missing:     x.Missing,
+	}
+	if x.Files != nil {
+		v.Files = make([]filePiecesV1, len(x.Files))
+		for i, z := range x.Files {
+			v.Files[i] = newFilePiecesV1(z)
+		}
+	}
+	return v
+}
+
+// newLogEntryV1 converts a remote host log message to its v1 output item.
+func newLogEntryV1(x tctypes.LogEntry) logEntryV1 {
+	return logEntryV1{
+		ID:      x.ID,
+		Time:    x.Time,
+		Level:   x.Level,
+		Message: x.Message,
+	}
+}
+
+// newPeerLogEntryV1 converts a remote host peer log message to its v1 output item.
+func newPeerLogEntryV1(x tctypes.PeerLogEntry) peerLogEntryV1 {
+	return peerLogEntryV1{
+		ID:      x.ID,
+		Time:    x.Time,
+		Address: x.Address,
+		Blocked: x.Blocked,
+		Reason:  x.Reason,
+	}
+}
+
+// newFreeSpaceV1 converts remote host free space to its v1 output item.
+func newFreeSpaceV1(x FreeSpace) freeSpaceV1 {
+	return freeSpaceV1{
+		Location:  x.Location,
+		FreeSpace: x.FreeSpace,
+		Error:     x.Error,
+		ID:        x.ID,
+	}
+}
+
+// newStatV1 converts a remote host stat to its v1 output item.
+func newStatV1(x Stat) statV1 {
+	return statV1{
+		Name:  x.Name,
+		Key:   x.Key,
+		Value: x.Value,
+		ID:    x.ID,
+	}
+}
+
+// newLabelV1 converts a label to its v1 output item.
+func newLabelV1(x tctypes.Label) labelV1 {
+	return labelV1{
+		Name:     x.Name,
+		Torrents: x.Torrents,
+	}
+}
+
+// newCategoryV1 converts a category to its v1 output item.
+func newCategoryV1(x tctypes.Category) categoryV1 {
+	return categoryV1{
+		Name:     x.Name,
+		SavePath: x.SavePath,
+	}
+}
+
+// newRSSFeedV1 converts a rss feed to its v1 output item.
+func newRSSFeedV1(x tctypes.RSSFeed) rssFeedV1 {
+	v := rssFeedV1{
+		Path:          x.Path,
+		UID:           x.UID,
+		URL:           x.URL,
+		Title:         x.Title,
+		LastBuildDate: x.LastBuildDate,
+		IsLoading:     x.IsLoading,
+		HasError:      x.HasError,
+	}
+	if x.Articles != nil {
+		v.Articles = make([]rssArticleV1, len(x.Articles))
+		for i, z := range x.Articles {
+			v.Articles[i] = newRSSArticleV1(z)
+		}
+	}
+	return v
+}
+
+// newRSSArticleV1 converts a rss article to its v1 output item.
+func newRSSArticleV1(x tctypes.RSSArticle) rssArticleV1 {
+	return rssArticleV1{
+		ID:          x.ID,
+		Date:        x.Date,
+		Title:       x.Title,
+		Author:      x.Author,
+		Description: x.Description,
+		TorrentURL:  x.TorrentURL,
+		Link:        x.Link,
+		IsRead:      x.IsRead,
+		Feed:        x.Feed,
+	}
+}
+
+// newRSSRuleV1 converts a rss auto-download rule to its v1 output item.
+func newRSSRuleV1(x tctypes.RSSRule) rssRuleV1 {
+	return rssRuleV1{
+		Name:                      x.Name,
+		Enabled:                   x.Enabled,
+		MustContain:               x.MustContain,
+		MustNotContain:            x.MustNotContain,
+		UseRegex:                  x.UseRegex,
+		EpisodeFilter:             x.EpisodeFilter,
+		SmartFilter:               x.SmartFilter,
+		PreviouslyMatchedEpisodes: x.PreviouslyMatchedEpisodes,
+		AffectedFeeds:             x.AffectedFeeds,
+		IgnoreDays:                x.IgnoreDays,
+		LastMatch:                 x.LastMatch,
+		AddPaused:                 x.AddPaused,
+		AssignedCategory:          x.AssignedCategory,
+		SavePath:                  x.SavePath,
+	}
+}
+
+// newRSSRuleMatchV1 converts a rss rule test result to its v1 output item.
+func newRSSRuleMatchV1(x RSSRuleMatch) rssRuleMatchV1 {
+	return rssRuleMatchV1{
+		Rule:    x.Rule,
+		Feed:    x.Feed,
+		Title:   x.Title,
+		Matched: x.Matched,
+		Reason:  x.Reason,
+		ID:      x.ID,
+	}
+}
+
+// newSearchResultV1 converts a search result to its v1 output item.
+func newSearchResultV1(x tctypes.SearchResult) searchResultV1 {
+	return searchResultV1{
+		ID:       x.ID,
+		Name:     x.Name,
+		Size:     x.Size,
+		Seeders:  x.Seeders,
+		Leechers: x.Leechers,
+		Site:     x.Site,
+		Link:     x.Link,
+		URL:      x.URL,
+	}
+}
+
+// newSearchPluginV1 converts a search plugin to its v1 output item.
+func newSearchPluginV1(x tctypes.SearchPlugin) searchPluginV1 {
+	return searchPluginV1{
+		Name:       x.Name,
+		FullName:   x.FullName,
+		Version:    x.Version,
+		Enabled:    x.Enabled,
+		URL:        x.URL,
+		Categories: x.Categories,
+	}
+}
+
+// newFilePiecesV1 converts a file's piece coverage to its v1 output item.
+func newFilePiecesV1(x tctypes.FilePieces) filePiecesV1 {
+	return filePiecesV1{
+		Name:        x.Name,
+		Length:      x.Length,
+		FirstPiece:  x.FirstPiece,
+		LastPiece:   x.LastPiece,
+		Have:        x.Have,
+		Downloading: x.Downloading,
+		Missing:     x.Missing,
+	}
+}
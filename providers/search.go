@@ -0,0 +1,268 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// searchPollInterval is the interval between search status checks.
+const searchPollInterval = 500 * time.Millisecond
+
+// SearchProvider is the interface for providers that support searching with
+// search plugins.
+type SearchProvider interface {
+	// SearchStart starts a search using the plugins and category, returning
+	// the search id.
+	SearchStart(context.Context, string, []string, string) (int64, error)
+
+	// SearchStatus returns whether the search is running, and its total
+	// result count.
+	SearchStatus(context.Context, int64) (bool, int64, error)
+
+	// SearchResults returns the search results.
+	SearchResults(context.Context, int64) ([]tctypes.SearchResult, error)
+
+	// SearchStop stops a running search.
+	SearchStop(context.Context, int64) error
+
+	// SearchDelete deletes a search and its results.
+	SearchDelete(context.Context, int64) error
+
+	// SearchPluginsGet returns the installed search plugins.
+	SearchPluginsGet(context.Context) ([]tctypes.SearchPlugin, error)
+
+	// SearchPluginsInstall installs search plugins from urls or file paths.
+	SearchPluginsInstall(context.Context, ...string) error
+
+	// SearchPluginsUninstall uninstalls search plugins.
+	SearchPluginsUninstall(context.Context, ...string) error
+
+	// SearchPluginsEnable enables or disables search plugins.
+	SearchPluginsEnable(context.Context, bool, ...string) error
+
+	// SearchPluginsUpdate updates all search plugins.
+	SearchPluginsUpdate(context.Context) error
+}
+
+// newSearchProvider creates the provider for the args, checking that it
+// supports search.
+func newSearchProvider(args *Args) (SearchProvider, error) {
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(SearchProvider)
+	if !ok {
+		return nil, fmt.Errorf("search %w", ErrNotSupportedByProvider)
+	}
+	return sp, nil
+}
+
+// DoSearch is the high-level entry point for 'search'.
+//
+// The search is polled until it completes or --wait elapses, after which it
+// is stopped and the results so far are used. As such, the command's timeout
+// is --wait plus the remote host timeout. Results are numbered by most
+// seeders, and the --add result (by id, url, or hash) is added to the
+// --add-context context (or the current context).
+func DoSearch(ctx context.Context, args *Args, cmd string) error {
+	ctx, cancel := context.WithTimeout(ctx, args.SearchParams.Wait+args.Host.Timeout)
+	defer cancel()
+	p, err := newSearchProvider(args)
+	if err != nil {
+		return err
+	}
+	result, err := search(ctx, p, args)
+	if err != nil {
+		return err
+	}
+
+	// add
+	if args.SearchParams.Add != "" {
+		r := findSearchResult(result, args.SearchParams.Add)
+		if r == nil {
+			return fmt.Errorf("search result %s not found", args.SearchParams.Add)
+		}
+		addArgs := args
+		if args.SearchParams.AddContext != "" {
+			addArgs = args.WithContext(args.SearchParams.AddContext)
+		}
+		ap, err := addArgs.NewProvider()
+		if err != nil {
+			return err
+		}
+		torrents, err := ap.Add(ctx, args.AddOptions(), r.URL)
+		if err != nil {
+			return err
+		}
+		// use the same column names as add
+		columnNames := make(map[string]string, len(getColumnNames))
+		for _, n := range getColumnNames {
+			kv := strings.SplitN(n, "=", 2)
+			columnNames[kv[0]] = kv[1]
+		}
+		return NewResult(torrents, args.ResultOptions(
+			TableColumns(defaultTableCols...),
+			WideColumns(defaultWideCols...),
+			FlatName("torrent"),
+			FlatIndex("shortHash"),
+			Kind(KindTorrentList),
+			ColumnNames(columnNames),
+		)...).Encode(os.Stdout)
+	}
+
+	return NewResult(result, args.ResultOptions(
+		TableColumns("id", "name", "size", "seeders", "leechers"),
+		WideColumns("id", "name", "size", "seeders", "leechers", "site", "url"),
+		FlatName("result"),
+		FlatIndex("id"),
+		Index("id"),
+		Kind(KindSearchResultList),
+	)...).Encode(os.Stdout)
+}
+
+// search runs the search, returning the results numbered by most seeders.
+func search(ctx context.Context, p SearchProvider, args *Args) ([]tctypes.SearchResult, error) {
+	id, err := p.SearchStart(ctx, args.SearchParams.Pattern, args.SearchParams.Plugins, args.SearchParams.Category)
+	if err != nil {
+		return nil, err
+	}
+	// delete on a fresh context, as ctx may have expired
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), args.Host.Timeout)
+		defer cancel()
+		_ = p.SearchDelete(ctx, id)
+	}()
+
+	// poll
+	timer := time.NewTimer(args.SearchParams.Wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(searchPollInterval)
+	defer ticker.Stop()
+loop:
+	for {
+		running, _, err := p.SearchStatus(ctx, id)
+		switch {
+		case err != nil:
+			return nil, err
+		case !running:
+			break loop
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			if err := p.SearchStop(ctx, id); err != nil {
+				return nil, err
+			}
+			break loop
+		case <-ticker.C:
+		}
+	}
+
+	// number
+	result, err := p.SearchResults(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Seeders != result[j].Seeders {
+			return result[i].Seeders > result[j].Seeders
+		}
+		return result[i].Size > result[j].Size
+	})
+	for i := range result {
+		result[i].ID = int64(i + 1)
+	}
+	return result, nil
+}
+
+// findSearchResult finds the search result with the id, url, or info hash.
+func findSearchResult(result []tctypes.SearchResult, v string) *tctypes.SearchResult {
+	if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+		for i := range result {
+			if result[i].ID == id {
+				return &result[i]
+			}
+		}
+		return nil
+	}
+	for i := range result {
+		if result[i].URL == v || result[i].Link == v {
+			return &result[i]
+		}
+	}
+	for i := range result {
+		if hash := magnetHash(result[i].URL); hash != "" && strings.EqualFold(hash, v) {
+			return &result[i]
+		}
+	}
+	return nil
+}
+
+// magnetHash returns the info hash of a magnet link, or an empty string.
+func magnetHash(urlstr string) string {
+	if !magnetRE.MatchString(urlstr) {
+		return ""
+	}
+	u, err := url.Parse(urlstr)
+	if err != nil {
+		return ""
+	}
+	for _, xt := range u.Query()["xt"] {
+		if strings.HasPrefix(strings.ToLower(xt), "urn:btih:") {
+			return xt[len("urn:btih:"):]
+		}
+	}
+	return ""
+}
+
+// DoSearchPluginsList is the high-level entry point for 'search plugins
+// list'.
+func DoSearchPluginsList(ctx context.Context, args *Args, cmd string) error {
+	p, err := newSearchProvider(args)
+	if err != nil {
+		return err
+	}
+	result, err := p.SearchPluginsGet(ctx)
+	if err != nil {
+		return err
+	}
+	return NewResult(result, args.ResultOptions(
+		TableColumns("name", "version", "enabled", "url"),
+		WideColumns("name", "fullName", "version", "enabled", "url", "categories"),
+		FlatName("plugin"),
+		FlatIndex("name"),
+		Index("name"),
+		Kind(KindSearchPluginList),
+	)...).Encode(os.Stdout)
+}
+
+// DoSearchPlugins is the high-level entry point for 'search plugins
+// install', 'search plugins uninstall', 'search plugins enable', 'search
+// plugins disable', and 'search plugins update'.
+func DoSearchPlugins(ctx context.Context, args *Args, cmd string) error {
+	p, err := newSearchProvider(args)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "search plugins install":
+		return p.SearchPluginsInstall(ctx, args.SearchParams.Names...)
+	case "search plugins uninstall":
+		return p.SearchPluginsUninstall(ctx, args.SearchParams.Names...)
+	case "search plugins enable", "search plugins disable":
+		return p.SearchPluginsEnable(ctx, cmd == "search plugins enable", args.SearchParams.Names...)
+	case "search plugins update":
+		return p.SearchPluginsUpdate(ctx)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
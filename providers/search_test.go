@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		polls   int
+		wait    time.Duration
+		stopped bool
+	}{
+		{0, time.Minute, false},
+		{2, time.Minute, false},
+		{-1, 10 * time.Millisecond, true},
+	}
+	for i, test := range tests {
+		p := &searchProvider{polls: test.polls, results: []tctypes.SearchResult{
+			{Name: "a", Size: 1, Seeders: 5},
+			{Name: "b", Size: 2, Seeders: 10},
+			{Name: "c", Size: 3, Seeders: 5},
+		}}
+		args := &Args{}
+		args.SearchParams.Pattern, args.SearchParams.Wait = "test", test.wait
+		result, err := search(context.Background(), p, args)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if p.stopped != test.stopped {
+			t.Errorf("test %d expected stopped %t, got: %t", i, test.stopped, p.stopped)
+		}
+		if !p.deleted {
+			t.Errorf("test %d expected search to be deleted", i)
+		}
+		exp := []string{"b", "c", "a"}
+		if len(result) != len(exp) {
+			t.Fatalf("test %d expected %d results, got: %d", i, len(exp), len(result))
+		}
+		for j, r := range result {
+			if r.Name != exp[j] || r.ID != int64(j+1) {
+				t.Errorf("test %d expected result %d to be %s (%d), got: %s (%d)", i, j, exp[j], j+1, r.Name, r.ID)
+			}
+		}
+	}
+}
+
+func TestSearchExpired(t *testing.T) {
+	p := &searchProvider{polls: -1}
+	args := &Args{}
+	args.Host.Timeout = time.Second
+	args.SearchParams.Pattern, args.SearchParams.Wait = "test", time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := search(ctx, p, args); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got: %v", err)
+	}
+	if !p.deleted {
+		t.Fatalf("expected search to be deleted")
+	}
+	if p.deleteErr != nil {
+		t.Errorf("expected search to be deleted with a live context, got: %v", p.deleteErr)
+	}
+}
+
+func TestFindSearchResult(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	result := []tctypes.SearchResult{
+		{ID: 1, Name: "a", URL: "https://example.com/a.torrent", Link: "https://example.com/a"},
+		{ID: 2, Name: "b", URL: "magnet:?xt=urn:btih:" + hash + "&dn=b"},
+		{ID: 3, Name: "c", URL: "magnet:?dn=c&xt=urn:btih:" + strings.ToUpper(hash[:39]) + "8"},
+	}
+	tests := []struct {
+		v   string
+		exp string
+	}{
+		{"1", "a"},
+		{"3", "c"},
+		{"4", ""},
+		{"https://example.com/a.torrent", "a"},
+		{"https://example.com/a", "a"},
+		{"magnet:?xt=urn:btih:" + hash + "&dn=b", "b"},
+		{hash, "b"},
+		{strings.ToUpper(hash), "b"},
+		{hash[:39] + "8", "c"},
+		{hash[:10], ""},
+		{"https://example.com/b.torrent", ""},
+	}
+	for _, test := range tests {
+		r := findSearchResult(result, test.v)
+		switch {
+		case test.exp == "" && r != nil:
+			t.Errorf("%q expected no result, got: %s", test.v, r.Name)
+		case test.exp != "" && r == nil:
+			t.Errorf("%q expected %s, got: nil", test.v, test.exp)
+		case r != nil && r.Name != test.exp:
+			t.Errorf("%q expected %s, got: %s", test.v, test.exp, r.Name)
+		}
+	}
+}
+
+// searchProvider is a search provider that runs for polls status requests
+// (or forever, when less than 0).
+type searchProvider struct {
+	polls   int
+	results []tctypes.SearchResult
+	stopped bool
+	deleted bool
+	// deleteErr is the context error when the search was deleted
+	deleteErr error
+}
+
+func (p *searchProvider) SearchStart(context.Context, string, []string, string) (int64, error) {
+	return 1, nil
+}
+
+func (p *searchProvider) SearchStatus(context.Context, int64) (bool, int64, error) {
+	if p.stopped || p.polls == 0 {
+		return false, int64(len(p.results)), nil
+	}
+	p.polls--
+	return true, 0, nil
+}
+
+func (p *searchProvider) SearchResults(context.Context, int64) ([]tctypes.SearchResult, error) {
+	return append([]tctypes.SearchResult(nil), p.results...), nil
+}
+
+func (p *searchProvider) SearchStop(context.Context, int64) error {
+	p.stopped = true
+	return nil
+}
+
+func (p *searchProvider) SearchDelete(ctx context.Context, _ int64) error {
+	p.deleted, p.deleteErr = true, ctx.Err()
+	return nil
+}
+
+func (p *searchProvider) SearchPluginsGet(context.Context) ([]tctypes.SearchPlugin, error) {
+	return nil, nil
+}
+
+func (p *searchProvider) SearchPluginsInstall(context.Context, ...string) error {
+	return nil
+}
+
+func (p *searchProvider) SearchPluginsUninstall(context.Context, ...string) error {
+	return nil
+}
+
+func (p *searchProvider) SearchPluginsEnable(context.Context, bool, ...string) error {
+	return nil
+}
+
+func (p *searchProvider) SearchPluginsUpdate(context.Context) error {
+	return nil
+}
@@ -0,0 +1,362 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a HTTP JSON API for the remote hosts of the configured contexts.
+//
+// Routes:
+//
+//	GET  /contexts
+//	GET  /contexts/{context}/torrents?filter=&recent=&fields=
+//	POST /contexts/{context}/torrents?download-dir=&paused=
+//	GET  /contexts/{context}/torrents/{id}
+//	GET  /contexts/{context}/torrents/{id}/{files,peers,trackers}
+//	POST /contexts/{context}/torrents/{id}/{start,stop,verify,reannounce}
+//	POST /contexts/{context}/torrents/{id}/remove?rm=
+//	POST /contexts/{context}/torrents/{id}/move?dest=
+//	GET  /contexts/{context}/stats
+//	GET  /contexts/{context}/free-space?location=
+//
+// Results are encoded the same as the equivalent command's JSON output, and
+// are versioned when versioned output is enabled.
+type Server struct {
+	args   *Args
+	logger *Logger
+	provs  map[string]Provider
+	sync.Mutex
+}
+
+// NewServer creates a new HTTP JSON API server.
+func NewServer(args *Args, logger *Logger) *Server {
+	return &Server{
+		args:   args,
+		logger: logger,
+		provs:  make(map[string]Provider),
+	}
+}
+
+// httpError is a HTTP error.
+type httpError struct {
+	code int
+	msg  string
+}
+
+// Error satisfies the error interface.
+func (err *httpError) Error() string {
+	return err.msg
+}
+
+// statusWriter wraps a response writer, capturing the status code.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+// WriteHeader satisfies the http.ResponseWriter interface.
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	w := &statusWriter{ResponseWriter: res, code: http.StatusOK}
+	if err := s.serve(w, req); err != nil {
+		code := http.StatusBadGateway
+		if e, ok := err.(*httpError); ok {
+			code = e.code
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	}
+	s.logger.Info("request", "method", req.Method, "path", req.URL.Path, "status", w.code, "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// serve routes and serves the request.
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) error {
+	// check auth
+	if s.args.ServeParams.Token != "" {
+		auth := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(s.args.ServeParams.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			return &httpError{http.StatusUnauthorized, "unauthorized"}
+		}
+	}
+	if req.Method != "GET" && req.Method != "POST" {
+		return &httpError{http.StatusMethodNotAllowed, "method not allowed"}
+	}
+	if req.Method != "GET" && s.args.ServeParams.ReadOnly {
+		return &httpError{http.StatusForbidden, "read-only mode"}
+	}
+
+	// split path
+	path := strings.Trim(req.URL.Path, "/")
+	if path != "contexts" && !strings.HasPrefix(path, "contexts/") {
+		return &httpError{http.StatusNotFound, "not found"}
+	}
+	parts := strings.Split(path, "/")[1:]
+	if len(parts) == 0 {
+		if req.Method != "GET" {
+			return &httpError{http.StatusMethodNotAllowed, "method not allowed"}
+		}
+		return writeJSON(w, s.contexts())
+	}
+
+	// load provider
+	name := parts[0]
+	if !s.hasContext(name) {
+		return &httpError{http.StatusNotFound, "unknown context " + name}
+	}
+	args := s.args.WithContext(name)
+	p, err := s.provider(args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), args.Host.Timeout)
+	defer cancel()
+
+	q := req.URL.Query()
+	switch strings.Join(append([]string{req.Method}, routeParts(parts[1:])...), " ") {
+	case "GET torrents":
+		args.Filter.Filter = q.Get("filter")
+		args.Filter.Recent = q.Get("recent") == "true" || q.Get("recent") == "1"
+		args.Filter.ListAll = !args.Filter.Recent && args.Filter.Filter == ""
+		return s.writeTorrents(ctx, w, args, p, splitList(q.Get("fields")))
+
+	case "POST torrents":
+		return s.add(ctx, w, req, p)
+
+	case "GET torrents {id}":
+		args.Filter.Filter, args.Args = defaultFilter, []string{parts[2]}
+		return s.writeTorrents(ctx, w, args, p, splitList(q.Get("fields")))
+	}
+
+	if len(parts) == 4 && parts[1] == "torrents" {
+		args.Filter.Filter, args.Args = defaultFilter, []string{parts[2]}
+		torrents, err := FindTorrents(ctx, args, p)
+		if err != nil {
+			return &httpError{http.StatusBadRequest, err.Error()}
+		}
+		if len(torrents) == 0 {
+			return &httpError{http.StatusNotFound, "no matching torrents"}
+		}
+		ids := ConvertTorrentIDs(torrents)
+		switch req.Method + " " + parts[3] {
+		case "GET files":
+			files, err := p.FilesGet(ctx, ids...)
+			if err != nil {
+				return err
+			}
+			return s.writeResult(w, files, YamlName("files"), FlatKey("id"), Kind(KindFileList))
+		case "GET peers":
+			peers, err := p.PeersGet(ctx, ids...)
+			if err != nil {
+				return err
+			}
+			return s.writeResult(w, peers, YamlName("peers"), FlatKey("id"), Kind(KindPeerList))
+		case "GET trackers":
+			trackers, err := p.TrackersGet(ctx, ids...)
+			if err != nil {
+				return err
+			}
+			return s.writeResult(w, trackers, YamlName("trackers"), FlatKey("id"), Kind(KindTrackerList))
+		case "POST start":
+			err = p.Start(ctx, ids...)
+		case "POST stop":
+			err = p.Stop(ctx, ids...)
+		case "POST verify":
+			err = p.Verify(ctx, ids...)
+		case "POST reannounce":
+			err = p.Reannounce(ctx, ids...)
+		case "POST remove":
+			err = p.Remove(ctx, q.Get("rm") == "true" || q.Get("rm") == "1", ids...)
+		case "POST move":
+			if q.Get("dest") == "" {
+				return &httpError{http.StatusBadRequest, "must specify dest"}
+			}
+			err = p.Move(ctx, q.Get("dest"), ids...)
+		default:
+			return &httpError{http.StatusNotFound, "not found"}
+		}
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, map[string]interface{}{"ok": true, "torrents": ids})
+	}
+
+	switch req.Method + " " + strings.Join(parts[1:], "/") {
+	case "GET stats":
+		stats, err := p.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		return s.writeResult(w, NewStats(stats), YamlName("session-stats"), Kind(KindStatList))
+
+	case "GET free-space":
+		locations := q["location"]
+		if len(locations) == 0 {
+			locations = splitList(args.getContextKey("free-space"))
+		}
+		if len(locations) == 0 {
+			return &httpError{http.StatusBadRequest, ErrMustSpecifyAtLeastOneLocation.Error()}
+		}
+		return s.writeResult(w, NewFreeSpace(ctx, p, locations...), YamlName("free-space"), Kind(KindFreeSpaceList))
+	}
+	return &httpError{http.StatusNotFound, "not found"}
+}
+
+// routeParts replaces the torrent identifier in the path parts with a
+// placeholder.
+func routeParts(parts []string) []string {
+	if len(parts) > 1 && parts[0] == "torrents" {
+		parts = append([]string{parts[0], "{id}"}, parts[2:]...)
+	}
+	return parts
+}
+
+// writeTorrents writes the torrents matching args as JSON. When identifiers
+// were passed in args, and no torrents matched, a not found error is returned.
+func (s *Server) writeTorrents(ctx context.Context, w http.ResponseWriter, args *Args, p Provider, fields []string) error {
+	torrents, err := FindTorrents(ctx, args, p)
+	switch {
+	case err != nil:
+		return &httpError{http.StatusBadRequest, err.Error()}
+	case len(torrents) == 0 && len(args.Args) != 0:
+		return &httpError{http.StatusNotFound, "no matching torrents"}
+	case len(torrents) != 0:
+		if torrents, err = p.Get(ctx, fields, ConvertTorrentIDs(torrents)...); err != nil {
+			return err
+		}
+	}
+	return s.writeResult(w, torrents, Kind(KindTorrentList))
+}
+
+// add adds the torrent or magnet link in the request body.
+func (s *Server) add(ctx context.Context, w http.ResponseWriter, req *http.Request, p Provider) error {
+	buf, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, 32<<20))
+	if err != nil {
+		return &httpError{http.StatusBadRequest, err.Error()}
+	}
+	var file interface{} = buf
+	if req.Header.Get("Content-Type") != "application/x-bittorrent" {
+		file = strings.TrimSpace(string(buf))
+	}
+	q := req.URL.Query()
+	opts := AddOptions{
+		DownloadDir: q.Get("download-dir"),
+		Paused:      q.Get("paused") == "true" || q.Get("paused") == "1",
+		Labels:      q["label"],
+	}
+	torrents, err := p.Add(ctx, opts, file)
+	if err != nil {
+		return err
+	}
+	return s.writeResult(w, torrents, Kind(KindTorrentList))
+}
+
+// contexts returns the configured context names.
+func (s *Server) contexts() []string {
+	names := []string{}
+	for _, n := range s.args.Config.SectionNames() {
+		if strings.HasPrefix(n, "context.") {
+			names = append(names, strings.TrimPrefix(n, "context."))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasContext determines if the named context is configured.
+func (s *Server) hasContext(name string) bool {
+	if name == "default" {
+		return true
+	}
+	for _, n := range s.contexts() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// provider returns the provider for the context, creating it if necessary.
+func (s *Server) provider(args *Args) (Provider, error) {
+	s.Lock()
+	defer s.Unlock()
+	if p, ok := s.provs[args.Context]; ok {
+		return p, nil
+	}
+	p, err := args.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	s.provs[args.Context] = p
+	return p, nil
+}
+
+// writeResult writes v as JSON, encoded the same as a command's JSON output
+// (versioned, when versioned output is enabled). Empty results are written
+// as an empty list.
+func (s *Server) writeResult(w http.ResponseWriter, v interface{}, opts ...ResultOption) error {
+	buf := new(bytes.Buffer)
+	if err := NewResult(v, append(opts, Output("json"), Versioned(s.args.Output.Versioned))...).Encode(buf); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		buf.WriteString("[]\n")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeJSON writes v as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// DoServe is the high-level entry point for 'serve'.
+func DoServe(ctx context.Context, args *Args, cmd string) error {
+	logger := NewLogger(os.Stderr, args.ServeParams.LogFormat)
+
+	// cancel on signal
+	ctx, cancel := signalContext(ctx, logger)
+	defer cancel()
+
+	s := &http.Server{
+		Addr:    args.ServeParams.Listen,
+		Handler: NewServer(args, logger),
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), args.Host.Timeout)
+		defer cancel()
+		_ = s.Shutdown(shutdownCtx)
+	}()
+	if args.ServeParams.Token == "" {
+		logger.Warn("no token specified, requests will not be authenticated")
+	}
+	logger.Info("listening", "addr", s.Addr, "read-only", args.ServeParams.ReadOnly)
+	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestServe(t *testing.T) {
+	const (
+		hash1 = "0123456789abcdef0123456789abcdef01234567"
+		hash2 = "89abcdef0123456789abcdef0123456789abcdef"
+	)
+	p := &serveProvider{torrents: []tctypes.Torrent{
+		{ID: 1, HashString: hash1, Name: "one"},
+		{ID: 2, HashString: hash2, Name: "two"},
+	}}
+	s, hs := newTestServer(t, p, "token", false)
+	defer hs.Close()
+	tests := []struct {
+		method string
+		path   string
+		auth   string
+		body   string
+		code   int
+		exp    string
+	}{
+		// auth
+		{"GET", "/contexts", "", "", http.StatusUnauthorized, `"unauthorized"`},
+		{"GET", "/contexts", "token", "", http.StatusUnauthorized, `"unauthorized"`},
+		{"GET", "/contexts", "Basic token", "", http.StatusUnauthorized, `"unauthorized"`},
+		{"GET", "/contexts", "Bearer bad", "", http.StatusUnauthorized, `"unauthorized"`},
+		{"GET", "/contexts", "Bearer token", "", http.StatusOK, `"other"`},
+		// routes
+		{"PUT", "/contexts", "Bearer token", "", http.StatusMethodNotAllowed, `"method not allowed"`},
+		{"GET", "/other", "Bearer token", "", http.StatusNotFound, `"not found"`},
+		{"GET", "/contexts/missing/torrents", "Bearer token", "", http.StatusNotFound, `"unknown context missing"`},
+		{"GET", "/contexts/default/torrents", "Bearer token", "", http.StatusOK, `"two"`},
+		{"GET", "/contexts/default/torrents/one", "Bearer token", "", http.StatusOK, `"one"`},
+		{"GET", "/contexts/default/torrents/three", "Bearer token", "", http.StatusNotFound, `"no matching torrents"`},
+		{"GET", "/contexts/default/torrents?filter=name+%3D%3D+%22three%22", "Bearer token", "", http.StatusOK, `[]`},
+		{"GET", "/contexts/default/torrents/three/files", "Bearer token", "", http.StatusNotFound, `"no matching torrents"`},
+		{"POST", "/contexts/default/torrents/two/stop", "Bearer token", "", http.StatusOK, hash2},
+		{"POST", "/contexts/default/torrents/two/move", "Bearer token", "", http.StatusBadRequest, `"must specify dest"`},
+		{"POST", "/contexts/default/torrents/two/bad", "Bearer token", "", http.StatusNotFound, `"not found"`},
+		{"GET", "/contexts/default/stats", "Bearer token", "", http.StatusOK, `"value": 2`},
+		{"GET", "/contexts/default/free-space?location=/dl", "Bearer token", "", http.StatusOK, `"freeSpace": 1024`},
+		{"GET", "/contexts/default/free-space", "Bearer token", "", http.StatusBadRequest, `"must specify at least one location"`},
+		{"POST", "/contexts/default/torrents?download-dir=/dl&paused=1&label=a&label=b", "Bearer token", "magnet:?xt=urn:btih:" + hash1, http.StatusOK, `"one"`},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			code, body := doServeRequest(t, hs.URL, test.method, test.path, test.auth, test.body)
+			if code != test.code {
+				t.Errorf("expected status %d, got: %d (%s)", test.code, code, body)
+			}
+			if !strings.Contains(body, test.exp) {
+				t.Errorf("expected body to contain %q, got: %s", test.exp, body)
+			}
+		})
+	}
+	if exp := []interface{}{hash2}; !reflect.DeepEqual(p.stopped, exp) {
+		t.Errorf("expected stopped %v, got: %v", exp, p.stopped)
+	}
+	exp := AddOptions{DownloadDir: "/dl", Paused: true, Labels: []string{"a", "b"}}
+	if !reflect.DeepEqual(p.opts, exp) {
+		t.Errorf("expected add options %+v, got: %+v", exp, p.opts)
+	}
+	if !reflect.DeepEqual(s.args.AddParams, (&Args{}).AddParams) {
+		t.Errorf("expected add params to be unchanged, got: %+v", s.args.AddParams)
+	}
+}
+
+func TestServeVersioned(t *testing.T) {
+	p := &serveProvider{torrents: []tctypes.Torrent{
+		{ID: 1, HashString: "0123456789abcdef0123456789abcdef01234567", Name: "one"},
+	}}
+	s, hs := newTestServer(t, p, "", false)
+	defer hs.Close()
+	s.args.Output.Versioned = true
+	tests := []struct {
+		path string
+		exp  string
+	}{
+		{"/contexts/default/torrents", `"kind": "TorrentList"`},
+		{"/contexts/default/torrents?filter=name+%3D%3D+%22three%22", `"items": []`},
+		{"/contexts/default/torrents/one/files", `"kind": "FileList"`},
+		{"/contexts/default/stats", `"kind": "StatList"`},
+		{"/contexts/default/free-space?location=/dl", `"kind": "FreeSpaceList"`},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			code, body := doServeRequest(t, hs.URL, "GET", test.path, "", "")
+			if code != http.StatusOK {
+				t.Errorf("expected status %d, got: %d (%s)", http.StatusOK, code, body)
+			}
+			if !strings.Contains(body, `"apiVersion": "`+APIVersion+`"`) || !strings.Contains(body, test.exp) {
+				t.Errorf("expected versioned body to contain %q, got: %s", test.exp, body)
+			}
+		})
+	}
+}
+
+func TestServeReadOnly(t *testing.T) {
+	p := &serveProvider{torrents: []tctypes.Torrent{
+		{ID: 1, HashString: "0123456789abcdef0123456789abcdef01234567", Name: "one"},
+	}}
+	_, hs := newTestServer(t, p, "", true)
+	defer hs.Close()
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{"GET", "/contexts/default/torrents", http.StatusOK},
+		{"GET", "/contexts/default/stats", http.StatusOK},
+		{"POST", "/contexts/default/torrents", http.StatusForbidden},
+		{"POST", "/contexts/default/torrents/one/stop", http.StatusForbidden},
+		{"POST", "/contexts/default/torrents/one/remove?rm=1", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			if code, body := doServeRequest(t, hs.URL, test.method, test.path, "", ""); code != test.code {
+				t.Errorf("expected status %d, got: %d (%s)", test.code, code, body)
+			}
+		})
+	}
+	if p.stopped != nil || p.removed {
+		t.Errorf("expected no changes in read-only mode")
+	}
+}
+
+// newTestServer creates a server for the provider, using it for all
+// contexts.
+func newTestServer(t *testing.T, p Provider, token string, readOnly bool) (*Server, *httptest.Server) {
+	t.Helper()
+	config := ini.NewFile()
+	config.SetKey("context.other.type", "transmission")
+	args := &Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.ServeParams.Token = token
+	args.ServeParams.ReadOnly = readOnly
+	s := NewServer(args, NewLogger(ioutil.Discard, ""))
+	s.provs[""], s.provs["default"], s.provs["other"] = p, p, p
+	return s, httptest.NewServer(s)
+}
+
+// doServeRequest performs a request against the server, returning the status
+// code and body.
+func doServeRequest(t *testing.T, urlstr, method, path, auth, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, urlstr+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer res.Body.Close()
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got: %q", ct)
+	}
+	if !json.Valid(buf) {
+		t.Errorf("expected valid json, got: %s", buf)
+	}
+	return res.StatusCode, string(buf)
+}
+
+// serveProvider is a provider with a fixed torrent list that records
+// changes.
+type serveProvider struct {
+	Provider
+	torrents []tctypes.Torrent
+	opts     AddOptions
+	stopped  []interface{}
+	removed  bool
+}
+
+func (p *serveProvider) Add(_ context.Context, opts AddOptions, files ...interface{}) ([]tctypes.Torrent, error) {
+	p.opts = opts
+	return p.torrents[:len(files)], nil
+}
+
+func (p *serveProvider) Get(_ context.Context, _ []string, ids ...interface{}) ([]tctypes.Torrent, error) {
+	if len(ids) == 0 {
+		return p.torrents, nil
+	}
+	var res []tctypes.Torrent
+	for _, t := range p.torrents {
+		for _, id := range ids {
+			if id == t.HashString {
+				res = append(res, t)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (p *serveProvider) Stop(_ context.Context, ids ...interface{}) error {
+	p.stopped = ids
+	return nil
+}
+
+func (p *serveProvider) Remove(context.Context, bool, ...interface{}) error {
+	p.removed = true
+	return nil
+}
+
+func (p *serveProvider) FilesGet(context.Context, ...interface{}) ([]tctypes.File, error) {
+	return nil, nil
+}
+
+func (p *serveProvider) FreeSpace(context.Context, string) (tctypes.ByteCount, error) {
+	return 1024, nil
+}
+
+func (p *serveProvider) Stats(context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"activeTorrentCount": len(p.torrents)}, nil
+}
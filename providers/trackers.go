@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// DoTrackersReport is the high-level entry point for 'trackers report'.
+//
+// The trackers of all the torrents are aggregated by announce host.
+func DoTrackersReport(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	var trackers []tctypes.Tracker
+	if len(torrents) != 0 {
+		if trackers, err = p.TrackersGet(ctx, ConvertTorrentIDs(torrents)...); err != nil {
+			return err
+		}
+	}
+	return NewResult(NewTrackerReports(trackers), args.ResultOptions(
+		TableColumns("host", "torrents", "successRate", "avgPeers", "errors"),
+		WideColumns("host", "torrents", "announced", "succeeded", "successRate", "avgPeers", "errors", "torrentNames"),
+		FlatName("tracker-report"),
+		FlatIndex("id"),
+		Index("id"),
+		NoTotals(true),
+		Kind(KindTrackerReportList),
+	)...).Encode(os.Stdout)
+}
+
+// NewTrackerReports aggregates the trackers by announce host, ordered by
+// host.
+//
+// The success rate is the ratio of trackers whose last announce succeeded to
+// those that have announced, and the average peers is the average peer count
+// of the last successful announces.
+func NewTrackerReports(trackers []tctypes.Tracker) []tctypes.TrackerReport {
+	type report struct {
+		tctypes.TrackerReport
+		peers  int64
+		hashes map[string]bool
+		names  map[string]bool
+		errs   map[string]bool
+	}
+	m := make(map[string]*report)
+	var hosts []string
+	for _, tracker := range trackers {
+		host := trackerHost(tracker.Announce)
+		r, ok := m[host]
+		if !ok {
+			r = &report{
+				TrackerReport: tctypes.TrackerReport{Host: host},
+				hashes:        make(map[string]bool),
+				names:         make(map[string]bool),
+				errs:          make(map[string]bool),
+			}
+			m[host] = r
+			hosts = append(hosts, host)
+		}
+		r.hashes[tracker.HashString], r.names[tracker.Torrent] = true, true
+		switch {
+		case !tracker.HasAnnounced:
+		case tracker.LastAnnounceSucceeded:
+			r.Announced, r.Succeeded, r.peers = r.Announced+1, r.Succeeded+1, r.peers+tracker.LastAnnouncePeerCount
+		default:
+			r.Announced++
+			if s := strings.TrimSpace(tracker.LastAnnounceResult); s != "" {
+				r.errs[s] = true
+			}
+		}
+	}
+	sort.Strings(hosts)
+	reports := make([]tctypes.TrackerReport, len(hosts))
+	for i, host := range hosts {
+		r := m[host]
+		r.ID, r.Torrents = int64(i), int64(len(r.hashes))
+		if r.Announced != 0 {
+			r.SuccessRate = tctypes.Percent(float64(r.Succeeded) / float64(r.Announced))
+		}
+		if r.Succeeded != 0 {
+			r.AvgPeers = math.Round(float64(r.peers)/float64(r.Succeeded)*10) / 10
+		}
+		r.Errors, r.TorrentNames = setKeys(r.errs), setKeys(r.names)
+		reports[i] = r.TrackerReport
+	}
+	return reports
+}
+
+// setKeys returns the sorted, non-empty keys of the set m.
+func setKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// trackerHost returns the (lower case) host of a tracker's announce url.
+func trackerHost(announce string) string {
+	if u, err := url.Parse(announce); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return announce
+}
+
+// DoTrackersDedupe is the high-level entry point for 'trackers dedupe'.
+func DoTrackersDedupe(ctx context.Context, args *Args, cmd string) error {
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	torrents, err := FindTorrents(ctx, args, p)
+	if err != nil {
+		return err
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+	trackers, err := p.TrackersGet(ctx, ConvertTorrentIDs(torrents)...)
+	if err != nil {
+		return err
+	}
+	dupes := duplicateTrackers(trackers)
+	hashes := make([]string, 0, len(dupes))
+	for hash := range dupes {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		if err := p.TrackersRemoveIDs(ctx, hash, dupes[hash]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicateTrackers returns the ids of the duplicate trackers of each
+// torrent, keyed by the torrent's hash. The tracker in the lowest tier is
+// kept, and announce urls are compared after normalizing their scheme, host,
+// and port.
+func duplicateTrackers(trackers []tctypes.Tracker) map[string][]int64 {
+	trackers = append([]tctypes.Tracker(nil), trackers...)
+	sort.SliceStable(trackers, func(i, j int) bool {
+		switch {
+		case trackers[i].HashString != trackers[j].HashString:
+			return trackers[i].HashString < trackers[j].HashString
+		case trackers[i].Tier != trackers[j].Tier:
+			return trackers[i].Tier < trackers[j].Tier
+		}
+		return trackers[i].ID < trackers[j].ID
+	})
+	seen := make(map[[2]string]bool)
+	dupes := make(map[string][]int64)
+	for _, tracker := range trackers {
+		key := [2]string{tracker.HashString, normalizeAnnounce(tracker.Announce)}
+		if seen[key] {
+			dupes[tracker.HashString] = append(dupes[tracker.HashString], tracker.ID)
+			continue
+		}
+		seen[key] = true
+	}
+	return dupes
+}
+
+// normalizeAnnounce normalizes an announce url's scheme, host, and port for
+// comparison. The path and query (which often contain a passkey) are left
+// unchanged.
+func normalizeAnnounce(announce string) string {
+	announce = strings.TrimSpace(announce)
+	u, err := url.Parse(announce)
+	if err != nil || u.Host == "" {
+		return announce
+	}
+	u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	switch port := u.Port(); {
+	case u.Scheme == "http" && port == "80", u.Scheme == "https" && port == "443":
+		u.Host = u.Hostname()
+	}
+	return u.String()
+}
+
+// trackerReplacement is a tracker replacement for a torrent.
+type trackerReplacement struct {
+	hash, tracker, replace string
+}
+
+// doTrackersReplaceRegexp replaces the trackers of the torrents matching the
+// regexp, expanding the replacement as with regexp.ReplaceAllString.
+func doTrackersReplaceRegexp(ctx context.Context, p Provider, expr, replace string, ids []interface{}) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid tracker regexp %q: %w", expr, err)
+	}
+	trackers, err := p.TrackersGet(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, r := range replaceTrackers(trackers, re, replace) {
+		if err := p.TrackersReplace(ctx, r.tracker, r.replace, r.hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceTrackers returns the replacements for the trackers matching the
+// regexp.
+func replaceTrackers(trackers []tctypes.Tracker, re *regexp.Regexp, replace string) []trackerReplacement {
+	var replacements []trackerReplacement
+	seen := make(map[[2]string]bool)
+	for _, tracker := range trackers {
+		key := [2]string{tracker.HashString, tracker.Announce}
+		if seen[key] || !re.MatchString(tracker.Announce) {
+			continue
+		}
+		seen[key] = true
+		if s := re.ReplaceAllString(tracker.Announce, replace); s != tracker.Announce {
+			replacements = append(replacements, trackerReplacement{tracker.HashString, tracker.Announce, s})
+		}
+	}
+	return replacements
+}
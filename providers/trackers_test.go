@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+func TestNewTrackerReports(t *testing.T) {
+	trackers := []tctypes.Tracker{
+		{Announce: "http://tracker.example.com/announce", HasAnnounced: true, LastAnnounceSucceeded: true, LastAnnouncePeerCount: 12, Torrent: "debian", HashString: "a"},
+		{Announce: "https://TRACKER.example.com:443/announce", HasAnnounced: true, LastAnnounceSucceeded: true, LastAnnouncePeerCount: 3, Torrent: "debian", HashString: "a"},
+		{Announce: "http://tracker.example.com/announce", HasAnnounced: true, LastAnnounceSucceeded: true, LastAnnouncePeerCount: 5, Torrent: "ubuntu", HashString: "b"},
+		{Announce: "udp://open.example.org:6969/announce", HasAnnounced: true, LastAnnounceResult: "Connection failed", Torrent: "debian", HashString: "a"},
+		{Announce: "udp://open.example.org:6969/announce", HasAnnounced: true, LastAnnounceResult: "Connection failed", Torrent: "ubuntu", HashString: "b"},
+		{Announce: "udp://open.example.org:6969/announce", HasAnnounced: true, LastAnnounceSucceeded: true, LastAnnouncePeerCount: 1, Torrent: "fedora", HashString: "c"},
+		{Announce: "udp://open.example.org:6969/announce", HasAnnounced: true, LastAnnounceResult: "Timed out", Torrent: "arch", HashString: "d"},
+		{Announce: "http://new.example.net/announce", Torrent: "fedora", HashString: "c"},
+	}
+	exp := []string{
+		"0 new.example.net 1 0/0 0% 0 [] [fedora]",
+		"1 open.example.org 4 1/4 25% 1 [Connection failed Timed out] [arch debian fedora ubuntu]",
+		"2 tracker.example.com 2 3/3 100% 6.7 [] [debian ubuntu]",
+	}
+	var lines []string
+	for _, r := range NewTrackerReports(trackers) {
+		lines = append(lines, fmt.Sprintf("%d %s %d %d/%d %s %v %v %v", r.ID, r.Host, r.Torrents, r.Succeeded, r.Announced, r.SuccessRate, r.AvgPeers, r.Errors, r.TorrentNames))
+	}
+	if s := strings.Join(lines, "\n"); s != strings.Join(exp, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), s)
+	}
+}
+
+func TestDuplicateTrackers(t *testing.T) {
+	trackers := []tctypes.Tracker{
+		{ID: 0, Tier: 1, Announce: "http://tracker.example.com/announce?passkey=abc", HashString: "a"},
+		{ID: 1, Tier: 0, Announce: "http://TRACKER.example.com:80/announce?passkey=abc", HashString: "a"},
+		{ID: 2, Tier: 2, Announce: "http://tracker.example.com/announce?passkey=ABC", HashString: "a"},
+		{ID: 3, Tier: 2, Announce: "https://tracker.example.com:443/announce?passkey=abc", HashString: "a"},
+		{ID: 4, Tier: 3, Announce: "https://tracker.example.com/announce?passkey=abc", HashString: "a"},
+		{ID: 0, Tier: 0, Announce: "http://tracker.example.com/announce?passkey=abc", HashString: "b"},
+		{ID: 1, Tier: 0, Announce: "udp://open.example.org:6969/announce", HashString: "b"},
+		{ID: 2, Tier: 1, Announce: "udp://open.example.org:6969/announce", HashString: "b"},
+		{ID: 0, Tier: 0, Announce: "udp://open.example.org:6969/announce", HashString: "c"},
+	}
+	exp := map[string][]int64{
+		"a": {0, 4},
+		"b": {2},
+	}
+	if dupes := duplicateTrackers(trackers); !reflect.DeepEqual(dupes, exp) {
+		t.Errorf("expected %v, got: %v", exp, dupes)
+	}
+}
+
+func TestReplaceTrackers(t *testing.T) {
+	trackers := []tctypes.Tracker{
+		{Announce: "http://tracker.example.com/announce?passkey=abc", HashString: "a"},
+		{Announce: "udp://open.example.org:6969/announce", HashString: "a"},
+		{Announce: "http://tracker.example.com/announce?passkey=abc", HashString: "b"},
+		{Announce: "http://tracker.example.com/announce?passkey=abc", HashString: "b"},
+		{Announce: "http://other.example.com/announce?passkey=abc", HashString: "c"},
+	}
+	tests := []struct {
+		expr    string
+		replace string
+		exp     []string
+	}{
+		{`passkey=abc`, `passkey=xyz`, []string{
+			"a http://tracker.example.com/announce?passkey=abc http://tracker.example.com/announce?passkey=xyz",
+			"b http://tracker.example.com/announce?passkey=abc http://tracker.example.com/announce?passkey=xyz",
+			"c http://other.example.com/announce?passkey=abc http://other.example.com/announce?passkey=xyz",
+		}},
+		{`^http://tracker\.example\.com/(.*)$`, `https://tracker.example.net/$1`, []string{
+			"a http://tracker.example.com/announce?passkey=abc https://tracker.example.net/announce?passkey=abc",
+			"b http://tracker.example.com/announce?passkey=abc https://tracker.example.net/announce?passkey=abc",
+		}},
+		{`^udp://(?P<host>[^:]+):6969`, `udp://${host}:1337`, []string{
+			"a udp://open.example.org:6969/announce udp://open.example.org:1337/announce",
+		}},
+		{`announce`, `announce`, nil},
+		{`^https://`, `http://`, nil},
+	}
+	for i, test := range tests {
+		var lines []string
+		for _, r := range replaceTrackers(trackers, regexp.MustCompile(test.expr), test.replace) {
+			lines = append(lines, r.hash+" "+r.tracker+" "+r.replace)
+		}
+		if !reflect.DeepEqual(lines, test.exp) {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, lines)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package transmission
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/tctypes"
 	"github.com/kenshaw/transctl/transrpc"
 )
 
@@ -13,45 +17,692 @@ func init() {
 	providers.Register("transmission", New)
 }
 
-/*
-	"config":             doConfig,
-	"add":                doAdd,
-	"get":                doGet,
-	"set":                doSet,
-	"start":              doReq(transrpc.TorrentStart),
-	"stop":               doReq(transrpc.TorrentStop),
-	"move":               doMove,
-	"remove":             doRemove,
-	"verify":             doReq(transrpc.TorrentVerify),
-	"reannounce":         doReq(transrpc.TorrentReannounce),
-	"queue top":          doReq(transrpc.QueueMoveTop),
-	"queue bottom":       doReq(transrpc.QueueMoveBottom),
-	"queue up":           doReq(transrpc.QueueMoveUp),
-	"queue down":         doReq(transrpc.QueueMoveDown),
-	"peers get":          doPeersGet,
-	"files get":          doFilesGet,
-	"files set-priority": doFilesSetPriority,
-	"files set-wanted":   doFilesSet("FilesWanted"),
-	"files set-unwanted": doFilesSet("FilesUnwanted"),
-	"files rename":       doFilesRename,
-	"trackers get":       doTrackersGet,
-	"trackers add":       doTrackersAdd,
-	"trackers replace":   doTrackersReplace,
-	"trackers remove":    doTrackersRemove,
-	"stats":              doStats,
-	"shutdown":           doShutdown,
-	"free-space":         doFreeSpace,
-	"blocklist-update":   doBlocklistUpdate,
-	"port-test":          doPortTest,
-*/
-
 // Provider is a transmission rpc host provider.
 type Provider struct {
+	args *providers.Args
+	cl   *transrpc.Client
 }
 
 // New creates a new transmission rpc host provdier.
 func New(args *providers.Args) (providers.Provider, error) {
-	return &Provider{}, nil
+	remote, err := args.Remote()
+	if err != nil {
+		return nil, err
+	}
+
+	// build options
+	opts := []transrpc.ClientOption{
+		transrpc.WithUserAgent(remote.UserAgent),
+		transrpc.WithURL(remote.URL.String()),
+		transrpc.WithTimeout(remote.Timeout),
+	}
+	if remote.Fallback != nil {
+		opts = append(opts, transrpc.WithCredentialFallback(remote.Fallback[0], remote.Fallback[1]))
+	}
+	if remote.TLS != nil {
+		opts = append(opts, transrpc.WithTLSConfig(remote.TLS))
+	}
+	if remote.Dialer != nil {
+		opts = append(opts, transrpc.WithDialer(remote.Dialer))
+	}
+	if remote.Session != nil {
+		opts = append(opts, transrpc.WithSessionCache(remote.Session))
+	}
+	switch {
+	case remote.HAR != nil:
+		opts = append(opts, transrpc.WithHAR(remote.HAR))
+	case remote.Logf != nil:
+		opts = append(opts, transrpc.WithLogf(remote.Logf))
+	}
+	if !remote.Strict {
+		opts = append(opts, transrpc.WithLenient(remote.Logf))
+	}
+
+	return &Provider{args: args, cl: transrpc.NewClient(opts...)}, nil
+}
+
+// Add satisfies the Provider interface.
+func (p *Provider) Add(ctx context.Context, opts providers.AddOptions, files ...interface{}) ([]tctypes.Torrent, error) {
+	var result []tctypes.Torrent
+	for _, file := range files {
+		// build request
+		req := transrpc.TorrentAdd().
+			WithCookiesMap(opts.Cookies).
+			WithDownloadDir(opts.DownloadDir).
+			WithPaused(opts.Paused).
+			WithPeerLimit(opts.PeerLimit).
+			WithBandwidthPriority(opts.BandwidthPriority)
+		switch v := file.(type) {
+		case []byte:
+			req = req.WithMetainfo(v)
+		case string:
+			req = req.WithFilename(v)
+		default:
+			return nil, fmt.Errorf("invalid torrent type %T", file)
+		}
+
+		// execute
+		res, err := req.Do(ctx, p.cl)
+		if err != nil {
+			return nil, err
+		}
+		var t *tctypes.Torrent
+		switch {
+		case res.TorrentAdded != nil:
+			t = res.TorrentAdded
+		case res.TorrentDuplicate != nil:
+			t = res.TorrentDuplicate
+		default:
+			continue
+		}
+
+		// set labels
+		if len(opts.Labels) != 0 {
+			if err = transrpc.TorrentSet(t.HashString).WithLabels(opts.Labels).Do(ctx, p.cl); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+// Get satisfies the Provider interface.
+func (p *Provider) Get(ctx context.Context, fields []string, ids ...interface{}) ([]tctypes.Torrent, error) {
+	res, err := transrpc.TorrentGet(ids...).WithFields(fields...).Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	return res.Torrents, nil
+}
+
+// Set satisfies the Provider interface.
+func (p *Provider) Set(ctx context.Context, opts map[string]interface{}, ids ...interface{}) error {
+	return doWithAndExecute(ctx, p.cl, transrpc.TorrentSet(ids...), "torrent", toVals(opts)...)
+}
+
+// Start satisfies the Provider interface.
+func (p *Provider) Start(ctx context.Context, ids ...interface{}) error {
+	return p.cl.TorrentStart(ctx, ids...)
+}
+
+// Stop satisfies the Provider interface.
+func (p *Provider) Stop(ctx context.Context, ids ...interface{}) error {
+	return p.cl.TorrentStop(ctx, ids...)
+}
+
+// Move satisfies the Provider interface.
+func (p *Provider) Move(ctx context.Context, dest string, ids ...interface{}) error {
+	return p.cl.TorrentSetLocation(ctx, dest, true, ids...)
+}
+
+// Remove satisfies the Provider interface.
+func (p *Provider) Remove(ctx context.Context, deleteLocalData bool, ids ...interface{}) error {
+	return p.cl.TorrentRemove(ctx, deleteLocalData, ids...)
+}
+
+// Verify satisfies the Provider interface.
+func (p *Provider) Verify(ctx context.Context, ids ...interface{}) error {
+	return p.cl.TorrentVerify(ctx, ids...)
+}
+
+// Reannounce satisfies the Provider interface.
+func (p *Provider) Reannounce(ctx context.Context, ids ...interface{}) error {
+	return p.cl.TorrentReannounce(ctx, ids...)
+}
+
+// Queue satisfies the Provider interface.
+func (p *Provider) Queue(ctx context.Context, dir string, ids ...interface{}) error {
+	switch dir {
+	case "top":
+		return p.cl.QueueMoveTop(ctx, ids...)
+	case "bottom":
+		return p.cl.QueueMoveBottom(ctx, ids...)
+	case "up":
+		return p.cl.QueueMoveUp(ctx, ids...)
+	case "down":
+		return p.cl.QueueMoveDown(ctx, ids...)
+	}
+	return fmt.Errorf("invalid queue direction %q", dir)
+}
+
+// PeersGet satisfies the Provider interface.
+func (p *Provider) PeersGet(ctx context.Context, ids ...interface{}) ([]tctypes.Peer, error) {
+	res, err := transrpc.TorrentGet(ids...).WithFields("name", "hashString", "peers").Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.Peer
+	for _, t := range res.Torrents {
+		for i, v := range t.Peers {
+			result = append(result, tctypes.Peer{
+				Address:            v.Address,
+				ClientName:         v.ClientName,
+				ClientIsChoked:     v.ClientIsChoked,
+				ClientIsInterested: v.ClientIsInterested,
+				FlagStr:            v.FlagStr,
+				IsDownloadingFrom:  v.IsDownloadingFrom,
+				IsEncrypted:        v.IsEncrypted,
+				IsIncoming:         v.IsIncoming,
+				IsUploadingTo:      v.IsUploadingTo,
+				IsUTP:              v.IsUTP,
+				PeerIsChoked:       v.PeerIsChoked,
+				PeerIsInterested:   v.PeerIsInterested,
+				Port:               v.Port,
+				Progress:           v.Progress,
+				RateToClient:       v.RateToClient,
+				RateToPeer:         v.RateToPeer,
+				ID:                 int64(i),
+				Torrent:            t.Name,
+				HashString:         t.HashString,
+			})
+		}
+	}
+	return result, nil
+}
+
+// PeersAdd satisfies the Provider interface.
+func (p *Provider) PeersAdd(context.Context, []string, ...interface{}) error {
+	return fmt.Errorf("peers add %w", providers.ErrNotSupportedByProvider)
+}
+
+// PeersBan satisfies the Provider interface.
+func (p *Provider) PeersBan(context.Context, ...string) error {
+	return fmt.Errorf("peers ban %w", providers.ErrNotSupportedByProvider)
+}
+
+// FilesGet satisfies the Provider interface.
+func (p *Provider) FilesGet(ctx context.Context, ids ...interface{}) ([]tctypes.File, error) {
+	res, err := transrpc.TorrentGet(ids...).WithFields("name", "hashString", "files", "fileStats").Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.File
+	for _, t := range res.Torrents {
+		for i, v := range t.Files {
+			result = append(result, tctypes.File{
+				BytesCompleted: v.BytesCompleted,
+				Length:         v.Length,
+				Name:           v.Name,
+				Wanted:         t.FileStats[i].Wanted,
+				Priority:       t.FileStats[i].Priority.String(),
+				ID:             int64(i),
+				Torrent:        t.Name,
+				HashString:     t.HashString,
+			})
+		}
+	}
+	return result, nil
+}
+
+// FilesSet satisfies the Provider interface.
+func (p *Provider) FilesSet(ctx context.Context, opts map[string]interface{}, ids ...interface{}) error {
+	return doWithAndExecute(ctx, p.cl, transrpc.TorrentSet(ids...), "files", toVals(opts)...)
+}
+
+// FilesRename satisfies the Provider interface.
+func (p *Provider) FilesRename(ctx context.Context, oldPath, newPath string, ids ...interface{}) error {
+	return p.cl.TorrentRenamePath(ctx, oldPath, newPath, ids...)
+}
+
+// PiecesGet satisfies the Provider interface.
+//
+// Transmission only reports the pieces it has, and all other pieces are
+// reported as missing.
+func (p *Provider) PiecesGet(ctx context.Context, ids ...interface{}) ([]tctypes.PieceMap, error) {
+	res, err := transrpc.TorrentGet(ids...).WithFields("name", "hashString", "pieces", "pieceCount", "pieceSize", "files").Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.PieceMap
+	for _, t := range res.Torrents {
+		m := tctypes.PieceMap{
+			Torrent:    t.Name,
+			HashString: t.HashString,
+			PieceCount: t.PieceCount,
+			PieceSize:  t.PieceSize,
+			States:     make([]tctypes.PieceState, t.PieceCount),
+		}
+		// pieces is a bitfield, with the first piece in the high bit
+		for i := int64(0); i < t.PieceCount && i/8 < int64(len(t.Pieces)); i++ {
+			if t.Pieces[i/8]&(0x80>>uint(i%8)) != 0 {
+				m.States[i] = tctypes.PieceStateHave
+			}
+		}
+		var offset int64
+		for _, v := range t.Files {
+			f := tctypes.FilePieces{
+				Name:   v.Name,
+				Length: v.Length,
+			}
+			if t.PieceSize != 0 {
+				f.FirstPiece, f.LastPiece = offset/int64(t.PieceSize), offset/int64(t.PieceSize)
+				if v.Length != 0 {
+					f.LastPiece = (offset + int64(v.Length) - 1) / int64(t.PieceSize)
+				}
+			}
+			offset += int64(v.Length)
+			m.Files = append(m.Files, f)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// TrackersGet satisfies the Provider interface.
+func (p *Provider) TrackersGet(ctx context.Context, ids ...interface{}) ([]tctypes.Tracker, error) {
+	res, err := transrpc.TorrentGet(ids...).WithFields("name", "hashString", "trackers", "trackerStats").Do(ctx, p.cl)
+	if err != nil {
+		return nil, err
+	}
+	var result []tctypes.Tracker
+	for _, t := range res.Torrents {
+		for i, v := range t.Trackers {
+			tracker := tctypes.Tracker{
+				Announce:   v.Announce,
+				ID:         v.ID,
+				Scrape:     v.Scrape,
+				Tier:       v.Tier,
+				Torrent:    t.Name,
+				HashString: t.HashString,
+			}
+			// tracker stats may be missing or shorter than the trackers
+			if i < len(t.TrackerStats) {
+				s := t.TrackerStats[i]
+				tracker.AnnounceState = s.AnnounceState
+				tracker.DownloadCount = s.DownloadCount
+				tracker.HasAnnounced = s.HasAnnounced
+				tracker.HasScraped = s.HasScraped
+				tracker.Host = s.Host
+				tracker.IsBackup = s.IsBackup
+				tracker.LastAnnouncePeerCount = s.LastAnnouncePeerCount
+				tracker.LastAnnounceResult = s.LastAnnounceResult
+				tracker.LastAnnounceStartTime = s.LastAnnounceStartTime
+				tracker.LastAnnounceSucceeded = s.LastAnnounceSucceeded
+				tracker.LastAnnounceTime = s.LastAnnounceTime
+				tracker.LastAnnounceTimedOut = s.LastAnnounceTimedOut
+				tracker.LastScrapeResult = s.LastScrapeResult
+				tracker.LastScrapeStartTime = s.LastScrapeStartTime
+				tracker.LastScrapeSucceeded = s.LastScrapeSucceeded
+				tracker.LastScrapeTime = s.LastScrapeTime
+				tracker.LastScrapeTimedOut = s.LastScrapeTimedOut
+				tracker.LeecherCount = s.LeecherCount
+				tracker.NextAnnounceTime = s.NextAnnounceTime
+				tracker.NextScrapeTime = s.NextScrapeTime
+				tracker.ScrapeState = s.ScrapeState
+				tracker.SeederCount = s.SeederCount
+			}
+			result = append(result, tracker)
+		}
+	}
+	return result, nil
+}
+
+// TrackersAdd satisfies the Provider interface.
+//
+// Trackers are added to a specific tier by rewriting the torrent's tracker
+// list, which requires rpc version 17+.
+func (p *Provider) TrackersAdd(ctx context.Context, tracker string, tier int64, ids ...interface{}) error {
+	if tier < 0 {
+		return p.cl.TorrentSet(ctx, transrpc.TorrentSet(ids...).WithTrackerAdd(tracker))
+	}
+	version, err := p.cl.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version.RPCVersion < 17 {
+		return fmt.Errorf("tracker tiers (rpc version %d < 17) %w", version.RPCVersion, providers.ErrNotSupportedByProvider)
+	}
+	res, err := transrpc.TorrentGet(ids...).WithFields("hashString", "trackers").Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Torrents {
+		trackers := make([]tctypes.Tracker, len(t.Trackers))
+		for i, v := range t.Trackers {
+			trackers[i] = tctypes.Tracker{Announce: v.Announce, Tier: v.Tier}
+		}
+		if err := p.cl.TorrentSet(ctx, transrpc.TorrentSet(t.HashString).WithTrackerList(trackerList(trackers, tracker, tier))); err != nil {
+			return fmt.Errorf("could not add tracker %s to %s: %w", tracker, t.HashString, err)
+		}
+	}
+	return nil
+}
+
+// trackerList builds a tracker list (announce urls, one per line, with a
+// blank line between tiers) from the trackers, adding the tracker to the
+// tier. The tracker is added to a new last tier when the tier does not exist.
+func trackerList(trackers []tctypes.Tracker, tracker string, tier int64) string {
+	var tiers []int64
+	m := make(map[int64][]string)
+	for _, v := range trackers {
+		if v.Announce == tracker {
+			continue
+		}
+		if _, ok := m[v.Tier]; !ok {
+			tiers = append(tiers, v.Tier)
+		}
+		m[v.Tier] = append(m[v.Tier], v.Announce)
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i] < tiers[j]
+	})
+	if _, ok := m[tier]; !ok {
+		tiers = append(tiers, tier)
+	}
+	m[tier] = append(m[tier], tracker)
+	var lists []string
+	for _, t := range tiers {
+		lists = append(lists, strings.Join(m[t], "\n"))
+	}
+	return strings.Join(lists, "\n\n")
+}
+
+// TrackersReplace satisfies the Provider interface.
+func (p *Provider) TrackersReplace(ctx context.Context, tracker, replace string, ids ...interface{}) error {
+	res, err := transrpc.TorrentGet(ids...).WithFields("hashString", "trackers").Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Torrents {
+		for _, v := range t.Trackers {
+			if v.Announce != tracker {
+				continue
+			}
+			if err := p.cl.TorrentSet(ctx, transrpc.TorrentSet(t.HashString).WithTrackerReplace(v.ID, replace)); err != nil {
+				return fmt.Errorf("could not replace tracker %d (%s) with %s for %s: %w", v.ID, tracker, replace, t.HashString, err)
+			}
+		}
+	}
+	return nil
+}
+
+// TrackersRemove satisfies the Provider interface.
+func (p *Provider) TrackersRemove(ctx context.Context, tracker string, ids ...interface{}) error {
+	res, err := transrpc.TorrentGet(ids...).WithFields("hashString", "trackers").Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Torrents {
+		for _, v := range t.Trackers {
+			if v.Announce != tracker {
+				continue
+			}
+			if err := p.cl.TorrentSet(ctx, transrpc.TorrentSet(t.HashString).WithTrackerRemove(v.ID)); err != nil {
+				return fmt.Errorf("could not remove tracker %d (%s) from %s: %w", v.ID, tracker, t.HashString, err)
+			}
+		}
+	}
+	return nil
+}
+
+// TrackersRemoveIDs satisfies the Provider interface.
+func (p *Provider) TrackersRemoveIDs(ctx context.Context, hash string, ids ...int64) error {
+	return p.cl.TorrentSet(ctx, transrpc.TorrentSet(hash).WithTrackerRemove(ids...))
+}
+
+// LabelsAdd satisfies the Provider interface.
+func (p *Provider) LabelsAdd(ctx context.Context, labels []string, ids ...interface{}) error {
+	return p.labelsUpdate(ctx, ids, func(l []string) []string {
+		for _, label := range labels {
+			if !contains(l, label) {
+				l = append(l, label)
+			}
+		}
+		return l
+	})
+}
+
+// LabelsRemove satisfies the Provider interface.
+func (p *Provider) LabelsRemove(ctx context.Context, labels []string, ids ...interface{}) error {
+	return p.labelsUpdate(ctx, ids, func(l []string) []string {
+		var z []string
+		for _, label := range l {
+			if !contains(labels, label) {
+				z = append(z, label)
+			}
+		}
+		return z
+	})
+}
+
+// LabelsSet satisfies the Provider interface.
+func (p *Provider) LabelsSet(ctx context.Context, labels []string, ids ...interface{}) error {
+	if labels == nil {
+		labels = []string{}
+	}
+	return p.cl.TorrentSet(ctx, transrpc.TorrentSet(ids...).WithLabels(labels))
+}
+
+// labelsUpdate updates the labels of each of the torrents with f, as the
+// transmission rpc only supports replacing the entire label list.
+func (p *Provider) labelsUpdate(ctx context.Context, ids []interface{}, f func([]string) []string) error {
+	res, err := transrpc.TorrentGet(ids...).WithFields("hashString", "labels").Do(ctx, p.cl)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Torrents {
+		labels := f(append([]string(nil), t.Labels...))
+		if equal(labels, t.Labels) {
+			continue
+		}
+		if err := p.LabelsSet(ctx, labels, t.HashString); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CategoriesGet satisfies the Provider interface.
+func (p *Provider) CategoriesGet(context.Context) ([]tctypes.Category, error) {
+	return nil, errCategoriesNotSupported
+}
+
+// CategoriesCreate satisfies the Provider interface.
+func (p *Provider) CategoriesCreate(context.Context, string, string) error {
+	return errCategoriesNotSupported
+}
+
+// CategoriesEdit satisfies the Provider interface.
+func (p *Provider) CategoriesEdit(context.Context, string, string) error {
+	return errCategoriesNotSupported
+}
+
+// CategoriesDelete satisfies the Provider interface.
+func (p *Provider) CategoriesDelete(context.Context, ...string) error {
+	return errCategoriesNotSupported
+}
+
+// errCategoriesNotSupported is the error returned for category operations,
+// as transmission only has labels.
+var errCategoriesNotSupported = fmt.Errorf("categories %w (use labels)", providers.ErrNotSupportedByProvider)
+
+// Stats satisfies the Provider interface.
+func (p *Provider) Stats(ctx context.Context) (map[string]interface{}, error) {
+	res, err := p.cl.SessionStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"active-torrent-count":              res.ActiveTorrentCount,
+		"download-speed":                    res.DownloadSpeed,
+		"paused-torrent-count":              res.PausedTorrentCount,
+		"torrent-count":                     res.TorrentCount,
+		"upload-speed":                      res.UploadSpeed,
+		"cumulative-stats.uploaded-bytes":   res.CumulativeStats.UploadedBytes,
+		"cumulative-stats.downloaded-bytes": res.CumulativeStats.DownloadedBytes,
+		"cumulative-stats.files-added":      res.CumulativeStats.FilesAdded,
+		"cumulative-stats.session-count":    res.CumulativeStats.SessionCount,
+		"cumulative-stats.seconds-active":   res.CumulativeStats.SecondsActive,
+		"current-stats.uploaded-bytes":      res.CurrentStats.UploadedBytes,
+		"current-stats.downloaded-bytes":    res.CurrentStats.DownloadedBytes,
+		"current-stats.files-added":         res.CurrentStats.FilesAdded,
+		"current-stats.session-count":       res.CurrentStats.SessionCount,
+		"current-stats.seconds-active":      res.CurrentStats.SecondsActive,
+	}, nil
+}
+
+// FreeSpace satisfies the Provider interface.
+func (p *Provider) FreeSpace(ctx context.Context, location string) (tctypes.ByteCount, error) {
+	return p.cl.FreeSpace(ctx, location)
+}
+
+// BlocklistUpdate satisfies the Provider interface.
+func (p *Provider) BlocklistUpdate(ctx context.Context) (int64, error) {
+	return p.cl.BlocklistUpdate(ctx)
+}
+
+// PortTest satisfies the Provider interface.
+func (p *Provider) PortTest(ctx context.Context) (bool, error) {
+	return p.cl.PortTest(ctx)
+}
+
+// Shutdown satisfies the Provider interface.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.cl.SessionClose(ctx)
+}
+
+// AltSpeedSet satisfies the LimitsProvider interface.
+func (p *Provider) AltSpeedSet(ctx context.Context, enabled bool) error {
+	return p.cl.SessionSet(ctx, transrpc.SessionSet().WithAltSpeedEnabled(enabled))
+}
+
+// LimitsGet satisfies the LimitsProvider interface.
+func (p *Provider) LimitsGet(ctx context.Context) (tctypes.SpeedLimits, error) {
+	session, err := p.cl.SessionGet(ctx)
+	if err != nil {
+		return tctypes.SpeedLimits{}, err
+	}
+	k := speedBytes(session)
+	return tctypes.SpeedLimits{
+		DownloadLimit:    tctypes.Rate(session.SpeedLimitDown * k),
+		DownloadLimited:  session.SpeedLimitDownEnabled,
+		UploadLimit:      tctypes.Rate(session.SpeedLimitUp * k),
+		UploadLimited:    session.SpeedLimitUpEnabled,
+		AltEnabled:       session.AltSpeedEnabled,
+		AltDownloadLimit: tctypes.Rate(session.AltSpeedDown * k),
+		AltUploadLimit:   tctypes.Rate(session.AltSpeedUp * k),
+		ScheduleEnabled:  session.AltSpeedTimeEnabled,
+		ScheduleBegin:    tctypes.TimeOfDay(session.AltSpeedTimeBegin),
+		ScheduleEnd:      tctypes.TimeOfDay(session.AltSpeedTimeEnd),
+		ScheduleDays:     tctypes.Days(session.AltSpeedTimeDay),
+	}, nil
+}
+
+// LimitsSet satisfies the LimitsProvider interface.
+func (p *Provider) LimitsSet(ctx context.Context, limits tctypes.SpeedLimits) error {
+	// speed limits are in the session's speed units
+	session, err := p.cl.SessionGet(ctx)
+	if err != nil {
+		return err
+	}
+	k := speedBytes(session)
+	return p.cl.SessionSet(ctx, transrpc.SessionSet().
+		WithSpeedLimitDown(toSpeedUnits(limits.DownloadLimit, k)).
+		WithSpeedLimitDownEnabled(limits.DownloadLimited).
+		WithSpeedLimitUp(toSpeedUnits(limits.UploadLimit, k)).
+		WithSpeedLimitUpEnabled(limits.UploadLimited).
+		WithAltSpeedEnabled(limits.AltEnabled).
+		WithAltSpeedDown(toSpeedUnits(limits.AltDownloadLimit, k)).
+		WithAltSpeedUp(toSpeedUnits(limits.AltUploadLimit, k)).
+		WithAltSpeedTimeEnabled(limits.ScheduleEnabled).
+		WithAltSpeedTimeBegin(int64(limits.ScheduleBegin)).
+		WithAltSpeedTimeEnd(int64(limits.ScheduleEnd)).
+		WithAltSpeedTimeDay(int64(limits.ScheduleDays)),
+	)
+}
+
+// TorrentLimitsSet satisfies the LimitsProvider interface.
+//
+// Transmission does not have seeding time limits, and unlimited speeds are
+// set by not honoring the session limits, which applies to both download and
+// upload speeds.
+func (p *Provider) TorrentLimitsSet(ctx context.Context, limits tctypes.TorrentLimits, ids ...interface{}) error {
+	if limits.SeedingTimeMode != nil {
+		return fmt.Errorf("seeding time limit %w", providers.ErrNotSupportedByProvider)
+	}
+	req := transrpc.TorrentSet(ids...)
+	if limits.RatioMode != nil {
+		req = req.WithSeedRatioMode(*limits.RatioMode)
+		if *limits.RatioMode == tctypes.ModeSingle {
+			req = req.WithSeedRatioLimit(limits.RatioLimit)
+		}
+	}
+	if limits.IdleMode != nil {
+		req = req.WithSeedIdleMode(*limits.IdleMode)
+		if *limits.IdleMode == tctypes.ModeSingle {
+			req = req.WithSeedIdleLimit(int64(limits.IdleLimit / time.Minute))
+		}
+	}
+	if limits.DownloadMode == nil && limits.UploadMode == nil {
+		return req.Do(ctx, p.cl)
+	}
+
+	// speed limits are in the session's speed units
+	session, err := p.cl.SessionGet(ctx)
+	if err != nil {
+		return err
+	}
+	k := speedBytes(session)
+	var global, unlimited bool
+	if mode := limits.DownloadMode; mode != nil {
+		switch *mode {
+		case tctypes.ModeGlobal:
+			global = true
+		case tctypes.ModeSingle:
+			req = req.WithDownloadLimit(toSpeedUnits(limits.DownloadLimit, k))
+		case tctypes.ModeUnlimited:
+			unlimited = true
+		}
+		req = req.WithDownloadLimited(*mode == tctypes.ModeSingle)
+	}
+	if mode := limits.UploadMode; mode != nil {
+		switch *mode {
+		case tctypes.ModeGlobal:
+			global = true
+		case tctypes.ModeSingle:
+			req = req.WithUploadLimit(toSpeedUnits(limits.UploadLimit, k))
+		case tctypes.ModeUnlimited:
+			unlimited = true
+		}
+		req = req.WithUploadLimited(*mode == tctypes.ModeSingle)
+	}
+	switch {
+	case global && unlimited:
+		return fmt.Errorf("global and unlimited speed limits together %w", providers.ErrNotSupportedByProvider)
+	case global, unlimited:
+		req = req.WithHonorsSessionLimits(global)
+	}
+	return req.Do(ctx, p.cl)
+}
+
+// ModeSet satisfies the ModeProvider interface.
+//
+// Sequential download requires rpc version 18+. Torrents can be force started
+// (started now, bypassing the queue), but cannot be set as no longer force
+// started.
+func (p *Provider) ModeSet(ctx context.Context, mode tctypes.DownloadMode, on bool, ids ...interface{}) error {
+	switch mode {
+	case tctypes.DownloadModeSequential:
+		version, err := p.cl.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version.RPCVersion < 18 {
+			return fmt.Errorf("sequential download (rpc version %d < 18) %w", version.RPCVersion, providers.ErrNotSupportedByProvider)
+		}
+		return transrpc.TorrentSet(ids...).WithSequentialDownload(on).Do(ctx, p.cl)
+	case tctypes.DownloadModeForceStart:
+		if !on {
+			return fmt.Errorf("force start off %w", providers.ErrNotSupportedByProvider)
+		}
+		return p.cl.TorrentStartNow(ctx, ids...)
+	case tctypes.DownloadModeFirstLast:
+		return fmt.Errorf("first and last piece priority %w", providers.ErrNotSupportedByProvider)
+	case tctypes.DownloadModeSuperSeed:
+		return fmt.Errorf("super seeding %w", providers.ErrNotSupportedByProvider)
+	}
+	return fmt.Errorf("download mode %v %w", mode, providers.ErrNotSupportedByProvider)
 }
 
 // RemoteConfigStore wraps setting configuration for the transrpc rpc host.
@@ -62,7 +713,7 @@ type RemoteConfigStore struct {
 }
 
 // NewRemoteConfigStore creates a new remote config store.
-func (p *Provider) NewRemoteConfigStore(ctx context.Context) (providers.RemoveConfigStore, error) {
+func (p *Provider) NewRemoteConfigStore(ctx context.Context) (providers.ConfigStore, error) {
 	session, err := p.cl.SessionGet(ctx)
 	if err != nil {
 		return nil, err
@@ -80,17 +731,29 @@ func (r *RemoteConfigStore) SetKey(key, value string) {
 	r.setKeys = append(r.setKeys, key, value)
 }
 
+// RemoveKey satisfies the ConfigStore interface. Remote options cannot be
+// unset, so this only removes any pending change for the key.
+func (r *RemoteConfigStore) RemoveKey(key string) {
+	var setKeys []string
+	for i := 0; i < len(r.setKeys); i += 2 {
+		if r.setKeys[i] != key {
+			setKeys = append(setKeys, r.setKeys[i], r.setKeys[i+1])
+		}
+	}
+	r.setKeys = setKeys
+}
+
 // GetMapFlat satisfies the ConfigStore interface.
 func (r *RemoteConfigStore) GetMapFlat() map[string]string {
 	m := make(map[string]string)
-	addFieldsToMap(m, "", reflect.ValueOf(*r.session))
+	providers.AddFieldsToMap(m, "", reflect.ValueOf(*r.session))
 	return m
 }
 
 // GetAllFlat satisfies the ConfigStore interface.
 func (r *RemoteConfigStore) GetAllFlat() []string {
 	m := make(map[string]string)
-	addFieldsToMap(m, "", reflect.ValueOf(*r.session))
+	providers.AddFieldsToMap(m, "", reflect.ValueOf(*r.session))
 	var keys []string
 	for k := range m {
 		keys = append(keys, k)
@@ -105,5 +768,5 @@ func (r *RemoteConfigStore) GetAllFlat() []string {
 
 // Write satisfies the ConfigStore interface.
 func (r *RemoteConfigStore) Write(string) error {
-	return doWithAndExecute(r.cl, transrpc.SessionSet(), "--remote config", r.setKeys...)
+	return doWithAndExecute(context.Background(), r.cl, transrpc.SessionSet(), "--remote config", r.setKeys...)
 }
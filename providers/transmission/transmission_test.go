@@ -0,0 +1,87 @@
+package transmission
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/providers"
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
+	"github.com/knq/ini"
+)
+
+func TestCapabilities(t *testing.T) {
+	s, p := newTestProvider(t)
+	defer s.Close()
+	var v interface{} = p
+	if _, ok := v.(providers.LimitsProvider); !ok {
+		t.Errorf("expected provider to support limits")
+	}
+	if _, ok := v.(providers.ModeProvider); !ok {
+		t.Errorf("expected provider to support download modes")
+	}
+	if _, ok := v.(providers.LogProvider); ok {
+		t.Errorf("expected provider to not support logs")
+	}
+	if _, ok := v.(providers.RSSProvider); ok {
+		t.Errorf("expected provider to not support rss")
+	}
+	if _, ok := v.(providers.SearchProvider); ok {
+		t.Errorf("expected provider to not support search")
+	}
+}
+
+// newTestProvider creates a fake Transmission server, and a provider for it.
+// The caller must close the server.
+func newTestProvider(t *testing.T) (*transctltest.Transmission, *Provider) {
+	t.Helper()
+	s := transctltest.NewTransmission()
+	u, err := url.Parse(s.URL + "/transmission/rpc")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	args := &providers.Args{Config: ini.NewFile()}
+	args.Host.URL = u
+	args.Host.Timeout = 10 * time.Second
+	args.Host.NoNetrc = true
+	args.Host.Strict = true
+	p, err := New(args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return s, p.(*Provider)
+}
+
+func TestModeSet(t *testing.T) {
+	s, p := newTestProvider(t)
+	defer s.Close()
+	id := s.AddTorrent("0123456789abcdef0123456789abcdef01234567", "one")
+	ctx := context.Background()
+	tests := []struct {
+		mode tctypes.DownloadMode
+		on   bool
+		exp  string
+	}{
+		{tctypes.DownloadModeSequential, true, "sequential download (rpc version 16 < 18) not supported by provider"},
+		{tctypes.DownloadModeFirstLast, true, "first and last piece priority not supported by provider"},
+		{tctypes.DownloadModeFirstLast, false, "first and last piece priority not supported by provider"},
+		{tctypes.DownloadModeSuperSeed, true, "super seeding not supported by provider"},
+		{tctypes.DownloadModeSuperSeed, false, "super seeding not supported by provider"},
+		{tctypes.DownloadModeForceStart, false, "force start off not supported by provider"},
+		{tctypes.DownloadModeForceStart, true, ""},
+	}
+	for _, test := range tests {
+		err := p.ModeSet(ctx, test.mode, test.on, id)
+		switch {
+		case test.exp == "" && err != nil:
+			t.Errorf("%v %t expected no error, got: %v", test.mode, test.on, err)
+		case test.exp != "" && !errors.Is(err, providers.ErrNotSupportedByProvider):
+			t.Errorf("%v %t expected not supported error, got: %v", test.mode, test.on, err)
+		case test.exp != "" && err.Error() != test.exp:
+			t.Errorf("%v %t expected %q, got: %q", test.mode, test.on, test.exp, err.Error())
+		}
+	}
+}
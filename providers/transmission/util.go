@@ -1 +1,152 @@
 package transmission
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transrpc"
+	"github.com/knq/snaker"
+)
+
+// executor interface is the common interface for settable requests.
+type executor interface {
+	Do(context.Context, *transrpc.Client) error
+}
+
+// doWithAndExecute calls the 'With*' method on the reflected request for the
+// provided name, value pairs in vals.
+func doWithAndExecute(ctx context.Context, cl *transrpc.Client, req executor, errMsg string, vals ...string) error {
+	if len(vals)%2 != 0 {
+		panic("invalid vals")
+	}
+	for i := 0; i < len(vals); i += 2 {
+		v := reflect.ValueOf(req)
+		name := "With" + snaker.ForceCamelIdentifier(vals[i])
+		f := v.MethodByName(name)
+		if f.Kind() == reflect.Invalid {
+			return fmt.Errorf("unsupported setting %s option %q", errMsg, vals[i])
+		}
+		args := make([]reflect.Value, 1)
+		switch f.Type().In(0).Kind() {
+		case reflect.String:
+			args[0] = reflect.ValueOf(vals[i+1]).Convert(f.Type().In(0))
+		case reflect.Int64:
+			z, err := strconv.ParseInt(vals[i+1], 10, 64)
+			if err != nil {
+				return err
+			}
+			args[0] = reflect.ValueOf(z).Convert(f.Type().In(0))
+		case reflect.Float64:
+			z, err := strconv.ParseFloat(vals[i+1], 64)
+			if err != nil {
+				return err
+			}
+			args[0] = reflect.ValueOf(z).Convert(f.Type().In(0))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(vals[i+1])
+			if err != nil {
+				return err
+			}
+			args[0] = reflect.ValueOf(b).Convert(f.Type().In(0))
+		case reflect.Slice:
+			// split values
+			z := strings.Split(vals[i+1], ",")
+			for j := range z {
+				z[j] = strings.TrimSpace(z[j])
+			}
+			// make slice
+			args[0] = reflect.Zero(f.Type().In(0))
+			switch args[0].Interface().(type) {
+			case []string:
+				args[0] = reflect.ValueOf(z)
+			case []int64:
+				y := make([]int64, len(z))
+				for a := range z {
+					var err error
+					y[a], err = strconv.ParseInt(z[a], 10, 64)
+					if err != nil {
+						return err
+					}
+				}
+				args[0] = reflect.ValueOf(y)
+			default:
+				panic(fmt.Sprintf("unknown slice type %v", f.Type().In(0)))
+			}
+		default:
+			panic(fmt.Sprintf("unknown type %v", f.Type().In(0)))
+		}
+		req = f.Call(args)[0].Interface().(executor)
+	}
+	return req.Do(ctx, cl)
+}
+
+// toVals converts the options map to sorted name, value pairs for use with
+// doWithAndExecute.
+func toVals(opts map[string]interface{}) []string {
+	var keys []string
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var vals []string
+	for _, k := range keys {
+		var s string
+		switch x := opts[k].(type) {
+		case []int64:
+			z := make([]string, len(x))
+			for i, v := range x {
+				z[i] = strconv.FormatInt(v, 10)
+			}
+			s = strings.Join(z, ",")
+		case []string:
+			s = strings.Join(x, ",")
+		default:
+			s = fmt.Sprintf("%v", x)
+		}
+		vals = append(vals, k, s)
+	}
+	return vals
+}
+
+// contains determines if s contains v.
+func contains(s []string, v string) bool {
+	for _, z := range s {
+		if z == v {
+			return true
+		}
+	}
+	return false
+}
+
+// equal determines if a and b are equal.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// speedBytes returns the number of bytes in a K for the session's speed
+// limits.
+func speedBytes(session *transrpc.Session) int64 {
+	if session.Units.SpeedBytes > 0 {
+		return session.Units.SpeedBytes
+	}
+	return 1000
+}
+
+// toSpeedUnits converts the rate to a speed limit in K bytes, rounding up so
+// that non-zero rates are never converted to 0.
+func toSpeedUnits(rate tctypes.Rate, k int64) int64 {
+	return (int64(rate) + k - 1) / k
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"os"
+	"os/signal"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/kenshaw/transctl/transrpc"
+	"github.com/kenshaw/transctl/tctypes"
 	"github.com/knq/snaker"
 )
 
@@ -17,10 +21,13 @@ const (
 	defaultConfig = `[default]
 	output=table
 `
+
+	// sessionMaxAge is the maximum age of a cached remote host session.
+	sessionMaxAge = 24 * time.Hour
 )
 
 // ConvertTorrentIDs converts torrent list to a hash string identifier list.
-func ConvertTorrentIDs(torrents []transrpc.Torrent) []interface{} {
+func ConvertTorrentIDs(torrents []tctypes.Torrent) []interface{} {
 	ids := make([]interface{}, len(torrents))
 	for i := 0; i < len(torrents); i++ {
 		ids[i] = torrents[i].HashString
@@ -28,8 +35,9 @@ func ConvertTorrentIDs(torrents []transrpc.Torrent) []interface{} {
 	return ids
 }
 
-// addFieldsToMap adds reflected field values to the map.
-func addFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
+// AddFieldsToMap adds the reflected field values of v to the map, using
+// the kebab-cased json tag names of the fields as keys.
+func AddFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
 	t := v.Type()
 	count := t.NumField()
 	for i := 0; i < count; i++ {
@@ -52,7 +60,7 @@ func addFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
 		case reflect.Bool:
 			m[prefix+name] = strconv.FormatBool(f.Bool())
 		case reflect.Struct:
-			addFieldsToMap(m, name+".", f)
+			AddFieldsToMap(m, name+".", f)
 		case reflect.Slice:
 			var s []string
 			switch x := f.Interface().(type) {
@@ -64,11 +72,15 @@ func addFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
 				for _, v := range x {
 					s = append(s, strconv.FormatInt(v, 10))
 				}
-			case []transrpc.Priority:
+			case []tctypes.Priority:
 				for _, v := range x {
 					s = append(s, fmt.Sprintf("%d", v))
 				}
-			case []transrpc.Bool:
+			case []tctypes.PieceRange:
+				for _, v := range x {
+					s = append(s, fmt.Sprintf("%d-%d", v[0], v[1]))
+				}
+			case []tctypes.Bool:
 				for _, v := range x {
 					if bool(v) {
 						s = append(s, "1")
@@ -82,7 +94,7 @@ func addFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
 				}
 				for i := 0; i < f.Len(); i++ {
 					z := make(map[string]string)
-					addFieldsToMap(z, "", f.Index(i))
+					AddFieldsToMap(z, "", f.Index(i))
 					var keys []string
 					for k := range z {
 						keys = append(keys, k)
@@ -105,74 +117,20 @@ func addFieldsToMap(m map[string]string, prefix string, v reflect.Value) {
 	}
 }
 
-// executor interface is the common interface for settable requests.
-type executor interface {
-	Do(context.Context, *transrpc.Client) error
-}
-
-// doWithAndExecute calls the 'With*' method on the reflected request for the
-// provided name, value pairs in vals.
-func doWithAndExecute(cl *transrpc.Client, req executor, errMsg string, vals ...string) error {
-	if len(vals)%2 != 0 {
-		panic("invalid vals")
-	}
-	v := reflect.ValueOf(req)
-	for i := 0; i < len(vals); i += 2 {
-		name := "With" + snaker.ForceCamelIdentifier(vals[i])
-		f := v.MethodByName(name)
-		if f.Kind() == reflect.Invalid {
-			return fmt.Errorf("unsupported setting %s option %q", errMsg, vals[i])
-		}
-		args := make([]reflect.Value, 1)
-		switch f.Type().In(0).Kind() {
-		case reflect.String:
-			args[0] = reflect.ValueOf(vals[i+1])
-		case reflect.Int64:
-			z, err := strconv.ParseInt(vals[i+1], 10, 64)
-			if err != nil {
-				return err
-			}
-			args[0] = reflect.ValueOf(z)
-		case reflect.Float64:
-			z, err := strconv.ParseFloat(vals[i+1], 64)
-			if err != nil {
-				return err
-			}
-			args[0] = reflect.ValueOf(z)
-		case reflect.Bool:
-			b, err := strconv.ParseBool(vals[i+1])
-			if err != nil {
-				return err
-			}
-			args[0] = reflect.ValueOf(b)
-		case reflect.Slice:
-			// split values
-			z := strings.Split(vals[i+1], ",")
-			for j := range z {
-				z[j] = strings.TrimSpace(z[j])
-			}
-			// make slice
-			args[0] = reflect.Zero(f.Type().In(0))
-			switch args[0].Interface().(type) {
-			case []string:
-				args[0] = reflect.ValueOf(z)
-			case []int64:
-				y := make([]int64, len(z))
-				for a := range z {
-					var err error
-					y[a], err = strconv.ParseInt(z[a], 10, 64)
-					if err != nil {
-						return err
-					}
-				}
-				args[0] = reflect.ValueOf(y)
-			default:
-				panic(fmt.Sprintf("unknown slice type %v", f.Type().In(0)))
-			}
-		default:
-			panic(fmt.Sprintf("unknown type %v", f.Type().In(0)))
+// signalContext returns a context that is canceled when an interrupt or
+// terminate signal is received, logging the signal.
+func signalContext(ctx context.Context, logger *Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case s := <-sig:
+			logger.Info("shutting down", "signal", s)
+			cancel()
+		case <-ctx.Done():
 		}
-		req = f.Call(args)[0].Interface().(executor)
-	}
-	return req.Do(context.Background(), cl)
+	}()
+	return ctx, cancel
 }
@@ -0,0 +1,207 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+const (
+	// watchAddedDir is the subdirectory of the watched directory that
+	// successfully added files are moved to.
+	watchAddedDir = "added"
+
+	// watchFailedDir is the subdirectory of the watched directory that
+	// files that could not be added are moved to.
+	watchFailedDir = "failed"
+
+	// watchSettleTime is the time a file must be unmodified before it is
+	// added, so that partially written files are not picked up.
+	watchSettleTime = 2 * time.Second
+)
+
+// DoWatchDir is the high-level entry point for 'watch-dir'.
+func DoWatchDir(ctx context.Context, args *Args, cmd string) error {
+	dir := args.WatchDirParams.Dir
+	fi, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		return err
+	case !fi.IsDir():
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	for _, d := range []string{watchAddedDir, watchFailedDir} {
+		if err = os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			return err
+		}
+	}
+
+	hooks, err := LoadHooks(args)
+	if err != nil {
+		return err
+	}
+
+	p, err := args.NewProvider()
+	if err != nil {
+		return err
+	}
+	logger := NewLogger(os.Stderr, args.WatchDirParams.LogFormat)
+
+	// wait for the event hooks to stop after canceling
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// cancel on signal
+	ctx, cancel := signalContext(ctx, logger)
+	defer cancel()
+
+	// run the watched context's event hooks alongside the directory scan
+	name := args.Context
+	if name == "" {
+		name = strings.TrimSpace(args.Config.GetKey("default.context"))
+	}
+	var contextHooks []*Hook
+	for _, hook := range hooks {
+		for _, c := range hook.Contexts {
+			if c == name {
+				contextHooks = append(contextHooks, hook)
+				break
+			}
+		}
+	}
+	if len(contextHooks) != 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchEvents(ctx, args.WithContext(name), p, contextHooks, args.WatchDirParams.PollInterval, logger)
+		}()
+	}
+
+	// watch for changes, falling back to polling
+	var notify <-chan struct{}
+	if !args.WatchDirParams.Poll {
+		if notify, err = watchDir(ctx, dir); err != nil {
+			logger.Warn("filesystem notifications unavailable, polling", "dir", dir, "err", err)
+		}
+	}
+	mode := "notify"
+	if notify == nil {
+		mode = "poll"
+	}
+	logger.Info("watching", "dir", dir, "mode", mode, "interval", args.WatchDirParams.Interval, "hooks", len(contextHooks))
+
+	// the ticker is used in both modes, as files that have not yet settled
+	// need to be rechecked
+	t := time.NewTicker(args.WatchDirParams.Interval)
+	defer t.Stop()
+	for {
+		if err := scanWatchDir(ctx, args, p, dir, logger); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-notify:
+		case <-t.C:
+		}
+	}
+}
+
+// scanWatchDir adds each settled .torrent and .magnet file in dir.
+func scanWatchDir(ctx context.Context, args *Args, p Provider, dir string, logger *Logger) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, fi := range files {
+		name := fi.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		switch {
+		case fi.IsDir(), ext != ".torrent" && ext != ".magnet":
+			continue
+		case time.Since(fi.ModTime()) < watchSettleTime:
+			continue
+		}
+
+		// stop between files on shutdown, but let an add in progress finish
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		path := filepath.Join(dir, name)
+		addErr := addWatchFile(args, p, path, ext)
+		switch {
+		case os.IsNotExist(addErr):
+			logger.Warn("removed before add", "file", name)
+			continue
+		case tctypes.IsTransient(addErr), errors.Is(addErr, context.DeadlineExceeded):
+			// leave the file in place, so that the next scan retries it
+			logger.Warn("add failed, retrying", "file", name, "err", addErr)
+			continue
+		case addErr != nil:
+			logger.Error("add failed", "file", name, "err", addErr)
+			switch err = moveWatchFile(dir, watchFailedDir, name); {
+			case os.IsNotExist(err):
+				continue
+			case err != nil:
+				return err
+			}
+			if err = ioutil.WriteFile(filepath.Join(dir, watchFailedDir, name+".error"), []byte(addErr.Error()+"\n"), 0644); err != nil {
+				return err
+			}
+			continue
+		}
+		logger.Info("added", "file", name)
+		// the file may have been moved or removed while being added
+		switch err = moveWatchFile(dir, watchAddedDir, name); {
+		case os.IsNotExist(err):
+			logger.Warn("removed after add", "file", name)
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+// addWatchFile adds the torrent or magnet file at path to the provider.
+func addWatchFile(args *Args, p Provider, path, ext string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file interface{} = buf
+	if ext == ".magnet" {
+		v := strings.TrimSpace(string(buf))
+		if !magnetRE.MatchString(v) {
+			return fmt.Errorf("invalid magnet link in %s", filepath.Base(path))
+		}
+		file = v
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), args.Host.Timeout)
+	defer cancel()
+	_, err = p.Add(ctx, args.AddOptions(), file)
+	return err
+}
+
+// moveWatchFile moves the named file in dir to the subdirectory sub, removing
+// any previous error sidecar.
+func moveWatchFile(dir, sub, name string) error {
+	if err := os.Remove(filepath.Join(dir, watchFailedDir, name+".error")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(filepath.Join(dir, name), filepath.Join(dir, sub, name))
+}
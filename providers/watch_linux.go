@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// watchDir watches dir for files being written or moved into it using
+// inotify, sending on the returned channel on each change.
+func watchDir(ctx context.Context, dir string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err = syscall.InotifyAddWatch(fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// wrap in a file, so that reads use the runtime poller and are
+	// interrupted by close
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	notify := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notify, nil
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knq/ini"
+)
+
+func TestWatchDirNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notify, err := watchDir(ctx, dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		name string
+		f    func() error
+	}{
+		{"write", func() error {
+			return ioutil.WriteFile(filepath.Join(dir, "a.torrent"), []byte("torrent"), 0644)
+		}},
+		{"move", func() error {
+			src := filepath.Join(filepath.Dir(dir), filepath.Base(dir)+".torrent")
+			if err := ioutil.WriteFile(src, []byte("torrent"), 0644); err != nil {
+				return err
+			}
+			return os.Rename(src, filepath.Join(dir, "b.torrent"))
+		}},
+	}
+	for _, test := range tests {
+		if err := test.f(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-notify:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: expected notification", test.name)
+		}
+	}
+
+	// no notifications after cancel
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if err := ioutil.WriteFile(filepath.Join(dir, "c.torrent"), []byte("torrent"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-notify:
+		t.Errorf("expected no notification after cancel")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDoWatchDirNotify(t *testing.T) {
+	p := &watchProvider{}
+	Register("test-watch-notify", func(*Args) (Provider, error) {
+		return p, nil
+	})
+	dir := newWatchDir(t)
+	defer os.RemoveAll(dir)
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-watch-notify")
+	args := &Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.WatchDirParams.Dir = dir
+	// the interval is long enough that only notifications trigger scans
+	args.WatchDirParams.Interval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- DoWatchDir(ctx, args, "watch-dir")
+	}()
+
+	// move a settled file into the directory, after the initial scan
+	src, err := ioutil.TempFile("", "watch-*.torrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(src.Name())
+	src.Close()
+	ts := time.Now().Add(-2 * watchSettleTime)
+	if err := os.Chtimes(src.Name(), ts, ts); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.Rename(src.Name(), filepath.Join(dir, "a.torrent")); err != nil {
+		t.Fatal(err)
+	}
+	waitWatchFile(t, dir, watchAddedDir, "a.torrent")
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
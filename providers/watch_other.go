@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package providers
+
+import (
+	"context"
+	"runtime"
+)
+
+// watchDir is not supported on this platform, and always returns an error,
+// causing the directory to be polled instead.
+func watchDir(ctx context.Context, dir string) (<-chan struct{}, error) {
+	return nil, Error("filesystem notifications not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,396 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/knq/ini"
+)
+
+func TestScanWatchDir(t *testing.T) {
+	dir := newWatchDir(t)
+	defer os.RemoveAll(dir)
+	const magnet = "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567"
+	writeWatchFile(t, dir, "a.torrent", "torrent", true)
+	writeWatchFile(t, dir, "b.magnet", magnet+"\n", true)
+	writeWatchFile(t, dir, "bad.magnet", "http://example.com", true)
+	writeWatchFile(t, dir, "partial.torrent", "tor", false)
+	writeWatchFile(t, dir, "other.txt", "text", true)
+	p := &watchProvider{}
+	args := &Args{}
+	args.Host.Timeout = 10 * time.Second
+	args.AddParams.DownloadDir = "/dl"
+	var buf bytes.Buffer
+	if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(&buf, "")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := []interface{}{[]byte("torrent"), magnet}; !reflect.DeepEqual(p.files, exp) {
+		t.Errorf("expected added %q, got: %q", exp, p.files)
+	}
+	if p.opts.DownloadDir != "/dl" {
+		t.Errorf("expected download dir /dl, got: %q", p.opts.DownloadDir)
+	}
+	checkWatchDir(t, dir, "", "other.txt", "partial.torrent")
+	checkWatchDir(t, dir, watchAddedDir, "a.torrent", "b.magnet")
+	checkWatchDir(t, dir, watchFailedDir, "bad.magnet", "bad.magnet.error")
+	if s := readWatchFile(t, dir, watchFailedDir, "bad.magnet.error"); s != "invalid magnet link in bad.magnet\n" {
+		t.Errorf("expected error sidecar, got: %q", s)
+	}
+
+	// partially written files are added once settled
+	settleWatchFile(t, dir, "partial.torrent")
+	if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(&buf, "")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkWatchDir(t, dir, watchAddedDir, "a.torrent", "b.magnet", "partial.torrent")
+	if n := len(p.files); n != 3 {
+		t.Errorf("expected 3 added files, got: %d", n)
+	}
+}
+
+func TestScanWatchDirAddFailed(t *testing.T) {
+	dir := newWatchDir(t)
+	defer os.RemoveAll(dir)
+	writeWatchFile(t, dir, "a.torrent", "torrent", true)
+	p := &watchProvider{err: errors.New("duplicate torrent")}
+	args := &Args{}
+	args.Host.Timeout = 10 * time.Second
+	if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(ioutil.Discard, "")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkWatchDir(t, dir, watchFailedDir, "a.torrent", "a.torrent.error")
+	if s := readWatchFile(t, dir, watchFailedDir, "a.torrent.error"); s != "duplicate torrent\n" {
+		t.Errorf("expected error sidecar, got: %q", s)
+	}
+
+	// a successful retry removes the error sidecar
+	if err := os.Rename(filepath.Join(dir, watchFailedDir, "a.torrent"), filepath.Join(dir, "a.torrent")); err != nil {
+		t.Fatal(err)
+	}
+	p.err = nil
+	if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(ioutil.Discard, "")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checkWatchDir(t, dir, watchAddedDir, "a.torrent")
+	checkWatchDir(t, dir, watchFailedDir)
+}
+
+func TestScanWatchDirTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+		{"reset", fmt.Errorf("post: %w", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)})},
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newWatchDir(t)
+			defer os.RemoveAll(dir)
+			writeWatchFile(t, dir, "a.torrent", "torrent", true)
+			p := &watchProvider{err: test.err}
+			args := &Args{}
+			args.Host.Timeout = 10 * time.Second
+			var buf bytes.Buffer
+			if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(&buf, "")); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !strings.Contains(buf.String(), `msg="add failed, retrying"`) {
+				t.Errorf("expected retry to be logged, got: %s", buf.String())
+			}
+			checkWatchDir(t, dir, "", "a.torrent")
+			checkWatchDir(t, dir, watchFailedDir)
+
+			// the next scan retries the file
+			p.err = nil
+			if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(&buf, "")); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			checkWatchDir(t, dir, "")
+			checkWatchDir(t, dir, watchAddedDir, "a.torrent")
+			if n := p.count(); n != 2 {
+				t.Errorf("expected 2 add attempts, got: %d", n)
+			}
+		})
+	}
+}
+
+func TestScanWatchDirRemoved(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		f    func(dir string) error
+		exp  string
+	}{
+		{"deleted", nil, func(dir string) error {
+			return os.Remove(filepath.Join(dir, "a.torrent"))
+		}, `msg="removed after add"`},
+		{"moved", nil, func(dir string) error {
+			return os.Rename(filepath.Join(dir, "a.torrent"), filepath.Join(dir, "a.torrent.bak"))
+		}, `msg="removed after add"`},
+		{"deleted failed", errors.New("failed"), func(dir string) error {
+			return os.Remove(filepath.Join(dir, "a.torrent"))
+		}, `msg="add failed"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newWatchDir(t)
+			defer os.RemoveAll(dir)
+			writeWatchFile(t, dir, "a.torrent", "torrent", true)
+			writeWatchFile(t, dir, "b.torrent", "torrent", true)
+			p := &watchProvider{err: test.err, added: func() {
+				if err := test.f(dir); err != nil {
+					t.Fatal(err)
+				}
+				test.f = func(string) error { return nil }
+			}}
+			args := &Args{}
+			args.Host.Timeout = 10 * time.Second
+			var buf bytes.Buffer
+			if err := scanWatchDir(context.Background(), args, p, dir, NewLogger(&buf, "")); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !strings.Contains(buf.String(), test.exp) {
+				t.Errorf("expected log to contain %q, got: %s", test.exp, buf.String())
+			}
+			// the following files are still added
+			if n := len(p.files); n != 2 {
+				t.Errorf("expected 2 add attempts, got: %d", n)
+			}
+		})
+	}
+}
+
+func TestWatchDirPoll(t *testing.T) {
+	p := &watchProvider{}
+	Register("test-watch-poll", func(*Args) (Provider, error) {
+		return p, nil
+	})
+	dir := newWatchDir(t)
+	defer os.RemoveAll(dir)
+	config := ini.NewFile()
+	config.SetKey("default.type", "test-watch-poll")
+	args := &Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.WatchDirParams.Dir = dir
+	args.WatchDirParams.Poll = true
+	args.WatchDirParams.Interval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- DoWatchDir(ctx, args, "watch-dir")
+	}()
+	writeWatchFile(t, dir, "a.torrent", "torrent", true)
+	waitWatchFile(t, dir, watchAddedDir, "a.torrent")
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if n := p.count(); n != 1 {
+		t.Errorf("expected 1 added file, got: %d", n)
+	}
+}
+
+func TestDoWatchDirHooks(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	p := &watchProvider{}
+	p.added = func() {
+		p.torrents = append(p.torrents, tctypes.Torrent{ID: 1, HashString: hash, Name: "a"})
+	}
+	Register("test-watch-hooks", func(*Args) (Provider, error) {
+		return p, nil
+	})
+	events := make(chan Event, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(req.Body).Decode(&ev); err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events <- ev
+	}))
+	defer s.Close()
+	dir := newWatchDir(t)
+	defer os.RemoveAll(dir)
+	config := ini.NewFile()
+	config.SetKey("default.context", "home")
+	config.SetKey("context.home.type", "test-watch-hooks")
+	config.SetKey("hook.added.events", "added")
+	config.SetKey("hook.added.url", s.URL)
+	config.SetKey("hook.other.url", s.URL)
+	config.SetKey("hook.other.contexts", "other")
+	args := &Args{Config: config}
+	args.Host.Timeout = 10 * time.Second
+	args.WatchDirParams.Dir = dir
+	args.WatchDirParams.Poll = true
+	args.WatchDirParams.Interval = 10 * time.Millisecond
+	args.WatchDirParams.PollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- DoWatchDir(ctx, args, "watch-dir")
+	}()
+
+	// add the file after the first torrent snapshot
+	for deadline := time.Now().Add(5 * time.Second); p.snapshots() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected torrent snapshot")
+		}
+	}
+	writeWatchFile(t, dir, "a.torrent", "torrent", true)
+	waitWatchFile(t, dir, watchAddedDir, "a.torrent")
+	select {
+	case ev := <-events:
+		if ev.Type != EventAdded || ev.Context != "home" || ev.Hash != hash || ev.Name != "a" {
+			t.Errorf("expected added event for a in home, got: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected hook to be run")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("expected no other events, got: %+v", ev)
+	default:
+	}
+}
+
+// newWatchDir creates a temporary watch directory, with the added and failed
+// subdirectories.
+func newWatchDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{watchAddedDir, watchFailedDir} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// writeWatchFile writes the named file in dir, setting its modification time
+// to before the settle time when settled is true.
+func writeWatchFile(t *testing.T, dir, name, content string, settled bool) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if settled {
+		settleWatchFile(t, dir, name)
+	}
+}
+
+// settleWatchFile sets the modification time of the named file in dir to
+// before the settle time.
+func settleWatchFile(t *testing.T, dir, name string) {
+	t.Helper()
+	ts := time.Now().Add(-2 * watchSettleTime)
+	if err := os.Chtimes(filepath.Join(dir, name), ts, ts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readWatchFile reads the named file in the subdirectory of dir.
+func readWatchFile(t *testing.T, dir, sub, name string) string {
+	t.Helper()
+	buf, err := ioutil.ReadFile(filepath.Join(dir, sub, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+// checkWatchDir checks the files in the subdirectory of dir.
+func checkWatchDir(t *testing.T, dir, sub string, exp ...string) {
+	t.Helper()
+	files, err := ioutil.ReadDir(filepath.Join(dir, sub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range files {
+		if !fi.IsDir() {
+			names = append(names, fi.Name())
+		}
+	}
+	if strings.Join(names, " ") != strings.Join(exp, " ") {
+		t.Errorf("expected %q to contain %q, got: %q", filepath.Join("<dir>", sub), exp, names)
+	}
+}
+
+// waitWatchFile waits for the named file to be in the subdirectory of dir.
+func waitWatchFile(t *testing.T, dir, sub, name string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(filepath.Join(dir, sub, name)); err == nil {
+			return
+		}
+	}
+	t.Fatalf("expected %s to be moved to %s", name, sub)
+}
+
+// watchProvider is a provider that records added files.
+type watchProvider struct {
+	Provider
+	opts     AddOptions
+	files    []interface{}
+	err      error
+	added    func()
+	torrents []tctypes.Torrent
+	gets     int
+	sync.Mutex
+}
+
+func (p *watchProvider) Add(_ context.Context, opts AddOptions, files ...interface{}) ([]tctypes.Torrent, error) {
+	p.Lock()
+	defer p.Unlock()
+	p.opts, p.files = opts, append(p.files, files...)
+	if p.added != nil {
+		p.added()
+	}
+	return nil, p.err
+}
+
+func (p *watchProvider) Get(context.Context, []string, ...interface{}) ([]tctypes.Torrent, error) {
+	p.Lock()
+	defer p.Unlock()
+	p.gets++
+	return append([]tctypes.Torrent(nil), p.torrents...), nil
+}
+
+// snapshots returns the number of torrent snapshots.
+func (p *watchProvider) snapshots() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.gets
+}
+
+// count returns the number of added files.
+func (p *watchProvider) count() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.files)
+}
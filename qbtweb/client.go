@@ -3,8 +3,12 @@ package qbtweb
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -33,6 +37,19 @@ type Client struct {
 	// authenticated is the authentication toggle.
 	authenticated bool
 
+	// retry is the retry policy used on network errors and server errors.
+	retry tctypes.RetryPolicy
+
+	// cache is the session cache used to persist the session cookies.
+	cache *tctypes.SessionCache
+
+	// lenient toggles tolerating unknown fields in responses.
+	lenient bool
+
+	// unknownf is the log func for unknown fields encountered when decoding
+	// leniently.
+	unknownf func(string, ...interface{})
+
 	sync.Mutex
 }
 
@@ -41,6 +58,7 @@ func NewClient(opts ...ClientOption) *Client {
 	cl := &Client{
 		cl:        new(http.Client),
 		userAgent: "qbtweb/0.1",
+		retry:     tctypes.DefaultRetryPolicy,
 	}
 	for _, o := range opts {
 		o(cl)
@@ -48,9 +66,39 @@ func NewClient(opts ...ClientOption) *Client {
 	if cl.url == "" {
 		WithHost("localhost:8080")(cl)
 	}
+	// load cached session cookies -- an expired session is re-authenticated
+	// on the first request (403)
+	if cl.cache != nil {
+		if sess, err := cl.cache.Load(); err == nil && sess != nil && len(sess.Cookies) != 0 {
+			if err := cl.setCookies(sess.Cookies); err == nil {
+				cl.authenticated = true
+			}
+		}
+	}
 	return cl
 }
 
+// setCookies sets the client's cookie jar to a new jar containing cookies.
+func (cl *Client) setCookies(cookies []*http.Cookie) error {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return err
+	}
+	urlstr, _, err := cl.buildRequestURL("")
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(urlstr)
+	if err != nil {
+		return err
+	}
+	jar.SetCookies(u, cookies)
+	cl.cl.Jar = jar
+	return nil
+}
+
 // authenticate
 func (cl *Client) authenticate(ctx context.Context) error {
 	cl.Lock()
@@ -59,11 +107,7 @@ func (cl *Client) authenticate(ctx context.Context) error {
 		return nil
 	}
 
-	var err error
-	cl.cl.Jar, err = cookiejar.New(&cookiejar.Options{
-		PublicSuffixList: publicsuffix.List,
-	})
-	if err != nil {
+	if err := cl.setCookies(nil); err != nil {
 		return err
 	}
 
@@ -106,12 +150,24 @@ func (cl *Client) authenticate(ctx context.Context) error {
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
+	// qbittorrent responds with "Fails." on invalid credentials
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) == "Fails." {
 		return ErrUnauthorizedUser
 	}
 
 	cl.authenticated = true
 
+	// cache session cookies
+	if cl.cache != nil {
+		if u, err := url.Parse(urlstr); err == nil {
+			_ = cl.cache.Store(&tctypes.Session{Cookies: cl.cl.Jar.Cookies(u)})
+		}
+	}
+
 	return nil
 }
 
@@ -166,11 +222,6 @@ func (cl *Client) buildRequestData(method string, arguments interface{}, w io.Wr
 // Do executes the qbittorrent web method, json marshaling the passed
 // arguments and unmarshaling the response to v (if provided).
 func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{}) error {
-	var err error
-	if err = cl.authenticate(ctx); err != nil {
-		return err
-	}
-
 	// build url, params, body
 	var buf bytes.Buffer
 	urlstr, contentType, _, err := cl.buildRequestData(method, arguments, &buf)
@@ -178,52 +229,125 @@ func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{
 		return err
 	}
 
-	/*
-		asFormData, params, err := buildParamMap(arguments)
-		if err != nil {
-			return err
-		}
-		contentType, err := cl.buildRequestBody(asFormData, params)
-		if err != nil {
-			return err
-		}
-	*/
-
-	// build request and execute
-	req, err := http.NewRequest("POST", urlstr, bytes.NewReader(buf.Bytes()))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("User-Agent", cl.userAgent)
-	req.Header.Set("Content-Type", contentType)
-	res, err := cl.cl.Do(req.WithContext(ctx))
-	if err != nil {
+	// execute, retrying as per the retry policy
+	var res []byte
+	if err = cl.retry.Do(ctx, func(int) (bool, error) {
+		var err error
+		res, err = cl.roundTrip(ctx, urlstr, contentType, buf.Bytes())
+		return retryable(method, err), err
+	}); err != nil {
 		return err
 	}
-	defer res.Body.Close()
 
-	switch {
-	case res.StatusCode == http.StatusForbidden:
-		return ErrUnauthorizedUser
-	case res.StatusCode == http.StatusNotFound:
-		return ErrTorrentNotFound
-	case res.StatusCode == http.StatusUnsupportedMediaType:
-		return ErrTorrentFileInvalid
-	case res.StatusCode != http.StatusOK:
-		return ErrRequestFailed
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case *string:
+		// plain text responses, such as app/version
+		*x = string(res)
+		return nil
 	}
 
-	if v == nil {
+	// decode, collecting unknown fields
+	if cl.lenient {
+		unknown, err := tctypes.DecodeExtra(res, v)
+		if err != nil {
+			return err
+		}
+		if cl.unknownf != nil && len(unknown) != 0 {
+			cl.unknownf("%s: unknown fields: %s", method, strings.Join(unknown, ", "))
+		}
 		return nil
 	}
 
 	// decode
-	dec := json.NewDecoder(res.Body)
+	dec := json.NewDecoder(bytes.NewReader(res))
 	dec.DisallowUnknownFields()
 	dec.UseNumber()
 	return dec.Decode(v)
 }
 
+// roundTrip sends the request body to the remote host, returning the response
+// body. When the session has expired (403), the client re-authenticates and
+// resends the request once.
+func (cl *Client) roundTrip(ctx context.Context, urlstr, contentType string, body []byte) ([]byte, error) {
+	for i := 0; ; i++ {
+		if err := cl.authenticate(ctx); err != nil {
+			return nil, err
+		}
+
+		// build request and execute
+		req, err := http.NewRequest("POST", urlstr, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", cl.userAgent)
+		req.Header.Set("Content-Type", contentType)
+		res, err := cl.cl.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case res.StatusCode == http.StatusForbidden && i == 0:
+			cl.Lock()
+			cl.authenticated = false
+			cl.Unlock()
+			if cl.cache != nil {
+				_ = cl.cache.Invalidate()
+			}
+			continue
+		case res.StatusCode == http.StatusForbidden:
+			return nil, ErrUnauthorizedUser
+		case res.StatusCode == http.StatusNotFound:
+			return nil, ErrTorrentNotFound
+		case res.StatusCode == http.StatusUnsupportedMediaType:
+			return nil, ErrTorrentFileInvalid
+		case res.StatusCode >= http.StatusInternalServerError:
+			return nil, fmt.Errorf("%w: %s", ErrServerError, res.Status)
+		case res.StatusCode != http.StatusOK:
+			return nil, ErrRequestFailed
+		}
+		return buf, nil
+	}
+}
+
+// retryable determines if the method can be retried after the error.
+// Non-idempotent methods are only retried when the request was never sent.
+func retryable(method string, err error) bool {
+	switch {
+	case tctypes.IsDialError(err):
+		return true
+	case nonIdempotent[method]:
+		return false
+	}
+	return tctypes.IsTransient(err) || errors.Is(err, ErrServerError)
+}
+
+// nonIdempotent are the web api methods that are not safe to blindly retry.
+var nonIdempotent = map[string]bool{
+	"app/shutdown":                      true,
+	"torrents/add":                      true,
+	"torrents/addPeers":                 true,
+	"torrents/addTrackers":              true,
+	"torrents/increasePrio":             true,
+	"torrents/decreasePrio":             true,
+	"torrents/renameFile":               true,
+	"torrents/renameFolder":             true,
+	"torrents/createCategory":           true,
+	"torrents/toggleSequentialDownload": true,
+	"torrents/toggleFirstLastPiecePrio": true,
+	"transfer/toggleSpeedLimitsMode":    true,
+	"rss/addFeed":                       true,
+	"rss/addFolder":                     true,
+	"search/start":                      true,
+}
+
 // AuthLogout executes a auth logout request.
 func (cl *Client) AuthLogout(ctx context.Context) error {
 	return AuthLogout().Do(ctx, cl)
@@ -282,6 +406,48 @@ func WithCredentialFallback(user, pass string) ClientOption {
 	}
 }
 
+// WithRetryPolicy is a qBittorrent web client option to set the retry policy
+// used on network errors and server (5xx) errors. Non-idempotent methods,
+// such as torrents/add, are not retried unless the request was never sent.
+func WithRetryPolicy(retry tctypes.RetryPolicy) ClientOption {
+	return func(cl *Client) {
+		cl.retry = retry
+	}
+}
+
+// WithSessionCache is a qBittorrent web client option to set the session cache
+// used to persist the session cookies between clients.
+func WithSessionCache(cache *tctypes.SessionCache) ClientOption {
+	return func(cl *Client) {
+		cl.cache = cache
+	}
+}
+
+// WithTLSConfig is a qBittorrent web client option to set the TLS config used
+// when connecting to the remote host.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.WithTLSConfig(cl.cl.Transport, cfg)
+	}
+}
+
+// WithDialer is a qBittorrent web client option to set the dialer used when
+// connecting to the remote host, such as for unix sockets and proxies.
+func WithDialer(d *tctypes.Dialer) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.WithDialer(cl.cl.Transport, d)
+	}
+}
+
+// WithLenient is a qBittorrent web client option to tolerate unknown fields in
+// responses, collecting them in the Extra field of the response types.
+// Unknown fields are logged with unknownf, if not nil.
+func WithLenient(unknownf func(string, ...interface{})) ClientOption {
+	return func(cl *Client) {
+		cl.lenient, cl.unknownf = true, unknownf
+	}
+}
+
 // WithLogf is a qBittorrent web client option to set a logging handler for
 // HTTP requests and responses.
 func WithLogf(logf func(string, ...interface{})) ClientOption {
@@ -289,3 +455,11 @@ func WithLogf(logf func(string, ...interface{})) ClientOption {
 		cl.cl.Transport = tctypes.NewHTTPLogf(cl.cl.Transport, logf)
 	}
 }
+
+// WithHAR is a qBittorrent web client option to write HTTP requests and
+// responses to a HAR file.
+func WithHAR(har *tctypes.HARWriter) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.NewHTTPHAR(cl.cl.Transport, har)
+	}
+}
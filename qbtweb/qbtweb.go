@@ -191,49 +191,53 @@ const (
 
 // Torrent holds information about a torrent.
 type Torrent struct {
-	AddedOn           Time      `json:"added_on,omitempty" yaml:"added_on,omitempty"`                     // Time (Unix Epoch) when the torrent was added to the client
-	AmountLeft        ByteCount `json:"amount_left,omitempty" yaml:"amount_left,omitempty"`               // Amount of data left to download (bytes)
-	Availability      Percent   `json:"availability,omitempty" yaml:"availability,omitempty"`             // not documented
-	AutoTmm           bool      `json:"auto_tmm,omitempty" yaml:"auto_tmm,omitempty"`                     // Whether this torrent is managed by Automatic Torrent Management
-	Category          string    `json:"category,omitempty" yaml:"category,omitempty"`                     // Category of the torrent
-	Completed         ByteCount `json:"completed,omitempty" yaml:"completed,omitempty"`                   // Amount of transfer data completed (bytes)
-	CompletionOn      Time      `json:"completion_on,omitempty" yaml:"completion_on,omitempty"`           // Time (Unix Epoch) when the torrent completed
-	DlLimit           Rate      `json:"dl_limit,omitempty" yaml:"dl_limit,omitempty"`                     // Torrent download speed limit (bytes/s). -1 if ulimited.
-	Dlspeed           Rate      `json:"dlspeed,omitempty" yaml:"dlspeed,omitempty"`                       // Torrent download speed (bytes/s)
-	Downloaded        ByteCount `json:"downloaded,omitempty" yaml:"downloaded,omitempty"`                 // Amount of data downloaded
-	DownloadedSession ByteCount `json:"downloaded_session,omitempty" yaml:"downloaded_session,omitempty"` // Amount of data downloaded this session
-	Eta               Duration  `json:"eta,omitempty" yaml:"eta,omitempty"`                               // Torrent ETA (seconds)
-	FLPiecePrio       bool      `json:"f_l_piece_prio,omitempty" yaml:"f_l_piece_prio,omitempty"`         // True if first last piece are prioritized
-	ForceStart        bool      `json:"force_start,omitempty" yaml:"force_start,omitempty"`               // True if force start is enabled for this torrent
-	Hash              string    `json:"hash,omitempty" yaml:"hash,omitempty"`                             // Torrent hash
-	LastActivity      Time      `json:"last_activity,omitempty" yaml:"last_activity,omitempty"`           // Last time (Unix Epoch) when a chunk was downloaded/uploaded
-	MagnetURI         string    `json:"magnet_uri,omitempty" yaml:"magnet_uri,omitempty"`                 // Magnet URI corresponding to this torrent
-	MaxRatio          Percent   `json:"max_ratio,omitempty" yaml:"max_ratio,omitempty"`                   // Maximum share ratio until torrent is stopped from seeding/uploading
-	MaxSeedingTime    Duration  `json:"max_seeding_time,omitempty" yaml:"max_seeding_time,omitempty"`     // Maximum seeding time (seconds) until torrent is stopped from seeding
-	Name              string    `json:"name,omitempty" yaml:"name,omitempty"`                             // Torrent name
-	NumComplete       int64     `json:"num_complete,omitempty" yaml:"num_complete,omitempty"`             // Number of seeds in the swarm
-	NumIncomplete     int64     `json:"num_incomplete,omitempty" yaml:"num_incomplete,omitempty"`         // Number of leechers in the swarm
-	NumLeechs         int64     `json:"num_leechs,omitempty" yaml:"num_leechs,omitempty"`                 // Number of leechers connected to
-	NumSeeds          int64     `json:"num_seeds,omitempty" yaml:"num_seeds,omitempty"`                   // Number of seeds connected to
-	Priority          int64     `json:"priority,omitempty" yaml:"priority,omitempty"`                     // Torrent priority. Returns -1 if queuing is disabled or torrent is in seed mode
-	Progress          Percent   `json:"progress,omitempty" yaml:"progress,omitempty"`                     // Torrent progress (percentage/100)
-	Ratio             Percent   `json:"ratio,omitempty" yaml:"ratio,omitempty"`                           // Torrent share ratio. Max ratio value: 9999.
-	RatioLimit        Percent   `json:"ratio_limit,omitempty" yaml:"ratio_limit,omitempty"`               // TODO (what is different from max_ratio?)
-	SavePath          string    `json:"save_path,omitempty" yaml:"save_path,omitempty"`                   // Path where this torrent's data is stored
-	SeedingTimeLimit  Duration  `json:"seeding_time_limit,omitempty" yaml:"seeding_time_limit,omitempty"` // TODO (what is different from max_seeding_time?)
-	SeenComplete      Time      `json:"seen_complete,omitempty" yaml:"seen_complete,omitempty"`           // Time (Unix Epoch) when this torrent was last seen complete
-	SeqDl             bool      `json:"seq_dl,omitempty" yaml:"seq_dl,omitempty"`                         // True if sequential download is enabled
-	Size              ByteCount `json:"size,omitempty" yaml:"size,omitempty"`                             // Total size (bytes) of files selected for download
-	State             State     `json:"state,omitempty" yaml:"state,omitempty"`                           // Torrent state. See table here below for the possible values
-	SuperSeeding      bool      `json:"super_seeding,omitempty" yaml:"super_seeding,omitempty"`           // True if super seeding is enabled
-	Tags              string    `json:"tags,omitempty" yaml:"tags,omitempty"`                             // Comma-concatenated tag list of the torrent
-	TimeActive        Duration  `json:"time_active,omitempty" yaml:"time_active,omitempty"`               // Total active time (seconds)
-	TotalSize         ByteCount `json:"total_size,omitempty" yaml:"total_size,omitempty"`                 // Total size (bytes) of all file in this torrent (including unselected ones)
-	Tracker           string    `json:"tracker,omitempty" yaml:"tracker,omitempty"`                       // The first tracker with working status. (TODO: what is returned if no tracker is working?)
-	UpLimit           Rate      `json:"up_limit,omitempty" yaml:"up_limit,omitempty"`                     // Torrent upload speed limit (bytes/s). -1 if ulimited.
-	Uploaded          ByteCount `json:"uploaded,omitempty" yaml:"uploaded,omitempty"`                     // Amount of data uploaded
-	UploadedSession   ByteCount `json:"uploaded_session,omitempty" yaml:"uploaded_session,omitempty"`     // Amount of data uploaded this session
-	Upspeed           Rate      `json:"upspeed,omitempty" yaml:"upspeed,omitempty"`                       // Torrent upload speed (bytes/s)
+	AddedOn                  Time      `json:"added_on,omitempty" yaml:"added_on,omitempty"`                                       // Time (Unix Epoch) when the torrent was added to the client
+	AmountLeft               ByteCount `json:"amount_left,omitempty" yaml:"amount_left,omitempty"`                                 // Amount of data left to download (bytes)
+	Availability             Percent   `json:"availability,omitempty" yaml:"availability,omitempty"`                               // not documented
+	AutoTmm                  bool      `json:"auto_tmm,omitempty" yaml:"auto_tmm,omitempty"`                                       // Whether this torrent is managed by Automatic Torrent Management
+	Category                 string    `json:"category,omitempty" yaml:"category,omitempty"`                                       // Category of the torrent
+	Completed                ByteCount `json:"completed,omitempty" yaml:"completed,omitempty"`                                     // Amount of transfer data completed (bytes)
+	CompletionOn             Time      `json:"completion_on,omitempty" yaml:"completion_on,omitempty"`                             // Time (Unix Epoch) when the torrent completed
+	DlLimit                  Rate      `json:"dl_limit,omitempty" yaml:"dl_limit,omitempty"`                                       // Torrent download speed limit (bytes/s). -1 if ulimited.
+	Dlspeed                  Rate      `json:"dlspeed,omitempty" yaml:"dlspeed,omitempty"`                                         // Torrent download speed (bytes/s)
+	Downloaded               ByteCount `json:"downloaded,omitempty" yaml:"downloaded,omitempty"`                                   // Amount of data downloaded
+	DownloadedSession        ByteCount `json:"downloaded_session,omitempty" yaml:"downloaded_session,omitempty"`                   // Amount of data downloaded this session
+	Eta                      Duration  `json:"eta,omitempty" yaml:"eta,omitempty"`                                                 // Torrent ETA (seconds)
+	FLPiecePrio              bool      `json:"f_l_piece_prio,omitempty" yaml:"f_l_piece_prio,omitempty"`                           // True if first last piece are prioritized
+	ForceStart               bool      `json:"force_start,omitempty" yaml:"force_start,omitempty"`                                 // True if force start is enabled for this torrent
+	Hash                     string    `json:"hash,omitempty" yaml:"hash,omitempty"`                                               // Torrent hash
+	InactiveSeedingTimeLimit int64     `json:"inactive_seeding_time_limit,omitempty" yaml:"inactive_seeding_time_limit,omitempty"` // Torrent inactive seeding time limit (minutes). -2 if the global limit is used, -1 if unlimited.
+	LastActivity             Time      `json:"last_activity,omitempty" yaml:"last_activity,omitempty"`                             // Last time (Unix Epoch) when a chunk was downloaded/uploaded
+	MagnetURI                string    `json:"magnet_uri,omitempty" yaml:"magnet_uri,omitempty"`                                   // Magnet URI corresponding to this torrent
+	MaxRatio                 Percent   `json:"max_ratio,omitempty" yaml:"max_ratio,omitempty"`                                     // Maximum share ratio until torrent is stopped from seeding/uploading
+	MaxSeedingTime           Duration  `json:"max_seeding_time,omitempty" yaml:"max_seeding_time,omitempty"`                       // Maximum seeding time (seconds) until torrent is stopped from seeding
+	Name                     string    `json:"name,omitempty" yaml:"name,omitempty"`                                               // Torrent name
+	NumComplete              int64     `json:"num_complete,omitempty" yaml:"num_complete,omitempty"`                               // Number of seeds in the swarm
+	NumIncomplete            int64     `json:"num_incomplete,omitempty" yaml:"num_incomplete,omitempty"`                           // Number of leechers in the swarm
+	NumLeechs                int64     `json:"num_leechs,omitempty" yaml:"num_leechs,omitempty"`                                   // Number of leechers connected to
+	NumSeeds                 int64     `json:"num_seeds,omitempty" yaml:"num_seeds,omitempty"`                                     // Number of seeds connected to
+	Priority                 int64     `json:"priority,omitempty" yaml:"priority,omitempty"`                                       // Torrent priority. Returns -1 if queuing is disabled or torrent is in seed mode
+	Progress                 Percent   `json:"progress,omitempty" yaml:"progress,omitempty"`                                       // Torrent progress (percentage/100)
+	Ratio                    Percent   `json:"ratio,omitempty" yaml:"ratio,omitempty"`                                             // Torrent share ratio. Max ratio value: 9999.
+	RatioLimit               Percent   `json:"ratio_limit,omitempty" yaml:"ratio_limit,omitempty"`                                 // Torrent share ratio limit. -2 if the global limit is used, -1 if unlimited.
+	SavePath                 string    `json:"save_path,omitempty" yaml:"save_path,omitempty"`                                     // Path where this torrent's data is stored
+	SeedingTimeLimit         int64     `json:"seeding_time_limit,omitempty" yaml:"seeding_time_limit,omitempty"`                   // Torrent seeding time limit (minutes). -2 if the global limit is used, -1 if unlimited.
+	SeenComplete             Time      `json:"seen_complete,omitempty" yaml:"seen_complete,omitempty"`                             // Time (Unix Epoch) when this torrent was last seen complete
+	SeqDl                    bool      `json:"seq_dl,omitempty" yaml:"seq_dl,omitempty"`                                           // True if sequential download is enabled
+	Size                     ByteCount `json:"size,omitempty" yaml:"size,omitempty"`                                               // Total size (bytes) of files selected for download
+	State                    State     `json:"state,omitempty" yaml:"state,omitempty"`                                             // Torrent state. See table here below for the possible values
+	SuperSeeding             bool      `json:"super_seeding,omitempty" yaml:"super_seeding,omitempty"`                             // True if super seeding is enabled
+	Tags                     string    `json:"tags,omitempty" yaml:"tags,omitempty"`                                               // Comma-concatenated tag list of the torrent
+	TimeActive               Duration  `json:"time_active,omitempty" yaml:"time_active,omitempty"`                                 // Total active time (seconds)
+	TotalSize                ByteCount `json:"total_size,omitempty" yaml:"total_size,omitempty"`                                   // Total size (bytes) of all file in this torrent (including unselected ones)
+	Tracker                  string    `json:"tracker,omitempty" yaml:"tracker,omitempty"`                                         // The first tracker with working status. (TODO: what is returned if no tracker is working?)
+	UpLimit                  Rate      `json:"up_limit,omitempty" yaml:"up_limit,omitempty"`                                       // Torrent upload speed limit (bytes/s). -1 if ulimited.
+	Uploaded                 ByteCount `json:"uploaded,omitempty" yaml:"uploaded,omitempty"`                                       // Amount of data uploaded
+	UploadedSession          ByteCount `json:"uploaded_session,omitempty" yaml:"uploaded_session,omitempty"`                       // Amount of data uploaded this session
+	Upspeed                  Rate      `json:"upspeed,omitempty" yaml:"upspeed,omitempty"`                                         // Torrent upload speed (bytes/s)
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // FilterType are the filter types.
@@ -340,6 +344,9 @@ type AppBuildInfoResponse struct {
 	Openssl    string `json:"openssl,omitempty" yaml:"openssl,omitempty"`       // OpenSSL version
 	Bitness    int64  `json:"bitness,omitempty" yaml:"bitness,omitempty"`       // Application bitness (e.g. 64-bit)
 	Zlib       string `json:"zlib,omitempty" yaml:"zlib,omitempty"`             // Zlib version
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // AppShutdownRequest is a app shutdown request.
@@ -519,6 +526,9 @@ type Preferences struct {
 	// SslCert                            string                 `json:"ssl_cert" yaml:"ssl_cert"`                                                             // SSL certificate contents (this is a not a path)
 	// SslKey                             string                 `json:"ssl_key" yaml:"ssl_key"`                                                               // SSL keyfile contents (this is a not a path)
 	// WebUiPassword                      string                 `json:"web_ui_password" yaml:"web_ui_password"`                                               // For API ≥ v2.3.0: Plaintext WebUI password, not readable, write-only. For API < v2.3.0: MD5 hash of WebUI password, hash is generated from the following string: username:Web UI Access:plain_text_web_ui_password
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // AppSetPreferencesRequest is a app setPreferences request.
@@ -1919,6 +1929,9 @@ type SyncMaindataResponse struct {
 	Tags              []string            `json:"tags,omitempty" yaml:"tags,omitempty"`                             // List of tags added since last request
 	TagsRemoved       []string            `json:"tags_removed,omitempty" yaml:"tags_removed,omitempty"`             // List of tags removed since last request
 	ServerState       State               `json:"server_state,omitempty" yaml:"server_state,omitempty"`             // Global transfer info
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // Category is a category.
@@ -1951,8 +1964,33 @@ func (req *SyncTorrentPeersRequest) Do(ctx context.Context, cl *Client) (*SyncTo
 
 // SyncTorrentPeersResponse is the sync torrentPeers response.
 //
-// TODO: not documented.
+// Not documented, fields are from the qBittorrent source.
 type SyncTorrentPeersResponse struct {
+	FullUpdate   bool                   `json:"full_update,omitempty" yaml:"full_update,omitempty"`     // Whether the response contains all the data or partial data
+	Peers        map[string]TorrentPeer `json:"peers,omitempty" yaml:"peers,omitempty"`                 // Property: peer address (ip:port), value: same as torrent peer
+	PeersRemoved []string               `json:"peers_removed,omitempty" yaml:"peers_removed,omitempty"` // List of peer addresses of peers removed since last request
+	Rid          int64                  `json:"rid,omitempty" yaml:"rid,omitempty"`                     // Response ID
+	ShowFlags    bool                   `json:"show_flags,omitempty" yaml:"show_flags,omitempty"`       // Whether peer flags are shown
+}
+
+// TorrentPeer holds information about a torrent peer.
+type TorrentPeer struct {
+	Client       string    `json:"client,omitempty" yaml:"client,omitempty"`                 // Peer client name
+	Connection   string    `json:"connection,omitempty" yaml:"connection,omitempty"`         // Peer connection type (BT, μTP, Web)
+	Country      string    `json:"country,omitempty" yaml:"country,omitempty"`               // Peer country name (if resolving peer countries is enabled)
+	CountryCode  string    `json:"country_code,omitempty" yaml:"country_code,omitempty"`     // Peer country code (if resolving peer countries is enabled)
+	DlSpeed      Rate      `json:"dl_speed,omitempty" yaml:"dl_speed,omitempty"`             // Download speed from peer (bytes/s)
+	Downloaded   ByteCount `json:"downloaded,omitempty" yaml:"downloaded,omitempty"`         // Amount of data downloaded from peer (bytes)
+	Files        string    `json:"files,omitempty" yaml:"files,omitempty"`                   // Files the peer is downloading, separated by newlines
+	Flags        string    `json:"flags,omitempty" yaml:"flags,omitempty"`                   // Peer flags, separated by spaces
+	FlagsDesc    string    `json:"flags_desc,omitempty" yaml:"flags_desc,omitempty"`         // Peer flag descriptions, separated by newlines
+	IP           string    `json:"ip,omitempty" yaml:"ip,omitempty"`                         // Peer ip address
+	PeerIDClient string    `json:"peer_id_client,omitempty" yaml:"peer_id_client,omitempty"` // Peer client name from the peer id
+	Port         int64     `json:"port,omitempty" yaml:"port,omitempty"`                     // Peer port
+	Progress     Percent   `json:"progress,omitempty" yaml:"progress,omitempty"`             // Peer progress (percentage/100)
+	Relevance    Percent   `json:"relevance,omitempty" yaml:"relevance,omitempty"`           // Peer relevance (percentage/100)
+	UpSpeed      Rate      `json:"up_speed,omitempty" yaml:"up_speed,omitempty"`             // Upload speed to peer (bytes/s)
+	Uploaded     ByteCount `json:"uploaded,omitempty" yaml:"uploaded,omitempty"`             // Amount of data uploaded to peer (bytes)
 }
 
 // WithResponseID sets the response id. If not provided, rid=0 will be assumed.
@@ -1990,6 +2028,9 @@ type TransferInfoResponse struct {
 	UpRateLimit      Rate             `json:"up_rate_limit,omitempty" yaml:"up_rate_limit,omitempty"`         // Upload rate limit (bytes/s)
 	DhtNodes         int64            `json:"dht_nodes,omitempty" yaml:"dht_nodes,omitempty"`                 // DHT nodes connected to
 	ConnectionStatus ConnectionStatus `json:"connection_status,omitempty" yaml:"connection_status,omitempty"` // Connection status. See possible values here below
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // TransferSpeedLimitsModeRequest is a transfer speedLimitsMode request.
@@ -2229,6 +2270,9 @@ type TorrentProperties struct {
 	TotalSize              ByteCount `json:"total_size,omitempty" yaml:"total_size,omitempty"`                             // Torrent total size (bytes)
 	UpSpeedAvg             Rate      `json:"up_speed_avg,omitempty" yaml:"up_speed_avg,omitempty"`                         // Torrent average upload speed (bytes/second)
 	UpSpeed                Rate      `json:"up_speed,omitempty" yaml:"up_speed,omitempty"`                                 // Torrent upload speed (bytes/second)
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // TorrentsTrackersRequest is a torrents trackers request.
@@ -2262,6 +2306,9 @@ type Tracker struct {
 	NumLeeches    int64         `json:"num_leeches,omitempty" yaml:"num_leeches,omitempty"`       // Number of leeches for current torrent, as reported by the tracker
 	NumDownloaded int64         `json:"num_downloaded,omitempty" yaml:"num_downloaded,omitempty"` // Number of completed downlods for current torrent, as reported by the tracker
 	Msg           string        `json:"msg,omitempty" yaml:"msg,omitempty"`                       // Tracker message (there is no way of knowing what this message is - it's up to tracker admins)
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // TorrentsWebseedsRequest is a torrents webseeds request.
@@ -2320,6 +2367,9 @@ type File struct {
 	IsSeed       bool         `json:"is_seed,omitempty" yaml:"is_seed,omitempty"`           // True if file is seeding/complete
 	PieceRange   []int64      `json:"piece_range,omitempty" yaml:"piece_range,omitempty"`   // The first number is the starting piece index and the second number is the ending piece index (inclusive)
 	Availability Percent      `json:"availability,omitempty" yaml:"availability,omitempty"` // Percentage of file pieces currently available
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // FilePriority is the file priority enum.
@@ -2911,9 +2961,10 @@ func (req *TorrentsSetDownloadLimitRequest) Do(ctx context.Context, cl *Client)
 
 // TorrentsSetShareLimitsRequest is a torrents setShareLimits request.
 type TorrentsSetShareLimitsRequest struct {
-	Hashes           []string `json:"hashes" yaml:"hashes"`                     // The hashes of the torrents you want to pause. hashes can contain multiple hashes separated by |, to pause multiple torrents, or set to all, to pause all torrents.
-	RatioLimit       Percent  `json:"ratioLimit" yaml:"ratioLimit"`             // The max ratio the torrent should be seeded until. -2 means the global limit should be used, -1 means no limit.
-	SeedingTimeLimit Duration `json:"seedingTimeLimit" yaml:"seedingTimeLimit"` // The max amount of time the torrent should be seeded. -2 means the global limit should be used, -1 means no limit.
+	Hashes                   []string `json:"hashes" yaml:"hashes"`                                     // The hashes of the torrents you want to pause. hashes can contain multiple hashes separated by |, to pause multiple torrents, or set to all, to pause all torrents.
+	RatioLimit               Percent  `json:"ratioLimit" yaml:"ratioLimit"`                             // The max ratio the torrent should be seeded until. -2 means the global limit should be used, -1 means no limit.
+	SeedingTimeLimit         int64    `json:"seedingTimeLimit" yaml:"seedingTimeLimit"`                 // The max amount of time (minutes) the torrent should be seeded. -2 means the global limit should be used, -1 means no limit.
+	InactiveSeedingTimeLimit int64    `json:"inactiveSeedingTimeLimit" yaml:"inactiveSeedingTimeLimit"` // The max amount of time (minutes) the torrent should be seeded while inactive. -2 means the global limit should be used, -1 means no limit.
 }
 
 // TorrentsSetShareLimits creates a torrents setShareLimits request.
@@ -2934,12 +2985,18 @@ func (req TorrentsSetShareLimitsRequest) WithRatioLimit(ratioLimit Percent) *Tor
 	return &req
 }
 
-// WithSeedingTimeLimit sets the max amount of time the torrent should be seeded. -2 means the global limit should be used, -1 means no limit.
-func (req TorrentsSetShareLimitsRequest) WithSeedingTimeLimit(seedingTimeLimit Duration) *TorrentsSetShareLimitsRequest {
+// WithSeedingTimeLimit sets the max amount of time (minutes) the torrent should be seeded. -2 means the global limit should be used, -1 means no limit.
+func (req TorrentsSetShareLimitsRequest) WithSeedingTimeLimit(seedingTimeLimit int64) *TorrentsSetShareLimitsRequest {
 	req.SeedingTimeLimit = seedingTimeLimit
 	return &req
 }
 
+// WithInactiveSeedingTimeLimit sets the max amount of time (minutes) the torrent should be seeded while inactive. -2 means the global limit should be used, -1 means no limit.
+func (req TorrentsSetShareLimitsRequest) WithInactiveSeedingTimeLimit(inactiveSeedingTimeLimit int64) *TorrentsSetShareLimitsRequest {
+	req.InactiveSeedingTimeLimit = inactiveSeedingTimeLimit
+	return &req
+}
+
 // TorrentsUploadLimitRequest is a torrents uploadLimit request.
 type TorrentsUploadLimitRequest struct {
 	Hashes []string `json:"hashes" yaml:"hashes"` // The hashes of the torrents you want to pause. hashes can contain multiple hashes separated by |, to pause multiple torrents, or set to all, to pause all torrents.
@@ -3099,7 +3156,9 @@ type TorrentsRemoveCategoriesRequest struct {
 
 // TorrentsRemoveCategories creates a torrents removeCategories request.
 func TorrentsRemoveCategories(categories ...string) *TorrentsRemoveCategoriesRequest {
-	return &TorrentsRemoveCategoriesRequest{}
+	return &TorrentsRemoveCategoriesRequest{
+		Categories: categories,
+	}
 }
 
 // Do executes the request against the provided context and client.
@@ -3427,19 +3486,19 @@ func (req *RssSetRuleRequest) Do(ctx context.Context, cl *Client) error {
 }
 
 type Rule struct {
-	Enabled                   bool        `json:"enabled,omitempty" yaml:"enabled,omitempty"`                                     // Whether the rule is enabled
-	MustContain               string      `json:"mustContain,omitempty" yaml:"mustContain,omitempty"`                             // The substring that the torrent name must contain
-	MustNotContain            string      `json:"mustNotContain,omitempty" yaml:"mustNotContain,omitempty"`                       // The substring that the torrent name must not contain
-	UseRegex                  bool        `json:"useRegex,omitempty" yaml:"useRegex,omitempty"`                                   // Enable regex mode in "mustContain" and "mustNotContain"
-	EpisodeFilter             string      `json:"episodeFilter,omitempty" yaml:"episodeFilter,omitempty"`                         // Episode filter definition
-	SmartFilter               bool        `json:"smartFilter,omitempty" yaml:"smartFilter,omitempty"`                             // Enable smart episode filter
-	PreviouslyMatchedEpisodes []string    `json:"previouslyMatchedEpisodes,omitempty" yaml:"previouslyMatchedEpisodes,omitempty"` // The list of episode IDs already matched by smart filter
-	AffectedFeeds             []string    `json:"affectedFeeds,omitempty" yaml:"affectedFeeds,omitempty"`                         // The feed URLs the rule applied to
-	IgnoreDays                DaySchedule `json:"ignoreDays,omitempty" yaml:"ignoreDays,omitempty"`                               // Ignore sunsequent rule matches
-	LastMatch                 string      `json:"lastMatch,omitempty" yaml:"lastMatch,omitempty"`                                 // The rule last match time
-	AddPaused                 bool        `json:"addPaused,omitempty" yaml:"addPaused,omitempty"`                                 // Add matched torrent in paused mode
-	AssignedCategory          string      `json:"assignedCategory,omitempty" yaml:"assignedCategory,omitempty"`                   // Assign category to the torrent
-	SavePath                  string      `json:"savePath,omitempty" yaml:"savePath,omitempty"`                                   // Save torrent to the given directory
+	Enabled                   bool     `json:"enabled" yaml:"enabled"`                                                         // Whether the rule is enabled
+	MustContain               string   `json:"mustContain,omitempty" yaml:"mustContain,omitempty"`                             // The substring that the torrent name must contain
+	MustNotContain            string   `json:"mustNotContain,omitempty" yaml:"mustNotContain,omitempty"`                       // The substring that the torrent name must not contain
+	UseRegex                  bool     `json:"useRegex,omitempty" yaml:"useRegex,omitempty"`                                   // Enable regex mode in "mustContain" and "mustNotContain"
+	EpisodeFilter             string   `json:"episodeFilter,omitempty" yaml:"episodeFilter,omitempty"`                         // Episode filter definition
+	SmartFilter               bool     `json:"smartFilter,omitempty" yaml:"smartFilter,omitempty"`                             // Enable smart episode filter
+	PreviouslyMatchedEpisodes []string `json:"previouslyMatchedEpisodes,omitempty" yaml:"previouslyMatchedEpisodes,omitempty"` // The list of episode IDs already matched by smart filter
+	AffectedFeeds             []string `json:"affectedFeeds,omitempty" yaml:"affectedFeeds,omitempty"`                         // The feed URLs the rule applied to
+	IgnoreDays                int64    `json:"ignoreDays,omitempty" yaml:"ignoreDays,omitempty"`                               // Ignore subsequent rule matches (days)
+	LastMatch                 string   `json:"lastMatch,omitempty" yaml:"lastMatch,omitempty"`                                 // The rule last match time
+	AddPaused                 bool     `json:"addPaused,omitempty" yaml:"addPaused,omitempty"`                                 // Add matched torrent in paused mode
+	AssignedCategory          string   `json:"assignedCategory,omitempty" yaml:"assignedCategory,omitempty"`                   // Assign category to the torrent
+	SavePath                  string   `json:"savePath,omitempty" yaml:"savePath,omitempty"`                                   // Save torrent to the given directory
 }
 
 // RssRenameRuleRequest is a rss renameRule request.
@@ -3586,14 +3645,16 @@ type SearchStatusInfo struct {
 
 // SearchResultsRequest is a search results request.
 type SearchResultsRequest struct {
-	ID     int64 `json:"id" yaml:"id"`                                               // ID of the search job
-	Limit  int64 `json:"limit optional,omitempty" yaml:"limit optional,omitempty"`   // max number of results to return. 0 or negative means no limit
-	Offset int64 `json:"offset optional,omitempty" yaml:"offset optional,omitempty"` // result to start at. A negative number means count backwards (e.g. -2 returns the 2 most recent results)
+	ID     int64 `json:"id" yaml:"id"`                             // ID of the search job
+	Limit  int64 `json:"limit,omitempty" yaml:"limit,omitempty"`   // max number of results to return. 0 or negative means no limit
+	Offset int64 `json:"offset,omitempty" yaml:"offset,omitempty"` // result to start at. A negative number means count backwards (e.g. -2 returns the 2 most recent results)
 }
 
 // SearchResults creates a search results request.
-func SearchResults() *SearchResultsRequest {
-	return &SearchResultsRequest{}
+func SearchResults(id int64) *SearchResultsRequest {
+	return &SearchResultsRequest{
+		ID: id,
+	}
 }
 
 // Do executes the request against the provided context and client.
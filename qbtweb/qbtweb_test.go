@@ -0,0 +1,499 @@
+package qbtweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
+)
+
+const (
+	hash1 = "0123456789abcdef0123456789abcdef01234567"
+	hash2 = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestFake(t *testing.T) {
+	s := transctltest.NewQBittorrent(transctltest.WithQBittorrentCredentials("user", "pass"))
+	defer s.Close()
+	ctx := context.Background()
+
+	// bad credentials
+	cl := NewClient(WithURL(s.URL+"/api/v2"), WithCredentialFallback("user", "bad"), WithRetryPolicy(tctypes.RetryPolicy{Attempts: 1}))
+	if _, err := AppVersion().Do(ctx, cl); !errors.Is(err, ErrUnauthorizedUser) {
+		t.Fatalf("expected ErrUnauthorizedUser, got: %v", err)
+	}
+
+	// text response
+	cl = NewClient(WithURL(s.URL+"/api/v2"), WithCredentialFallback("user", "pass"))
+	version, err := AppVersion().Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if version != "v4.2.5" {
+		t.Errorf("expected v4.2.5, got: %q", version)
+	}
+
+	// add
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	magnet := "magnet:?xt=urn:btih:" + hash + "&dn=fake"
+	if err := TorrentsAdd().WithURLs([]string{magnet}).Do(ctx, cl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := TorrentsAdd().WithTorrent("a.torrent", []byte("torrent")).WithPaused(true).Do(ctx, cl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// expired session is re-authenticated
+	s.ExpireSession()
+	if err := TorrentsPause(hash).Do(ctx, cl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := s.Logins(); n != 2 {
+		t.Errorf("expected 2 logins, got: %d", n)
+	}
+
+	// info
+	torrents, err := TorrentsInfo().Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("expected 2 torrents, got: %d", len(torrents))
+	}
+	for _, torrent := range torrents {
+		if torrent.State != StatePausedDL {
+			t.Errorf("torrent %s expected state %s, got: %s", torrent.Hash, StatePausedDL, torrent.State)
+		}
+	}
+	if torrents[0].Name != "fake" {
+		t.Errorf("expected name fake, got: %q", torrents[0].Name)
+	}
+
+	// delete
+	if err := TorrentsDelete(false, hash).Do(ctx, cl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if torrents := s.Torrents(); len(torrents) != 1 || torrents[0]["hash"] == hash {
+		t.Errorf("expected 1 torrent, got: %v", torrents)
+	}
+}
+
+func TestFakeRSS(t *testing.T) {
+	s, cl := newFakeClient(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// feeds
+	s.AddRSSFeed("shows", "http://example.com/rss", "The.Show.S01E01", "The.Show.S01E02")
+	doRequests(ctx, t, cl, RssAddFolder(`tv`), RssMoveItem(`shows`, `tv\shows`))
+	items, err := RssItems(true).Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tv, ok := items["tv"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected tv folder, got: %v", items)
+	}
+	feed, ok := tv["shows"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected shows feed, got: %v", tv)
+	}
+	if articles, _ := feed["articles"].([]interface{}); len(articles) != 2 {
+		t.Errorf("expected 2 articles, got: %v", feed["articles"])
+	}
+	if err := RssRemoveItem(`missing`).Do(ctx, cl); err == nil {
+		t.Errorf("expected error")
+	}
+
+	// rules
+	rule := Rule{Enabled: true, MustContain: "show", AffectedFeeds: []string{"http://example.com/rss"}, IgnoreDays: 2}
+	doRequests(ctx, t, cl, RssSetRule("a", rule), RssRenameRule("a", "b"))
+	rules, err := RssRules().Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	r, ok := rules["b"]
+	if !ok || len(rules) != 1 {
+		t.Fatalf("expected rule b, got: %v", rules)
+	}
+	if r.MustContain != "show" || r.IgnoreDays != 2 || !r.Enabled {
+		t.Errorf("expected rule %v, got: %v", rule, r)
+	}
+	doRequests(ctx, t, cl, RssRemoveRule("b"))
+	if rules := s.RSSRules(); len(rules) != 0 {
+		t.Errorf("expected no rules, got: %v", rules)
+	}
+}
+
+func TestFakeSearch(t *testing.T) {
+	s, cl := newFakeClient(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// plugins
+	doRequests(ctx, t, cl,
+		SearchInstallPlugin("http://example.com/a.py", "http://example.com/b.py"),
+		SearchEnablePlugin(false, "b"),
+	)
+	plugins, err := SearchPlugins().Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(plugins) != 2 || !plugins[0].Enabled || plugins[1].Enabled {
+		t.Errorf("expected plugin a enabled and b disabled, got: %v", plugins)
+	}
+	doRequests(ctx, t, cl, SearchUninstallPlugin("a"))
+	if plugins := s.Plugins(); len(plugins) != 1 || plugins[0]["name"] != "b" {
+		t.Errorf("expected plugin b, got: %v", plugins)
+	}
+
+	// search
+	s.AddSearchResult("debian-10.3.0-amd64-netinst.iso", "magnet:?xt=urn:btih:a", 1<<20, 10)
+	s.AddSearchResult("ubuntu-20.04-desktop-amd64.iso", "magnet:?xt=urn:btih:b", 1<<20, 20)
+	res, err := SearchStart().WithPattern("debian").WithPlugins([]string{"all"}).Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	status, err := SearchStatus(res.ID).Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(status) != 1 || status[0].Status != "Running" {
+		t.Errorf("expected running, got: %v", status)
+	}
+	results, err := SearchResults(res.ID).Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(results.Results) != 1 || results.Results[0].FileName != "debian-10.3.0-amd64-netinst.iso" {
+		t.Errorf("expected 1 debian result, got: %v", results.Results)
+	}
+	doRequests(ctx, t, cl, SearchDelete(res.ID))
+	if _, err := SearchStatus(res.ID).Do(ctx, cl); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestFakeTorrents(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		setup func(*transctltest.QBittorrent)
+		reqs  []request
+		check func(*testing.T, *transctltest.QBittorrent, *Client)
+	}{
+		{"limits", nil, []request{
+			TorrentsSetShareLimits(hash1).WithRatioLimit(1.5).WithSeedingTimeLimit(43200).WithInactiveSeedingTimeLimit(-1),
+			TorrentsSetDownloadLimit(1<<20, hash1),
+		}, func(t *testing.T, _ *transctltest.QBittorrent, cl *Client) {
+			torrents, err := TorrentsInfo().Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if len(torrents) != 1 {
+				t.Fatalf("expected 1 torrent, got: %d", len(torrents))
+			}
+			switch torrent := torrents[0]; {
+			case torrent.RatioLimit != 1.5:
+				t.Errorf("expected ratio limit 1.5, got: %v", float64(torrent.RatioLimit))
+			case torrent.SeedingTimeLimit != 43200:
+				t.Errorf("expected seeding time limit 43200, got: %d", torrent.SeedingTimeLimit)
+			case torrent.InactiveSeedingTimeLimit != -1:
+				t.Errorf("expected inactive seeding time limit -1, got: %d", torrent.InactiveSeedingTimeLimit)
+			case torrent.DlLimit != 1<<20:
+				t.Errorf("expected download limit 1048576, got: %d", torrent.DlLimit)
+			}
+		}},
+		{"peers add", func(s *transctltest.QBittorrent) {
+			s.AddPeer(hash1, "1.2.3.4:6881", "qBittorrent 4.2.5", "μTP", "E I")
+		}, []request{
+			TorrentsAddPeers(hash1).WithPeers([]string{"5.6.7.8:51413"}),
+		}, func(t *testing.T, _ *transctltest.QBittorrent, cl *Client) {
+			res, err := SyncTorrentPeers(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if len(res.Peers) != 2 {
+				t.Fatalf("expected 2 peers, got: %d", len(res.Peers))
+			}
+			if peer := res.Peers["1.2.3.4:6881"]; peer.IP != "1.2.3.4" || peer.Port != 6881 || peer.Connection != "μTP" || peer.Flags != "E I" {
+				t.Errorf("expected peer 1.2.3.4:6881, got: %+v", peer)
+			}
+		}},
+		{"peers ban", func(s *transctltest.QBittorrent) {
+			s.AddPeer(hash1, "1.2.3.4:6881", "qBittorrent 4.2.5", "μTP", "E I")
+		}, []request{
+			TorrentsAddPeers(hash1).WithPeers([]string{"5.6.7.8:51413"}),
+			TransferBanPeers("1.2.3.4:0"),
+		}, func(t *testing.T, s *transctltest.QBittorrent, cl *Client) {
+			if banned := s.BannedPeers(); len(banned) != 1 || banned[0] != "1.2.3.4:0" {
+				t.Errorf("expected 1.2.3.4:0 to be banned, got: %v", banned)
+			}
+			res, err := SyncTorrentPeers(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if _, ok := res.Peers["5.6.7.8:51413"]; len(res.Peers) != 1 || !ok {
+				t.Errorf("expected only peer 5.6.7.8:51413, got: %v", res.Peers)
+			}
+		}},
+		{"trackers", func(s *transctltest.QBittorrent) {
+			s.AddTracker(hash1, "http://tracker.example.com/announce", 0, 12, "")
+		}, []request{
+			TorrentsAddTrackers(hash1, "udp://open.example.org:6969/announce"),
+			TorrentsEditTracker(hash1, "http://tracker.example.com/announce", "https://tracker.example.net/announce"),
+		}, func(t *testing.T, s *transctltest.QBittorrent, cl *Client) {
+			if err := TorrentsEditTracker(hash1, "http://missing.example.com/announce", "http://tracker.example.com/announce").Do(ctx, cl); err == nil {
+				t.Errorf("expected error editing a missing tracker")
+			}
+			trackers, err := TorrentsTrackers(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			var urls []string
+			for _, tracker := range trackers {
+				urls = append(urls, tracker.URL)
+			}
+			if exp := "** [DHT] **,** [PeX] **,** [LSD] **,https://tracker.example.net/announce,udp://open.example.org:6969/announce"; strings.Join(urls, ",") != exp {
+				t.Errorf("expected %s, got: %s", exp, strings.Join(urls, ","))
+			}
+			doRequests(ctx, t, cl, TorrentsRemoveTrackers(hash1, "udp://open.example.org:6969/announce"))
+			if urls := s.Trackers(hash1); len(urls) != 1 || urls[0] != "https://tracker.example.net/announce" {
+				t.Errorf("expected only https://tracker.example.net/announce, got: %v", urls)
+			}
+		}},
+		{"pieces", func(s *transctltest.QBittorrent) {
+			s.AddFile(hash1, "debian/README", 100)
+			s.AddFile(hash1, "debian/debian.iso", 300)
+			s.SetPieces(hash1, 64, "2221200")
+		}, nil, func(t *testing.T, _ *transctltest.QBittorrent, cl *Client) {
+			props, err := TorrentsProperties(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if props.PieceSize != 64 || props.PiecesNum != 7 || props.PiecesHave != 4 {
+				t.Errorf("expected piece size 64, 7 pieces, and 4 have, got: %d, %d, %d", props.PieceSize, props.PiecesNum, props.PiecesHave)
+			}
+			states, err := TorrentsPieceStates(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if exp := []PieceState{2, 2, 2, 1, 2, 0, 0}; fmt.Sprint(states) != fmt.Sprint(exp) {
+				t.Errorf("expected %v, got: %v", exp, states)
+			}
+			files, err := TorrentsFiles(hash1).Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			var ranges []string
+			for _, file := range files {
+				ranges = append(ranges, fmt.Sprintf("%s=%v", file.Name, file.PieceRange))
+			}
+			if exp := "debian/README=[0 1],debian/debian.iso=[1 6]"; strings.Join(ranges, ",") != exp {
+				t.Errorf("expected %s, got: %s", exp, strings.Join(ranges, ","))
+			}
+		}},
+		{"modes", func(s *transctltest.QBittorrent) {
+			s.AddTorrent(hash2, "ubuntu-20.04-desktop-amd64.iso")
+		}, []request{
+			TorrentsToggleSequentialDownload(hash1),
+			TorrentsToggleFirstLastPiecePrio(hash1, hash2),
+			TorrentsSetForceStart(true, hash2),
+			TorrentsSetSuperSeeding(true, hash1),
+		}, func(t *testing.T, _ *transctltest.QBittorrent, cl *Client) {
+			res, err := TorrentsInfo().Do(ctx, cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			var modes []string
+			for _, torrent := range res {
+				modes = append(modes, fmt.Sprintf("%t %t %t %t %s", torrent.SeqDl, torrent.FLPiecePrio, torrent.ForceStart, torrent.SuperSeeding, torrent.State))
+			}
+			if exp := "true true false true downloading,false true true false forcedDL"; strings.Join(modes, ",") != exp {
+				t.Errorf("expected %s, got: %s", exp, strings.Join(modes, ","))
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, cl := newFakeClient(t)
+			defer s.Close()
+			if test.setup != nil {
+				test.setup(s)
+			}
+			doRequests(ctx, t, cl, test.reqs...)
+			test.check(t, s, cl)
+		})
+	}
+}
+
+func TestFakeLogs(t *testing.T) {
+	s, cl := newFakeClient(t)
+	defer s.Close()
+	ctx := context.Background()
+	s.AddLog("qBittorrent v4.2.5 started", 1)
+	s.AddLog("Trying to listen on: 0.0.0.0:6881", 2)
+	s.AddLog("Could not get GUID of network interface", 4)
+	s.AddLog("Failed to listen on IP: 0.0.0.0, port: TCP/6881", 8)
+	s.AddPeerLog("1.2.3.4", false, "banned manually")
+
+	tests := []struct {
+		req *LogMainRequest
+		exp string
+	}{
+		{LogMain(), "0 1 1 2 2 4 3 8"},
+		{LogMain().WithNormal(false).WithInfo(false), "2 4 3 8"},
+		{LogMain().WithLastKnownID(1), "2 4 3 8"},
+		{LogMain().WithCritical(false).WithLastKnownID(0), "1 2 2 4"},
+		{LogMain().WithLastKnownID(3), ""},
+	}
+	for i, test := range tests {
+		entries, err := test.req.Do(ctx, cl)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		var v []string
+		for _, entry := range entries {
+			if time.Time(entry.Timestamp).IsZero() {
+				t.Errorf("test %d expected entry %d to have a timestamp", i, entry.ID)
+			}
+			v = append(v, fmt.Sprintf("%d %d", entry.ID, entry.Type))
+		}
+		if s := strings.Join(v, " "); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+	peers, err := LogPeers().Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(peers) != 1 || peers[0].Ip != "1.2.3.4" || peers[0].Blocked || peers[0].Reason != "banned manually" {
+		t.Errorf("expected banned peer 1.2.3.4, got: %+v", peers)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	serverErr := fmt.Errorf("%w: 503 Service Unavailable", ErrServerError)
+	tests := []struct {
+		method string
+		err    error
+		exp    bool
+	}{
+		{"torrents/info", serverErr, true},
+		{"transfer/speedLimitsMode", serverErr, true},
+		{"transfer/toggleSpeedLimitsMode", serverErr, false},
+		{"torrents/toggleSequentialDownload", serverErr, false},
+		{"torrents/toggleFirstLastPiecePrio", serverErr, false},
+		{"rss/addFeed", serverErr, false},
+		{"rss/addFolder", serverErr, false},
+		{"search/start", serverErr, false},
+		{"torrents/info", ErrTorrentNotFound, false},
+	}
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			if b := retryable(test.method, test.err); b != test.exp {
+				t.Errorf("expected %t, got: %t", test.exp, b)
+			}
+		})
+	}
+}
+
+func TestDialer(t *testing.T) {
+	s := transctltest.NewQBittorrent()
+	defer s.Close()
+	var requests int64
+	h := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		s.Config.Handler.ServeHTTP(res, req)
+	})
+
+	// unix socket listener
+	dir, err := ioutil.TempDir("", "qbtweb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "api.sock")
+	var l net.Listener
+	if runtime.GOOS != "windows" {
+		if l, err = net.Listen("unix", socket); err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go func() { _ = http.Serve(l, h) }()
+	}
+
+	// http proxy
+	proxy := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !req.URL.IsAbs() {
+			http.Error(res, "not a proxy request", http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(res, req)
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name string
+		d    *tctypes.Dialer
+	}{
+		{"unix", &tctypes.Dialer{Socket: socket}},
+		{"http", &tctypes.Dialer{Proxy: &url.URL{Scheme: "http", Host: proxy.Listener.Addr().String()}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name == "unix" && l == nil {
+				t.Skip("unix sockets not supported")
+			}
+			n := atomic.LoadInt64(&requests)
+			cl := NewClient(WithURL("http://localhost/api/v2"), WithCredentialFallback("admin", "adminadmin"), WithDialer(test.d))
+			version, err := AppVersion().Do(context.Background(), cl)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			// login and version
+			if version != "v4.2.5" || atomic.LoadInt64(&requests) != n+2 {
+				t.Errorf("expected requests to be handled")
+			}
+		})
+	}
+}
+
+// newFakeClient creates a fake qBittorrent server with the default
+// credentials and a debian torrent (hash1), and a client for it. The caller
+// must close the server.
+func newFakeClient(t *testing.T) (*transctltest.QBittorrent, *Client) {
+	t.Helper()
+	s := transctltest.NewQBittorrent()
+	s.AddTorrent(hash1, "debian-10.3.0-amd64-netinst.iso")
+	return s, NewClient(WithURL(s.URL+"/api/v2"), WithCredentialFallback("admin", "adminadmin"))
+}
+
+// request is a request without a response.
+type request interface {
+	Do(context.Context, *Client) error
+}
+
+// doRequests performs the requests, failing the test on the first error.
+func doRequests(ctx context.Context, t *testing.T, cl *Client, reqs ...request) {
+	t.Helper()
+	for i, req := range reqs {
+		if err := req.Do(ctx, cl); err != nil {
+			t.Fatalf("request %d (%T) expected no error, got: %v", i, req, err)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package qbtweb
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -29,6 +31,9 @@ const (
 
 	// ErrRequestFailed is the request failed error.
 	ErrRequestFailed Error = "request failed"
+
+	// ErrServerError is the server error error.
+	ErrServerError Error = "server error"
 )
 
 // buildParamMap converts z into a map[string]interface{}.
@@ -79,7 +84,21 @@ func buildParamMap(z interface{}) (map[string]interface{}, error) {
 func buildRequestBody(w io.Writer, params map[string]interface{}) (string, error) {
 	x := make(url.Values)
 	for k, v := range params {
-		x.Add(k, fmt.Sprintf("%v", v))
+		// structs and maps (such as rule definitions) are sent as json
+		switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+		case reflect.Struct, reflect.Map:
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			x.Add(k, string(buf))
+		default:
+			s, err := formValue(v)
+			if err != nil {
+				return "", err
+			}
+			x.Add(k, s)
+		}
 	}
 	if _, err := w.Write([]byte(x.Encode())); err != nil {
 		return "", err
@@ -87,6 +106,32 @@ func buildRequestBody(w io.Writer, params map[string]interface{}) (string, error
 	return "application/x-www-form-urlencoded", nil
 }
 
+// formValue formats v as a form value. Values are formatted by their json
+// encoding (such as durations in seconds) or underlying kind, and not by
+// their String method (which is for display).
+func formValue(v interface{}) (string, error) {
+	if m, ok := v.(json.Marshaler); ok {
+		buf, err := m.MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		return strings.Trim(string(buf), `"`), nil
+	}
+	switch x := reflect.ValueOf(v); x.Kind() {
+	case reflect.String:
+		return x.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(x.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(x.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(x.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(x.Float(), 'f', -1, 64), nil
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
 // contains determines if needle is contained in haystack.
 func contains(haystack []string, needle string) bool {
 	for _, s := range haystack {
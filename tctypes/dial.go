@@ -0,0 +1,149 @@
+package tctypes
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// Dialer dials connections to a remote host, optionally through a unix socket
+// or a proxy.
+type Dialer struct {
+	// Socket is the unix socket path. When set, all connections are made to
+	// the socket.
+	Socket string
+
+	// Proxy is the proxy url. Supported schemes are socks5, socks5h, http,
+	// and https.
+	Proxy *url.URL
+
+	// Timeout is the dial timeout.
+	Timeout time.Duration
+}
+
+// DialContext dials the address using the provided context.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	nd := &net.Dialer{
+		Timeout: d.Timeout,
+	}
+	switch {
+	case d.Socket != "":
+		return nd.DialContext(ctx, "unix", d.Socket)
+	case d.Proxy == nil:
+		return nd.DialContext(ctx, network, addr)
+	}
+	switch d.Proxy.Scheme {
+	case "socks5", "socks5h":
+		pd, err := proxy.FromURL(d.Proxy, nd)
+		if err != nil {
+			return nil, err
+		}
+		return pd.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	case "http", "https":
+		return dialConnect(ctx, nd, d.Proxy, addr)
+	}
+	return nil, fmt.Errorf("%s: %w", d.Proxy.Scheme, ErrUnsupportedProxyScheme)
+}
+
+// dialConnect dials addr through the HTTP proxy, using a CONNECT tunnel.
+func dialConnect(ctx context.Context, nd *net.Dialer, u *url.URL, addr string) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := nd.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// send connect
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(u.User.Username()+":"+pass)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// read response -- the remote host is not sent anything until the
+	// tunnel is established, so nothing beyond the response is buffered
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w: %s", u.Host, ErrProxyConnectFailed, res.Status)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// WithDialer returns a transport using the dialer, based on the passed
+// transport. HTTP proxies are used as the transport's proxy, rather than
+// tunneled. When transport is a HTTPLogger, the logger's transport will be
+// replaced.
+func WithDialer(transport http.RoundTripper, d *Dialer) http.RoundTripper {
+	return withTransport(transport, func(t *http.Transport) {
+		if d.Socket == "" && d.Proxy != nil && (d.Proxy.Scheme == "http" || d.Proxy.Scheme == "https") {
+			t.Proxy = http.ProxyURL(d.Proxy)
+			return
+		}
+		t.Proxy, t.DialContext = nil, d.DialContext
+	})
+}
+
+// withTransport returns a copy of the passed transport modified by f. When
+// transport is nil, the default transport is used. When transport is a
+// HTTPLogger, the logger's transport will be replaced.
+func withTransport(transport http.RoundTripper, f func(*http.Transport)) http.RoundTripper {
+	switch t := transport.(type) {
+	case nil:
+		base, ok := DefaultTransport.(*http.Transport)
+		if !ok {
+			base = http.DefaultTransport.(*http.Transport)
+		}
+		return withTransport(base, f)
+	case *HTTPLogger:
+		t.transport = withTransport(t.transport, f)
+		return t
+	case *http.Transport:
+		t = t.Clone()
+		f(t)
+		return t
+	}
+	return transport
+}
@@ -0,0 +1,119 @@
+package tctypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// extraType is the type of the Extra field of a struct, used to collect
+// unknown fields when decoding leniently.
+var extraType = reflect.TypeOf(map[string]json.RawMessage(nil))
+
+// DecodeExtra decodes the JSON in buf to v, tolerating fields that do not
+// exist in v. Unknown fields are collected into the Extra
+// map[string]json.RawMessage field of the enclosing struct (if present).
+//
+// Returns the sorted, dot-separated paths of the unknown fields.
+func DecodeExtra(buf []byte, v interface{}) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return nil, err
+	}
+	unknown := make(map[string]bool)
+	collectExtra(buf, reflect.ValueOf(v), "", unknown)
+	paths := make([]string, 0, len(unknown))
+	for path := range unknown {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// collectExtra walks the JSON in buf alongside v, collecting unknown fields.
+func collectExtra(buf []byte, v reflect.Value, path string, unknown map[string]bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() {
+		if _, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var values []json.RawMessage
+		if err := json.Unmarshal(buf, &values); err != nil {
+			return
+		}
+		for i := 0; i < len(values) && i < v.Len(); i++ {
+			collectExtra(values[i], v.Index(i), path, unknown)
+		}
+	case reflect.Struct:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(buf, &values); err != nil {
+			return
+		}
+		fields, extra := structFields(v)
+		for name, value := range values {
+			key := path + name
+			f, ok := fields[name]
+			if !ok {
+				for n, z := range fields {
+					if strings.EqualFold(n, name) {
+						f, ok = z, true
+						break
+					}
+				}
+			}
+			if ok {
+				collectExtra(value, f, key+".", unknown)
+				continue
+			}
+			unknown[key] = true
+			if extra.IsValid() && extra.CanSet() {
+				if extra.IsNil() {
+					extra.Set(reflect.MakeMap(extraType))
+				}
+				extra.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(value))
+			}
+		}
+	}
+}
+
+// structFields returns the JSON fields of the struct v, and its Extra field.
+func structFields(v reflect.Value) (map[string]reflect.Value, reflect.Value) {
+	fields := make(map[string]reflect.Value)
+	var extra reflect.Value
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case f.Name == "Extra" && f.Type == extraType:
+			extra = v.Field(i)
+		case f.PkgPath != "" && !f.Anonymous, tag == "-":
+		case f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct:
+			embedded, z := structFields(v.Field(i))
+			for name, value := range embedded {
+				if _, ok := fields[name]; !ok {
+					fields[name] = value
+				}
+			}
+			if !extra.IsValid() {
+				extra = z
+			}
+		case tag != "":
+			fields[tag] = v.Field(i)
+		default:
+			fields[f.Name] = v.Field(i)
+		}
+	}
+	return fields, extra
+}
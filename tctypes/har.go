@@ -0,0 +1,246 @@
+package tctypes
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HAR is a HTTP Archive (HAR 1.2).
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is a HAR log.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator is a HAR creator.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a HAR entry.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is a HAR request.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is a HAR response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a HAR name/value pair, used for headers, cookies and query
+// strings.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is HAR request post data.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is HAR response content.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are HAR entry timings.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARWriter writes requests and responses to a HAR file.
+//
+// Entries are not kept in memory. Each entry is written in place of the
+// archive's closing brackets, which are then written again after the entry,
+// so that the file is always a complete archive, and the cost of adding an
+// entry does not grow with the number of entries already written.
+type HARWriter struct {
+	path    string
+	creator HARCreator
+	f       *os.File
+	off     int64
+	count   int
+	sync.Mutex
+}
+
+// NewHARWriter creates a HAR writer for the file path, using name and version
+// as the archive's creator. The file is created when the first entry is
+// added.
+func NewHARWriter(path, name, version string) *HARWriter {
+	return &HARWriter{
+		path:    path,
+		creator: HARCreator{Name: name, Version: version},
+	}
+}
+
+// harTrailer closes the entries, log, and archive.
+const harTrailer = "\n    ]\n  }\n}\n"
+
+// Add adds the entry to the end of the archive on disk.
+func (w *HARWriter) Add(entry HAREntry) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	buf, err := json.MarshalIndent(entry, "      ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n      "
+	if w.count == 0 {
+		sep = "\n      "
+	}
+	buf = append([]byte(sep), buf...)
+	if _, err := w.f.WriteAt(append(buf, harTrailer...), w.off); err != nil {
+		return err
+	}
+	w.off += int64(len(buf))
+	w.count++
+	return nil
+}
+
+// open creates the file, and writes the archive header and trailer.
+func (w *HARWriter) open() error {
+	creator, err := json.MarshalIndent(w.creator, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	header := `{
+  "log": {
+    "version": "1.2",
+    "creator": ` + string(creator) + `,
+    "entries": [`
+	f, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(header + harTrailer); err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.off, w.count = f, int64(len(header)), 0
+	return nil
+}
+
+// Close closes the file. Entries added after Close start a new archive.
+func (w *HARWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// NewHAREntry creates a HAR entry for the request and response, redacting
+// sensitive headers and body content.
+func NewHAREntry(req *http.Request, reqBody []byte, res *http.Response, resBody []byte, start time.Time, d time.Duration) HAREntry {
+	ms := float64(d) / float64(time.Millisecond)
+	u := *req.URL
+	u.User = nil
+	entry := HAREntry{
+		StartedDateTime: start,
+		Time:            ms,
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         u.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBody)),
+		},
+		Response: HARResponse{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: res.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(res.Header),
+			Content: HARContent{
+				Size:     int64(len(resBody)),
+				MimeType: res.Header.Get("Content-Type"),
+				Text:     string(RedactBody(res.Header.Get("Content-Type"), resBody)),
+			},
+			HeadersSize: -1,
+			BodySize:    int64(len(resBody)),
+		},
+		Timings: HARTimings{Wait: ms},
+	}
+	for k, v := range u.Query() {
+		for _, s := range v {
+			entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: k, Value: s})
+		}
+	}
+	if reqBody != nil {
+		entry.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(RedactBody(req.Header.Get("Content-Type"), reqBody)),
+		}
+	}
+	return entry
+}
+
+// harHeaders returns the sorted, redacted HAR headers for the header.
+func harHeaders(header http.Header) []HARNameValue {
+	var names []string
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := []HARNameValue{}
+	for _, name := range names {
+		for _, v := range header[name] {
+			headers = append(headers, HARNameValue{Name: name, Value: RedactHeader(name, v)})
+		}
+	}
+	return headers
+}
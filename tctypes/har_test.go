@@ -0,0 +1,115 @@
+package tctypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHARWriter(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"result": "success"}`))
+	}))
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.har")
+	har := NewHARWriter(path, "transctl", "0.1")
+	defer har.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no file before the first entry, got: %v", err)
+	}
+
+	// a long-running command (daemon, logs --follow, ...) makes requests
+	// from multiple goroutines for as long as it runs, and the archive must
+	// be complete after every request
+	cl := &http.Client{Transport: NewHTTPHAR(nil, har)}
+	const workers, requests = 4, 100
+	for i := 0; i < 5; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < workers; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				for k := 0; k < requests/workers; k++ {
+					res, err := cl.Post(s.URL+fmt.Sprintf("/rpc?n=%d", j), "application/json", strings.NewReader(`{"method": "session-get"}`))
+					if err != nil {
+						t.Errorf("expected no error, got: %v", err)
+						return
+					}
+					_, _ = ioutil.ReadAll(res.Body)
+					res.Body.Close()
+				}
+			}(j)
+		}
+		wg.Wait()
+		v := readHAR(t, path)
+		if exp := (i + 1) * requests; len(v.Log.Entries) != exp {
+			t.Fatalf("expected %d entries, got: %d", exp, len(v.Log.Entries))
+		}
+		if v.Log.Version != "1.2" || v.Log.Creator.Name != "transctl" || v.Log.Creator.Version != "0.1" {
+			t.Errorf("expected version 1.2 and creator transctl 0.1, got: %+v", v.Log)
+		}
+	}
+	v := readHAR(t, path)
+	for i, entry := range v.Log.Entries {
+		if entry.Request.Method != "POST" || entry.Response.Status != http.StatusOK || entry.Request.PostData == nil || entry.Response.Content.Text != `{"result": "success"}` {
+			t.Fatalf("entry %d expected POST with post data and 200 response, got: %+v", i, entry)
+		}
+	}
+
+	// closing and adding starts a new archive
+	if err := har.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := har.Add(v.Log.Entries[0]); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := readHAR(t, path); len(v.Log.Entries) != 1 {
+		t.Errorf("expected 1 entry, got: %d", len(v.Log.Entries))
+	}
+}
+
+func TestHARWriterEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.har")
+	har := NewHARWriter(path, "transctl", "0.1")
+	if err := har.open(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := har.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := readHAR(t, path); v.Log.Entries == nil || len(v.Log.Entries) != 0 {
+		t.Errorf("expected empty entries, got: %v", v.Log.Entries)
+	}
+}
+
+// readHAR reads and decodes the HAR file.
+func readHAR(t *testing.T, path string) HAR {
+	t.Helper()
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var v HAR
+	if err := json.Unmarshal(buf, &v); err != nil {
+		t.Fatalf("expected valid har, got: %v\n%s", err, buf)
+	}
+	return v
+}
@@ -2,8 +2,10 @@ package tctypes
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"time"
 )
 
 // DefaultTransport is the default transport used by the HTTP logger.
@@ -11,11 +13,13 @@ var DefaultTransport = http.DefaultTransport
 
 // HTTPLogger provides a logging http.RoundTripper transport.
 //
-// Handles logging of HTTP requests and responses to standard logging funcs.
+// Handles logging of HTTP requests and responses to standard logging funcs,
+// or to a HAR file. Sensitive headers and body content are redacted.
 type HTTPLogger struct {
 	transport http.RoundTripper
 	reqf      func([]byte)
 	resf      func([]byte)
+	har       *HARWriter
 }
 
 // NewHTTPLogger creates a new HTTP transport.
@@ -45,12 +49,24 @@ func NewHTTPLogf(transport http.RoundTripper, logf func(string, ...interface{}))
 	)
 }
 
+// NewHTTPHAR creates a new HTTP transport that writes requests and responses
+// to the HAR writer for the provided transport.
+func NewHTTPHAR(transport http.RoundTripper, har *HARWriter) *HTTPLogger {
+	return &HTTPLogger{
+		transport: transport,
+		har:       har,
+	}
+}
+
 // RoundTrip satisfies the http.RoundTripper interface.
 func (hl *HTTPLogger) RoundTrip(req *http.Request) (*http.Response, error) {
 	trans := hl.transport
 	if trans == nil {
 		trans = DefaultTransport
 	}
+	if hl.har != nil {
+		return hl.roundTripHAR(trans, req)
+	}
 
 	reqBody, err := httputil.DumpRequestOut(req, true)
 	if err != nil {
@@ -65,8 +81,39 @@ func (hl *HTTPLogger) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
-	hl.reqf(reqBody)
-	hl.resf(resBody)
+	hl.reqf(RedactDump(reqBody))
+	hl.resf(RedactDump(resBody))
 
 	return res, err
 }
+
+// roundTripHAR executes the request, adding the request and response to the
+// HAR writer.
+func (hl *HTTPLogger) roundTripHAR(trans http.RoundTripper, req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	start := time.Now()
+	res, err := trans.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	// a failure writing the trace does not fail the request, as the request
+	// has already been executed
+	_ = hl.har.Add(NewHAREntry(req, reqBody, res, resBody, start, time.Since(start)))
+
+	return res, nil
+}
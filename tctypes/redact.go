@@ -0,0 +1,134 @@
+package tctypes
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue is the value substituted for redacted secrets.
+const RedactedValue = "[REDACTED]"
+
+// MaxLogBody is the maximum length of a logged request or response body,
+// after which the body is truncated.
+var MaxLogBody = 4096
+
+// sensitiveHeaders are the (canonical) headers redacted when logging.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":             true,
+	"Cookie":                    true,
+	"Proxy-Authorization":       true,
+	"Set-Cookie":                true,
+	"X-Transmission-Session-Id": true,
+}
+
+// sensitiveFields are the form fields and JSON keys redacted when logging.
+var sensitiveFields = []string{
+	"password",
+	"passwd",
+	"proxy-password",
+	"proxy_password",
+	"rpc-password",
+	"rpc_password",
+	"web_ui_password",
+	"web_ui_api_key",
+	"SID",
+}
+
+// sensitiveField determines if name is a sensitive form field or JSON key.
+func sensitiveField(name string) bool {
+	for _, s := range sensitiveFields {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// jsonRE matches sensitive JSON string values.
+	jsonRE = regexp.MustCompile(`(?i)("(?:` + strings.Join(sensitiveFields, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	// multipartRE matches sensitive multipart form values.
+	multipartRE = regexp.MustCompile(`(?i)(content-disposition:[^\r\n]*\bname="(?:` + strings.Join(sensitiveFields, "|") + `)"[^\r\n]*\r\n(?:[^\r\n]+\r\n)*\r\n)[^\r\n]*`)
+)
+
+// RedactHeader returns the value of the named header, redacted when the
+// header is sensitive.
+func RedactHeader(name, value string) string {
+	if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+		return RedactedValue
+	}
+	return value
+}
+
+// RedactBody returns the body with sensitive form fields and JSON keys
+// redacted, truncated to MaxLogBody.
+func RedactBody(contentType string, body []byte) []byte {
+	body = Redact(contentType, body)
+	if MaxLogBody > 0 && len(body) > MaxLogBody {
+		body = append(body[:MaxLogBody:MaxLogBody], fmt.Sprintf("... [%d bytes truncated]", len(body)-MaxLogBody)...)
+	}
+	return body
+}
+
+// Redact returns the body with sensitive form fields and JSON keys redacted.
+func Redact(contentType string, body []byte) []byte {
+	typ, _, _ := mime.ParseMediaType(contentType)
+	switch typ {
+	case "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			for k, v := range values {
+				for i := range v {
+					if sensitiveField(k) {
+						v[i] = RedactedValue
+					} else {
+						// qbittorrent encodes preferences as json in a form field
+						v[i] = jsonRE.ReplaceAllString(v[i], `${1}"`+RedactedValue+`"`)
+					}
+				}
+			}
+			body = []byte(values.Encode())
+		}
+	case "multipart/form-data":
+		body = multipartRE.ReplaceAll(body, []byte("${1}"+RedactedValue))
+	default:
+		body = jsonRE.ReplaceAll(body, []byte(`${1}"`+RedactedValue+`"`))
+	}
+	return body
+}
+
+// RedactDump redacts sensitive headers and body content in a HTTP request or
+// response dump (such as from httputil.DumpRequestOut).
+func RedactDump(buf []byte) []byte {
+	var header, body []byte
+	if i := bytes.Index(buf, []byte("\r\n\r\n")); i != -1 {
+		header, body = buf[:i], buf[i+4:]
+	} else {
+		header = buf
+	}
+	var contentType string
+	lines := bytes.Split(header, []byte("\r\n"))
+	for i, line := range lines {
+		j := bytes.IndexByte(line, ':')
+		if i == 0 || j == -1 {
+			continue
+		}
+		name := string(line[:j])
+		value := strings.TrimSpace(string(line[j+1:]))
+		if http.CanonicalHeaderKey(name) == "Content-Type" {
+			contentType = value
+		}
+		lines[i] = []byte(name + ": " + RedactHeader(name, value))
+	}
+	out := bytes.Join(lines, []byte("\r\n"))
+	if len(body) == 0 {
+		return append(out, buf[len(header):]...)
+	}
+	out = append(out, "\r\n\r\n"...)
+	return append(out, RedactBody(contentType, body)...)
+}
@@ -0,0 +1,111 @@
+package tctypes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy is a retry policy, using exponential backoff with jitter
+// between attempts.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int
+
+	// Initial is the initial backoff.
+	Initial time.Duration
+
+	// Max is the maximum backoff.
+	Max time.Duration
+
+	// Multiplier is the backoff multiplier applied after each attempt.
+	Multiplier float64
+
+	// Jitter is the random fraction (0-1) of the backoff added or removed.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the default retry policy.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   5,
+	Initial:    250 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Backoff returns the backoff before the retry following the attempt
+// (starting at 0).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.Initial)
+	for i := 0; i < attempt && d < float64(p.Max); i++ {
+		d *= p.Multiplier
+	}
+	if p.Max != 0 && d > float64(p.Max) {
+		d = float64(p.Max)
+	}
+	if p.Jitter != 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// Do calls f until it succeeds, it returns a non-retryable error, the
+// attempts are exhausted, or the context is closed. f is passed the attempt
+// (starting at 0), and returns whether its error is retryable.
+func (p RetryPolicy) Do(ctx context.Context, f func(int) (bool, error)) error {
+	for attempt := 0; ; attempt++ {
+		retry, err := f(attempt)
+		if err == nil || !retry || attempt+1 >= p.Attempts {
+			return err
+		}
+		t := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// IsTransient determines if the error is a transient network error, that may
+// succeed if retried. Only dial errors, and connection errors with a known
+// errno (such as a reset connection), are transient. DNS and TLS failures are
+// not transient.
+func IsTransient(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		isDNSError(err):
+		return false
+	case IsDialError(err),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+	return false
+}
+
+// IsDialError determines if the error occurred while dialing the remote host,
+// in which case the request was never sent, and can be safely retried. DNS
+// failures are not dial errors.
+func IsDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && !isDNSError(err)
+}
+
+// isDNSError determines if the error is a DNS resolution failure.
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
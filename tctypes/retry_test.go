@@ -0,0 +1,47 @@
+package tctypes
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	dnsErr := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+		dial      bool
+	}{
+		{"nil", nil, false, false},
+		{"canceled", context.Canceled, false, false},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), false, false},
+		{"dial refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true, true},
+		{"dial timeout", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, true, true},
+		{"dns", dnsErr, false, false},
+		{"dns wrapped", fmt.Errorf("post: %w", dnsErr), false, false},
+		{"reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true, false},
+		{"broken pipe", &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}, true, false},
+		{"eof", fmt.Errorf("post: %w", io.EOF), true, false},
+		{"read timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, false, false},
+		{"tls alert", &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}, false, false},
+		{"tls verify", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if b := IsTransient(test.err); b != test.transient {
+				t.Errorf("expected transient %t, got: %t", test.transient, b)
+			}
+			if b := IsDialError(test.err); b != test.dial {
+				t.Errorf("expected dial error %t, got: %t", test.dial, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package tctypes
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// SecretPrefix is the prefix of encrypted secrets.
+	SecretPrefix = "enc:v1:"
+
+	// secretSaltLen is the salt length of encrypted secrets.
+	secretSaltLen = 16
+
+	// secretNonceLen is the nonce length of encrypted secrets.
+	secretNonceLen = 24
+
+	// secretKeyLen is the derived key length.
+	secretKeyLen = 32
+
+	// secretN, secretR, and secretP are the scrypt cost parameters.
+	secretN, secretR, secretP = 1 << 15, 8, 1
+)
+
+// IsSecret determines if s is an encrypted secret.
+func IsSecret(s string) bool {
+	return strings.HasPrefix(s, SecretPrefix)
+}
+
+// EncryptSecret encrypts the secret with NaCl secretbox, using a key derived
+// with scrypt from passphrase (such as a passphrase or the contents of a key
+// file). The returned string is the base64 encoded salt, nonce, and sealed
+// secret, prefixed with SecretPrefix.
+func EncryptSecret(secret, passphrase []byte) (string, error) {
+	buf := make([]byte, secretSaltLen+secretNonceLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	key, err := secretKey(passphrase, buf[:secretSaltLen])
+	if err != nil {
+		return "", err
+	}
+	var nonce [secretNonceLen]byte
+	copy(nonce[:], buf[secretSaltLen:])
+	buf = secretbox.Seal(buf, secret, &nonce, key)
+	return SecretPrefix + base64.RawStdEncoding.EncodeToString(buf), nil
+}
+
+// DecryptSecret decrypts a secret encrypted with EncryptSecret.
+func DecryptSecret(s string, passphrase []byte) ([]byte, error) {
+	if !IsSecret(s) {
+		return nil, ErrInvalidSecret
+	}
+	buf, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(s, SecretPrefix))
+	if err != nil || len(buf) < secretSaltLen+secretNonceLen+secretbox.Overhead {
+		return nil, ErrInvalidSecret
+	}
+	key, err := secretKey(passphrase, buf[:secretSaltLen])
+	if err != nil {
+		return nil, err
+	}
+	var nonce [secretNonceLen]byte
+	copy(nonce[:], buf[secretSaltLen:])
+	secret, ok := secretbox.Open(nil, buf[secretSaltLen+secretNonceLen:], &nonce, key)
+	if !ok {
+		return nil, ErrSecretDecryptionFailed
+	}
+	return secret, nil
+}
+
+// secretKey derives the secretbox key for the passphrase and salt.
+func secretKey(passphrase, salt []byte) (*[secretKeyLen]byte, error) {
+	buf, err := scrypt.Key(passphrase, salt, secretN, secretR, secretP, secretKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	var key [secretKeyLen]byte
+	copy(key[:], buf)
+	return &key, nil
+}
@@ -0,0 +1,62 @@
+package tctypes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	tests := []string{"", "pass", "a longer password with spaces\tand\nnewlines", strings.Repeat("x", 1024)}
+	for i, test := range tests {
+		s, err := EncryptSecret([]byte(test), []byte("passphrase"))
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if !IsSecret(s) {
+			t.Errorf("test %d expected %q to be a secret", i, s)
+		}
+		if strings.Contains(s, test) && test != "" {
+			t.Errorf("test %d expected secret to not contain the plaintext", i)
+		}
+		buf, err := DecryptSecret(s, []byte("passphrase"))
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if string(buf) != test {
+			t.Errorf("test %d expected %q, got: %q", i, test, string(buf))
+		}
+		if _, err := DecryptSecret(s, []byte("bad")); !errors.Is(err, ErrSecretDecryptionFailed) {
+			t.Errorf("test %d expected ErrSecretDecryptionFailed, got: %v", i, err)
+		}
+	}
+
+	// salt and nonce are random
+	a, err := EncryptSecret([]byte("pass"), []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	b, err := EncryptSecret([]byte("pass"), []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if a == b {
+		t.Errorf("expected different secrets, got: %q", a)
+	}
+
+	// tampered
+	c := a[:len(a)-2] + "AA"
+	if c == a {
+		c = a[:len(a)-2] + "BB"
+	}
+	if _, err := DecryptSecret(c, []byte("passphrase")); !errors.Is(err, ErrSecretDecryptionFailed) {
+		t.Errorf("expected ErrSecretDecryptionFailed, got: %v", err)
+	}
+
+	// invalid
+	for _, s := range []string{"pass", SecretPrefix, SecretPrefix + "!!", SecretPrefix + "AAAA"} {
+		if _, err := DecryptSecret(s, []byte("passphrase")); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("%q expected ErrInvalidSecret, got: %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package tctypes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session is the cached session state for a remote host.
+type Session struct {
+	// CSRF is the transmission rpc session id.
+	CSRF string `json:"csrf,omitempty"`
+
+	// Cookies are the session cookies, such as the qbittorrent SID cookie.
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+
+	// Version is the remote host's negotiated version, such as the
+	// transmission rpc version.
+	Version json.RawMessage `json:"version,omitempty"`
+
+	// Created is the time the session was cached.
+	Created time.Time `json:"created"`
+}
+
+// SessionCache is an on-disk cache of a remote host's session state, stored
+// with mode 0600.
+type SessionCache struct {
+	// Path is the cache file path.
+	Path string
+
+	// MaxAge is the maximum age of a cached session. Sessions older than
+	// MaxAge are discarded on load.
+	MaxAge time.Duration
+}
+
+// NewSessionCache creates a session cache in dir for the key (such as the
+// context name and remote host url).
+func NewSessionCache(dir, key string, maxAge time.Duration) *SessionCache {
+	hash := sha256.Sum256([]byte(key))
+	return &SessionCache{
+		Path:   filepath.Join(dir, hex.EncodeToString(hash[:16])+".json"),
+		MaxAge: maxAge,
+	}
+}
+
+// Load loads the cached session. Returns nil when there is no cached session,
+// or when the cached session is invalid, expired, or readable by other users
+// (in which case it is removed).
+func (c *SessionCache) Load() (*Session, error) {
+	fi, err := os.Stat(c.Path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case fi.IsDir():
+		return nil, nil
+	case fi.Mode().Perm()&0077 != 0:
+		return nil, c.Invalidate()
+	}
+	buf, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	s := new(Session)
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, c.Invalidate()
+	}
+	if c.MaxAge != 0 && time.Since(s.Created) > c.MaxAge {
+		return nil, c.Invalidate()
+	}
+	return s, nil
+}
+
+// Store stores the session.
+func (c *SessionCache) Store(s *Session) error {
+	dir := filepath.Dir(c.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if s.Created.IsZero() {
+		s.Created = time.Now()
+	}
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename, so concurrent invocations never see a
+	// partially written session
+	f, err := ioutil.TempFile(dir, ".session")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.Path)
+}
+
+// Invalidate removes the cached session.
+func (c *SessionCache) Invalidate() error {
+	if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
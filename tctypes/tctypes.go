@@ -2,8 +2,10 @@
 package tctypes
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,9 @@ import (
 //go:generate stringer -type Priority -trimprefix Priority
 //go:generate stringer -type Mode -trimprefix Mode
 //go:generate stringer -type State -trimprefix State
+//go:generate stringer -type PieceState -trimprefix PieceState
+//go:generate stringer -type DownloadMode -trimprefix DownloadMode
+//go:generate stringer -type LogLevel -trimprefix LogLevel
 
 // DefaultAsIEC toggles whether the IEC format is used by default for byte
 // counts/rates/limits' string conversion.
@@ -52,6 +57,44 @@ func Format(i int64, asIEC bool, precision int) string {
 	return fmt.Sprintf("%."+strconv.Itoa(precision)+"f %c%s", float64(i)/float64(div), sizes[exp], end)
 }
 
+// ParseByteCount parses a human readable size (1024, 1.5 GB, 10GiB, 5M, ...)
+// as a byte count. Units with an "i" (KiB, MiB, ...) and bare unit prefixes
+// (K, M, ...) are powers of 1024, otherwise units (kB, MB, ...) are powers of
+// 1000.
+func ParseByteCount(s string) (ByteCount, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	num, unit := s, ""
+	if i != -1 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, ErrInvalidByteCount
+	}
+	if unit == "" || strings.EqualFold(unit, "b") {
+		return ByteCount(f), nil
+	}
+	exp := strings.IndexRune("KMGTPE", rune(strings.ToUpper(unit)[0]))
+	if exp == -1 {
+		return 0, ErrInvalidByteCount
+	}
+	c := 1024.0
+	switch rest := strings.ToLower(unit[1:]); rest {
+	case "", "i", "ib":
+	case "b":
+		c = 1000.0
+	default:
+		return 0, ErrInvalidByteCount
+	}
+	for ; exp >= 0; exp-- {
+		f *= c
+	}
+	return ByteCount(f), nil
+}
+
 // ByteCount wraps a byte count as int64.
 type ByteCount int64
 
@@ -227,7 +270,7 @@ func (t MilliTime) MarshalJSON() ([]byte, error) {
 	if time.Time(t).IsZero() {
 		return []byte("-1"), nil
 	}
-	return []byte(strconv.FormatInt(time.Time(t).Unix()/int64(time.Millisecond), 10)), nil
+	return []byte(strconv.FormatInt(time.Time(t).UnixNano()/int64(time.Millisecond), 10)), nil
 }
 
 // MarshalYAML satisfies the yaml.Marshaler interface.
@@ -490,6 +533,7 @@ func (errNo ErrNo) String() string {
 type Torrent struct {
 	ActivityDate      Time      `json:"activityDate,omitempty" yaml:"activityDate,omitempty"`           // tr_stat
 	AddedDate         Time      `json:"addedDate,omitempty" yaml:"addedDate,omitempty"`                 // tr_stat
+	Availability      []int64   `json:"availability,omitempty" yaml:"availability,omitempty"`           // tr_torrent
 	BandwidthPriority Priority  `json:"bandwidthPriority,omitempty" yaml:"bandwidthPriority,omitempty"` // tr_priority_t
 	Comment           string    `json:"comment,omitempty" yaml:"comment,omitempty"`                     // tr_info
 	CorruptEver       ByteCount `json:"corruptEver,omitempty" yaml:"corruptEver,omitempty"`             // tr_stat
@@ -516,6 +560,8 @@ type Torrent struct {
 		Wanted         bool      `json:"wanted,omitempty" yaml:"wanted,omitempty"`                 // tr_info
 		Priority       Priority  `json:"priority,omitempty" yaml:"priority,omitempty"`             // tr_info
 	} `json:"fileStats,omitempty" yaml:"fileStats,omitempty"` // n/a
+	FileCount               int64     `json:"file-count,omitempty" yaml:"file-count,omitempty"`                           // tr_info
+	Group                   string    `json:"group,omitempty" yaml:"group,omitempty"`                                     // tr_torrent
 	HashString              string    `json:"hashString,omitempty" yaml:"hashString,omitempty"`                           // tr_info
 	HaveUnchecked           ByteCount `json:"haveUnchecked,omitempty" yaml:"haveUnchecked,omitempty"`                     // tr_stat
 	HaveValid               ByteCount `json:"haveValid,omitempty" yaml:"haveValid,omitempty"`                             // tr_stat
@@ -567,6 +613,7 @@ type Torrent struct {
 	PieceCount         int64      `json:"pieceCount,omitempty" yaml:"pieceCount,omitempty"`                 // tr_info
 	PieceSize          ByteCount  `json:"pieceSize,omitempty" yaml:"pieceSize,omitempty"`                   // tr_info
 	Priorities         []Priority `json:"priorities,omitempty" yaml:"priorities,omitempty"`                 // n/a
+	PrimaryMimeType    string     `json:"primary-mime-type,omitempty" yaml:"primary-mime-type,omitempty"`   // tr_torrent
 	QueuePosition      int64      `json:"queuePosition,omitempty" yaml:"queuePosition,omitempty"`           // tr_stat
 	RateDownload       Rate       `json:"rateDownload,omitempty" yaml:"rateDownload,omitempty"`             // tr_stat
 	RateUpload         Rate       `json:"rateUpload,omitempty" yaml:"rateUpload,omitempty"`                 // tr_stat
@@ -577,6 +624,7 @@ type Torrent struct {
 	SeedIdleMode       Mode       `json:"seedIdleMode,omitempty" yaml:"seedIdleMode,omitempty"`             // tr_inactvelimit
 	SeedRatioLimit     float64    `json:"seedRatioLimit,omitempty" yaml:"seedRatioLimit,omitempty"`         // tr_torrent
 	SeedRatioMode      Mode       `json:"seedRatioMode,omitempty" yaml:"seedRatioMode,omitempty"`           // tr_ratiolimit
+	SequentialDownload bool       `json:"sequentialDownload,omitempty" yaml:"sequentialDownload,omitempty"` // tr_torrent
 	SizeWhenDone       ByteCount  `json:"sizeWhenDone,omitempty" yaml:"sizeWhenDone,omitempty"`             // tr_stat
 	StartDate          Time       `json:"startDate,omitempty" yaml:"startDate,omitempty"`                   // tr_stat
 	Status             Status     `json:"status,omitempty" yaml:"status,omitempty"`                         // tr_stat
@@ -614,6 +662,7 @@ type Torrent struct {
 		SeederCount           int64  `json:"seederCount,omitempty" yaml:"seederCount,omitempty"`                     // tr_tracker_stat
 		Tier                  int64  `json:"tier,omitempty" yaml:"tier,omitempty"`                                   // tr_tracker_stat
 	} `json:"trackerStats,omitempty" yaml:"trackerStats,omitempty"` // n/a
+	TrackerList         string    `json:"trackerList,omitempty" yaml:"trackerList,omitempty"`                 // tr_torrent
 	TotalSize           ByteCount `json:"totalSize,omitempty" yaml:"totalSize,omitempty"`                     // tr_info
 	TorrentFile         string    `json:"torrentFile,omitempty" yaml:"torrentFile,omitempty"`                 // tr_info
 	UploadedEver        ByteCount `json:"uploadedEver,omitempty" yaml:"uploadedEver,omitempty"`               // tr_stat
@@ -623,6 +672,9 @@ type Torrent struct {
 	Wanted              []Bool    `json:"wanted,omitempty" yaml:"wanted,omitempty"`                           // n/a
 	Webseeds            []string  `json:"webseeds,omitempty" yaml:"webseeds,omitempty"`                       // n/a
 	WebseedsSendingToUs int64     `json:"webseedsSendingToUs,omitempty" yaml:"webseedsSendingToUs,omitempty"` // tr_stat
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // ShortHash returns the short hash of the torrent.
@@ -679,6 +731,7 @@ type Peer struct {
 	Progress           Percent `json:"progress,omitempty" yaml:"progress,omitempty"`                     // tr_peer_stat
 	RateToClient       Rate    `json:"rateToClient,omitempty" yaml:"rateToClient,omitempty"`             // tr_peer_stat
 	RateToPeer         Rate    `json:"rateToPeer,omitempty" yaml:"rateToPeer,omitempty"`                 // tr_peer_stat
+	Country            string  `json:"country,omitempty" yaml:"country,omitempty"`                       // iso country code
 	ID                 int64   `json:"id" yaml:"id"`
 	Torrent            string  `json:"-" yaml:"-" all:"torrent"`
 	HashString         string  `json:"-" yaml:"-" all:"hashString"`
@@ -692,6 +745,17 @@ func (p Peer) ShortHash() string {
 	return p.HashString[:7]
 }
 
+// PeerStat is the count and rates of connected peers grouped by a peer
+// attribute (client, encryption, transport, or country).
+type PeerStat struct {
+	Group        string `json:"group" yaml:"group"`
+	Name         string `json:"name" yaml:"name"`
+	Peers        int64  `json:"peers" yaml:"peers"`
+	RateToClient Rate   `json:"rateToClient" yaml:"rateToClient"`
+	RateToPeer   Rate   `json:"rateToPeer" yaml:"rateToPeer"`
+	ID           int64  `json:"id" yaml:"id"`
+}
+
 // Tracker is combined fields of trackers, trackerStats from a torrent.
 type Tracker struct {
 	Announce              string `json:"announce,omitempty" yaml:"announce,omitempty"`                           // tr_tracker_info
@@ -732,6 +796,303 @@ func (t Tracker) ShortHash() string {
 	return t.HashString[:7]
 }
 
+// TrackerReport is the announce health of the trackers of an announce host,
+// aggregated across torrents.
+type TrackerReport struct {
+	Host         string   `json:"host" yaml:"host"`
+	Torrents     int64    `json:"torrents" yaml:"torrents"`
+	Announced    int64    `json:"announced" yaml:"announced"`
+	Succeeded    int64    `json:"succeeded" yaml:"succeeded"`
+	SuccessRate  Percent  `json:"successRate" yaml:"successRate"`
+	AvgPeers     float64  `json:"avgPeers" yaml:"avgPeers"`
+	Errors       []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+	TorrentNames []string `json:"torrentNames,omitempty" yaml:"torrentNames,omitempty"`
+	ID           int64    `json:"id" yaml:"id"`
+}
+
+// PieceState is a piece's download state.
+type PieceState int64
+
+// Piece states.
+const (
+	PieceStateMissing PieceState = iota
+	PieceStateDownloading
+	PieceStateHave
+)
+
+// PieceRange is an inclusive range of piece indexes.
+type PieceRange [2]int64
+
+// PieceMap is the state of a torrent's pieces, as ranges of pieces, and the
+// piece coverage of the torrent's files.
+type PieceMap struct {
+	Torrent     string       `json:"torrent" yaml:"torrent"`
+	HashString  string       `json:"hashString" yaml:"hashString"`
+	PieceCount  int64        `json:"pieceCount" yaml:"pieceCount"`
+	PieceSize   ByteCount    `json:"pieceSize" yaml:"pieceSize"`
+	Have        []PieceRange `json:"have" yaml:"have"`
+	Downloading []PieceRange `json:"downloading" yaml:"downloading"`
+	Missing     []PieceRange `json:"missing" yaml:"missing"`
+	Files       []FilePieces `json:"files,omitempty" yaml:"files,omitempty"`
+	States      []PieceState `json:"-" yaml:"-"`
+}
+
+// ShortHash returns the short hash of the torrent.
+func (m PieceMap) ShortHash() string {
+	if len(m.HashString) < 7 {
+		return ""
+	}
+	return m.HashString[:7]
+}
+
+// FilePieces is the piece coverage of a torrent file. Pieces shared with
+// other files are counted for each file.
+type FilePieces struct {
+	Name        string    `json:"name" yaml:"name"`
+	Length      ByteCount `json:"length" yaml:"length"`
+	FirstPiece  int64     `json:"firstPiece" yaml:"firstPiece"`
+	LastPiece   int64     `json:"lastPiece" yaml:"lastPiece"`
+	Have        int64     `json:"have" yaml:"have"`
+	Downloading int64     `json:"downloading" yaml:"downloading"`
+	Missing     int64     `json:"missing" yaml:"missing"`
+}
+
+// Label is a torrent label (a tag in qBittorrent), and the number of torrents
+// with the label.
+type Label struct {
+	Name     string `json:"name" yaml:"name"`
+	Torrents int64  `json:"torrents" yaml:"torrents"`
+}
+
+// Category is a torrent category, and its save path.
+type Category struct {
+	Name     string `json:"name" yaml:"name"`
+	SavePath string `json:"savePath,omitempty" yaml:"savePath,omitempty"`
+}
+
+// RSSFeed is a rss feed.
+type RSSFeed struct {
+	Path          string       `json:"path" yaml:"path"`
+	UID           string       `json:"uid,omitempty" yaml:"uid,omitempty"`
+	URL           string       `json:"url" yaml:"url"`
+	Title         string       `json:"title,omitempty" yaml:"title,omitempty"`
+	LastBuildDate string       `json:"lastBuildDate,omitempty" yaml:"lastBuildDate,omitempty"`
+	IsLoading     bool         `json:"isLoading,omitempty" yaml:"isLoading,omitempty"`
+	HasError      bool         `json:"hasError,omitempty" yaml:"hasError,omitempty"`
+	Articles      []RSSArticle `json:"articles,omitempty" yaml:"articles,omitempty"`
+}
+
+// RSSArticle is a rss feed article.
+type RSSArticle struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Date        string `json:"date,omitempty" yaml:"date,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Author      string `json:"author,omitempty" yaml:"author,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	TorrentURL  string `json:"torrentURL,omitempty" yaml:"torrentURL,omitempty"`
+	Link        string `json:"link,omitempty" yaml:"link,omitempty"`
+	IsRead      bool   `json:"isRead,omitempty" yaml:"isRead,omitempty"`
+	Feed        string `json:"-" yaml:"-" all:"feed"`
+}
+
+// RSSRule is a rss auto-download rule.
+type RSSRule struct {
+	Name                      string   `json:"-" yaml:"-" all:"name"`
+	Enabled                   bool     `json:"enabled" yaml:"enabled"`
+	MustContain               string   `json:"mustContain,omitempty" yaml:"mustContain,omitempty"`
+	MustNotContain            string   `json:"mustNotContain,omitempty" yaml:"mustNotContain,omitempty"`
+	UseRegex                  bool     `json:"useRegex,omitempty" yaml:"useRegex,omitempty"`
+	EpisodeFilter             string   `json:"episodeFilter,omitempty" yaml:"episodeFilter,omitempty"`
+	SmartFilter               bool     `json:"smartFilter,omitempty" yaml:"smartFilter,omitempty"`
+	PreviouslyMatchedEpisodes []string `json:"previouslyMatchedEpisodes,omitempty" yaml:"previouslyMatchedEpisodes,omitempty"`
+	AffectedFeeds             []string `json:"affectedFeeds,omitempty" yaml:"affectedFeeds,omitempty"`
+	IgnoreDays                int64    `json:"ignoreDays,omitempty" yaml:"ignoreDays,omitempty"`
+	LastMatch                 string   `json:"lastMatch,omitempty" yaml:"lastMatch,omitempty"`
+	AddPaused                 bool     `json:"addPaused,omitempty" yaml:"addPaused,omitempty"`
+	AssignedCategory          string   `json:"assignedCategory,omitempty" yaml:"assignedCategory,omitempty"`
+	SavePath                  string   `json:"savePath,omitempty" yaml:"savePath,omitempty"`
+}
+
+// SearchResult is a search result.
+type SearchResult struct {
+	ID       int64     `json:"id" yaml:"id"`
+	Name     string    `json:"name" yaml:"name"`
+	Size     ByteCount `json:"size" yaml:"size"`
+	Seeders  int64     `json:"seeders" yaml:"seeders"`
+	Leechers int64     `json:"leechers" yaml:"leechers"`
+	Site     string    `json:"site,omitempty" yaml:"site,omitempty"`
+	Link     string    `json:"link,omitempty" yaml:"link,omitempty"`
+	URL      string    `json:"url" yaml:"url"`
+}
+
+// SearchPlugin is a search plugin.
+type SearchPlugin struct {
+	Name       string   `json:"name" yaml:"name"`
+	FullName   string   `json:"fullName,omitempty" yaml:"fullName,omitempty"`
+	Version    string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Enabled    bool     `json:"enabled" yaml:"enabled"`
+	URL        string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// Days is a bitmask of days of the week, using the same values as
+// transmission's alt-speed-time-day.
+type Days int64
+
+// Days values.
+const (
+	DaySunday Days = 1 << iota
+	DayMonday
+	DayTuesday
+	DayWednesday
+	DayThursday
+	DayFriday
+	DaySaturday
+
+	DaysWeekdays = DayMonday | DayTuesday | DayWednesday | DayThursday | DayFriday
+	DaysWeekend  = DaySaturday | DaySunday
+	DaysAll      = DaysWeekdays | DaysWeekend
+)
+
+// dayNames are the day names, in Days bit order.
+var dayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ParseDays parses a comma separated list of days (all, weekdays, weekend,
+// monday, mon, ...).
+func ParseDays(s string) (Days, error) {
+	var days Days
+	for _, v := range strings.Split(strings.ToLower(s), ",") {
+		switch v = strings.TrimSpace(v); v {
+		case "all", "everyday", "daily":
+			days |= DaysAll
+			continue
+		case "weekdays":
+			days |= DaysWeekdays
+			continue
+		case "weekend", "weekends":
+			days |= DaysWeekend
+			continue
+		}
+		i := 0
+		for ; i < len(dayNames); i++ {
+			if v == dayNames[i] || len(v) >= 3 && strings.HasPrefix(dayNames[i], v) {
+				break
+			}
+		}
+		if i == len(dayNames) {
+			return 0, ErrInvalidDays
+		}
+		days |= 1 << uint(i)
+	}
+	return days, nil
+}
+
+// String satisfies the fmt.Stringer interface.
+func (d Days) String() string {
+	switch d {
+	case DaysAll:
+		return "all"
+	case DaysWeekdays:
+		return "weekdays"
+	case DaysWeekend:
+		return "weekend"
+	}
+	var s []string
+	for i, name := range dayNames {
+		if d&(1<<uint(i)) != 0 {
+			s = append(s, name[:3])
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+// TimeOfDay is a time of day, in minutes after midnight.
+type TimeOfDay int64
+
+// ParseTimeOfDay parses a time of day (15:04).
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, ErrInvalidTime
+	}
+	return TimeOfDay(t.Hour()*60 + t.Minute()), nil
+}
+
+// String satisfies the fmt.Stringer interface.
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t/60, t%60)
+}
+
+// SpeedLimits are a remote host's global and alternate speed limits.
+type SpeedLimits struct {
+	DownloadLimit    Rate      `json:"downloadLimit" yaml:"downloadLimit"`
+	DownloadLimited  bool      `json:"downloadLimited" yaml:"downloadLimited"`
+	UploadLimit      Rate      `json:"uploadLimit" yaml:"uploadLimit"`
+	UploadLimited    bool      `json:"uploadLimited" yaml:"uploadLimited"`
+	AltEnabled       bool      `json:"altEnabled" yaml:"altEnabled"`
+	AltDownloadLimit Rate      `json:"altDownloadLimit" yaml:"altDownloadLimit"`
+	AltUploadLimit   Rate      `json:"altUploadLimit" yaml:"altUploadLimit"`
+	ScheduleEnabled  bool      `json:"scheduleEnabled" yaml:"scheduleEnabled"`
+	ScheduleBegin    TimeOfDay `json:"scheduleBegin" yaml:"scheduleBegin"`
+	ScheduleEnd      TimeOfDay `json:"scheduleEnd" yaml:"scheduleEnd"`
+	ScheduleDays     Days      `json:"scheduleDays" yaml:"scheduleDays"`
+}
+
+// TorrentLimits are per-torrent share and speed limits. Limits with a nil mode
+// are left unchanged, and limit values are only used with ModeSingle.
+type TorrentLimits struct {
+	RatioMode        *Mode
+	RatioLimit       float64
+	IdleMode         *Mode
+	IdleLimit        time.Duration
+	SeedingTimeMode  *Mode
+	SeedingTimeLimit time.Duration
+	DownloadMode     *Mode
+	DownloadLimit    Rate
+	UploadMode       *Mode
+	UploadLimit      Rate
+}
+
+// DownloadMode are torrent download modes.
+type DownloadMode int64
+
+// Download modes.
+const (
+	DownloadModeSequential DownloadMode = iota
+	DownloadModeFirstLast
+	DownloadModeForceStart
+	DownloadModeSuperSeed
+)
+
+// LogLevel are remote host log message levels.
+type LogLevel int64
+
+// Log levels.
+const (
+	LogLevelNormal LogLevel = iota
+	LogLevelInfo
+	LogLevelWarning
+	LogLevelCritical
+)
+
+// LogEntry is a remote host log message.
+type LogEntry struct {
+	ID      int64     `json:"id" yaml:"id"`
+	Time    MilliTime `json:"time" yaml:"time"`
+	Level   LogLevel  `json:"level" yaml:"level"`
+	Message string    `json:"message" yaml:"message"`
+}
+
+// PeerLogEntry is a remote host peer log message, for a peer that was banned,
+// or blocked by the ip filter.
+type PeerLogEntry struct {
+	ID      int64     `json:"id" yaml:"id"`
+	Time    MilliTime `json:"time" yaml:"time"`
+	Address string    `json:"address" yaml:"address"`
+	Blocked bool      `json:"blocked" yaml:"blocked"`
+	Reason  string    `json:"reason" yaml:"reason"`
+}
+
 // Error is an error.
 type Error string
 
@@ -764,4 +1125,35 @@ const (
 
 	// ErrInvalidEncryption is the invalid encryption error.
 	ErrInvalidEncryption Error = "invalid encryption"
+
+	// ErrInvalidByteCount is the invalid byte count error.
+	ErrInvalidByteCount Error = "invalid byte count"
+
+	// ErrInvalidDays is the invalid days error.
+	ErrInvalidDays Error = "invalid days"
+
+	// ErrNoCertificatesFound is the no certificates found error.
+	ErrNoCertificatesFound Error = "no certificates found"
+
+	// ErrMustSpecifyBothCertAndKey is the must specify both cert and key
+	// error.
+	ErrMustSpecifyBothCertAndKey Error = "must specify both cert and key"
+
+	// ErrInvalidPin is the invalid pin error.
+	ErrInvalidPin Error = "invalid pin"
+
+	// ErrPinnedKeyNotFound is the pinned key not found error.
+	ErrPinnedKeyNotFound Error = "pinned key not found"
+
+	// ErrUnsupportedProxyScheme is the unsupported proxy scheme error.
+	ErrUnsupportedProxyScheme Error = "unsupported proxy scheme"
+
+	// ErrProxyConnectFailed is the proxy connect failed error.
+	ErrProxyConnectFailed Error = "proxy connect failed"
+
+	// ErrInvalidSecret is the invalid secret error.
+	ErrInvalidSecret Error = "invalid secret"
+
+	// ErrSecretDecryptionFailed is the secret decryption failed error.
+	ErrSecretDecryptionFailed Error = "secret decryption failed"
 )
@@ -0,0 +1,119 @@
+package tctypes
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// TLSOptions are the TLS options used when connecting to a remote host.
+type TLSOptions struct {
+	// CACert is the path to a PEM encoded CA bundle used to verify the
+	// remote host's certificate.
+	CACert string
+
+	// Cert is the path to a PEM encoded client certificate.
+	Cert string
+
+	// Key is the path to the PEM encoded client certificate's key.
+	Key string
+
+	// Pins are the base64 encoded SHA256 hashes of the remote host's public
+	// key (SPKI), optionally prefixed with "sha256//". The remote host's
+	// certificate must have one of the pinned keys, or when the remote host's
+	// certificate is verified, a certificate in the verified chain must.
+	Pins []string
+
+	// Insecure toggles skipping verification of the remote host's
+	// certificate.
+	Insecure bool
+}
+
+// Empty returns true when no TLS options have been set.
+func (opts TLSOptions) Empty() bool {
+	return opts.CACert == "" && opts.Cert == "" && opts.Key == "" && len(opts.Pins) == 0 && !opts.Insecure
+}
+
+// Config builds a TLS config for the options.
+func (opts TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: opts.Insecure,
+	}
+
+	// load ca bundle
+	if opts.CACert != "" {
+		buf, err := ioutil.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("%s: %w", opts.CACert, ErrNoCertificatesFound)
+		}
+	}
+
+	// load client cert
+	switch {
+	case opts.Cert != "" && opts.Key != "":
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case opts.Cert != "" || opts.Key != "":
+		return nil, ErrMustSpecifyBothCertAndKey
+	}
+
+	// add pin verification
+	if len(opts.Pins) != 0 {
+		pins := make(map[string]bool, len(opts.Pins))
+		for _, pin := range opts.Pins {
+			pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256//")
+			if buf, err := base64.StdEncoding.DecodeString(pin); err != nil || len(buf) != sha256.Size {
+				return nil, fmt.Errorf("%q: %w", pin, ErrInvalidPin)
+			}
+			pins[pin] = true
+		}
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			pinned := func(cert *x509.Certificate) bool {
+				hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				return pins[base64.StdEncoding.EncodeToString(hash[:])]
+			}
+			// when the chain was verified, any certificate in a verified
+			// chain can be pinned, otherwise only the leaf can be, as the
+			// remaining certificates sent by the remote host are unverified
+			for _, chain := range verifiedChains {
+				for _, cert := range chain {
+					if pinned(cert) {
+						return nil
+					}
+				}
+			}
+			if len(verifiedChains) == 0 && len(rawCerts) != 0 {
+				cert, err := x509.ParseCertificate(rawCerts[0])
+				if err != nil {
+					return err
+				}
+				if pinned(cert) {
+					return nil
+				}
+			}
+			return ErrPinnedKeyNotFound
+		}
+	}
+	return cfg, nil
+}
+
+// WithTLSConfig returns a transport using the TLS config, based on the passed
+// transport. When transport is a HTTPLogger, the logger's transport will be
+// replaced.
+func WithTLSConfig(transport http.RoundTripper, cfg *tls.Config) http.RoundTripper {
+	return withTransport(transport, func(t *http.Transport) {
+		t.TLSClientConfig = cfg
+	})
+}
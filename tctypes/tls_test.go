@@ -0,0 +1,122 @@
+package tctypes
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTLSOptionsPins(t *testing.T) {
+	// create ca and leaf certs, with the server sending the full chain
+	ca, caKey := newCert(t, "ca", nil, nil)
+	leaf, leafKey := newCert(t, "leaf", ca, caKey)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte("ok"))
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{leaf.Raw, ca.Raw},
+			PrivateKey:  leafKey,
+		}},
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	// write ca bundle
+	f, err := ioutil.TempFile("", "tctypes-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pin := func(cert *x509.Certificate) string {
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		return "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+	}
+	tests := []struct {
+		name string
+		opts TLSOptions
+		err  error
+	}{
+		{"leaf", TLSOptions{Insecure: true, Pins: []string{pin(leaf)}}, nil},
+		{"leaf verified", TLSOptions{CACert: f.Name(), Pins: []string{pin(leaf)}}, nil},
+		{"ca verified", TLSOptions{CACert: f.Name(), Pins: []string{pin(ca)}}, nil},
+		{"ca unverified", TLSOptions{Insecure: true, Pins: []string{pin(ca)}}, ErrPinnedKeyNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := test.opts.Config()
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			cfg.ServerName = "localhost"
+			cl := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			res, err := cl.Get(s.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			switch {
+			case test.err == nil && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			case test.err != nil && !errors.Is(err, test.err):
+				t.Errorf("expected %v, got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+// newCert creates a certificate with the common name, signed by the parent
+// (or self-signed when parent is nil).
+func newCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		tpl.IsCA, tpl.BasicConstraintsValid = true, true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tpl, key
+	} else {
+		tpl.DNSNames = []string{"localhost"}
+		tpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		tpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	buf, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
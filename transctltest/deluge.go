@@ -0,0 +1,560 @@
+package transctltest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdm85/go-rencode"
+)
+
+// Deluge is a fake Deluge RPC server, listening on a TLS connection with a
+// self-signed certificate.
+//
+// Requests and responses are rencoded and zlib compressed, as with the
+// deluge daemon. Clients must call daemon.login (with the client_version
+// kwarg, as required by deluge 2.x) before calling other methods. The Label
+// plugin's methods are always available.
+type Deluge struct {
+	// Addr is the host:port the server is listening on.
+	Addr string
+
+	// URL is the url of the server.
+	URL string
+
+	listener   net.Listener
+	cert       *x509.Certificate
+	user, pass string
+	conns      map[net.Conn]bool
+	torrents   map[string]map[string]interface{}
+	labels     map[string]bool
+	logins     int
+	requests   []string
+	wg         sync.WaitGroup
+
+	sync.Mutex
+}
+
+// DelugeOption is a fake Deluge RPC server option.
+type DelugeOption func(*Deluge)
+
+// WithDelugeCredentials is a fake Deluge RPC server option to set the login
+// credentials.
+func WithDelugeCredentials(user, pass string) DelugeOption {
+	return func(d *Deluge) {
+		d.user, d.pass = user, pass
+	}
+}
+
+// NewDeluge creates and starts a fake Deluge RPC server. Panics when the
+// server cannot be started.
+func NewDeluge(opts ...DelugeOption) *Deluge {
+	d := &Deluge{
+		user:     "localclient",
+		pass:     "deluge",
+		conns:    make(map[net.Conn]bool),
+		torrents: make(map[string]map[string]interface{}),
+		labels:   make(map[string]bool),
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	cert, err := selfSignedCert()
+	if err != nil {
+		panic(fmt.Sprintf("transctltest: could not generate certificate: %v", err))
+	}
+	if d.cert, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		panic(fmt.Sprintf("transctltest: could not parse certificate: %v", err))
+	}
+	d.listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("transctltest: could not listen: %v", err))
+	}
+	d.Addr = d.listener.Addr().String()
+	d.URL = "deluge://" + d.Addr
+	d.wg.Add(1)
+	go d.serve()
+	return d
+}
+
+// Certificate returns the server's self-signed certificate.
+func (d *Deluge) Certificate() *x509.Certificate {
+	return d.cert
+}
+
+// Close closes the server and all open connections.
+func (d *Deluge) Close() {
+	d.listener.Close()
+	d.Disconnect()
+	d.wg.Wait()
+}
+
+// Disconnect closes all open connections, forcing clients to reconnect and
+// login again.
+func (d *Deluge) Disconnect() {
+	d.Lock()
+	defer d.Unlock()
+	for conn := range d.conns {
+		conn.Close()
+		delete(d.conns, conn)
+	}
+}
+
+// Logins returns the number of successful logins.
+func (d *Deluge) Logins() int {
+	d.Lock()
+	defer d.Unlock()
+	return d.logins
+}
+
+// AddTorrent adds a torrent with the hash and name to the server.
+func (d *Deluge) AddTorrent(hash, name string) {
+	d.Lock()
+	defer d.Unlock()
+	d.add(hash, name, nil)
+}
+
+// Torrents returns a copy of the torrents on the server, keyed by hash.
+func (d *Deluge) Torrents() map[string]map[string]interface{} {
+	d.Lock()
+	defer d.Unlock()
+	torrents := make(map[string]map[string]interface{}, len(d.torrents))
+	for hash, torrent := range d.torrents {
+		torrents[hash] = make(map[string]interface{}, len(torrent))
+		for k, v := range torrent {
+			torrents[hash][k] = v
+		}
+	}
+	return torrents
+}
+
+// Requests returns the rpc methods of the requests received by the server.
+func (d *Deluge) Requests() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.requests...)
+}
+
+// serve accepts connections.
+func (d *Deluge) serve() {
+	defer d.wg.Done()
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		d.Lock()
+		d.conns[conn] = true
+		d.Unlock()
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.handle(conn)
+		}()
+	}
+}
+
+// handle handles the requests on a connection, until the connection is closed.
+func (d *Deluge) handle(conn net.Conn) {
+	defer func() {
+		d.Lock()
+		delete(d.conns, conn)
+		d.Unlock()
+		conn.Close()
+	}()
+	var authenticated bool
+	r := bufio.NewReader(conn)
+	for {
+		// read
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, zr); err != nil {
+			return
+		}
+		x, err := rencode.NewDecoder(&buf).DecodeNext()
+		if err != nil {
+			return
+		}
+		reqs, ok := plain(x).([]interface{})
+		if !ok {
+			return
+		}
+
+		// execute
+		for _, req := range reqs {
+			l, ok := req.([]interface{})
+			if !ok || len(l) < 3 {
+				return
+			}
+			id, _ := l[0].(int64)
+			method, _ := l[1].(string)
+			args, _ := l[2].([]interface{})
+			var kwargs map[string]interface{}
+			if len(l) > 3 {
+				kwargs, _ = l[3].(map[string]interface{})
+			}
+			res := d.exec(&authenticated, id, method, args, kwargs)
+			var out bytes.Buffer
+			if enc := rencode.NewEncoder(&out); enc.Encode(encodable(res)) != nil {
+				return
+			}
+			w := zlib.NewWriter(conn)
+			if _, err := w.Write(out.Bytes()); err != nil {
+				return
+			}
+			if err := w.Close(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec executes a rpc method, returning the response message.
+func (d *Deluge) exec(authenticated *bool, id int64, method string, args []interface{}, kwargs map[string]interface{}) []interface{} {
+	d.Lock()
+	defer d.Unlock()
+	d.requests = append(d.requests, method)
+	var v interface{}
+	var err error
+	switch f, ok := delugeMethods[method]; {
+	case method == "daemon.login" && kwargs["client_version"] == nil:
+		// deluge 2.x requires the client version
+		return delugeError(id, "IncompatibleClient", "Your deluge client is not compatible with the daemon. Please upgrade your client to 2.0.3")
+	case method == "daemon.login":
+		if len(args) < 2 || args[0] != d.user || args[1] != d.pass {
+			return delugeError(id, "BadLoginError", "Username does not exist")
+		}
+		*authenticated = true
+		d.logins++
+		v = 10
+	case method == "daemon.info":
+		v = "2.0.3"
+	case !*authenticated:
+		return delugeError(id, "NotAuthorizedError", "Auth level too low")
+	case !ok:
+		return delugeError(id, "WrappedException", "Unknown method "+method)
+	default:
+		v, err = f(d, args)
+	}
+	if err != nil {
+		return delugeError(id, "InvalidTorrentError", err.Error())
+	}
+	return []interface{}{int64(1), id, v}
+}
+
+// delugeError returns a deluge 2 error response message.
+func delugeError(id int64, typ, msg string) []interface{} {
+	return []interface{}{int64(2), id, typ, []interface{}{msg}, map[string]interface{}{}, ""}
+}
+
+// add adds a torrent.
+func (d *Deluge) add(hash, name string, options map[string]interface{}) {
+	state := "Downloading"
+	if paused, _ := options["add_paused"].(bool); paused {
+		state = "Paused"
+	}
+	savePath, _ := options["download_location"].(string)
+	if savePath == "" {
+		savePath = "/downloads"
+	}
+	d.torrents[hash] = map[string]interface{}{
+		"hash":       hash,
+		"name":       name,
+		"state":      state,
+		"save_path":  savePath,
+		"progress":   0.0,
+		"time_added": time.Now().Unix(),
+		"queue":      int64(len(d.torrents)),
+		"label":      "",
+	}
+}
+
+// setState sets the state of the torrents in args[0].
+func (d *Deluge) setState(args []interface{}, state string) error {
+	if len(args) < 1 {
+		return errInvalidArgument
+	}
+	ids, ok := args[0].([]interface{})
+	if !ok {
+		ids = args[:1]
+	}
+	for _, id := range ids {
+		if torrent, ok := d.torrents[fmt.Sprintf("%v", id)]; ok {
+			torrent["state"] = state
+		}
+	}
+	return nil
+}
+
+// delugeMethods are the fake's rpc methods (other than daemon.login and
+// daemon.info).
+var delugeMethods = map[string]func(*Deluge, []interface{}) (interface{}, error){
+	"core.get_torrents_status": func(d *Deluge, args []interface{}) (interface{}, error) {
+		var filter map[string]interface{}
+		var keys []interface{}
+		if len(args) > 0 {
+			filter, _ = args[0].(map[string]interface{})
+		}
+		if len(args) > 1 {
+			keys, _ = args[1].([]interface{})
+		}
+		var ids []interface{}
+		if filter != nil {
+			switch x := filter["id"].(type) {
+			case []interface{}:
+				ids = x
+			case string:
+				ids = []interface{}{x}
+			}
+		}
+		status := make(map[string]interface{})
+		for hash, torrent := range d.torrents {
+			if ids != nil && !containsValue(ids, hash) {
+				continue
+			}
+			if state, ok := filter["state"]; ok && state != torrent["state"] {
+				continue
+			}
+			m := make(map[string]interface{})
+			for k, v := range torrent {
+				if len(keys) == 0 || containsValue(keys, k) {
+					m[k] = v
+				}
+			}
+			status[hash] = m
+		}
+		return status, nil
+	},
+	"core.add_torrent_magnet": func(d *Deluge, args []interface{}) (interface{}, error) {
+		if len(args) < 1 {
+			return nil, errInvalidArgument
+		}
+		uri, _ := args[0].(string)
+		if !btihRE.MatchString(uri) {
+			return nil, errInvalidOrCorruptTorrent
+		}
+		hash, name := infoHash([]byte(uri), len(d.torrents)+1)
+		if _, ok := d.torrents[hash]; ok {
+			return nil, fmt.Errorf("Torrent already in session (%s).", hash)
+		}
+		options := make(map[string]interface{})
+		if len(args) > 1 {
+			options, _ = args[1].(map[string]interface{})
+		}
+		d.add(hash, name, options)
+		return hash, nil
+	},
+	"core.add_torrent_file": func(d *Deluge, args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, errInvalidArgument
+		}
+		filedump, _ := args[1].(string)
+		buf, err := base64.StdEncoding.DecodeString(filedump)
+		if err != nil || len(buf) == 0 {
+			return nil, errInvalidOrCorruptTorrent
+		}
+		hash, name := infoHash(buf, len(d.torrents)+1)
+		if filename, _ := args[0].(string); filename != "" {
+			name = strings.TrimSuffix(filename, ".torrent")
+		}
+		if _, ok := d.torrents[hash]; ok {
+			return nil, fmt.Errorf("Torrent already in session (%s).", hash)
+		}
+		options := make(map[string]interface{})
+		if len(args) > 2 {
+			options, _ = args[2].(map[string]interface{})
+		}
+		d.add(hash, name, options)
+		return hash, nil
+	},
+	"core.remove_torrent": func(d *Deluge, args []interface{}) (interface{}, error) {
+		if len(args) < 1 {
+			return nil, errInvalidArgument
+		}
+		hash := fmt.Sprintf("%v", args[0])
+		if _, ok := d.torrents[hash]; !ok {
+			return nil, fmt.Errorf("torrent_id %s not in session.", hash)
+		}
+		delete(d.torrents, hash)
+		return true, nil
+	},
+	"core.pause_torrent": func(d *Deluge, args []interface{}) (interface{}, error) {
+		return nil, d.setState(args, "Paused")
+	},
+	"core.resume_torrent": func(d *Deluge, args []interface{}) (interface{}, error) {
+		return nil, d.setState(args, "Downloading")
+	},
+	"core.force_recheck": func(d *Deluge, args []interface{}) (interface{}, error) {
+		return nil, d.setState(args, "Checking")
+	},
+	"core.get_session_state": func(d *Deluge, args []interface{}) (interface{}, error) {
+		hashes := make([]string, 0, len(d.torrents))
+		for hash := range d.torrents {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		return hashes, nil
+	},
+	"core.get_free_space": func(d *Deluge, args []interface{}) (interface{}, error) {
+		return int64(1 << 40), nil
+	},
+	"label.get_labels": func(d *Deluge, args []interface{}) (interface{}, error) {
+		labels := make([]string, 0, len(d.labels))
+		for label := range d.labels {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		return labels, nil
+	},
+	"label.add": func(d *Deluge, args []interface{}) (interface{}, error) {
+		if len(args) < 1 {
+			return nil, errInvalidArgument
+		}
+		label := strings.ToLower(strings.TrimSpace(fmt.Sprintf("%v", args[0])))
+		switch {
+		case !delugeLabelRE.MatchString(label):
+			return nil, errors.New("Invalid label, valid characters:[a-z0-9_-]")
+		case d.labels[label]:
+			return nil, errors.New("Label already exists")
+		}
+		d.labels[label] = true
+		return nil, nil
+	},
+	"label.set_torrent": func(d *Deluge, args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, errInvalidArgument
+		}
+		hash, label := fmt.Sprintf("%v", args[0]), fmt.Sprintf("%v", args[1])
+		if label == "No Label" {
+			label = ""
+		}
+		torrent, ok := d.torrents[hash]
+		switch {
+		case label != "" && !d.labels[label]:
+			return nil, errors.New("Unknown Label")
+		case !ok:
+			return nil, errors.New("Unknown Torrent")
+		}
+		torrent["label"] = label
+		return nil, nil
+	},
+}
+
+// delugeLabelRE matches a valid Label plugin label.
+var delugeLabelRE = regexp.MustCompile(`^[a-z0-9_\-.]+$`)
+
+// containsValue determines if v is contained in l.
+func containsValue(l []interface{}, v interface{}) bool {
+	for _, x := range l {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// plain converts a decoded rencode value to a plain value.
+func plain(x interface{}) interface{} {
+	switch v := x.(type) {
+	case []byte:
+		return string(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case big.Int:
+		return v.Int64()
+	case rencode.List:
+		l := make([]interface{}, v.Length())
+		for i, y := range v.Values() {
+			l[i] = plain(y)
+		}
+		return l
+	case rencode.Dictionary:
+		m := make(map[string]interface{}, v.Length())
+		keys := v.Keys()
+		for i, y := range v.Values() {
+			m[fmt.Sprintf("%v", plain(keys[i]))] = plain(y)
+		}
+		return m
+	}
+	return x
+}
+
+// encodable converts a plain value to a value that can be rencoded.
+func encodable(x interface{}) interface{} {
+	switch v := x.(type) {
+	case []interface{}:
+		var l rencode.List
+		for _, y := range v {
+			l.Add(encodable(y))
+		}
+		return l
+	case []string:
+		var l rencode.List
+		for _, y := range v {
+			l.Add(y)
+		}
+		return l
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var d rencode.Dictionary
+		for _, k := range keys {
+			d.Add(k, encodable(v[k]))
+		}
+		return d
+	}
+	return x
+}
+
+// selfSignedCert generates a self-signed certificate for 127.0.0.1.
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Deluge Daemon"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, key.Public(), key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
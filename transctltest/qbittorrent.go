@@ -0,0 +1,1206 @@
+package transctltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QBittorrent is a fake qBittorrent WebUI API server.
+//
+// Clients must login using auth/login, which sets the SID cookie required by
+// all other methods (otherwise a 403 is returned).
+type QBittorrent struct {
+	*httptest.Server
+
+	user, pass string
+	sid        string
+	sessions   int64
+	logins     int
+	torrents   []map[string]interface{}
+	categories map[string]string
+	peers      map[string]map[string]interface{}
+	trackers   map[string][]map[string]interface{}
+	files      map[string][]map[string]interface{}
+	pieceSizes map[string]int64
+	pieces     map[string][]int64
+	logs       []map[string]interface{}
+	peerLogs   []map[string]interface{}
+	banned     []string
+	rssItems   map[string]interface{}
+	rssRules   map[string]interface{}
+	results    []map[string]interface{}
+	searches   map[int64]*qbittorrentSearch
+	plugins    []map[string]interface{}
+	prefs      map[string]interface{}
+	altSpeed   bool
+	requests   []string
+
+	sync.Mutex
+}
+
+// QBittorrentOption is a fake qBittorrent WebUI API server option.
+type QBittorrentOption func(*QBittorrent)
+
+// WithQBittorrentCredentials is a fake qBittorrent WebUI API server option to
+// set the login credentials.
+func WithQBittorrentCredentials(user, pass string) QBittorrentOption {
+	return func(q *QBittorrent) {
+		q.user, q.pass = user, pass
+	}
+}
+
+// NewQBittorrent creates and starts a fake qBittorrent WebUI API server. The
+// server's api url is URL + "/api/v2".
+func NewQBittorrent(opts ...QBittorrentOption) *QBittorrent {
+	q := &QBittorrent{
+		user:       "admin",
+		pass:       "adminadmin",
+		peers:      make(map[string]map[string]interface{}),
+		trackers:   make(map[string][]map[string]interface{}),
+		files:      make(map[string][]map[string]interface{}),
+		pieceSizes: make(map[string]int64),
+		pieces:     make(map[string][]int64),
+		rssItems:   make(map[string]interface{}),
+		rssRules:   make(map[string]interface{}),
+		searches:   make(map[int64]*qbittorrentSearch),
+		prefs: map[string]interface{}{
+			"save_path":          "/downloads/",
+			"dl_limit":           0,
+			"up_limit":           0,
+			"alt_dl_limit":       10,
+			"alt_up_limit":       10,
+			"scheduler_enabled":  false,
+			"schedule_from_hour": 8,
+			"schedule_from_min":  0,
+			"schedule_to_hour":   20,
+			"schedule_to_min":    0,
+			"scheduler_days":     0,
+		},
+	}
+	for _, o := range opts {
+		o(q)
+	}
+	q.Server = httptest.NewServer(q)
+	return q
+}
+
+// ExpireSession expires the current session cookie, forcing clients to login
+// again.
+func (q *QBittorrent) ExpireSession() {
+	q.Lock()
+	defer q.Unlock()
+	q.sid = ""
+}
+
+// Logins returns the number of successful logins.
+func (q *QBittorrent) Logins() int {
+	q.Lock()
+	defer q.Unlock()
+	return q.logins
+}
+
+// AddTorrent adds a torrent with the hash and name to the server.
+func (q *QBittorrent) AddTorrent(hash, name string) {
+	q.Lock()
+	defer q.Unlock()
+	q.add(hash, name, "", false)
+}
+
+// AddPeer adds a connected peer (ip:port) with the client name, connection
+// type (BT, μTP), and space separated flags to the torrent with the hash.
+func (q *QBittorrent) AddPeer(hash, addr, client, connection, flags string) {
+	q.Lock()
+	defer q.Unlock()
+	q.addPeer(hash, addr, client, connection, flags)
+}
+
+// AddTracker adds a tracker with the url and tier to the torrent with the
+// hash. The tracker is working, with the peer count, when msg is empty, and
+// otherwise is not working with the msg.
+func (q *QBittorrent) AddTracker(hash, urlstr string, tier, peers int64, msg string) {
+	q.Lock()
+	defer q.Unlock()
+	q.addTracker(hash, urlstr, tier)
+	tracker := q.trackers[hash][len(q.trackers[hash])-1]
+	tracker["status"], tracker["num_peers"], tracker["msg"] = 2, peers, msg
+	if msg != "" {
+		tracker["status"] = 4
+	}
+}
+
+// AddFile adds a file with the name and size to the torrent with the hash.
+func (q *QBittorrent) AddFile(hash, name string, size int64) {
+	q.Lock()
+	defer q.Unlock()
+	q.files[hash] = append(q.files[hash], map[string]interface{}{
+		"name":     name,
+		"size":     size,
+		"progress": 0,
+		"priority": 1,
+	})
+}
+
+// SetPieces sets the piece size and piece states of the torrent with the
+// hash, with each state being '0' (missing), '1' (downloading), or '2'
+// (have).
+func (q *QBittorrent) SetPieces(hash string, pieceSize int64, states string) {
+	q.Lock()
+	defer q.Unlock()
+	q.pieceSizes[hash], q.pieces[hash] = pieceSize, make([]int64, len(states))
+	for i, c := range states {
+		q.pieces[hash][i] = int64(c - '0')
+	}
+}
+
+// AddLog adds a log message with the type (1 normal, 2 info, 4 warning, 8
+// critical) to the server.
+func (q *QBittorrent) AddLog(msg string, typ int64) {
+	q.Lock()
+	defer q.Unlock()
+	q.logs = append(q.logs, map[string]interface{}{
+		"id":        len(q.logs),
+		"message":   msg,
+		"timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+		"type":      typ,
+	})
+}
+
+// AddPeerLog adds a peer log message for the peer ip to the server. The peer
+// was blocked by the ip filter when blocked is true, and otherwise was banned.
+func (q *QBittorrent) AddPeerLog(ip string, blocked bool, reason string) {
+	q.Lock()
+	defer q.Unlock()
+	q.peerLogs = append(q.peerLogs, map[string]interface{}{
+		"id":        len(q.peerLogs),
+		"ip":        ip,
+		"timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+		"blocked":   blocked,
+		"reason":    reason,
+	})
+}
+
+// Trackers returns the tracker urls of the torrent with the hash.
+func (q *QBittorrent) Trackers(hash string) []string {
+	q.Lock()
+	defer q.Unlock()
+	var urls []string
+	for _, tracker := range q.trackers[hash] {
+		urls = append(urls, tracker["url"].(string))
+	}
+	return urls
+}
+
+// BannedPeers returns the banned peers on the server.
+func (q *QBittorrent) BannedPeers() []string {
+	q.Lock()
+	defer q.Unlock()
+	return append([]string(nil), q.banned...)
+}
+
+// AddRSSFeed adds a rss feed with the path (folders separated by '\'), url,
+// and article titles to the server.
+func (q *QBittorrent) AddRSSFeed(path, urlstr string, titles ...string) {
+	q.Lock()
+	defer q.Unlock()
+	articles := make([]interface{}, len(titles))
+	for i, title := range titles {
+		articles[i] = map[string]interface{}{
+			"id":         fmt.Sprintf("%d", i+1),
+			"date":       "01 Jan 2020 00:00:00 +0000",
+			"title":      title,
+			"torrentURL": fmt.Sprintf("%s/%d.torrent", urlstr, i+1),
+		}
+	}
+	q.rssAddItem(path, map[string]interface{}{
+		"uid":      sessionID("rss", int64(len(q.rssItems)+1)),
+		"url":      urlstr,
+		"title":    path,
+		"articles": articles,
+	})
+}
+
+// RSSRules returns the rss auto-download rule definitions on the server,
+// keyed by rule name.
+func (q *QBittorrent) RSSRules() map[string]interface{} {
+	q.Lock()
+	defer q.Unlock()
+	rules := make(map[string]interface{}, len(q.rssRules))
+	for k, v := range q.rssRules {
+		rules[k] = v
+	}
+	return rules
+}
+
+// AddSearchResult adds a search result returned by searches with a pattern
+// contained in the name.
+func (q *QBittorrent) AddSearchResult(name, urlstr string, size, seeders int64) {
+	q.Lock()
+	defer q.Unlock()
+	q.results = append(q.results, map[string]interface{}{
+		"fileName":   name,
+		"fileUrl":    urlstr,
+		"fileSize":   size,
+		"nbSeeders":  seeders,
+		"nbLeechers": seeders / 2,
+		"siteUrl":    "http://example.com",
+		"descrLink":  urlstr,
+	})
+}
+
+// Plugins returns a copy of the search plugins on the server.
+func (q *QBittorrent) Plugins() []map[string]interface{} {
+	q.Lock()
+	defer q.Unlock()
+	return append([]map[string]interface{}(nil), q.plugins...)
+}
+
+// Preferences returns a copy of the preferences on the server.
+func (q *QBittorrent) Preferences() map[string]interface{} {
+	q.Lock()
+	defer q.Unlock()
+	prefs := make(map[string]interface{}, len(q.prefs))
+	for k, v := range q.prefs {
+		prefs[k] = v
+	}
+	return prefs
+}
+
+// Torrents returns a copy of the torrents on the server.
+func (q *QBittorrent) Torrents() []map[string]interface{} {
+	q.Lock()
+	defer q.Unlock()
+	torrents := make([]map[string]interface{}, len(q.torrents))
+	for i, torrent := range q.torrents {
+		torrents[i] = make(map[string]interface{}, len(torrent))
+		for k, v := range torrent {
+			torrents[i][k] = v
+		}
+	}
+	return torrents
+}
+
+// Requests returns the api methods of the requests received by the server,
+// including those rejected for missing session cookies.
+func (q *QBittorrent) Requests() []string {
+	q.Lock()
+	defer q.Unlock()
+	return append([]string(nil), q.requests...)
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (q *QBittorrent) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	method := strings.TrimPrefix(req.URL.Path, "/api/v2/")
+	if err := req.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q.Lock()
+	defer q.Unlock()
+	q.requests = append(q.requests, method)
+
+	// login
+	if method == "auth/login" {
+		if req.FormValue("username") != q.user || req.FormValue("password") != q.pass {
+			_, _ = res.Write([]byte("Fails."))
+			return
+		}
+		q.sessions++
+		q.logins++
+		q.sid = sessionID("qbittorrent", q.sessions)
+		http.SetCookie(res, &http.Cookie{Name: "SID", Value: q.sid, Path: "/", HttpOnly: true})
+		_, _ = res.Write([]byte("Ok."))
+		return
+	}
+
+	// check session cookie
+	if c, err := req.Cookie("SID"); err != nil || q.sid == "" || c.Value != q.sid {
+		http.Error(res, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// execute
+	f, ok := qbittorrentMethods[method]
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+	v, err := f(q, req)
+	switch {
+	case err == errTorrentNotFound:
+		http.Error(res, err.Error(), http.StatusNotFound)
+		return
+	case err == errInvalidOrCorruptTorrent:
+		http.Error(res, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case err == errConflict:
+		http.Error(res, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s, ok := v.(string); ok {
+		res.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		_, _ = res.Write([]byte(s))
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(res).Encode(v)
+}
+
+// add adds a torrent.
+func (q *QBittorrent) add(hash, name, savePath string, paused bool) map[string]interface{} {
+	if savePath == "" {
+		savePath = "/downloads/"
+	}
+	state := "downloading"
+	if paused {
+		state = "pausedDL"
+	}
+	torrent := map[string]interface{}{
+		"hash":                        hash,
+		"name":                        name,
+		"state":                       state,
+		"save_path":                   savePath,
+		"added_on":                    time.Now().Unix(),
+		"progress":                    0,
+		"priority":                    len(q.torrents) + 1,
+		"tags":                        "",
+		"category":                    "",
+		"ratio_limit":                 -2,
+		"seeding_time_limit":          -2,
+		"inactive_seeding_time_limit": -2,
+		"seq_dl":                      false,
+		"f_l_piece_prio":              false,
+		"force_start":                 false,
+		"super_seeding":               false,
+	}
+	q.torrents = append(q.torrents, torrent)
+	return torrent
+}
+
+// addPeer adds a peer to a torrent.
+func (q *QBittorrent) addPeer(hash, addr, client, connection, flags string) {
+	host, port, _ := net.SplitHostPort(addr)
+	n, _ := strconv.Atoi(port)
+	if q.peers[hash] == nil {
+		q.peers[hash] = make(map[string]interface{})
+	}
+	q.peers[hash][addr] = map[string]interface{}{
+		"ip":         host,
+		"port":       n,
+		"client":     client,
+		"connection": connection,
+		"flags":      flags,
+		"progress":   0,
+	}
+}
+
+// addTracker adds a not yet contacted tracker to the torrent with the hash.
+func (q *QBittorrent) addTracker(hash, urlstr string, tier int64) {
+	q.trackers[hash] = append(q.trackers[hash], map[string]interface{}{
+		"url":    urlstr,
+		"status": 1,
+		"tier":   tier,
+	})
+}
+
+// tracker returns the index of the tracker with the url for the torrent with
+// the hash.
+func (q *QBittorrent) tracker(hash, urlstr string) int {
+	for i, tracker := range q.trackers[hash] {
+		if tracker["url"] == urlstr {
+			return i
+		}
+	}
+	return -1
+}
+
+// torrent returns the torrent matching the hash form value.
+func (q *QBittorrent) torrent(req *http.Request) (map[string]interface{}, error) {
+	for _, torrent := range q.torrents {
+		if torrent["hash"] == strings.ToLower(req.FormValue("hash")) {
+			return torrent, nil
+		}
+	}
+	return nil, errTorrentNotFound
+}
+
+// find returns the torrents matching the hashes form value ("all" or hashes
+// separated by "|").
+func (q *QBittorrent) find(req *http.Request) []map[string]interface{} {
+	hashes := req.FormValue("hashes")
+	if hashes == "all" {
+		return q.torrents
+	}
+	var torrents []map[string]interface{}
+	for _, hash := range strings.Split(hashes, "|") {
+		for _, torrent := range q.torrents {
+			if torrent["hash"] == strings.ToLower(hash) {
+				torrents = append(torrents, torrent)
+			}
+		}
+	}
+	return torrents
+}
+
+// rssFolder returns the rss folder and item name for the path.
+func (q *QBittorrent) rssFolder(path string) (map[string]interface{}, string, error) {
+	names := strings.Split(path, `\`)
+	folder := q.rssItems
+	for _, name := range names[:len(names)-1] {
+		f, ok := folder[name].(map[string]interface{})
+		if !ok || f["url"] != nil {
+			return nil, "", errRSSItemNotFound
+		}
+		folder = f
+	}
+	return folder, names[len(names)-1], nil
+}
+
+// rssAddItem adds a rss item at the path.
+func (q *QBittorrent) rssAddItem(path string, item map[string]interface{}) error {
+	folder, name, err := q.rssFolder(path)
+	switch {
+	case err != nil:
+		return err
+	case name == "":
+		return errRSSInvalidPath
+	case folder[name] != nil:
+		return errRSSItemExists
+	}
+	folder[name] = item
+	return nil
+}
+
+// rssRemoveItem removes the rss item at the path.
+func (q *QBittorrent) rssRemoveItem(path string) (map[string]interface{}, error) {
+	folder, name, err := q.rssFolder(path)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := folder[name].(map[string]interface{})
+	if !ok {
+		return nil, errRSSItemNotFound
+	}
+	delete(folder, name)
+	return item, nil
+}
+
+// rssItems returns a copy of the rss items, with or without the feed data.
+func rssItems(items map[string]interface{}, withData bool) map[string]interface{} {
+	m := make(map[string]interface{}, len(items))
+	for name, v := range items {
+		item := v.(map[string]interface{})
+		if item["url"] == nil {
+			m[name] = rssItems(item, withData)
+			continue
+		}
+		if !withData {
+			m[name] = map[string]interface{}{"uid": item["uid"], "url": item["url"]}
+			continue
+		}
+		m[name] = item
+	}
+	return m
+}
+
+// qbittorrentSearch is a fake search job.
+//
+// Searches report a Running status for the first status request, and
+// Stopped for all subsequent status requests.
+type qbittorrentSearch struct {
+	pattern string
+	polls   int
+	stopped bool
+}
+
+// status returns the search status.
+func (search *qbittorrentSearch) status() string {
+	if search.stopped || search.polls > 1 {
+		return "Stopped"
+	}
+	return "Running"
+}
+
+// searchResults returns the search results matching the pattern.
+func (q *QBittorrent) searchResults(pattern string) []map[string]interface{} {
+	results := make([]map[string]interface{}, 0)
+	for _, result := range q.results {
+		if strings.Contains(strings.ToLower(result["fileName"].(string)), strings.ToLower(pattern)) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// searchFind returns the search for the id form value.
+func (q *QBittorrent) searchFind(req *http.Request) (int64, *qbittorrentSearch, error) {
+	id, err := strconv.ParseInt(req.FormValue("id"), 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	search, ok := q.searches[id]
+	if !ok {
+		return 0, nil, errTorrentNotFound
+	}
+	return id, search, nil
+}
+
+// plugin returns the index of the named search plugin.
+func (q *QBittorrent) plugin(name string) int {
+	for i, plugin := range q.plugins {
+		if plugin["name"] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// logsAfter returns the log messages with an id greater than the last known
+// id (-1 when not set).
+func logsAfter(logs []map[string]interface{}, lastKnownID string) []map[string]interface{} {
+	id, err := strconv.Atoi(lastKnownID)
+	if err != nil {
+		id = -1
+	}
+	if id+1 >= len(logs) {
+		return nil
+	}
+	if id < -1 {
+		id = -1
+	}
+	return logs[id+1:]
+}
+
+// qbittorrentMethods are the fake's api methods.
+var qbittorrentMethods = map[string]func(*QBittorrent, *http.Request) (interface{}, error){
+	"auth/logout": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		q.sid = ""
+		return "", nil
+	},
+	"app/version": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return "v4.2.5", nil
+	},
+	"app/webapiVersion": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return "2.4.1", nil
+	},
+	"app/defaultSavePath": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return "/downloads/", nil
+	},
+	"app/preferences": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return q.prefs, nil
+	},
+	"app/setPreferences": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		var prefs map[string]interface{}
+		if err := json.Unmarshal([]byte(req.FormValue("json")), &prefs); err != nil {
+			return nil, err
+		}
+		for k, v := range prefs {
+			q.prefs[k] = v
+		}
+		return "", nil
+	},
+	"transfer/speedLimitsMode": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		if q.altSpeed {
+			return "1", nil
+		}
+		return "0", nil
+	},
+	"transfer/toggleSpeedLimitsMode": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		q.altSpeed = !q.altSpeed
+		return "", nil
+	},
+	"transfer/info": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return map[string]interface{}{
+			"connection_status": "connected",
+			"dht_nodes":         0,
+			"dl_info_data":      0,
+			"dl_info_speed":     0,
+			"up_info_data":      0,
+			"up_info_speed":     0,
+		}, nil
+	},
+	"log/main": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		types := map[int64]bool{
+			1: req.FormValue("normal") != "false",
+			2: req.FormValue("info") != "false",
+			4: req.FormValue("warning") != "false",
+			8: req.FormValue("critical") != "false",
+		}
+		logs := []interface{}{}
+		for _, log := range logsAfter(q.logs, req.FormValue("last_known_id")) {
+			if types[log["type"].(int64)] {
+				logs = append(logs, log)
+			}
+		}
+		return logs, nil
+	},
+	"log/peers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		logs := []interface{}{}
+		for _, log := range logsAfter(q.peerLogs, req.FormValue("last_known_id")) {
+			logs = append(logs, log)
+		}
+		return logs, nil
+	},
+	"torrents/info": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		if req.FormValue("hashes") == "" {
+			return append([]map[string]interface{}{}, q.torrents...), nil
+		}
+		return append([]map[string]interface{}{}, q.find(req)...), nil
+	},
+	"torrents/add": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		paused := req.FormValue("paused") == "true"
+		var added int
+		for _, urlstr := range strings.Split(req.FormValue("urls"), "\n") {
+			if urlstr = strings.TrimSpace(urlstr); urlstr != "" {
+				hash, name := infoHash([]byte(urlstr), len(q.torrents)+1)
+				q.add(hash, name, req.FormValue("savepath"), paused)
+				added++
+			}
+		}
+		if req.MultipartForm != nil {
+			for _, fh := range req.MultipartForm.File["torrents"] {
+				f, err := fh.Open()
+				if err != nil {
+					return nil, err
+				}
+				buf, err := ioutil.ReadAll(f)
+				f.Close()
+				if err != nil {
+					return nil, err
+				}
+				hash, name := infoHash(buf, len(q.torrents)+1)
+				q.add(hash, name, req.FormValue("savepath"), paused)
+				added++
+			}
+		}
+		if added == 0 {
+			return nil, errInvalidOrCorruptTorrent
+		}
+		return "Ok.", nil
+	},
+	"torrents/delete": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		remove := q.find(req)
+		var torrents []map[string]interface{}
+		for _, torrent := range q.torrents {
+			var found bool
+			for _, t := range remove {
+				found = found || t["hash"] == torrent["hash"]
+			}
+			if !found {
+				torrents = append(torrents, torrent)
+			}
+		}
+		q.torrents = torrents
+		return "", nil
+	},
+	"torrents/pause": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["state"] = "pausedDL"
+		}
+		return "", nil
+	},
+	"torrents/resume": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["state"] = "downloading"
+		}
+		return "", nil
+	},
+	"torrents/recheck": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["state"] = "checkingDL"
+		}
+		return "", nil
+	},
+	"torrents/setLocation": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["save_path"] = req.FormValue("location")
+		}
+		return "", nil
+	},
+	"torrents/addPeers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			for _, addr := range strings.Split(req.FormValue("peers"), "|") {
+				q.addPeer(torrent["hash"].(string), addr, "", "BT", "")
+			}
+		}
+		return "", nil
+	},
+	"torrents/toggleSequentialDownload": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["seq_dl"] = !torrent["seq_dl"].(bool)
+		}
+		return "", nil
+	},
+	"torrents/toggleFirstLastPiecePrio": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["f_l_piece_prio"] = !torrent["f_l_piece_prio"].(bool)
+		}
+		return "", nil
+	},
+	"torrents/setForceStart": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		value, state := req.FormValue("value") == "true", "downloading"
+		if value {
+			state = "forcedDL"
+		}
+		for _, torrent := range q.find(req) {
+			torrent["force_start"], torrent["state"] = value, state
+		}
+		return "", nil
+	},
+	"torrents/setSuperSeeding": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			torrent["super_seeding"] = req.FormValue("value") == "true"
+		}
+		return "", nil
+	},
+	"torrents/setShareLimits": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		ratio, err := strconv.ParseFloat(req.FormValue("ratioLimit"), 64)
+		if err != nil {
+			return nil, errBadRequest
+		}
+		seedingTime, err := strconv.ParseInt(req.FormValue("seedingTimeLimit"), 10, 64)
+		if err != nil {
+			return nil, errBadRequest
+		}
+		inactiveSeedingTime, err := strconv.ParseInt(req.FormValue("inactiveSeedingTimeLimit"), 10, 64)
+		if err != nil {
+			return nil, errBadRequest
+		}
+		for _, torrent := range q.find(req) {
+			torrent["ratio_limit"] = ratio
+			torrent["seeding_time_limit"] = seedingTime
+			torrent["inactive_seeding_time_limit"] = inactiveSeedingTime
+		}
+		return "", nil
+	},
+	"torrents/setDownloadLimit": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		limit, err := strconv.ParseInt(req.FormValue("limit"), 10, 64)
+		if err != nil {
+			return nil, errBadRequest
+		}
+		for _, torrent := range q.find(req) {
+			torrent["dl_limit"] = limit
+		}
+		return "", nil
+	},
+	"torrents/setUploadLimit": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		limit, err := strconv.ParseInt(req.FormValue("limit"), 10, 64)
+		if err != nil {
+			return nil, errBadRequest
+		}
+		for _, torrent := range q.find(req) {
+			torrent["up_limit"] = limit
+		}
+		return "", nil
+	},
+	"torrents/trackers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		trackers := []interface{}{
+			map[string]interface{}{"url": "** [DHT] **", "status": 2},
+			map[string]interface{}{"url": "** [PeX] **", "status": 2},
+			map[string]interface{}{"url": "** [LSD] **", "status": 2},
+		}
+		for _, tracker := range q.trackers[torrent["hash"].(string)] {
+			trackers = append(trackers, tracker)
+		}
+		return trackers, nil
+	},
+	"torrents/properties": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		hash := torrent["hash"].(string)
+		var have int64
+		for _, state := range q.pieces[hash] {
+			if state == 2 {
+				have++
+			}
+		}
+		return map[string]interface{}{
+			"save_path":   torrent["save_path"],
+			"piece_size":  q.pieceSizes[hash],
+			"pieces_num":  len(q.pieces[hash]),
+			"pieces_have": have,
+		}, nil
+	},
+	"torrents/pieceStates": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		return append([]int64{}, q.pieces[torrent["hash"].(string)]...), nil
+	},
+	"torrents/files": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		hash := torrent["hash"].(string)
+		files, offset, size := []interface{}{}, int64(0), q.pieceSizes[hash]
+		for _, file := range q.files[hash] {
+			f := make(map[string]interface{}, len(file)+1)
+			for k, v := range file {
+				f[k] = v
+			}
+			length := file["size"].(int64)
+			if size != 0 {
+				last := offset / size
+				if length != 0 {
+					last = (offset + length - 1) / size
+				}
+				f["piece_range"] = []int64{offset / size, last}
+			}
+			offset += length
+			files = append(files, f)
+		}
+		return files, nil
+	},
+	"torrents/addTrackers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		hash := torrent["hash"].(string)
+		for _, urlstr := range strings.Split(req.FormValue("urls"), "\n") {
+			if urlstr = strings.TrimSpace(urlstr); urlstr != "" && q.tracker(hash, urlstr) == -1 {
+				q.addTracker(hash, urlstr, 0)
+			}
+		}
+		return "", nil
+	},
+	"torrents/editTracker": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		hash := torrent["hash"].(string)
+		i := q.tracker(hash, req.FormValue("origUrl"))
+		if i == -1 || q.tracker(hash, req.FormValue("newUrl")) != -1 {
+			return nil, errConflict
+		}
+		q.trackers[hash][i] = map[string]interface{}{
+			"url":    req.FormValue("newUrl"),
+			"status": 1,
+			"tier":   q.trackers[hash][i]["tier"],
+		}
+		return "", nil
+	},
+	"torrents/removeTrackers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		torrent, err := q.torrent(req)
+		if err != nil {
+			return nil, err
+		}
+		hash := torrent["hash"].(string)
+		for _, urlstr := range strings.Split(req.FormValue("urls"), "|") {
+			i := q.tracker(hash, urlstr)
+			if i == -1 {
+				return nil, errConflict
+			}
+			q.trackers[hash] = append(q.trackers[hash][:i], q.trackers[hash][i+1:]...)
+		}
+		return "", nil
+	},
+	"sync/torrentPeers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.torrents {
+			if hash := torrent["hash"].(string); hash == req.FormValue("hash") {
+				peers := make(map[string]interface{}, len(q.peers[hash]))
+				for addr, peer := range q.peers[hash] {
+					peers[addr] = peer
+				}
+				return map[string]interface{}{
+					"full_update": true,
+					"peers":       peers,
+					"rid":         1,
+					"show_flags":  true,
+				}, nil
+			}
+		}
+		return nil, errTorrentNotFound
+	},
+	"transfer/banPeers": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, addr := range strings.Split(req.FormValue("peers"), "|") {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, errBadRequest
+			}
+			q.banned = append(q.banned, addr)
+			for _, peers := range q.peers {
+				for k, peer := range peers {
+					if peer.(map[string]interface{})["ip"] == host {
+						delete(peers, k)
+					}
+				}
+			}
+		}
+		return "", nil
+	},
+	"rss/addFolder": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return "", q.rssAddItem(req.FormValue("path"), make(map[string]interface{}))
+	},
+	"rss/addFeed": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		urlstr, path := req.FormValue("url"), req.FormValue("path")
+		if path == "" {
+			path = urlstr
+		}
+		return "", q.rssAddItem(path, map[string]interface{}{
+			"uid": sessionID("rss", int64(len(q.rssItems)+1)),
+			"url": urlstr,
+		})
+	},
+	"rss/removeItem": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		_, err := q.rssRemoveItem(req.FormValue("path"))
+		return "", err
+	},
+	"rss/moveItem": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		item, err := q.rssRemoveItem(req.FormValue("itemPath"))
+		if err != nil {
+			return nil, err
+		}
+		return "", q.rssAddItem(req.FormValue("destPath"), item)
+	},
+	"rss/items": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return rssItems(q.rssItems, req.FormValue("withData") == "true"), nil
+	},
+	"rss/setRule": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		var rule map[string]interface{}
+		if err := json.Unmarshal([]byte(req.FormValue("ruleDef")), &rule); err != nil {
+			return nil, err
+		}
+		q.rssRules[req.FormValue("ruleName")] = rule
+		return "", nil
+	},
+	"rss/renameRule": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		rule, ok := q.rssRules[req.FormValue("ruleName")]
+		if !ok {
+			return nil, errRSSItemNotFound
+		}
+		delete(q.rssRules, req.FormValue("ruleName"))
+		q.rssRules[req.FormValue("newRuleName")] = rule
+		return "", nil
+	},
+	"rss/removeRule": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		delete(q.rssRules, req.FormValue("ruleName"))
+		return "", nil
+	},
+	"rss/rules": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return q.rssRules, nil
+	},
+	"search/start": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		id := int64(len(q.searches) + 1)
+		q.searches[id] = &qbittorrentSearch{pattern: req.FormValue("pattern")}
+		return map[string]interface{}{"id": id}, nil
+	},
+	"search/stop": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		_, search, err := q.searchFind(req)
+		if err != nil {
+			return nil, err
+		}
+		search.stopped = true
+		return "", nil
+	},
+	"search/status": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		id, search, err := q.searchFind(req)
+		if err != nil {
+			return nil, err
+		}
+		search.polls++
+		return []interface{}{map[string]interface{}{
+			"id":     id,
+			"status": search.status(),
+			"total":  len(q.searchResults(search.pattern)),
+		}}, nil
+	},
+	"search/results": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		_, search, err := q.searchFind(req)
+		if err != nil {
+			return nil, err
+		}
+		results := q.searchResults(search.pattern)
+		return map[string]interface{}{
+			"results": results,
+			"status":  search.status(),
+			"total":   len(results),
+		}, nil
+	},
+	"search/delete": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		id, _, err := q.searchFind(req)
+		if err != nil {
+			return nil, err
+		}
+		delete(q.searches, id)
+		return "", nil
+	},
+	"search/plugins": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return append([]map[string]interface{}{}, q.plugins...), nil
+	},
+	"search/installPlugin": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, source := range strings.Split(req.FormValue("sources"), "|") {
+			name := strings.TrimSuffix(path.Base(source), ".py")
+			if q.plugin(name) != -1 {
+				continue
+			}
+			q.plugins = append(q.plugins, map[string]interface{}{
+				"name":                name,
+				"fullName":            name,
+				"version":             "1.0",
+				"enabled":             true,
+				"url":                 source,
+				"supportedCategories": []string{"all"},
+			})
+		}
+		return "", nil
+	},
+	"search/uninstallPlugin": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, name := range strings.Split(req.FormValue("names"), "|") {
+			if i := q.plugin(name); i != -1 {
+				q.plugins = append(q.plugins[:i], q.plugins[i+1:]...)
+			}
+		}
+		return "", nil
+	},
+	"search/enablePlugin": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, name := range strings.Split(req.FormValue("names"), "|") {
+			if i := q.plugin(name); i != -1 {
+				q.plugins[i]["enabled"] = req.FormValue("enable") == "true"
+			}
+		}
+		return "", nil
+	},
+	"search/updatePlugins": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		return "", nil
+	},
+	"torrents/rename": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.torrents {
+			if torrent["hash"] == req.FormValue("hash") {
+				torrent["name"] = req.FormValue("name")
+				return "", nil
+			}
+		}
+		return nil, errTorrentNotFound
+	},
+	"torrents/addTags": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, torrent := range q.find(req) {
+			tags := splitTags(torrent["tags"].(string))
+			for _, tag := range splitTags(req.FormValue("tags")) {
+				if !contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			sort.Strings(tags)
+			torrent["tags"] = strings.Join(tags, ", ")
+		}
+		return "", nil
+	},
+	"torrents/removeTags": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		remove := splitTags(req.FormValue("tags"))
+		for _, torrent := range q.find(req) {
+			var tags []string
+			for _, tag := range splitTags(torrent["tags"].(string)) {
+				if len(remove) != 0 && !contains(remove, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			torrent["tags"] = strings.Join(tags, ", ")
+		}
+		return "", nil
+	},
+	"torrents/categories": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		categories := make(map[string]interface{}, len(q.categories))
+		for name, savePath := range q.categories {
+			categories[name] = map[string]interface{}{
+				"name":     name,
+				"savePath": savePath,
+			}
+		}
+		return categories, nil
+	},
+	"torrents/createCategory": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		name := req.FormValue("category")
+		if _, ok := q.categories[name]; ok || name == "" {
+			return nil, errInvalidArgument
+		}
+		if q.categories == nil {
+			q.categories = make(map[string]string)
+		}
+		q.categories[name] = req.FormValue("savePath")
+		return "", nil
+	},
+	"torrents/editCategory": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		name := req.FormValue("category")
+		if _, ok := q.categories[name]; !ok {
+			return nil, errInvalidArgument
+		}
+		q.categories[name] = req.FormValue("savePath")
+		return "", nil
+	},
+	"torrents/removeCategories": func(q *QBittorrent, req *http.Request) (interface{}, error) {
+		for _, name := range strings.Split(req.FormValue("categories"), "\n") {
+			delete(q.categories, name)
+			for _, torrent := range q.torrents {
+				if torrent["category"] == name {
+					torrent["category"] = ""
+				}
+			}
+		}
+		return "", nil
+	},
+}
+
+// splitTags splits a comma separated tag list.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// contains determines if s contains v.
+func contains(s []string, v string) bool {
+	for _, z := range s {
+		if z == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Fake errors.
+const (
+	// errTorrentNotFound is the torrent not found error.
+	errTorrentNotFound fakeError = "Not Found"
+
+	// errBadRequest is the bad request error.
+	errBadRequest fakeError = "Bad Request"
+
+	// errConflict is the conflict error.
+	errConflict fakeError = "Conflict"
+
+	// errRSSItemNotFound is the rss item not found error.
+	errRSSItemNotFound fakeError = "Item not found"
+
+	// errRSSItemExists is the rss item already exists error.
+	errRSSItemExists fakeError = "Item already exists"
+
+	// errRSSInvalidPath is the rss invalid path error.
+	errRSSInvalidPath fakeError = "Invalid path"
+)
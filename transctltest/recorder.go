@@ -0,0 +1,321 @@
+package transctltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kenshaw/transctl/tctypes"
+)
+
+// Error is a transctltest error.
+type Error string
+
+// Error satisfies the error interface.
+func (err Error) Error() string {
+	return string(err)
+}
+
+// Error values.
+const (
+	// ErrInteractionNotFound is the interaction not found error.
+	ErrInteractionNotFound Error = "interaction not found"
+)
+
+// Mode is a recorder mode.
+type Mode int
+
+// Mode values.
+const (
+	// ModeAuto replays the cassette when it exists, otherwise records a new
+	// cassette. Setting the TRANSCTLTEST_RECORD environment variable forces
+	// recording.
+	ModeAuto Mode = iota
+
+	// ModeRecord records a new cassette.
+	ModeRecord
+
+	// ModeReplay replays the cassette.
+	ModeReplay
+)
+
+// Cassette is a set of recorded HTTP interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded HTTP request and response.
+type Interaction struct {
+	Request  InteractionRequest  `json:"request"`
+	Response InteractionResponse `json:"response"`
+}
+
+// InteractionRequest is a recorded HTTP request.
+type InteractionRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// InteractionResponse is a recorded HTTP response.
+type InteractionResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is a HTTP transport that records HTTP interactions with a remote
+// host to a cassette, or replays a previously recorded cassette.
+//
+// Sensitive headers and body fields (such as passwords and session ids) are
+// redacted before being recorded. Request bodies are normalized
+// before being recorded and matched, removing transmission rpc tags and
+// multipart boundaries, so that replay does not depend on the order or
+// randomness of the client. Interactions are replayed in order, with the
+// first unused interaction with a matching method, path, query, and body used
+// for each request.
+type Recorder struct {
+	// Path is the cassette path.
+	Path string
+
+	mode      Mode
+	transport http.RoundTripper
+	cassette  *Cassette
+	used      []bool
+
+	sync.Mutex
+}
+
+// RecorderOption is a recorder option.
+type RecorderOption func(*Recorder)
+
+// WithMode is a recorder option to set the mode.
+func WithMode(mode Mode) RecorderOption {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport is a recorder option to set the transport used when
+// recording.
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// NewRecorder creates a new recorder for the cassette path.
+func NewRecorder(path string, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		Path:      path,
+		transport: http.DefaultTransport,
+		cassette:  new(Cassette),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil && os.Getenv("TRANSCTLTEST_RECORD") == "" {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(buf, r.cassette); err != nil {
+			return nil, err
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Recording returns whether or not the recorder is recording.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Client returns a HTTP client using the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	ireq := InteractionRequest{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Header: redactHeader(req.Header),
+		Body:   normalize(req.Header.Get("Content-Type"), body),
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, ireq)
+	}
+
+	// record
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: ireq,
+		Response: InteractionResponse{
+			StatusCode: res.StatusCode,
+			Header:     redactHeader(res.Header),
+			Body:       string(tctypes.Redact(res.Header.Get("Content-Type"), resBody)),
+		},
+	})
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	return res, nil
+}
+
+// replay replays the first unused matching interaction.
+func (r *Recorder) replay(req *http.Request, ireq InteractionRequest) (*http.Response, error) {
+	r.Lock()
+	defer r.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !match(interaction.Request, ireq) {
+			continue
+		}
+		r.used[i] = true
+		header := make(http.Header, len(interaction.Response.Header))
+		for k, v := range interaction.Response.Header {
+			header[k] = append([]string(nil), v...)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, ireq.Method, ireq.URL)
+}
+
+// Save saves the recorded cassette. Does nothing when not recording.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	buf, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, append(buf, '\n'), 0644)
+}
+
+// match determines if the recorded request matches the request.
+func match(a, b InteractionRequest) bool {
+	if a.Method != b.Method || a.Body != b.Body {
+		return false
+	}
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return false
+	}
+	v, err := url.Parse(b.URL)
+	if err != nil {
+		return false
+	}
+	return u.Path == v.Path && u.Query().Encode() == v.Query().Encode()
+}
+
+// redactURL returns the url without any user info.
+func redactURL(u *url.URL) string {
+	z := *u
+	z.User = nil
+	return z.String()
+}
+
+// redactHeader returns a copy of the header with sensitive values redacted.
+func redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	z := make(http.Header, len(header))
+	for k, v := range header {
+		for _, s := range v {
+			z[k] = append(z[k], tctypes.RedactHeader(k, s))
+		}
+	}
+	return z
+}
+
+// normalize normalizes and redacts a request body. JSON bodies are
+// re-encoded without the transmission rpc tag, and multipart bodies are
+// encoded as a form.
+func normalize(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	typ, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case typ == "multipart/form-data":
+		values := make(url.Values)
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return string(body)
+			}
+			buf, err := ioutil.ReadAll(p)
+			if err != nil {
+				return string(body)
+			}
+			values.Add(p.FormName(), string(buf))
+		}
+		typ, body = "application/x-www-form-urlencoded", []byte(values.Encode())
+	case typ == "application/x-www-form-urlencoded":
+	case json.Valid(body):
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err == nil && m != nil {
+			delete(m, "tag")
+			if buf, err := json.Marshal(m); err == nil {
+				body = buf
+			}
+		}
+	}
+	return string(tctypes.Redact(typ, body))
+}
@@ -0,0 +1,50 @@
+// Package transctltest provides in-process fake torrent client servers and a
+// HTTP record/replay transport for testing transctl's client packages and
+// commands.
+//
+// The fakes implement enough of each remote host's protocol (including
+// authentication and session handling) to statefully add, list, start, stop,
+// and remove torrents:
+//
+//	Transmission - Transmission RPC over HTTP, with CSRF and basic auth
+//	QBittorrent  - qBittorrent WebUI API, with a cookie (SID) login
+//	Deluge       - Deluge RPC, rencoded and zlib compressed over TLS
+//
+// The fakes do not depend on the client packages, so they can be used by the
+// client packages' own tests.
+package transctltest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// btihRE matches the info hash of a magnet link.
+var btihRE = regexp.MustCompile(`(?i)xt=urn:btih:([0-9a-f]{40})`)
+
+// infoHash returns the info hash and name for a magnet link, url or torrent
+// file. Magnet links use the link's info hash and display name, otherwise the
+// SHA1 of the data is used.
+func infoHash(data []byte, n int) (string, string) {
+	s := string(data)
+	if m := btihRE.FindStringSubmatch(s); m != nil {
+		name := fmt.Sprintf("torrent %d", n)
+		if u, err := url.Parse(s); err == nil && u.Query().Get("dn") != "" {
+			name = u.Query().Get("dn")
+		}
+		return strings.ToLower(m[1]), name
+	}
+	hash := sha1.Sum(data)
+	return hex.EncodeToString(hash[:]), fmt.Sprintf("torrent %d", n)
+}
+
+// sessionID returns a deterministic session id for the prefix and n, used for
+// CSRF tokens and cookies.
+func sessionID(prefix string, n int64) string {
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s-%d", prefix, n)))
+	return hex.EncodeToString(hash[:16])
+}
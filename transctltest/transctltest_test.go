@@ -0,0 +1,109 @@
+package transctltest
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transctltest")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+	bodies := []string{
+		`{"method":"session-get","tag":1}`,
+		`{"method":"session-get","tag":2}`,
+		`{"method":"session-set","arguments":{"rpc-password":"secret"},"tag":3}`,
+		`{"method":"torrent-add","arguments":{"filename":"magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567"},"tag":4}`,
+		`{"method":"torrent-get","arguments":{"fields":["name"]},"tag":5}`,
+	}
+
+	// record
+	s := NewTransmission()
+	rec, err := NewRecorder(path, WithMode(ModeRecord))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	recorded := run(t, rec.Client(), s.URL+"/transmission/rpc", bodies)
+	s.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if recorded[0].status != http.StatusConflict || recorded[1].status != http.StatusOK {
+		t.Errorf("expected 409 then 200, got: %d, %d", recorded[0].status, recorded[1].status)
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, s := range []string{"secret", sessionID("transmission", 1)} {
+		if bytes.Contains(buf, []byte(s)) {
+			t.Errorf("expected cassette to not contain %q", s)
+		}
+	}
+
+	// replay, with different tags
+	rec, err = NewRecorder(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if rec.Recording() {
+		t.Fatalf("expected recorder to be replaying")
+	}
+	for i := range bodies {
+		bodies[i] = strings.Replace(bodies[i], `"tag":`, `"tag":1`, 1)
+	}
+	replayed := run(t, rec.Client(), "http://localhost:9091/transmission/rpc", bodies)
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("request %d expected %+v, got: %+v", i, recorded[i], replayed[i])
+		}
+	}
+
+	// no more interactions
+	_, err = rec.Client().Post("http://localhost:9091/transmission/rpc", "application/json", strings.NewReader(bodies[0]))
+	if !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("expected ErrInteractionNotFound, got: %v", err)
+	}
+}
+
+type result struct {
+	status int
+	body   string
+}
+
+// run posts the bodies to urlstr, sending the session id from the previous
+// response.
+func run(t *testing.T, cl *http.Client, urlstr string, bodies []string) []result {
+	var csrf string
+	var results []result
+	for _, body := range bodies {
+		req, err := http.NewRequest("POST", urlstr, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Transmission-Session-Id", csrf)
+		res, err := cl.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		csrf = res.Header.Get("X-Transmission-Session-Id")
+		// remove the tag, which differs between record and replay
+		results = append(results, result{res.StatusCode, normalize("application/json", buf)})
+	}
+	return results
+}
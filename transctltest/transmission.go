@@ -0,0 +1,815 @@
+package transctltest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Transmission is a fake Transmission RPC server.
+//
+// Requests must carry the current X-Transmission-Session-Id (otherwise a 409
+// with the session id is returned), and basic auth credentials when
+// credentials are set. Method names use the pre-4.x (rpc version < 17)
+// naming, unless the server was created with WithTransmissionSnakeCase.
+type Transmission struct {
+	*httptest.Server
+
+	user, pass string
+	csrf       string
+	sessions   int64
+	version    int64
+	snakeCase  bool
+	session    map[string]interface{}
+	torrents   []map[string]interface{}
+	nextID     int64
+	requests   []string
+
+	sync.Mutex
+}
+
+// TransmissionOption is a fake Transmission RPC server option.
+type TransmissionOption func(*Transmission)
+
+// WithTransmissionCredentials is a fake Transmission RPC server option to
+// require basic auth credentials.
+func WithTransmissionCredentials(user, pass string) TransmissionOption {
+	return func(t *Transmission) {
+		t.user, t.pass = user, pass
+	}
+}
+
+// WithTransmissionVersion is a fake Transmission RPC server option to set the
+// reported rpc version.
+func WithTransmissionVersion(rpcVersion int64) TransmissionOption {
+	return func(t *Transmission) {
+		t.version = rpcVersion
+	}
+}
+
+// WithTransmissionSnakeCase is a fake Transmission RPC server option to use
+// the Transmission 4.1+ (rpc version semver 6+) snake case method and argument
+// names.
+func WithTransmissionSnakeCase() TransmissionOption {
+	return func(t *Transmission) {
+		t.version, t.snakeCase = 18, true
+	}
+}
+
+// NewTransmission creates and starts a fake Transmission RPC server. The
+// server's rpc url is URL + "/transmission/rpc".
+func NewTransmission(opts ...TransmissionOption) *Transmission {
+	t := &Transmission{
+		version: 16,
+		nextID:  1,
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	t.session = map[string]interface{}{
+		"download-dir":             "/downloads",
+		"peer-port":                51413,
+		"rpc-version":              t.version,
+		"rpc-version-minimum":      1,
+		"rpc-version-semver":       "5.2.0",
+		"speed-limit-down":         100,
+		"speed-limit-down-enabled": false,
+		"speed-limit-up":           100,
+		"speed-limit-up-enabled":   false,
+		"alt-speed-enabled":        false,
+		"version":                  "3.00 (fake)",
+	}
+	if t.snakeCase {
+		t.session["rpc-version-semver"] = "6.0.0"
+	}
+	t.ExpireSession()
+	t.Server = httptest.NewServer(t)
+	return t
+}
+
+// ExpireSession expires the current session id, forcing clients to
+// renegotiate it.
+func (t *Transmission) ExpireSession() {
+	t.Lock()
+	defer t.Unlock()
+	t.sessions++
+	t.csrf = sessionID("transmission", t.sessions)
+}
+
+// AddTorrent adds a torrent with the hash and name to the server, returning
+// its id.
+func (t *Transmission) AddTorrent(hash, name string) int64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.add(hash, name)["id"].(int64)
+}
+
+// AddTracker adds a tracker with the announce url and tier to the torrent with
+// the hash. The tracker's last announce succeeded, with the peer count, when
+// msg is empty, and otherwise failed with the msg.
+func (t *Transmission) AddTracker(hash, announce string, tier, peers int64, msg string) {
+	t.Lock()
+	defer t.Unlock()
+	for _, torrent := range t.torrents {
+		if torrent["hashString"] != hash {
+			continue
+		}
+		stats := trackerStats(torrent)
+		stat := newTracker(nextTrackerID(stats), announce, tier)
+		stat["hasAnnounced"], stat["lastAnnounceSucceeded"], stat["lastAnnouncePeerCount"], stat["lastAnnounceResult"] = true, msg == "", peers, msg
+		if msg == "" {
+			stat["lastAnnounceResult"] = "Success"
+		}
+		setTrackers(torrent, append(stats, stat))
+	}
+}
+
+// AddFile adds a file with the name and length to the torrent with the hash.
+func (t *Transmission) AddFile(hash, name string, length int64) {
+	t.Lock()
+	defer t.Unlock()
+	for _, torrent := range t.torrents {
+		if torrent["hashString"] != hash {
+			continue
+		}
+		files, _ := torrent["files"].([]interface{})
+		torrent["files"] = append(files, map[string]interface{}{
+			"name":           name,
+			"length":         length,
+			"bytesCompleted": 0,
+		})
+		torrent["totalSize"] = jsonInt(torrent["totalSize"]) + length
+	}
+}
+
+// SetPieces sets the piece size and piece states of the torrent with the
+// hash, with each state being '0' (missing), '1' (downloading), or '2'
+// (have). Transmission only reports the pieces it has, so downloading pieces
+// are reported as missing.
+func (t *Transmission) SetPieces(hash string, pieceSize int64, states string) {
+	t.Lock()
+	defer t.Unlock()
+	bitfield := make([]byte, (len(states)+7)/8)
+	for i, c := range states {
+		if c == '2' {
+			bitfield[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	for _, torrent := range t.torrents {
+		if torrent["hashString"] == hash {
+			torrent["pieces"] = base64.StdEncoding.EncodeToString(bitfield)
+			torrent["pieceCount"], torrent["pieceSize"] = len(states), pieceSize
+		}
+	}
+}
+
+// Torrents returns a copy of the torrents on the server.
+func (t *Transmission) Torrents() []map[string]interface{} {
+	t.Lock()
+	defer t.Unlock()
+	torrents := make([]map[string]interface{}, len(t.torrents))
+	for i, torrent := range t.torrents {
+		torrents[i] = make(map[string]interface{}, len(torrent))
+		for k, v := range torrent {
+			torrents[i][k] = v
+		}
+	}
+	return torrents
+}
+
+// Requests returns the rpc methods of the requests received by the server,
+// including those rejected for missing session ids or credentials.
+func (t *Transmission) Requests() []string {
+	t.Lock()
+	defer t.Unlock()
+	return append([]string(nil), t.requests...)
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (t *Transmission) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	var v struct {
+		Method    string                 `json:"method"`
+		Arguments map[string]interface{} `json:"arguments"`
+		Tag       int64                  `json:"tag"`
+	}
+	dec := json.NewDecoder(req.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.requests = append(t.requests, v.Method)
+
+	// check credentials and session id
+	if user, pass, _ := req.BasicAuth(); t.user != "" && (user != t.user || pass != t.pass) {
+		res.Header().Set("WWW-Authenticate", `Basic realm="Transmission"`)
+		http.Error(res, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	res.Header().Set("X-Transmission-Session-Id", t.csrf)
+	if req.Header.Get("X-Transmission-Session-Id") != t.csrf {
+		http.Error(res, "Conflict", http.StatusConflict)
+		return
+	}
+
+	// execute
+	result, args := "success", map[string]interface{}(nil)
+	method := strings.ReplaceAll(v.Method, "_", "-")
+	if f, ok := transmissionMethods[method]; ok {
+		if t.snakeCase {
+			v.Arguments = t.legacyArgs(method, v.Arguments)
+		}
+		var err error
+		if args, err = f(t, v.Arguments); err != nil {
+			result = err.Error()
+		}
+		if t.snakeCase {
+			args = snakeArgs(args).(map[string]interface{})
+		}
+	} else {
+		result = "method name not recognized"
+	}
+	res.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(res).Encode(map[string]interface{}{
+		"result":    result,
+		"arguments": args,
+		"tag":       v.Tag,
+	})
+}
+
+// legacyArgs converts the snake case argument names, and field names, of the
+// method's arguments to the pre-4.x names. Torrent names are used for
+// torrent-get and torrent-set, and session names for all other methods, as
+// the same snake case name has a different original name for each (for
+// example, "download_dir" is "downloadDir" and "download-dir").
+func (t *Transmission) legacyArgs(method string, args map[string]interface{}) map[string]interface{} {
+	names := make(map[string]string)
+	add := func(name string) {
+		names[snakeCase(name)] = name
+	}
+	switch method {
+	case "torrent-get", "torrent-set":
+		for _, torrent := range t.torrents {
+			for k := range torrent {
+				add(k)
+			}
+		}
+		for _, k := range []string{"trackerAdd", "trackerRemove", "trackerReplace", "trackerList"} {
+			add(k)
+		}
+	default:
+		for k := range t.session {
+			add(k)
+		}
+		for _, k := range []string{"download-dir", "delete-local-data"} {
+			add(k)
+		}
+	}
+	legacy := func(name string) string {
+		if s, ok := names[name]; ok {
+			return s
+		}
+		return name
+	}
+	m := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		m[legacy(k)] = v
+	}
+	if fields, ok := m["fields"].([]interface{}); ok {
+		for i, field := range fields {
+			if s, ok := field.(string); ok {
+				fields[i] = legacy(s)
+			}
+		}
+	}
+	if ids, ok := m["ids"].(string); ok {
+		m["ids"] = strings.ReplaceAll(ids, "_", "-")
+	}
+	return m
+}
+
+// snakeArgs recursively converts the object keys in v to snake case, copying
+// maps and slices so that the server's state is not modified.
+func snakeArgs(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if x == nil {
+			return x
+		}
+		m := make(map[string]interface{}, len(x))
+		for k, z := range x {
+			m[snakeCase(k)] = snakeArgs(z)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(x))
+		for i, z := range x {
+			s[i] = snakeArgs(z)
+		}
+		return s
+	case []map[string]interface{}:
+		s := make([]interface{}, len(x))
+		for i, z := range x {
+			s[i] = snakeArgs(z)
+		}
+		return s
+	}
+	return v
+}
+
+// snakeCase converts a camel case or kebab case rpc name to snake case.
+func snakeCase(s string) string {
+	r := []rune(s)
+	var buf strings.Builder
+	for i, c := range r {
+		switch {
+		case c == '-':
+			buf.WriteRune('_')
+			continue
+		case unicode.IsUpper(c) && i != 0 && (unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1]) ||
+			(unicode.IsUpper(r[i-1]) && i+1 < len(r) && unicode.IsLower(r[i+1]))):
+			buf.WriteRune('_')
+		}
+		buf.WriteRune(unicode.ToLower(c))
+	}
+	return buf.String()
+}
+
+// add adds a torrent.
+func (t *Transmission) add(hash, name string) map[string]interface{} {
+	id := t.nextID
+	t.nextID++
+	torrent := map[string]interface{}{
+		"id":            id,
+		"hashString":    hash,
+		"name":          name,
+		"status":        transmissionStatusDownloading,
+		"downloadDir":   t.session["download-dir"],
+		"addedDate":     time.Now().Unix(),
+		"percentDone":   0,
+		"totalSize":     0,
+		"queuePosition": len(t.torrents),
+		"labels":        []interface{}{},
+		"trackers":      []interface{}{},
+		"rateDownload":  0,
+		"rateUpload":    0,
+		"uploadRatio":   0,
+		"error":         0,
+		"errorString":   "",
+	}
+	t.torrents = append(t.torrents, torrent)
+	return torrent
+}
+
+// find returns the torrents matching the ids argument. All torrents are
+// returned when ids is not present.
+func (t *Transmission) find(args map[string]interface{}) []map[string]interface{} {
+	ids, ok := args["ids"]
+	if !ok {
+		return t.torrents
+	}
+	var list []interface{}
+	switch x := ids.(type) {
+	case []interface{}:
+		list = x
+	case string:
+		if x == "recently-active" {
+			return t.torrents
+		}
+		list = []interface{}{x}
+	default:
+		list = []interface{}{x}
+	}
+	var torrents []map[string]interface{}
+	for _, torrent := range t.torrents {
+		for _, id := range list {
+			if n, ok := id.(json.Number); ok && n.String() == jsonString(torrent["id"]) || id == torrent["hashString"] {
+				torrents = append(torrents, torrent)
+				break
+			}
+		}
+	}
+	return torrents
+}
+
+// transmission status values.
+const (
+	transmissionStatusStopped     = 0
+	transmissionStatusChecking    = 2
+	transmissionStatusDownloading = 4
+)
+
+// transmissionMethods are the fake's rpc methods.
+var transmissionMethods = map[string]func(*Transmission, map[string]interface{}) (map[string]interface{}, error){
+	"session-get": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		fields, _ := args["fields"].([]interface{})
+		if len(fields) == 0 {
+			return t.session, nil
+		}
+		res := make(map[string]interface{})
+		for _, field := range fields {
+			if s, ok := field.(string); ok && t.session[s] != nil {
+				res[s] = t.session[s]
+			}
+		}
+		return res, nil
+	},
+	"session-set": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		for k, v := range args {
+			t.session[k] = v
+		}
+		return nil, nil
+	},
+	"session-stats": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		var active, paused int
+		for _, torrent := range t.torrents {
+			if torrent["status"] == transmissionStatusStopped {
+				paused++
+			} else {
+				active++
+			}
+		}
+		return map[string]interface{}{
+			"activeTorrentCount": active,
+			"pausedTorrentCount": paused,
+			"torrentCount":       len(t.torrents),
+		}, nil
+	},
+	"session-close": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		return nil, nil
+	},
+	"torrent-get": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		fields, _ := args["fields"].([]interface{})
+		torrents := []interface{}{}
+		for _, torrent := range t.find(args) {
+			m := make(map[string]interface{})
+			for _, field := range fields {
+				if s, ok := field.(string); ok && torrent[s] != nil {
+					m[s] = torrent[s]
+				}
+			}
+			torrents = append(torrents, m)
+		}
+		return map[string]interface{}{"torrents": torrents}, nil
+	},
+	"torrent-add": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		var data []byte
+		switch {
+		case args["metainfo"] != nil:
+			var err error
+			if data, err = base64.StdEncoding.DecodeString(jsonString(args["metainfo"])); err != nil {
+				return nil, errInvalidOrCorruptTorrent
+			}
+		case args["filename"] != nil:
+			data = []byte(jsonString(args["filename"]))
+		default:
+			return nil, errInvalidOrCorruptTorrent
+		}
+		hash, name := infoHash(data, int(t.nextID))
+		for _, torrent := range t.torrents {
+			if torrent["hashString"] == hash {
+				return map[string]interface{}{"torrent-duplicate": summary(torrent)}, nil
+			}
+		}
+		torrent := t.add(hash, name)
+		if dir, ok := args["download-dir"].(string); ok && dir != "" {
+			torrent["downloadDir"] = dir
+		}
+		if paused, ok := args["paused"].(bool); ok && paused {
+			torrent["status"] = transmissionStatusStopped
+		}
+		return map[string]interface{}{"torrent-added": summary(torrent)}, nil
+	},
+	"torrent-remove": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		remove := t.find(args)
+		var torrents []map[string]interface{}
+		for _, torrent := range t.torrents {
+			if !containsTorrent(remove, torrent) {
+				torrents = append(torrents, torrent)
+			}
+		}
+		t.torrents = torrents
+		return nil, nil
+	},
+	"torrent-start":      setStatus(transmissionStatusDownloading),
+	"torrent-start-now":  setStatus(transmissionStatusDownloading),
+	"torrent-stop":       setStatus(transmissionStatusStopped),
+	"torrent-verify":     setStatus(transmissionStatusChecking),
+	"torrent-reannounce": setStatus(-1),
+	"torrent-set": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		for _, torrent := range t.find(args) {
+			for k, v := range args {
+				switch k {
+				case "ids":
+				case "trackerAdd", "trackerRemove", "trackerReplace":
+					setTrackers(torrent, editTrackers(trackerStats(torrent), k, v))
+				case "trackerList":
+					if t.version >= 17 {
+						setTrackers(torrent, parseTrackerList(trackerStats(torrent), jsonString(v)))
+					}
+				default:
+					torrent[k] = v
+				}
+			}
+		}
+		return nil, nil
+	},
+	"torrent-set-location": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		for _, torrent := range t.find(args) {
+			torrent["downloadDir"] = args["location"]
+		}
+		return nil, nil
+	},
+	"torrent-rename-path": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		torrents := t.find(args)
+		if len(torrents) != 1 {
+			return nil, errInvalidArgument
+		}
+		if args["path"] == torrents[0]["name"] {
+			torrents[0]["name"] = args["name"]
+		}
+		return map[string]interface{}{
+			"id":   torrents[0]["id"],
+			"path": args["path"],
+			"name": args["name"],
+		}, nil
+	},
+	"queue-move-top":    moveQueue(func(i, n int) int { return -1 }),
+	"queue-move-up":     moveQueue(func(i, n int) int { return i - 1 }),
+	"queue-move-down":   moveQueue(func(i, n int) int { return i + 1 }),
+	"queue-move-bottom": moveQueue(func(i, n int) int { return n }),
+	"free-space": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"path":       args["path"],
+			"size-bytes": int64(1 << 40),
+		}, nil
+	},
+	"port-test": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"port-is-open": true}, nil
+	},
+	"blocklist-update": func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"blocklist-size": 0}, nil
+	},
+}
+
+// setStatus returns a rpc method setting the status of the matching
+// torrents. A negative status leaves the status unchanged.
+func setStatus(status int) func(*Transmission, map[string]interface{}) (map[string]interface{}, error) {
+	return func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		for _, torrent := range t.find(args) {
+			if status >= 0 {
+				torrent["status"] = status
+			}
+		}
+		return nil, nil
+	}
+}
+
+// moveQueue returns a rpc method moving the matching torrents in the queue
+// to the position returned by f.
+func moveQueue(f func(int, int) int) func(*Transmission, map[string]interface{}) (map[string]interface{}, error) {
+	return func(t *Transmission, args map[string]interface{}) (map[string]interface{}, error) {
+		n := len(t.torrents)
+		pos := make(map[int64]float64, n)
+		for i, torrent := range t.torrents {
+			pos[torrent["id"].(int64)] = float64(i)
+		}
+		for _, torrent := range t.find(args) {
+			id := torrent["id"].(int64)
+			// move half a position past the target, so ties sort correctly
+			switch p := f(int(pos[id]), n); {
+			case p < int(pos[id]):
+				pos[id] = float64(p) - 0.5
+			case p > int(pos[id]):
+				pos[id] = float64(p) + 0.5
+			}
+		}
+		sort.SliceStable(t.torrents, func(i, j int) bool {
+			return pos[t.torrents[i]["id"].(int64)] < pos[t.torrents[j]["id"].(int64)]
+		})
+		for i, torrent := range t.torrents {
+			torrent["queuePosition"] = i
+		}
+		return nil, nil
+	}
+}
+
+// newTracker creates a tracker (as tracker stats) with the id, announce url,
+// and tier.
+func newTracker(id int64, announce string, tier int64) map[string]interface{} {
+	var host string
+	if u, err := url.Parse(announce); err == nil {
+		host = u.Host
+	}
+	return map[string]interface{}{
+		"id":                    id,
+		"announce":              announce,
+		"tier":                  tier,
+		"host":                  host,
+		"hasAnnounced":          false,
+		"lastAnnounceSucceeded": false,
+		"lastAnnouncePeerCount": 0,
+		"lastAnnounceResult":    "",
+	}
+}
+
+// trackerStats returns the tracker stats of the torrent.
+func trackerStats(torrent map[string]interface{}) []map[string]interface{} {
+	v, _ := torrent["trackerStats"].([]interface{})
+	stats := make([]map[string]interface{}, len(v))
+	for i, stat := range v {
+		stats[i] = stat.(map[string]interface{})
+	}
+	return stats
+}
+
+// setTrackers sets the torrent's trackers, tracker stats, and tracker list
+// from the tracker stats.
+func setTrackers(torrent map[string]interface{}, stats []map[string]interface{}) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return jsonInt(stats[i]["tier"]) < jsonInt(stats[j]["tier"])
+	})
+	trackers, list := make([]interface{}, len(stats)), make([]interface{}, len(stats))
+	var lines []string
+	for i, stat := range stats {
+		trackers[i] = map[string]interface{}{
+			"id":       stat["id"],
+			"announce": stat["announce"],
+			"tier":     stat["tier"],
+		}
+		list[i] = stat
+		if i != 0 && jsonInt(stat["tier"]) != jsonInt(stats[i-1]["tier"]) {
+			lines = append(lines, "")
+		}
+		lines = append(lines, jsonString(stat["announce"]))
+	}
+	torrent["trackers"], torrent["trackerStats"], torrent["trackerList"] = trackers, list, strings.Join(lines, "\n")
+}
+
+// editTrackers applies a trackerAdd, trackerRemove, or trackerReplace
+// argument to the tracker stats. Added trackers are each added to a new tier.
+func editTrackers(stats []map[string]interface{}, k string, v interface{}) []map[string]interface{} {
+	values, _ := v.([]interface{})
+	find := func(id interface{}) int {
+		for i, stat := range stats {
+			if jsonInt(stat["id"]) == jsonInt(id) {
+				return i
+			}
+		}
+		return -1
+	}
+	switch k {
+	case "trackerAdd":
+		for _, announce := range values {
+			if containsTracker(stats, jsonString(announce)) {
+				continue
+			}
+			var tier int64
+			for _, stat := range stats {
+				if n := jsonInt(stat["tier"]) + 1; n > tier {
+					tier = n
+				}
+			}
+			stats = append(stats, newTracker(nextTrackerID(stats), jsonString(announce), tier))
+		}
+	case "trackerRemove":
+		for _, id := range values {
+			if i := find(id); i != -1 {
+				stats = append(stats[:i], stats[i+1:]...)
+			}
+		}
+	case "trackerReplace":
+		for i := 0; i+1 < len(values); i += 2 {
+			if j := find(values[i]); j != -1 {
+				stats[j] = newTracker(jsonInt(stats[j]["id"]), jsonString(values[i+1]), jsonInt(stats[j]["tier"]))
+			}
+		}
+	}
+	return stats
+}
+
+// parseTrackerList parses a tracker list (announce urls, one per line, with
+// a blank line between tiers), keeping the tracker stats of existing
+// trackers.
+func parseTrackerList(stats []map[string]interface{}, list string) []map[string]interface{} {
+	var trackers []map[string]interface{}
+	id, tier, blank := nextTrackerID(stats), int64(0), false
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			blank = true
+			continue
+		case blank && len(trackers) != 0:
+			tier++
+		}
+		blank = false
+		if containsTracker(trackers, line) {
+			continue
+		}
+		tracker := newTracker(id, line, tier)
+		for _, stat := range stats {
+			if stat["announce"] == line {
+				tracker = stat
+				tracker["tier"] = tier
+			}
+		}
+		if jsonInt(tracker["id"]) == id {
+			id++
+		}
+		trackers = append(trackers, tracker)
+	}
+	return trackers
+}
+
+// nextTrackerID returns the next tracker id for the tracker stats.
+func nextTrackerID(stats []map[string]interface{}) int64 {
+	var id int64
+	for _, stat := range stats {
+		if n := jsonInt(stat["id"]) + 1; n > id {
+			id = n
+		}
+	}
+	return id
+}
+
+// containsTracker determines if the tracker stats contain the announce url.
+func containsTracker(stats []map[string]interface{}, announce string) bool {
+	for _, stat := range stats {
+		if stat["announce"] == announce {
+			return true
+		}
+	}
+	return false
+}
+
+// summary returns the id, name and hash of the torrent.
+func summary(torrent map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         torrent["id"],
+		"name":       torrent["name"],
+		"hashString": torrent["hashString"],
+	}
+}
+
+// containsTorrent determines if torrent is in torrents.
+func containsTorrent(torrents []map[string]interface{}, torrent map[string]interface{}) bool {
+	for _, t := range torrents {
+		if t["id"] == torrent["id"] {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonInt returns v as an int64.
+func jsonInt(v interface{}) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case int:
+		return int64(x)
+	case float64:
+		return int64(x)
+	case json.Number:
+		n, _ := x.Int64()
+		return n
+	}
+	return 0
+}
+
+// jsonString returns v as a string.
+func jsonString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case nil:
+		return ""
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+// fakeError is a fake server error.
+type fakeError string
+
+// Error satisfies the error interface.
+func (err fakeError) Error() string {
+	return string(err)
+}
+
+// fake server errors.
+const (
+	errInvalidOrCorruptTorrent fakeError = "invalid or corrupt torrent file"
+	errInvalidArgument         fakeError = "Invalid argument"
+)
@@ -3,9 +3,15 @@ package transrpc
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,10 +33,13 @@ type Client struct {
 	// injectCredentialFallback injects the credential fallback.
 	injectCredentialFallback bool
 
-	// retries is the number of times to retry on a error, such as a 409
+	// retries is the number of times to resend a request on a 409
 	// (http.StatusConflict / missing CSRF token) error.
 	retries int
 
+	// retry is the retry policy used on network errors and server errors.
+	retry tctypes.RetryPolicy
+
 	// url is the remote url host.
 	url string
 
@@ -40,6 +49,19 @@ type Client struct {
 	// csrf is the CSRF session id.
 	csrf string
 
+	// version is the negotiated rpc version.
+	version *Version
+
+	// lenient toggles tolerating unknown fields in responses.
+	lenient bool
+
+	// unknownf is the log func for unknown fields encountered when decoding
+	// leniently.
+	unknownf func(string, ...interface{})
+
+	// cache is the session cache used to persist the CSRF session id.
+	cache *tctypes.SessionCache
+
 	sync.RWMutex
 }
 
@@ -49,6 +71,7 @@ func NewClient(opts ...ClientOption) *Client {
 		cl:        new(http.Client),
 		userAgent: "transrpc/0.1",
 		retries:   5,
+		retry:     tctypes.DefaultRetryPolicy,
 	}
 	for _, o := range opts {
 		o(cl)
@@ -56,12 +79,66 @@ func NewClient(opts ...ClientOption) *Client {
 	if cl.url == "" {
 		WithHost("transmission:transmission@localhost:9091")(cl)
 	}
+	// load cached session id and rpc version -- an invalid session id is
+	// replaced on the first request (409)
+	if cl.cache != nil {
+		if sess, err := cl.cache.Load(); err == nil && sess != nil {
+			if cl.csrf == "" {
+				cl.csrf = sess.CSRF
+			}
+			if cl.version == nil && len(sess.Version) != 0 {
+				version := new(Version)
+				if err := json.Unmarshal(sess.Version, version); err == nil {
+					cl.version = version
+				}
+			}
+		}
+	}
 	return cl
 }
 
 // Do executes the transmission rpc method, json marshaling the passed
 // arguments and unmarshaling the response to v (if provided).
+//
+// The remote host's rpc version is negotiated on the first call, and the
+// method and argument names are converted to the naming used by the host.
 func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{}) error {
+	version, err := cl.Version(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.do(ctx, method, arguments, v, version.SnakeCase)
+}
+
+// Version returns the remote host's rpc version, negotiating it with the
+// remote host if not previously negotiated. The negotiated version is stored
+// in the session cache (when set), and reused by later clients for the same
+// remote host.
+func (cl *Client) Version(ctx context.Context) (*Version, error) {
+	cl.RLock()
+	version := cl.version
+	cl.RUnlock()
+	if version != nil {
+		return version, nil
+	}
+	var res map[string]interface{}
+	if err := cl.do(ctx, "session-get", map[string]interface{}{
+		"fields": versionFields,
+	}, &res, false); err != nil {
+		return nil, err
+	}
+	version = newVersion(res)
+	cl.Lock()
+	cl.version = version
+	csrf := cl.csrf
+	cl.Unlock()
+	cl.storeSession(csrf, version)
+	return version, nil
+}
+
+// do executes the transmission rpc method, converting the method and argument
+// names to snake case when snakeCase is true.
+func (cl *Client) do(ctx context.Context, method string, arguments, v interface{}, snakeCase bool) error {
 	var err error
 
 	// encode args
@@ -70,26 +147,106 @@ func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{
 		return err
 	}
 	args := buf.Bytes()
+	if snakeCase {
+		if method, args, err = toSnakeCase(method, args); err != nil {
+			return err
+		}
+	}
+
+	// execute, retrying as per the retry policy
+	var res []byte
+	if err = cl.retry.Do(ctx, func(int) (bool, error) {
+		var err error
+		res, err = cl.roundTrip(ctx, method, args)
+		return retryable(method, err), err
+	}); err != nil {
+		return err
+	}
+
+	// convert result
+	var body io.Reader = bytes.NewReader(res)
+	if snakeCase {
+		if body, err = fromSnakeCase(body, v); err != nil {
+			return err
+		}
+	}
+
+	// decode result
+	result := struct {
+		Result    string      `json:"result,omitempty"`
+		Arguments interface{} `json:"arguments,omitempty"`
+		Tag       int64       `json:"tag,omitempty"`
+	}{
+		Arguments: v,
+	}
+	var raw json.RawMessage
+	dec := json.NewDecoder(body)
+	if cl.lenient {
+		result.Arguments = &raw
+	} else {
+		dec.DisallowUnknownFields()
+	}
+	dec.UseNumber()
+	if err = dec.Decode(&result); err != nil {
+		return err
+	}
+
+	// check success
+	if result.Result != "success" {
+		return &ErrRequestFailed{result.Result}
+	}
+
+	// decode arguments, collecting unknown fields
+	if cl.lenient && v != nil && len(raw) != 0 {
+		unknown, err := tctypes.DecodeExtra(raw, v)
+		if err != nil {
+			return err
+		}
+		if cl.unknownf != nil && len(unknown) != 0 {
+			cl.unknownf("%s: unknown fields: %s", method, strings.Join(unknown, ", "))
+		}
+	}
+	return nil
+}
 
-	// execute, retrying as per rpc spec
-	var res *http.Response
-	for i := 0; (res == nil || res.StatusCode != http.StatusOK) && i < cl.retries; i++ {
+// storeSession stores the CSRF session id and negotiated rpc version in the
+// session cache.
+func (cl *Client) storeSession(csrf string, version *Version) {
+	if cl.cache == nil {
+		return
+	}
+	sess := &tctypes.Session{CSRF: csrf}
+	if version != nil {
+		sess.Version, _ = json.Marshal(version)
+	}
+	_ = cl.cache.Store(sess)
+}
+
+// roundTrip sends the encoded method and arguments to the remote host,
+// returning the response body. Requests failing due to a missing CSRF token,
+// or unauthorized requests when a credential fallback is available, are
+// immediately resent (as per the rpc spec).
+func (cl *Client) roundTrip(ctx context.Context, method string, args []byte) ([]byte, error) {
+	for i := 0; i < cl.retries; i++ {
 		// encode envelope + body
 		var body bytes.Buffer
-		if err = json.NewEncoder(&body).Encode(map[string]interface{}{
+		if err := json.NewEncoder(&body).Encode(map[string]interface{}{
 			"method":    method,
 			"arguments": json.RawMessage(args),
 			"tag":       atomic.AddInt64(&cl.tag, 1),
 		}); err != nil {
-			return err
+			return nil, err
 		}
 		urlstr := cl.url
 
 		// inject credential fallback
-		if cl.injectCredentialFallback {
+		cl.RLock()
+		inject := cl.injectCredentialFallback
+		cl.RUnlock()
+		if inject {
 			u, err := url.Parse(urlstr)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if cl.credentialFallback[1] == "" {
 				u.User = url.User(cl.credentialFallback[0])
@@ -100,9 +257,9 @@ func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{
 		}
 
 		// create http request
-		var req *http.Request
-		if req, err = http.NewRequest("POST", urlstr, bytes.NewReader(body.Bytes())); err != nil {
-			return err
+		req, err := http.NewRequest("POST", urlstr, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return nil, err
 		}
 		req.Header.Set("User-Agent", cl.userAgent)
 		req.Header.Set("Content-Type", "application/json")
@@ -113,61 +270,74 @@ func (cl *Client) Do(ctx context.Context, method string, arguments, v interface{
 		cl.RUnlock()
 
 		// execute
-		res, err = cl.cl.Do(req.WithContext(ctx))
+		res, err := cl.cl.Do(req.WithContext(ctx))
 		if err != nil {
-			return err
+			return nil, err
+		}
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
 		}
-		defer res.Body.Close()
 		cl.Lock()
-		if csrf := res.Header.Get(csrfHeader); csrf != "" {
+		csrf := res.Header.Get(csrfHeader)
+		changed := csrf != "" && csrf != cl.csrf
+		if changed {
 			cl.csrf = csrf
 		}
+		version := cl.version
 		cl.Unlock()
+		if changed {
+			cl.storeSession(csrf, version)
+		}
 
 		// status code check
 		switch {
-		case res.StatusCode == http.StatusOK || res.StatusCode == http.StatusConflict:
-		case res.StatusCode == http.StatusUnauthorized && cl.credentialFallback == nil:
-			return ErrUnauthorizedUser
-		case res.StatusCode == http.StatusUnauthorized && cl.credentialFallback != nil:
+		case res.StatusCode == http.StatusOK:
+			return buf, nil
+		case res.StatusCode == http.StatusConflict:
+		case res.StatusCode == http.StatusUnauthorized && (cl.credentialFallback == nil || inject):
+			if cl.cache != nil {
+				_ = cl.cache.Invalidate()
+			}
+			return nil, ErrUnauthorizedUser
+		case res.StatusCode == http.StatusUnauthorized:
+			cl.Lock()
 			cl.injectCredentialFallback = true
+			cl.Unlock()
+		case res.StatusCode >= http.StatusInternalServerError:
+			return nil, fmt.Errorf("%w: %s", ErrServerError, res.Status)
 		default:
-			return ErrUnknownProblemEncountered
+			return nil, ErrUnknownProblemEncountered
 		}
 	}
+	return nil, ErrUnknownProblemEncountered
+}
 
-	// check status
-	if res == nil {
-		return ErrUnknownProblemEncountered
-	}
-	switch res.StatusCode {
-	case http.StatusOK:
-	case http.StatusUnauthorized:
-		return ErrUnauthorizedUser
-	default:
-		return ErrUnknownProblemEncountered
-	}
-
-	// decode result
-	result := struct {
-		Result    string      `json:"result,omitempty"`
-		Arguments interface{} `json:"arguments,omitempty"`
-		Tag       int64       `json:"tag,omitempty"`
-	}{
-		Arguments: v,
-	}
-	dec := json.NewDecoder(res.Body)
-	dec.DisallowUnknownFields()
-	dec.UseNumber()
-	if err = dec.Decode(&result); err != nil {
-		return err
+// retryable determines if the method can be retried after the error.
+// Non-idempotent methods are only retried when the request was never sent.
+func retryable(method string, err error) bool {
+	switch {
+	case tctypes.IsDialError(err):
+		return true
+	case nonIdempotent[method]:
+		return false
 	}
+	return tctypes.IsTransient(err) || errors.Is(err, ErrServerError)
+}
 
-	// check success
-	if result.Result != "success" {
-		return &ErrRequestFailed{result.Result}
-	}
-	return nil
+// nonIdempotent are the rpc methods that are not safe to blindly retry.
+var nonIdempotent = map[string]bool{
+	"torrent-add":         true,
+	"torrent_add":         true,
+	"torrent-rename-path": true,
+	"torrent_rename_path": true,
+	"queue-move-up":       true,
+	"queue_move_up":       true,
+	"queue-move-down":     true,
+	"queue_move_down":     true,
+	"session-close":       true,
+	"session_close":       true,
 }
 
 // TorrentStart issues a torrent start request for the specified ids.
@@ -287,6 +457,16 @@ func (cl *Client) FreeSpace(ctx context.Context, path string) (ByteCount, error)
 	return FreeSpace(path).Do(ctx, cl)
 }
 
+// GroupGet issues a group get request for the named bandwidth groups.
+func (cl *Client) GroupGet(ctx context.Context, names ...string) ([]Group, error) {
+	return GroupGet(names...).Do(ctx, cl)
+}
+
+// GroupSet issues a group set request.
+func (cl *Client) GroupSet(ctx context.Context, req *GroupSetRequest) error {
+	return req.Do(ctx, cl)
+}
+
 // ClientOption is a transmission rpc client option.
 type ClientOption = func(*Client)
 
@@ -310,14 +490,32 @@ func WithCSRF(csrf string) ClientOption {
 	}
 }
 
-// WithRetries is a transmission rpc client option to set the number of retry
-// attempts when a failure is encountered.
+// WithRetries is a transmission rpc client option to set the number of times a
+// request is resent when the CSRF token is missing or expired.
 func WithRetries(retries int) ClientOption {
 	return func(cl *Client) {
 		cl.retries = retries
 	}
 }
 
+// WithRetryPolicy is a transmission rpc client option to set the retry policy
+// used on network errors and server (5xx) errors. Non-idempotent methods,
+// such as torrent-add, are not retried unless the request was never sent.
+func WithRetryPolicy(retry tctypes.RetryPolicy) ClientOption {
+	return func(cl *Client) {
+		cl.retry = retry
+	}
+}
+
+// WithSessionCache is a transmission rpc client option to set the session cache
+// used to persist the CSRF session id and negotiated rpc version between
+// clients.
+func WithSessionCache(cache *tctypes.SessionCache) ClientOption {
+	return func(cl *Client) {
+		cl.cache = cache
+	}
+}
+
 // WithClient is a transmission rpc client option to set the underlying
 // http.Client used.
 func WithClient(httpClient *http.Client) ClientOption {
@@ -350,6 +548,39 @@ func WithCredentialFallback(user, pass string) ClientOption {
 	}
 }
 
+// WithVersion is a transmission rpc client option to set the remote host's
+// rpc version, disabling version negotiation.
+func WithVersion(version *Version) ClientOption {
+	return func(cl *Client) {
+		cl.version = version
+	}
+}
+
+// WithTLSConfig is a transmission rpc client option to set the TLS config
+// used when connecting to the rpc host.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.WithTLSConfig(cl.cl.Transport, cfg)
+	}
+}
+
+// WithDialer is a transmission rpc client option to set the dialer used when
+// connecting to the rpc host, such as for unix sockets and proxies.
+func WithDialer(d *tctypes.Dialer) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.WithDialer(cl.cl.Transport, d)
+	}
+}
+
+// WithLenient is a transmission rpc client option to tolerate unknown fields
+// in responses, collecting them in the Extra field of the response types.
+// Unknown fields are logged with unknownf, if not nil.
+func WithLenient(unknownf func(string, ...interface{})) ClientOption {
+	return func(cl *Client) {
+		cl.lenient, cl.unknownf = true, unknownf
+	}
+}
+
 // WithLogf is a transmission rpc client option to set a log handler for HTTP
 // requests and responses.
 func WithLogf(logf func(string, ...interface{})) ClientOption {
@@ -357,3 +588,11 @@ func WithLogf(logf func(string, ...interface{})) ClientOption {
 		cl.cl.Transport = tctypes.NewHTTPLogf(cl.cl.Transport, logf)
 	}
 }
+
+// WithHAR is a transmission rpc client option to write HTTP requests and
+// responses to a HAR file.
+func WithHAR(har *tctypes.HARWriter) ClientOption {
+	return func(cl *Client) {
+		cl.cl.Transport = tctypes.NewHTTPHAR(cl.cl.Transport, har)
+	}
+}
@@ -5,6 +5,7 @@ package transrpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -125,6 +126,7 @@ type TorrentSetRequest struct {
 	DownloadLimited     bool          `json:"downloadLimited,omitempty" yaml:"downloadLimited,omitempty"`         // true if downloadLimit is honored
 	FilesWanted         []int64       `json:"files-wanted,omitempty" yaml:"files-wanted,omitempty"`               // indices of file(s) to download
 	FilesUnwanted       []int64       `json:"files-unwanted,omitempty" yaml:"files-unwanted,omitempty"`           // indices of file(s) to not download
+	Group               string        `json:"group,omitempty" yaml:"group,omitempty"`                             // the name of this torrent's bandwidth group
 	HonorsSessionLimits bool          `json:"honorsSessionLimits,omitempty" yaml:"honorsSessionLimits,omitempty"` // true if session upload limits are honored
 	IDs                 []interface{} `json:"ids,omitempty" yaml:"ids,omitempty"`                                 // torrent list, as described in 3.1
 	Labels              []string      `json:"labels,omitempty" yaml:"labels,omitempty"`                           // array of string labels
@@ -138,7 +140,9 @@ type TorrentSetRequest struct {
 	SeedIdleMode        Mode          `json:"seedIdleMode,omitempty" yaml:"seedIdleMode,omitempty"`               // which seeding inactivity to use.  See tr_idlelimit
 	SeedRatioLimit      float64       `json:"seedRatioLimit,omitempty" yaml:"seedRatioLimit,omitempty"`           // torrent-level seeding ratio
 	SeedRatioMode       Mode          `json:"seedRatioMode,omitempty" yaml:"seedRatioMode,omitempty"`             // which ratio to use.  See tr_ratiolimit
+	SequentialDownload  bool          `json:"sequentialDownload,omitempty" yaml:"sequentialDownload,omitempty"`   // download torrent pieces sequentially
 	TrackerAdd          []string      `json:"trackerAdd,omitempty" yaml:"trackerAdd,omitempty"`                   // strings of announce URLs to add
+	TrackerList         string        `json:"trackerList,omitempty" yaml:"trackerList,omitempty"`                 // string of announce URLs, one per line, and a blank line between tiers
 	TrackerRemove       []int64       `json:"trackerRemove,omitempty" yaml:"trackerRemove,omitempty"`             // ids of trackers to remove
 	TrackerReplace      []interface{} `json:"trackerReplace,omitempty" yaml:"trackerReplace,omitempty"`           // pairs of <trackerId/new announce URLs>
 	UploadLimit         int64         `json:"uploadLimit,omitempty" yaml:"uploadLimit,omitempty"`                 // maximum upload speed (KBps)
@@ -183,6 +187,9 @@ func (req *TorrentSetRequest) Do(ctx context.Context, cl *Client) error {
 	if req.changed["FilesUnwanted"] {
 		params["files-unwanted"] = req.FilesUnwanted
 	}
+	if req.changed["Group"] {
+		params["group"] = req.Group
+	}
 	if req.changed["HonorsSessionLimits"] {
 		params["honorsSessionLimits"] = req.HonorsSessionLimits
 	}
@@ -219,9 +226,15 @@ func (req *TorrentSetRequest) Do(ctx context.Context, cl *Client) error {
 	if req.changed["SeedRatioMode"] {
 		params["seedRatioMode"] = req.SeedRatioMode
 	}
+	if req.changed["SequentialDownload"] {
+		params["sequentialDownload"] = req.SequentialDownload
+	}
 	if req.changed["TrackerAdd"] {
 		params["trackerAdd"] = req.TrackerAdd
 	}
+	if req.changed["TrackerList"] {
+		params["trackerList"] = req.TrackerList
+	}
 	if req.changed["TrackerRemove"] {
 		params["trackerRemove"] = req.TrackerRemove
 	}
@@ -276,6 +289,12 @@ func (req TorrentSetRequest) WithFilesUnwanted(filesUnwanted []int64) *TorrentSe
 	return req.WithChanged("FilesUnwanted")
 }
 
+// WithGroup sets the name of this torrent's bandwidth group.
+func (req TorrentSetRequest) WithGroup(group string) *TorrentSetRequest {
+	req.Group = group
+	return req.WithChanged("Group")
+}
+
 // WithHonorsSessionLimits sets true if session upload limits are honored.
 func (req TorrentSetRequest) WithHonorsSessionLimits(honorsSessionLimits bool) *TorrentSetRequest {
 	req.HonorsSessionLimits = honorsSessionLimits
@@ -354,6 +373,12 @@ func (req TorrentSetRequest) WithSeedRatioMode(seedRatioMode Mode) *TorrentSetRe
 	return req.WithChanged("SeedRatioMode")
 }
 
+// WithSequentialDownload sets download torrent pieces sequentially.
+func (req TorrentSetRequest) WithSequentialDownload(sequentialDownload bool) *TorrentSetRequest {
+	req.SequentialDownload = sequentialDownload
+	return req.WithChanged("SequentialDownload")
+}
+
 // WithTrackerAdd sets strings of announce URLs to add.
 func (req TorrentSetRequest) WithTrackerAdd(trackerAdd ...string) *TorrentSetRequest {
 	req.TrackerAdd = trackerAdd
@@ -366,6 +391,13 @@ func (req TorrentSetRequest) WithTrackerRemove(trackerRemove ...int64) *TorrentS
 	return req.WithChanged("TrackerRemove")
 }
 
+// WithTrackerList sets string of announce URLs, one per line, and a blank line
+// between tiers.
+func (req TorrentSetRequest) WithTrackerList(trackerList string) *TorrentSetRequest {
+	req.TrackerList = trackerList
+	return req.WithChanged("TrackerList")
+}
+
 // WithTrackerReplace sets pairs of <trackerId/new announce URLs>.
 func (req TorrentSetRequest) WithTrackerReplace(trackerReplace ...interface{}) *TorrentSetRequest {
 	req.TrackerReplace = trackerReplace
@@ -405,7 +437,11 @@ func (req TorrentGetRequest) WithFields(fields ...string) *TorrentGetRequest {
 func (req *TorrentGetRequest) Do(ctx context.Context, cl *Client) (*TorrentGetResponse, error) {
 	fields := req.fields
 	if fields == nil {
-		fields = DefaultTorrentGetFields()
+		version, err := cl.Version(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fields = TorrentGetFields(version.RPCVersion)
 	}
 	params := map[string]interface{}{
 		"fields": fields,
@@ -453,10 +489,29 @@ func DefaultTorrentGetFields() []string {
 	}
 }
 
+// TorrentGetFields returns the list of all torrent field names supported by
+// the rpc version.
+func TorrentGetFields(rpcVersion int64) []string {
+	fields := DefaultTorrentGetFields()
+	if rpcVersion >= 17 {
+		fields = append(fields,
+			"availability", "file-count", "group",
+			"primary-mime-type", "trackerList",
+		)
+	}
+	if rpcVersion >= 18 {
+		fields = append(fields, "sequentialDownload")
+	}
+	return fields
+}
+
 // TorrentGetResponse is the torrent get response.
 type TorrentGetResponse struct {
 	Torrents []Torrent     `json:"torrents,omitempty" yaml:"torrents,omitempty"` // contains the key/value pairs matching the request's "fields" argument
 	Removed  []interface{} `json:"removed,omitempty" yaml:"removed,omitempty"`   // populated when the requested id was "recently-active"
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // TorrentAddRequest is the torrent add request.
@@ -588,6 +643,9 @@ func (req TorrentAddRequest) WithPriorityNormal(priorityNormal []int64) *Torrent
 type TorrentAddResponse struct {
 	TorrentAdded     *Torrent `json:"torrent-added" yaml:"torrent-added"`
 	TorrentDuplicate *Torrent `json:"torrent-duplicate" yaml:"torrent-duplicate"`
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // TorrentRemoveRequest is the torrent remove request.
@@ -721,55 +779,66 @@ func (t *Encryption) UnmarshalJSON(buf []byte) error {
 
 // Session holds transmission rpc session arguments.
 type Session struct {
-	AltSpeedDown              int64      `json:"alt-speed-down,omitempty" yaml:"alt-speed-down,omitempty"`                             // max global download speed (KBps)
-	AltSpeedEnabled           bool       `json:"alt-speed-enabled,omitempty" yaml:"alt-speed-enabled,omitempty"`                       // true means use the alt speeds
-	AltSpeedTimeBegin         int64      `json:"alt-speed-time-begin,omitempty" yaml:"alt-speed-time-begin,omitempty"`                 // when to turn on alt speeds (units: minutes after midnight)
-	AltSpeedTimeEnabled       bool       `json:"alt-speed-time-enabled,omitempty" yaml:"alt-speed-time-enabled,omitempty"`             // true means the scheduled on/off times are used
-	AltSpeedTimeEnd           int64      `json:"alt-speed-time-end,omitempty" yaml:"alt-speed-time-end,omitempty"`                     // when to turn off alt speeds (units: same)
-	AltSpeedTimeDay           int64      `json:"alt-speed-time-day,omitempty" yaml:"alt-speed-time-day,omitempty"`                     // what day(s) to turn on alt speeds (look at tr_sched_day)
-	AltSpeedUp                int64      `json:"alt-speed-up,omitempty" yaml:"alt-speed-up,omitempty"`                                 // max global upload speed (KBps)
-	BlocklistURL              string     `json:"blocklist-url,omitempty" yaml:"blocklist-url,omitempty"`                               // location of the blocklist to use for "blocklist-update"
-	BlocklistEnabled          bool       `json:"blocklist-enabled,omitempty" yaml:"blocklist-enabled,omitempty"`                       // true means enabled
-	BlocklistSize             int64      `json:"blocklist-size,omitempty" yaml:"blocklist-size,omitempty"`                             // number of rules in the blocklist
-	CacheSizeMb               int64      `json:"cache-size-mb,omitempty" yaml:"cache-size-mb,omitempty"`                               // maximum size of the disk cache (MB)
-	ConfigDir                 string     `json:"config-dir,omitempty" yaml:"config-dir,omitempty"`                                     // location of transmission's configuration directory
-	DownloadDir               string     `json:"download-dir,omitempty" yaml:"download-dir,omitempty"`                                 // default path to download torrents
-	DownloadQueueSize         int64      `json:"download-queue-size,omitempty" yaml:"download-queue-size,omitempty"`                   // max number of torrents to download at once (see download-queue-enabled)
-	DownloadQueueEnabled      bool       `json:"download-queue-enabled,omitempty" yaml:"download-queue-enabled,omitempty"`             // if true, limit how many torrents can be downloaded at once
-	DownloadDirFreeSpace      int64      `json:"download-dir-free-space,omitempty" yaml:"download-dir-free-space,omitempty"`           // ---- not documented ----
-	DhtEnabled                bool       `json:"dht-enabled,omitempty" yaml:"dht-enabled,omitempty"`                                   // true means allow dht in public torrents
-	Encryption                Encryption `json:"encryption,omitempty" yaml:"encryption,omitempty"`                                     // "required", "preferred", "tolerated"
-	IdleSeedingLimit          int64      `json:"idle-seeding-limit,omitempty" yaml:"idle-seeding-limit,omitempty"`                     // torrents we're seeding will be stopped if they're idle for this long
-	IdleSeedingLimitEnabled   bool       `json:"idle-seeding-limit-enabled,omitempty" yaml:"idle-seeding-limit-enabled,omitempty"`     // true if the seeding inactivity limit is honored by default
-	IncompleteDir             string     `json:"incomplete-dir,omitempty" yaml:"incomplete-dir,omitempty"`                             // path for incomplete torrents, when enabled
-	IncompleteDirEnabled      bool       `json:"incomplete-dir-enabled,omitempty" yaml:"incomplete-dir-enabled,omitempty"`             // true means keep torrents in incomplete-dir until done
-	LpdEnabled                bool       `json:"lpd-enabled,omitempty" yaml:"lpd-enabled,omitempty"`                                   // true means allow Local Peer Discovery in public torrents
-	PeerLimitGlobal           int64      `json:"peer-limit-global,omitempty" yaml:"peer-limit-global,omitempty"`                       // maximum global number of peers
-	PeerLimitPerTorrent       int64      `json:"peer-limit-per-torrent,omitempty" yaml:"peer-limit-per-torrent,omitempty"`             // maximum global number of peers
-	PexEnabled                bool       `json:"pex-enabled,omitempty" yaml:"pex-enabled,omitempty"`                                   // true means allow pex in public torrents
-	PeerPort                  int64      `json:"peer-port,omitempty" yaml:"peer-port,omitempty"`                                       // port number
-	PeerPortRandomOnStart     bool       `json:"peer-port-random-on-start,omitempty" yaml:"peer-port-random-on-start,omitempty"`       // true means pick a random peer port on launch
-	PortForwardingEnabled     bool       `json:"port-forwarding-enabled,omitempty" yaml:"port-forwarding-enabled,omitempty"`           // true means enabled
-	QueueStalledEnabled       bool       `json:"queue-stalled-enabled,omitempty" yaml:"queue-stalled-enabled,omitempty"`               // whether or not to consider idle torrents as stalled
-	QueueStalledMinutes       int64      `json:"queue-stalled-minutes,omitempty" yaml:"queue-stalled-minutes,omitempty"`               // torrents that are idle for N minutes aren't counted toward seed-queue-size or download-queue-size
-	RenamePartialFiles        bool       `json:"rename-partial-files,omitempty" yaml:"rename-partial-files,omitempty"`                 // true means append ".part" to incomplete files
-	RPCVersion                int64      `json:"rpc-version,omitempty" yaml:"rpc-version,omitempty"`                                   // the current RPC API version
-	RPCVersionMinimum         int64      `json:"rpc-version-minimum,omitempty" yaml:"rpc-version-minimum,omitempty"`                   // the minimum RPC API version supported
-	ScriptTorrentDoneFilename string     `json:"script-torrent-done-filename,omitempty" yaml:"script-torrent-done-filename,omitempty"` // filename of the script to run
-	ScriptTorrentDoneEnabled  bool       `json:"script-torrent-done-enabled,omitempty" yaml:"script-torrent-done-enabled,omitempty"`   // whether or not to call the "done" script
-	SeedRatioLimit            float64    `json:"seedRatioLimit,omitempty" yaml:"seedRatioLimit,omitempty"`                             // the default seed ratio for torrents to use
-	SeedRatioLimited          bool       `json:"seedRatioLimited,omitempty" yaml:"seedRatioLimited,omitempty"`                         // true if seedRatioLimit is honored by default
-	SeedQueueSize             int64      `json:"seed-queue-size,omitempty" yaml:"seed-queue-size,omitempty"`                           // max number of torrents to uploaded at once (see seed-queue-enabled)
-	SeedQueueEnabled          bool       `json:"seed-queue-enabled,omitempty" yaml:"seed-queue-enabled,omitempty"`                     // if true, limit how many torrents can be uploaded at once
-	SpeedLimitDown            int64      `json:"speed-limit-down,omitempty" yaml:"speed-limit-down,omitempty"`                         // max global download speed (KBps)
-	SpeedLimitDownEnabled     bool       `json:"speed-limit-down-enabled,omitempty" yaml:"speed-limit-down-enabled,omitempty"`         // true means enabled
-	SpeedLimitUp              int64      `json:"speed-limit-up,omitempty" yaml:"speed-limit-up,omitempty"`                             // max global upload speed (KBps)
-	SpeedLimitUpEnabled       bool       `json:"speed-limit-up-enabled,omitempty" yaml:"speed-limit-up-enabled,omitempty"`             // true means enabled
-	StartAddedTorrents        bool       `json:"start-added-torrents,omitempty" yaml:"start-added-torrents,omitempty"`                 // true means added torrents will be started right away
-	TrashOriginalTorrentFiles bool       `json:"trash-original-torrent-files,omitempty" yaml:"trash-original-torrent-files,omitempty"` // true means the .torrent file of added torrents will be deleted
-	Units                     Units      `json:"units,omitempty" yaml:"units,omitempty"`                                               // see units below
-	UtpEnabled                bool       `json:"utp-enabled,omitempty" yaml:"utp-enabled,omitempty"`                                   // true means allow utp
-	Version                   string     `json:"version,omitempty" yaml:"version,omitempty"`                                           // long version string "$version ($revision)"
+	AltSpeedDown                     int64      `json:"alt-speed-down,omitempty" yaml:"alt-speed-down,omitempty"`                                             // max global download speed (KBps)
+	AltSpeedEnabled                  bool       `json:"alt-speed-enabled,omitempty" yaml:"alt-speed-enabled,omitempty"`                                       // true means use the alt speeds
+	AltSpeedTimeBegin                int64      `json:"alt-speed-time-begin,omitempty" yaml:"alt-speed-time-begin,omitempty"`                                 // when to turn on alt speeds (units: minutes after midnight)
+	AltSpeedTimeEnabled              bool       `json:"alt-speed-time-enabled,omitempty" yaml:"alt-speed-time-enabled,omitempty"`                             // true means the scheduled on/off times are used
+	AltSpeedTimeEnd                  int64      `json:"alt-speed-time-end,omitempty" yaml:"alt-speed-time-end,omitempty"`                                     // when to turn off alt speeds (units: same)
+	AltSpeedTimeDay                  int64      `json:"alt-speed-time-day,omitempty" yaml:"alt-speed-time-day,omitempty"`                                     // what day(s) to turn on alt speeds (look at tr_sched_day)
+	AltSpeedUp                       int64      `json:"alt-speed-up,omitempty" yaml:"alt-speed-up,omitempty"`                                                 // max global upload speed (KBps)
+	BlocklistURL                     string     `json:"blocklist-url,omitempty" yaml:"blocklist-url,omitempty"`                                               // location of the blocklist to use for "blocklist-update"
+	BlocklistEnabled                 bool       `json:"blocklist-enabled,omitempty" yaml:"blocklist-enabled,omitempty"`                                       // true means enabled
+	BlocklistSize                    int64      `json:"blocklist-size,omitempty" yaml:"blocklist-size,omitempty"`                                             // number of rules in the blocklist
+	CacheSizeMb                      int64      `json:"cache-size-mb,omitempty" yaml:"cache-size-mb,omitempty"`                                               // maximum size of the disk cache (MB)
+	ConfigDir                        string     `json:"config-dir,omitempty" yaml:"config-dir,omitempty"`                                                     // location of transmission's configuration directory
+	DefaultTrackers                  string     `json:"default-trackers,omitempty" yaml:"default-trackers,omitempty"`                                         // announce URLs, one per line, and a blank line between tiers
+	DownloadDir                      string     `json:"download-dir,omitempty" yaml:"download-dir,omitempty"`                                                 // default path to download torrents
+	DownloadQueueSize                int64      `json:"download-queue-size,omitempty" yaml:"download-queue-size,omitempty"`                                   // max number of torrents to download at once (see download-queue-enabled)
+	DownloadQueueEnabled             bool       `json:"download-queue-enabled,omitempty" yaml:"download-queue-enabled,omitempty"`                             // if true, limit how many torrents can be downloaded at once
+	DownloadDirFreeSpace             int64      `json:"download-dir-free-space,omitempty" yaml:"download-dir-free-space,omitempty"`                           // ---- not documented ----
+	DhtEnabled                       bool       `json:"dht-enabled,omitempty" yaml:"dht-enabled,omitempty"`                                                   // true means allow dht in public torrents
+	Encryption                       Encryption `json:"encryption,omitempty" yaml:"encryption,omitempty"`                                                     // "required", "preferred", "tolerated"
+	IdleSeedingLimit                 int64      `json:"idle-seeding-limit,omitempty" yaml:"idle-seeding-limit,omitempty"`                                     // torrents we're seeding will be stopped if they're idle for this long
+	IdleSeedingLimitEnabled          bool       `json:"idle-seeding-limit-enabled,omitempty" yaml:"idle-seeding-limit-enabled,omitempty"`                     // true if the seeding inactivity limit is honored by default
+	IncompleteDir                    string     `json:"incomplete-dir,omitempty" yaml:"incomplete-dir,omitempty"`                                             // path for incomplete torrents, when enabled
+	IncompleteDirEnabled             bool       `json:"incomplete-dir-enabled,omitempty" yaml:"incomplete-dir-enabled,omitempty"`                             // true means keep torrents in incomplete-dir until done
+	LpdEnabled                       bool       `json:"lpd-enabled,omitempty" yaml:"lpd-enabled,omitempty"`                                                   // true means allow Local Peer Discovery in public torrents
+	PeerLimitGlobal                  int64      `json:"peer-limit-global,omitempty" yaml:"peer-limit-global,omitempty"`                                       // maximum global number of peers
+	PeerLimitPerTorrent              int64      `json:"peer-limit-per-torrent,omitempty" yaml:"peer-limit-per-torrent,omitempty"`                             // maximum global number of peers
+	PexEnabled                       bool       `json:"pex-enabled,omitempty" yaml:"pex-enabled,omitempty"`                                                   // true means allow pex in public torrents
+	PeerPort                         int64      `json:"peer-port,omitempty" yaml:"peer-port,omitempty"`                                                       // port number
+	PeerPortRandomOnStart            bool       `json:"peer-port-random-on-start,omitempty" yaml:"peer-port-random-on-start,omitempty"`                       // true means pick a random peer port on launch
+	PortForwardingEnabled            bool       `json:"port-forwarding-enabled,omitempty" yaml:"port-forwarding-enabled,omitempty"`                           // true means enabled
+	QueueStalledEnabled              bool       `json:"queue-stalled-enabled,omitempty" yaml:"queue-stalled-enabled,omitempty"`                               // whether or not to consider idle torrents as stalled
+	QueueStalledMinutes              int64      `json:"queue-stalled-minutes,omitempty" yaml:"queue-stalled-minutes,omitempty"`                               // torrents that are idle for N minutes aren't counted toward seed-queue-size or download-queue-size
+	RenamePartialFiles               bool       `json:"rename-partial-files,omitempty" yaml:"rename-partial-files,omitempty"`                                 // true means append ".part" to incomplete files
+	RPCVersion                       int64      `json:"rpc-version,omitempty" yaml:"rpc-version,omitempty"`                                                   // the current RPC API version
+	RPCVersionMinimum                int64      `json:"rpc-version-minimum,omitempty" yaml:"rpc-version-minimum,omitempty"`                                   // the minimum RPC API version supported
+	RPCVersionSemver                 string     `json:"rpc-version-semver,omitempty" yaml:"rpc-version-semver,omitempty"`                                     // the current RPC API version in semver format
+	ScriptTorrentAddedFilename       string     `json:"script-torrent-added-filename,omitempty" yaml:"script-torrent-added-filename,omitempty"`               // filename of the script to run
+	ScriptTorrentAddedEnabled        bool       `json:"script-torrent-added-enabled,omitempty" yaml:"script-torrent-added-enabled,omitempty"`                 // whether or not to call the "added" script
+	ScriptTorrentDoneFilename        string     `json:"script-torrent-done-filename,omitempty" yaml:"script-torrent-done-filename,omitempty"`                 // filename of the script to run
+	ScriptTorrentDoneEnabled         bool       `json:"script-torrent-done-enabled,omitempty" yaml:"script-torrent-done-enabled,omitempty"`                   // whether or not to call the "done" script
+	ScriptTorrentDoneSeedingFilename string     `json:"script-torrent-done-seeding-filename,omitempty" yaml:"script-torrent-done-seeding-filename,omitempty"` // filename of the script to run
+	ScriptTorrentDoneSeedingEnabled  bool       `json:"script-torrent-done-seeding-enabled,omitempty" yaml:"script-torrent-done-seeding-enabled,omitempty"`   // whether or not to call the "done seeding" script
+	SeedRatioLimit                   float64    `json:"seedRatioLimit,omitempty" yaml:"seedRatioLimit,omitempty"`                                             // the default seed ratio for torrents to use
+	SeedRatioLimited                 bool       `json:"seedRatioLimited,omitempty" yaml:"seedRatioLimited,omitempty"`                                         // true if seedRatioLimit is honored by default
+	SeedQueueSize                    int64      `json:"seed-queue-size,omitempty" yaml:"seed-queue-size,omitempty"`                                           // max number of torrents to uploaded at once (see seed-queue-enabled)
+	SeedQueueEnabled                 bool       `json:"seed-queue-enabled,omitempty" yaml:"seed-queue-enabled,omitempty"`                                     // if true, limit how many torrents can be uploaded at once
+	SessionID                        string     `json:"session-id,omitempty" yaml:"session-id,omitempty"`                                                     // the current session ID
+	SpeedLimitDown                   int64      `json:"speed-limit-down,omitempty" yaml:"speed-limit-down,omitempty"`                                         // max global download speed (KBps)
+	SpeedLimitDownEnabled            bool       `json:"speed-limit-down-enabled,omitempty" yaml:"speed-limit-down-enabled,omitempty"`                         // true means enabled
+	SpeedLimitUp                     int64      `json:"speed-limit-up,omitempty" yaml:"speed-limit-up,omitempty"`                                             // max global upload speed (KBps)
+	SpeedLimitUpEnabled              bool       `json:"speed-limit-up-enabled,omitempty" yaml:"speed-limit-up-enabled,omitempty"`                             // true means enabled
+	StartAddedTorrents               bool       `json:"start-added-torrents,omitempty" yaml:"start-added-torrents,omitempty"`                                 // true means added torrents will be started right away
+	TCPEnabled                       bool       `json:"tcp-enabled,omitempty" yaml:"tcp-enabled,omitempty"`                                                   // true means allow tcp
+	TrashOriginalTorrentFiles        bool       `json:"trash-original-torrent-files,omitempty" yaml:"trash-original-torrent-files,omitempty"`                 // true means the .torrent file of added torrents will be deleted
+	Units                            Units      `json:"units,omitempty" yaml:"units,omitempty"`                                                               // see units below
+	UtpEnabled                       bool       `json:"utp-enabled,omitempty" yaml:"utp-enabled,omitempty"`                                                   // true means allow utp
+	Version                          string     `json:"version,omitempty" yaml:"version,omitempty"`                                                           // long version string "$version ($revision)"
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // Units are session units.
@@ -837,6 +906,9 @@ func (req *SessionSetRequest) Do(ctx context.Context, cl *Client) error {
 	if req.changed["ConfigDir"] {
 		params["config-dir"] = req.ConfigDir
 	}
+	if req.changed["DefaultTrackers"] {
+		params["default-trackers"] = req.DefaultTrackers
+	}
 	if req.changed["DownloadDir"] {
 		params["download-dir"] = req.DownloadDir
 	}
@@ -909,6 +981,18 @@ func (req *SessionSetRequest) Do(ctx context.Context, cl *Client) error {
 	if req.changed["ScriptTorrentDoneEnabled"] {
 		params["script-torrent-done-enabled"] = req.ScriptTorrentDoneEnabled
 	}
+	if req.changed["ScriptTorrentDoneSeedingFilename"] {
+		params["script-torrent-done-seeding-filename"] = req.ScriptTorrentDoneSeedingFilename
+	}
+	if req.changed["ScriptTorrentDoneSeedingEnabled"] {
+		params["script-torrent-done-seeding-enabled"] = req.ScriptTorrentDoneSeedingEnabled
+	}
+	if req.changed["ScriptTorrentAddedFilename"] {
+		params["script-torrent-added-filename"] = req.ScriptTorrentAddedFilename
+	}
+	if req.changed["ScriptTorrentAddedEnabled"] {
+		params["script-torrent-added-enabled"] = req.ScriptTorrentAddedEnabled
+	}
 	if req.changed["SeedRatioLimit"] {
 		params["seedRatioLimit"] = req.SeedRatioLimit
 	}
@@ -936,6 +1020,9 @@ func (req *SessionSetRequest) Do(ctx context.Context, cl *Client) error {
 	if req.changed["StartAddedTorrents"] {
 		params["start-added-torrents"] = req.StartAddedTorrents
 	}
+	if req.changed["TCPEnabled"] {
+		params["tcp-enabled"] = req.TCPEnabled
+	}
 	if req.changed["TrashOriginalTorrentFiles"] {
 		params["trash-original-torrent-files"] = req.TrashOriginalTorrentFiles
 	}
@@ -1037,6 +1124,13 @@ func (req SessionSetRequest) WithDownloadQueueEnabled(downloadQueueEnabled bool)
 	return req.WithChanged("DownloadQueueEnabled")
 }
 
+// WithDefaultTrackers sets announce URLs, one per line, and a blank line
+// between tiers.
+func (req SessionSetRequest) WithDefaultTrackers(defaultTrackers string) *SessionSetRequest {
+	req.DefaultTrackers = defaultTrackers
+	return req.WithChanged("DefaultTrackers")
+}
+
 // WithDhtEnabled sets true means allow dht in public torrents.
 func (req SessionSetRequest) WithDhtEnabled(dhtEnabled bool) *SessionSetRequest {
 	req.DhtEnabled = dhtEnabled
@@ -1145,6 +1239,31 @@ func (req SessionSetRequest) WithScriptTorrentDoneEnabled(scriptTorrentDoneEnabl
 	return req.WithChanged("ScriptTorrentDoneEnabled")
 }
 
+// WithScriptTorrentDoneSeedingFilename sets filename of the script to run.
+func (req SessionSetRequest) WithScriptTorrentDoneSeedingFilename(scriptTorrentDoneSeedingFilename string) *SessionSetRequest {
+	req.ScriptTorrentDoneSeedingFilename = scriptTorrentDoneSeedingFilename
+	return req.WithChanged("ScriptTorrentDoneSeedingFilename")
+}
+
+// WithScriptTorrentDoneSeedingEnabled sets whether or not to call the "done
+// seeding" script.
+func (req SessionSetRequest) WithScriptTorrentDoneSeedingEnabled(scriptTorrentDoneSeedingEnabled bool) *SessionSetRequest {
+	req.ScriptTorrentDoneSeedingEnabled = scriptTorrentDoneSeedingEnabled
+	return req.WithChanged("ScriptTorrentDoneSeedingEnabled")
+}
+
+// WithScriptTorrentAddedFilename sets filename of the script to run.
+func (req SessionSetRequest) WithScriptTorrentAddedFilename(scriptTorrentAddedFilename string) *SessionSetRequest {
+	req.ScriptTorrentAddedFilename = scriptTorrentAddedFilename
+	return req.WithChanged("ScriptTorrentAddedFilename")
+}
+
+// WithScriptTorrentAddedEnabled sets whether or not to call the "added" script.
+func (req SessionSetRequest) WithScriptTorrentAddedEnabled(scriptTorrentAddedEnabled bool) *SessionSetRequest {
+	req.ScriptTorrentAddedEnabled = scriptTorrentAddedEnabled
+	return req.WithChanged("ScriptTorrentAddedEnabled")
+}
+
 // WithSeedRatioLimit sets the default seed ratio for torrents to use.
 func (req SessionSetRequest) WithSeedRatioLimit(seedRatioLimit float64) *SessionSetRequest {
 	req.SeedRatioLimit = seedRatioLimit
@@ -1199,6 +1318,12 @@ func (req SessionSetRequest) WithStartAddedTorrents(startAddedTorrents bool) *Se
 	return req.WithChanged("StartAddedTorrents")
 }
 
+// WithTCPEnabled sets true means allow tcp.
+func (req SessionSetRequest) WithTCPEnabled(tcpEnabled bool) *SessionSetRequest {
+	req.TCPEnabled = tcpEnabled
+	return req.WithChanged("TCPEnabled")
+}
+
 // WithTrashOriginalTorrentFiles sets true means the .torrent file of added torrents will be deleted.
 func (req SessionSetRequest) WithTrashOriginalTorrentFiles(trashOriginalTorrentFiles bool) *SessionSetRequest {
 	req.TrashOriginalTorrentFiles = trashOriginalTorrentFiles
@@ -1276,6 +1401,9 @@ type SessionStatsResponse struct {
 		SessionCount    int64     `json:"sessionCount,omitempty" yaml:"sessionCount,omitempty"`       // tr_session_stats
 		SecondsActive   Duration  `json:"secondsActive,omitempty" yaml:"secondsActive,omitempty"`     // tr_session_stats
 	} `json:"current-stats,omitempty" yaml:"current-stats,omitempty"`
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
 }
 
 // BlocklistUpdateRequest is the blocklist update request.
@@ -1394,3 +1522,117 @@ func (req *FreeSpaceRequest) Do(ctx context.Context, cl *Client) (ByteCount, err
 	}
 	return res.SizeBytes, nil
 }
+
+// Group is a bandwidth group.
+type Group struct {
+	HonorsSessionLimits   bool   `json:"honorsSessionLimits,omitempty" yaml:"honorsSessionLimits,omitempty"`           // true if session upload limits are honored
+	Name                  string `json:"name,omitempty" yaml:"name,omitempty"`                                         // bandwidth group name
+	SpeedLimitDown        int64  `json:"speed-limit-down,omitempty" yaml:"speed-limit-down,omitempty"`                 // max global download speed (KBps)
+	SpeedLimitDownEnabled bool   `json:"speed-limit-down-enabled,omitempty" yaml:"speed-limit-down-enabled,omitempty"` // true means enabled
+	SpeedLimitUp          int64  `json:"speed-limit-up,omitempty" yaml:"speed-limit-up,omitempty"`                     // max global upload speed (KBps)
+	SpeedLimitUpEnabled   bool   `json:"speed-limit-up-enabled,omitempty" yaml:"speed-limit-up-enabled,omitempty"`     // true means enabled
+
+	// Extra are the unknown fields encountered when decoding leniently.
+	Extra map[string]json.RawMessage `json:"-" yaml:"-"`
+}
+
+// GroupGetRequest is the group get request.
+type GroupGetRequest struct {
+	names []string
+}
+
+// GroupGet creates a group get request for the named bandwidth groups. When
+// no names are specified, all bandwidth groups are returned.
+func GroupGet(names ...string) *GroupGetRequest {
+	return &GroupGetRequest{names: names}
+}
+
+// Do executes the group get request against the provided context and client.
+func (req *GroupGetRequest) Do(ctx context.Context, cl *Client) ([]Group, error) {
+	params := map[string]interface{}{}
+	if len(req.names) != 0 {
+		params["group"] = req.names
+	}
+	var res struct {
+		Group []Group `json:"group,omitempty" yaml:"group,omitempty"`
+	}
+	if err := cl.Do(ctx, "group-get", params, &res); err != nil {
+		return nil, err
+	}
+	return res.Group, nil
+}
+
+// GroupSetRequest is the group set request.
+type GroupSetRequest struct {
+	Group
+	changed map[string]bool
+}
+
+// GroupSet creates a group set request for the named bandwidth group.
+func GroupSet(name string) *GroupSetRequest {
+	return &GroupSetRequest{
+		Group:   Group{Name: name},
+		changed: make(map[string]bool),
+	}
+}
+
+// Do executes the group set request against the provided context and client.
+func (req *GroupSetRequest) Do(ctx context.Context, cl *Client) error {
+	params := map[string]interface{}{
+		"name": req.Name,
+	}
+	if req.changed["HonorsSessionLimits"] {
+		params["honorsSessionLimits"] = req.HonorsSessionLimits
+	}
+	if req.changed["SpeedLimitDown"] {
+		params["speed-limit-down"] = req.SpeedLimitDown
+	}
+	if req.changed["SpeedLimitDownEnabled"] {
+		params["speed-limit-down-enabled"] = req.SpeedLimitDownEnabled
+	}
+	if req.changed["SpeedLimitUp"] {
+		params["speed-limit-up"] = req.SpeedLimitUp
+	}
+	if req.changed["SpeedLimitUpEnabled"] {
+		params["speed-limit-up-enabled"] = req.SpeedLimitUpEnabled
+	}
+	return cl.Do(ctx, "group-set", params, nil)
+}
+
+// WithChanged marks the fields that were changed.
+func (req *GroupSetRequest) WithChanged(fields ...string) *GroupSetRequest {
+	for _, field := range fields {
+		req.changed[field] = true
+	}
+	return req
+}
+
+// WithHonorsSessionLimits sets true if session upload limits are honored.
+func (req GroupSetRequest) WithHonorsSessionLimits(honorsSessionLimits bool) *GroupSetRequest {
+	req.HonorsSessionLimits = honorsSessionLimits
+	return req.WithChanged("HonorsSessionLimits")
+}
+
+// WithSpeedLimitDown sets max global download speed (KBps).
+func (req GroupSetRequest) WithSpeedLimitDown(speedLimitDown int64) *GroupSetRequest {
+	req.SpeedLimitDown = speedLimitDown
+	return req.WithChanged("SpeedLimitDown")
+}
+
+// WithSpeedLimitDownEnabled sets true means enabled.
+func (req GroupSetRequest) WithSpeedLimitDownEnabled(speedLimitDownEnabled bool) *GroupSetRequest {
+	req.SpeedLimitDownEnabled = speedLimitDownEnabled
+	return req.WithChanged("SpeedLimitDownEnabled")
+}
+
+// WithSpeedLimitUp sets max global upload speed (KBps).
+func (req GroupSetRequest) WithSpeedLimitUp(speedLimitUp int64) *GroupSetRequest {
+	req.SpeedLimitUp = speedLimitUp
+	return req.WithChanged("SpeedLimitUp")
+}
+
+// WithSpeedLimitUpEnabled sets true means enabled.
+func (req GroupSetRequest) WithSpeedLimitUpEnabled(speedLimitUpEnabled bool) *GroupSetRequest {
+	req.SpeedLimitUpEnabled = speedLimitUpEnabled
+	return req.WithChanged("SpeedLimitUpEnabled")
+}
@@ -1,9 +1,31 @@
 package transrpc
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/kenshaw/transctl/tctypes"
+	"github.com/kenshaw/transctl/transctltest"
 )
 
 func TestUnmarshalJSON(t *testing.T) {
@@ -20,6 +42,471 @@ func TestUnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		rpcVersion int64
+		semver     string
+		snakeCase  bool
+		method     string
+		field      string
+		res        string
+	}{
+		{15, "", false, "torrent-get", "peer-limit", `{"torrents": [{"hashString": "abc", "peer-limit": 5}]}`},
+		{17, "5.3.0", false, "torrent-get", "primary-mime-type", `{"torrents": [{"hashString": "abc", "peer-limit": 5, "primary-mime-type": "video/mp4"}]}`},
+		{18, "6.0.0", true, "torrent_get", "primary_mime_type", `{"torrents": [{"hash_string": "abc", "peer_limit": 5, "primary_mime_type": "video/mp4"}]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.semver, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				var v struct {
+					Method    string `json:"method"`
+					Arguments struct {
+						Fields []string `json:"fields"`
+					} `json:"arguments"`
+				}
+				if err := json.NewDecoder(req.Body).Decode(&v); err != nil {
+					http.Error(res, err.Error(), http.StatusBadRequest)
+					return
+				}
+				args := map[string]interface{}{
+					"rpc-version":         test.rpcVersion,
+					"rpc-version-minimum": 14,
+					"version":             "test",
+				}
+				if test.semver != "" {
+					args["rpc-version-semver"] = test.semver
+				}
+				switch v.Method {
+				case "session-get":
+				case test.method:
+					if !contains(v.Arguments.Fields, test.field) {
+						t.Errorf("expected fields to contain %q, got: %v", test.field, v.Arguments.Fields)
+					}
+					args = nil
+					if err := json.Unmarshal([]byte(test.res), &args); err != nil {
+						http.Error(res, err.Error(), http.StatusInternalServerError)
+						return
+					}
+				default:
+					t.Errorf("unexpected method %q", v.Method)
+				}
+				_ = json.NewEncoder(res).Encode(map[string]interface{}{
+					"result":    "success",
+					"arguments": args,
+				})
+			}))
+			defer s.Close()
+			cl := NewClient(WithURL(s.URL))
+			res, err := cl.TorrentGet(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			version, err := cl.Version(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if version.RPCVersion != test.rpcVersion || version.SnakeCase != test.snakeCase {
+				t.Errorf("expected rpc version %d (snake case: %t), got: %+v", test.rpcVersion, test.snakeCase, version)
+			}
+			if len(res.Torrents) != 1 {
+				t.Fatalf("expected 1 torrent, got: %d", len(res.Torrents))
+			}
+			if torrent := res.Torrents[0]; torrent.HashString != "abc" || torrent.PeerLimit != 5 {
+				t.Errorf("expected hash abc and peer limit 5, got: %q %d", torrent.HashString, torrent.PeerLimit)
+			}
+		})
+	}
+}
+
+func TestLenient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"result": "success", "arguments": {"torrents": [
+			{"hashString": "abc", "name": "a", "fooBar": 1, "trackerStats": [{"announce": "http://x/announce", "baz": true}]}
+		], "qux": "quux"}}`))
+	}))
+	defer s.Close()
+	version := &Version{RPCVersion: 16}
+
+	// strict
+	if _, err := NewClient(WithURL(s.URL), WithVersion(version)).TorrentGet(context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	// lenient
+	var logged string
+	cl := NewClient(WithURL(s.URL), WithVersion(version), WithLenient(func(s string, v ...interface{}) {
+		logged = fmt.Sprintf(s, v...)
+	}))
+	res, err := cl.TorrentGet(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(res.Torrents) != 1 || res.Torrents[0].HashString != "abc" {
+		t.Fatalf("expected torrent abc, got: %+v", res.Torrents)
+	}
+	if v := string(res.Torrents[0].Extra["fooBar"]); v != "1" {
+		t.Errorf("expected extra fooBar to be 1, got: %q", v)
+	}
+	if v := string(res.Extra["qux"]); v != `"quux"` {
+		t.Errorf("expected extra qux to be \"quux\", got: %q", v)
+	}
+	if exp := "torrent-get: unknown fields: qux, torrents.fooBar, torrents.trackerStats.baz"; logged != exp {
+		t.Errorf("expected %q, got: %q", exp, logged)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var count int64
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch n := atomic.AddInt64(&count, 1); {
+		case req.Header.Get(csrfHeader) != "csrf":
+			res.Header().Set(csrfHeader, "csrf")
+			res.WriteHeader(http.StatusConflict)
+		case n < 4:
+			http.Error(res, "unavailable", http.StatusServiceUnavailable)
+		default:
+			_, _ = res.Write([]byte(`{"result": "success", "arguments": {"port-is-open": true}}`))
+		}
+	}))
+	defer s.Close()
+	policy := tctypes.RetryPolicy{Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+	version := &Version{RPCVersion: 16}
+
+	// idempotent: 409 resent immediately, then 503 retried with backoff
+	cl := NewClient(WithURL(s.URL), WithVersion(version), WithRetryPolicy(policy))
+	ok, err := cl.PortTest(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected port-is-open to be true")
+	}
+	if n := atomic.LoadInt64(&count); n != 4 {
+		t.Errorf("expected 4 requests, got: %d", n)
+	}
+
+	// non-idempotent: not retried
+	atomic.StoreInt64(&count, 1)
+	_, err = cl.TorrentAdd(context.Background(), TorrentAdd().WithFilename("magnet:?xt=urn:btih:abc"))
+	if !errors.Is(err, ErrServerError) {
+		t.Errorf("expected ErrServerError, got: %v", err)
+	}
+	if n := atomic.LoadInt64(&count); n != 2 {
+		t.Errorf("expected 1 request, got: %d", n-1)
+	}
+}
+
+func TestSessionCache(t *testing.T) {
+	var count int64
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&count, 1)
+		if req.Header.Get(csrfHeader) != "csrf" {
+			res.Header().Set(csrfHeader, "csrf")
+			res.WriteHeader(http.StatusConflict)
+			return
+		}
+		_, _ = res.Write([]byte(`{"result": "success", "arguments": {"port-is-open": true}}`))
+	}))
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "transrpc-session")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := tctypes.NewSessionCache(dir, s.URL, time.Hour)
+	version := &Version{RPCVersion: 16}
+
+	for i, exp := range []int64{2, 1} {
+		atomic.StoreInt64(&count, 0)
+		cl := NewClient(WithURL(s.URL), WithVersion(version), WithSessionCache(cache))
+		if _, err := cl.PortTest(context.Background()); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if n := atomic.LoadInt64(&count); n != exp {
+			t.Errorf("test %d expected %d requests, got: %d", i, exp, n)
+		}
+	}
+	fi, err := os.Stat(cache.Path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected mode 0600, got: %o", mode)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set(csrfHeader, "csrf")
+		_, _ = res.Write([]byte(`{"result": "success", "arguments": {"rpc-password": "secret"}}`))
+	}))
+	defer s.Close()
+	version := &Version{RPCVersion: 16}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	u.User = url.UserPassword("user", "secret")
+	args := map[string]interface{}{"rpc-password": "secret"}
+
+	// log
+	var logged strings.Builder
+	cl := NewClient(WithURL(u.String()), WithVersion(version), WithLogf(func(s string, v ...interface{}) {
+		fmt.Fprintf(&logged, s, v...)
+	}))
+	if err := cl.Do(context.Background(), "session-set", args, nil); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := logged.String(); strings.Contains(v, "secret") || strings.Contains(v, "dXNlcjpzZWNyZXQ") || !strings.Contains(v, tctypes.RedactedValue) {
+		t.Errorf("expected redacted log, got: %s", v)
+	}
+
+	// har
+	dir, err := ioutil.TempDir("", "transrpc-har")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	har := tctypes.NewHARWriter(filepath.Join(dir, "out.har"), "transrpc", "0.1")
+	cl = NewClient(WithURL(u.String()), WithVersion(version), WithHAR(har))
+	for i := 0; i < 2; i++ {
+		if err := cl.Do(context.Background(), "session-set", args, nil); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "out.har"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Contains(string(buf), "secret") || strings.Contains(string(buf), "dXNlcjpzZWNyZXQ") {
+		t.Errorf("expected redacted har, got: %s", string(buf))
+	}
+	var v tctypes.HAR
+	if err := json.Unmarshal(buf, &v); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(v.Log.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got: %d", len(v.Log.Entries))
+	}
+	if entry := v.Log.Entries[0]; entry.Request.Method != "POST" || entry.Response.Status != http.StatusOK || entry.Request.PostData == nil {
+		t.Errorf("expected POST with post data and 200 response, got: %+v", entry)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"result": "success", "arguments": {"port-is-open": true}}`))
+	}))
+	defer s.Close()
+
+	// write ca bundle
+	f, err := ioutil.TempFile("", "transrpc-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(s.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+	badPin := "sha256//" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name string
+		opts *tctypes.TLSOptions
+		err  bool
+	}{
+		{"none", nil, true},
+		{"insecure", &tctypes.TLSOptions{Insecure: true}, false},
+		{"cacert", &tctypes.TLSOptions{CACert: f.Name()}, false},
+		{"pin", &tctypes.TLSOptions{CACert: f.Name(), Pins: []string{pin}}, false},
+		{"insecure pin", &tctypes.TLSOptions{Insecure: true, Pins: []string{pin}}, false},
+		{"bad pin", &tctypes.TLSOptions{Insecure: true, Pins: []string{badPin}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := []ClientOption{WithURL(s.URL), WithVersion(&Version{RPCVersion: 16})}
+			if test.opts != nil {
+				cfg, err := test.opts.Config()
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				opts = append(opts, WithTLSConfig(cfg))
+			}
+			_, err := NewClient(opts...).PortTest(context.Background())
+			switch {
+			case test.err && err == nil:
+				t.Errorf("expected error, got nil")
+			case !test.err && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDialer(t *testing.T) {
+	var requests int64
+	h := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		_, _ = res.Write([]byte(`{"result": "success", "arguments": {"port-is-open": true}}`))
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	// unix socket listener
+	dir, err := ioutil.TempDir("", "transrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "rpc.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = http.Serve(l, h) }()
+	defer l.Close()
+
+	// socks5 proxy listener
+	socks, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveSocks5(socks)
+	defer socks.Close()
+
+	// http proxy
+	proxy := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !req.URL.IsAbs() {
+			http.Error(res, "not a proxy request", http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(res, req)
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name string
+		d    *tctypes.Dialer
+	}{
+		{"unix", &tctypes.Dialer{Socket: socket}},
+		{"socks5", &tctypes.Dialer{Proxy: &url.URL{Scheme: "socks5", Host: socks.Addr().String()}}},
+		{"http", &tctypes.Dialer{Proxy: &url.URL{Scheme: "http", Host: proxy.Listener.Addr().String()}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name == "unix" && runtime.GOOS == "windows" {
+				t.Skip("unix sockets not supported")
+			}
+			n := atomic.LoadInt64(&requests)
+			cl := NewClient(WithURL(s.URL), WithVersion(&Version{RPCVersion: 16}), WithDialer(test.d))
+			ok, err := cl.PortTest(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !ok || atomic.LoadInt64(&requests) != n+1 {
+				t.Errorf("expected request to be handled")
+			}
+		})
+	}
+}
+
+// serveSocks5 serves a minimal, unauthenticated socks5 proxy on l.
+func serveSocks5(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			// greeting
+			buf := make([]byte, 2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			if _, err := io.ReadFull(r, make([]byte, buf[1])); err != nil {
+				return
+			}
+			if _, err := conn.Write([]byte{5, 0}); err != nil {
+				return
+			}
+			// connect request
+			buf = make([]byte, 4)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			var host string
+			switch buf[3] {
+			case 1:
+				ip := make([]byte, 4)
+				if _, err := io.ReadFull(r, ip); err != nil {
+					return
+				}
+				host = net.IP(ip).String()
+			case 3:
+				n, err := r.ReadByte()
+				if err != nil {
+					return
+				}
+				name := make([]byte, n)
+				if _, err := io.ReadFull(r, name); err != nil {
+					return
+				}
+				host = string(name)
+			default:
+				return
+			}
+			port := make([]byte, 2)
+			if _, err := io.ReadFull(r, port); err != nil {
+				return
+			}
+			remote, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))))
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+			if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(remote, r) }()
+			_, _ = io.Copy(conn, remote)
+		}(conn)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		s, exp string
+	}{
+		{"torrent-get", "torrent_get"},
+		{"hashString", "hash_string"},
+		{"peer-limit", "peer_limit"},
+		{"isUTP", "is_utp"},
+		{"webseedsSendingToUs", "webseeds_sending_to_us"},
+		{"rpc-version-semver", "rpc_version_semver"},
+	}
+	for _, test := range tests {
+		if s := snakeCase(test.s); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+}
+
+func contains(v []string, s string) bool {
+	for _, z := range v {
+		if z == s {
+			return true
+		}
+	}
+	return false
+}
+
 const torrentJSON = `[
   {
     "activityDate": 0,
@@ -452,3 +939,113 @@ const sessionJSON = `{
   "utp-enabled": true,
   "version": "2.94 (d8e60ee44f)"
 }`
+
+func TestFake(t *testing.T) {
+	s := transctltest.NewTransmission(transctltest.WithTransmissionCredentials("user", "pass"))
+	defer s.Close()
+	ctx := context.Background()
+
+	// bad credentials
+	cl := NewClient(WithURL(s.URL+"/transmission/rpc"), WithRetryPolicy(tctypes.RetryPolicy{Attempts: 1}))
+	if _, err := cl.SessionGet(ctx); !errors.Is(err, ErrUnauthorizedUser) {
+		t.Fatalf("expected ErrUnauthorizedUser, got: %v", err)
+	}
+
+	// add
+	cl = NewClient(WithURL(s.URL+"/transmission/rpc"), WithCredentialFallback("user", "pass"))
+	magnet := "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=fake"
+	res, err := cl.TorrentAdd(ctx, TorrentAdd().WithFilename(magnet))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if res.TorrentAdded == nil || res.TorrentAdded.Name != "fake" {
+		t.Fatalf("expected torrent-added with name fake, got: %+v", res)
+	}
+	if res, err = cl.TorrentAdd(ctx, TorrentAdd().WithFilename(magnet)); err != nil || res.TorrentDuplicate == nil {
+		t.Fatalf("expected torrent-duplicate, got: %+v, %v", res, err)
+	}
+	if _, err := cl.TorrentAdd(ctx, TorrentAdd().WithMetainfo([]byte("torrent")).WithPaused(true)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// expired session id is renegotiated
+	s.ExpireSession()
+	if err := cl.TorrentStop(ctx, "0123456789abcdef0123456789abcdef01234567"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// get
+	get, err := TorrentGet().WithFields("id", "name", "hashString", "status").Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(get.Torrents) != 2 {
+		t.Fatalf("expected 2 torrents, got: %d", len(get.Torrents))
+	}
+	for i, torrent := range get.Torrents {
+		if torrent.Status != tctypes.StatusStopped {
+			t.Errorf("torrent %d expected status stopped, got: %v", i, torrent.Status)
+		}
+	}
+
+	// remove
+	if err := cl.TorrentRemove(ctx, false, get.Torrents[0].ID); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if torrents := s.Torrents(); len(torrents) != 1 || torrents[0]["hashString"] != get.Torrents[1].HashString {
+		t.Errorf("expected only torrent %s, got: %v", get.Torrents[1].HashString, torrents)
+	}
+}
+
+func TestFakeSnakeCase(t *testing.T) {
+	s := transctltest.NewTransmission(transctltest.WithTransmissionSnakeCase())
+	defer s.Close()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "transrpc")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := tctypes.NewSessionCache(dir, "transmission", 0)
+
+	// add, with a download dir different from the session's
+	cl := NewClient(WithURL(s.URL+"/transmission/rpc"), WithSessionCache(cache))
+	magnet := "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=fake"
+	if _, err := cl.TorrentAdd(ctx, TorrentAdd().WithFilename(magnet).WithDownloadDir("/data")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	version, err := cl.Version(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !version.SnakeCase || version.RPCVersion != 18 {
+		t.Errorf("expected rpc version 18 with snake case, got: %+v", version)
+	}
+
+	// download_dir is downloadDir for torrents, and download-dir for the
+	// session
+	get, err := TorrentGet().WithFields("id", "hashString", "downloadDir", "peer-limit").Do(ctx, cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(get.Torrents) != 1 || get.Torrents[0].DownloadDir != "/data" {
+		t.Fatalf("expected 1 torrent with download dir /data, got: %+v", get.Torrents)
+	}
+	session, err := cl.SessionGet(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if session.DownloadDir != "/downloads" {
+		t.Errorf("expected session download dir /downloads, got: %q", session.DownloadDir)
+	}
+
+	// negotiated version is reused from the session cache
+	n := len(s.Requests())
+	cl = NewClient(WithURL(s.URL+"/transmission/rpc"), WithSessionCache(cache))
+	if _, err := cl.TorrentGet(ctx); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if requests := s.Requests()[n:]; len(requests) != 1 || requests[0] != "torrent_get" {
+		t.Errorf("expected only torrent_get, got: %v", requests)
+	}
+}
@@ -32,6 +32,9 @@ const (
 	// ErrUnknownProblemEncountered is the unknown problem encountered error.
 	ErrUnknownProblemEncountered Error = "unknown problem encountered"
 
+	// ErrServerError is the server error error.
+	ErrServerError Error = "server error"
+
 	// ErrRecentlyActiveCanHaveOnlyOneValue is the recently-active can have only one value error.
 	ErrRecentlyActiveCanHaveOnlyOneValue Error = "recently-active can have only one value"
 
@@ -0,0 +1,229 @@
+package transrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Version is a remote host's rpc version.
+type Version struct {
+	// RPCVersion is the current rpc version.
+	RPCVersion int64
+
+	// RPCVersionMinimum is the minimum rpc version supported.
+	RPCVersionMinimum int64
+
+	// RPCVersionSemver is the current rpc version in semver format (rpc
+	// version 17+).
+	RPCVersionSemver string
+
+	// Version is the long version string of the remote host.
+	Version string
+
+	// SnakeCase indicates that the remote host uses snake case method and
+	// argument names (rpc version semver 6+).
+	SnakeCase bool
+}
+
+// versionFields are the session fields retrieved when negotiating the rpc
+// version.
+var versionFields = []string{
+	"rpc-version", "rpc-version-minimum", "rpc-version-semver", "version",
+}
+
+// newVersion creates a version from the session get arguments.
+func newVersion(m map[string]interface{}) *Version {
+	get := func(key string) string {
+		v, ok := m[key]
+		if !ok {
+			v = m[snakeCase(key)]
+		}
+		switch x := v.(type) {
+		case json.Number:
+			return x.String()
+		case string:
+			return x
+		}
+		return ""
+	}
+	version := &Version{
+		RPCVersionSemver: get("rpc-version-semver"),
+		Version:          get("version"),
+	}
+	version.RPCVersion, _ = strconv.ParseInt(get("rpc-version"), 10, 64)
+	version.RPCVersionMinimum, _ = strconv.ParseInt(get("rpc-version-minimum"), 10, 64)
+	if i := strings.IndexByte(version.RPCVersionSemver, '.'); i != -1 {
+		major, _ := strconv.Atoi(version.RPCVersionSemver[:i])
+		version.SnakeCase = major >= 6
+	}
+	return version
+}
+
+// snakeCase converts a camel case or kebab case rpc name to snake case.
+//
+// For example, "hashString" => "hash_string", "peer-limit" => "peer_limit",
+// and "isUTP" => "is_utp".
+func snakeCase(s string) string {
+	r := []rune(s)
+	var buf strings.Builder
+	for i, c := range r {
+		switch {
+		case c == '-':
+			buf.WriteRune('_')
+			continue
+		case unicode.IsUpper(c) && i != 0 && (unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1]) ||
+			(unicode.IsUpper(r[i-1]) && i+1 < len(r) && unicode.IsLower(r[i+1]))):
+			buf.WriteRune('_')
+		}
+		buf.WriteRune(unicode.ToLower(c))
+	}
+	return buf.String()
+}
+
+// toSnakeCase converts the method and the encoded arguments' names to snake
+// case.
+func toSnakeCase(method string, args []byte) (string, []byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", nil, err
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		// field names and the recently-active identifier are passed as
+		// values
+		if fields, ok := m["fields"].([]interface{}); ok {
+			for i, field := range fields {
+				if s, ok := field.(string); ok {
+					fields[i] = snakeCase(s)
+				}
+			}
+		}
+		if ids, ok := m["ids"].(string); ok && ids == RecentlyActive {
+			m["ids"] = snakeCase(ids)
+		}
+	}
+	buf, err := json.Marshal(convertNames(v, snakeCase))
+	if err != nil {
+		return "", nil, err
+	}
+	return snakeCase(method), buf, nil
+}
+
+// fromSnakeCase converts the snake case names in the encoded result to the
+// original rpc names of the fields of v.
+//
+// Names are converted using the json tags of the struct fields of v's type,
+// as the same snake case name can map to different original names (for
+// example, "download_dir" is "downloadDir" in torrent-get, but "download-dir"
+// in session-get).
+func fromSnakeCase(r io.Reader, v interface{}) (io.Reader, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var z interface{}
+	if err := dec.Decode(&z); err != nil {
+		return nil, err
+	}
+	if m, ok := z.(map[string]interface{}); ok && v != nil {
+		if args, ok := m["arguments"]; ok {
+			m["arguments"] = legacyNames(args, reflect.TypeOf(v))
+		}
+	}
+	buf, err := json.Marshal(z)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
+
+// convertNames recursively converts the object keys in v using f.
+func convertNames(v interface{}, f func(string) string) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, z := range x {
+			m[f(k)] = convertNames(z, f)
+		}
+		return m
+	case []interface{}:
+		for i, z := range x {
+			x[i] = convertNames(z, f)
+		}
+	}
+	return v
+}
+
+// legacyNames recursively converts the snake case object keys in v to the
+// json names of the fields of typ. Keys not matching a field are left as-is.
+func legacyNames(v interface{}, typ reflect.Type) interface{} {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		switch typ.Kind() {
+		case reflect.Struct:
+			fields := legacyFields(typ)
+			m := make(map[string]interface{}, len(x))
+			for k, z := range x {
+				if f, ok := fields[k]; ok {
+					m[f.name] = legacyNames(z, f.typ)
+				} else {
+					m[k] = z
+				}
+			}
+			return m
+		case reflect.Map:
+			for k, z := range x {
+				x[k] = legacyNames(z, typ.Elem())
+			}
+		}
+	case []interface{}:
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			for i, z := range x {
+				x[i] = legacyNames(z, typ.Elem())
+			}
+		}
+	}
+	return v
+}
+
+// legacyField is a struct field's json name and type.
+type legacyField struct {
+	name string
+	typ  reflect.Type
+}
+
+// legacyFieldCache is the cache of struct types to their snake case field
+// names.
+var legacyFieldCache sync.Map
+
+// legacyFields returns the fields of the struct type, keyed by the snake case
+// json name. Fields of embedded structs are included.
+func legacyFields(typ reflect.Type) map[string]legacyField {
+	if fields, ok := legacyFieldCache.Load(typ); ok {
+		return fields.(map[string]legacyField)
+	}
+	fields := make(map[string]legacyField)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-":
+		case name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct:
+			for k, z := range legacyFields(f.Type) {
+				fields[k] = z
+			}
+		case name != "":
+			fields[snakeCase(name)] = legacyField{name, f.Type}
+		}
+	}
+	legacyFieldCache.Store(typ, fields)
+	return fields
+}